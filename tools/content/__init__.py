@@ -0,0 +1,3 @@
+"""Utilities for collecting, checking, and exporting lesson and vocabulary content."""
+
+from .common import TOOL_VERSION as __version__  # noqa: F401
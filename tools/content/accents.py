@@ -0,0 +1,100 @@
+"""Detect Spanish words missing obligatory accents and restore them."""
+
+from __future__ import annotations
+
+import csv
+import re
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Set, Union
+
+try:
+    from .common import CONFIG_DIR, ROOT, is_spanish_key, load_json
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, ROOT, is_spanish_key, load_json  # type: ignore
+
+DEFAULT_ACCENTS_PATH = CONFIG_DIR / "accents.json"
+# Only vowel accents are folded; ñ is a separate letter, not an accented n.
+FOLD = str.maketrans("áéíóúüÁÉÍÓÚÜ", "aeiouuAEIOUU")
+WORD_RE = re.compile(r"[A-Za-zÁÉÍÓÚÜÑáéíóúüñ]+")
+
+
+@dataclass
+class AccentDictionary:
+    """Accented forms keyed by their folded spelling, plus unaccented spellings that are words in their own right."""
+
+    forms: Dict[str, Set[str]]
+    ambiguous: Set[str]
+
+
+@dataclass
+class AccentFix:
+    path: str
+    word: str
+    suggestion: str
+    ambiguous: bool
+
+
+def fold(text: str) -> str:
+    return text.translate(FOLD)
+
+
+def load_accent_dictionary(path: Optional[Union[str, Path]] = None) -> AccentDictionary:
+    """Build the dictionary from the accents config and the CSV form banks it lists."""
+    cfg_path = Path(path) if path else DEFAULT_ACCENTS_PATH
+    config = load_json(cfg_path) if cfg_path.exists() else {}
+    words: List[str] = [str(word) for word in config.get("forms", [])]
+    for raw in config.get("dictionaries", []):
+        csv_path = Path(raw) if Path(raw).is_absolute() else ROOT / raw
+        if csv_path.exists():
+            with open(csv_path, newline="", encoding="utf-8") as handle:
+                for row in csv.DictReader(handle):
+                    words += [row.get("form", ""), row.get("lemma", "")]
+    forms: Dict[str, Set[str]] = {}
+    for word in words:
+        for token in WORD_RE.findall(word.lower()):
+            if fold(token) != token:
+                forms.setdefault(fold(token), set()).add(token)
+    return AccentDictionary(forms, {str(word).lower() for word in config.get("ambiguous", [])})
+
+
+def match_case(source: str, target: str) -> str:
+    if source.isupper() and len(source) > 1:
+        return target.upper()
+    return target[:1].upper() + target[1:] if source[:1].isupper() else target
+
+
+def suggest(text: str, dictionary: AccentDictionary, path: str = "") -> List[AccentFix]:
+    fixes: List[AccentFix] = []
+    for word in WORD_RE.findall(text):
+        lower = word.lower()
+        if fold(lower) != lower or lower not in dictionary.forms:
+            continue
+        candidates = sorted(dictionary.forms[lower])
+        ambiguous = lower in dictionary.ambiguous or len(candidates) > 1
+        fixes.append(AccentFix(path, word, match_case(word, candidates[0]), ambiguous))
+    return fixes
+
+
+def apply_fixes(text: str, fixes: List[AccentFix]) -> str:
+    """Replace each unambiguous word in place, matching whole words only."""
+    for fix in fixes:
+        if not fix.ambiguous:
+            text = re.sub(rf"(?<![\wÁÉÍÓÚÜÑáéíóúüñ]){re.escape(fix.word)}(?![\wÁÉÍÓÚÜÑáéíóúüñ])", fix.suggestion, text)
+    return text
+
+
+def restore_accents(value: Any, dictionary: AccentDictionary, fix: bool = False, path: str = "") -> List[AccentFix]:
+    """Collect suggestions for every Spanish string in a record; with fix set, rewrite the unambiguous ones in place."""
+    found: List[AccentFix] = []
+    items = value.items() if isinstance(value, dict) else enumerate(value) if isinstance(value, list) else []
+    for key, item in list(items):
+        child = f"{path}.{key}" if path and isinstance(value, dict) else f"{path}[{key}]" if isinstance(value, list) else str(key)
+        if isinstance(item, str) and isinstance(value, dict) and is_spanish_key(key):
+            fixes = suggest(item, dictionary, child)
+            found += fixes
+            if fix:
+                value[key] = apply_fixes(item, fixes)
+        elif isinstance(item, (dict, list)):
+            found += restore_accents(item, dictionary, fix, child)
+    return found
@@ -0,0 +1,106 @@
+"""Word- and sentence-level audio timing on example sentences, for karaoke-style highlighting in the player."""
+
+from __future__ import annotations
+
+import wave
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional, Tuple
+
+try:
+    from .common import ROOT, words
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import ROOT, words  # type: ignore
+
+AUDIO_DIR = ROOT / "assets"
+ALIGNMENT_UNITS = ("word", "sentence")
+# Seconds of slack for rounding in aligner output.
+TOLERANCE = 0.05
+
+
+def audio_src(value: Any) -> Tuple[str, Optional[float]]:
+    """Accept either a bare path or an object with src/duration."""
+    if isinstance(value, str):
+        return value.strip(), None
+    if isinstance(value, dict):
+        duration = value.get("duration")
+        return str(value.get("src", "")).strip(), float(duration) if isinstance(duration, (int, float)) else None
+    return "", None
+
+
+def audio_duration(src: str, assets: Path) -> Optional[float]:
+    """Duration read from a WAV header; other formats need an explicit duration on the audio reference."""
+    path = assets / src
+    if path.suffix.lower() != ".wav" or not path.is_file():
+        return None
+    try:
+        with wave.open(str(path), "rb") as handle:
+            return handle.getnframes() / float(handle.getframerate())
+    except (wave.Error, EOFError):
+        return None
+
+
+def iter_examples(entry: Dict[str, Any]) -> Iterator[Tuple[str, Dict[str, Any]]]:
+    """(path, example) for flat examples and per-sense examples; flat ones mirroring the first sense are skipped."""
+    senses = [sense for sense in entry.get("senses") or [] if isinstance(sense, dict)]
+    mirrored = bool(senses) and "examples" in senses[0]
+    for idx, example in enumerate([] if mirrored else entry.get("examples") or []):
+        if isinstance(example, dict):
+            yield f"examples[{idx}]", example
+    for sense_idx, sense in enumerate(senses):
+        for idx, example in enumerate(sense.get("examples") or []):
+            if isinstance(example, dict):
+                yield f"senses[{sense_idx}].examples[{idx}]", example
+
+
+def check_alignment(example: Dict[str, Any], assets: Path = AUDIO_DIR) -> List[str]:
+    """Problems with an example's alignment: malformed segments, times running backwards or overlapping, or past the audio's end."""
+    alignment = example.get("alignment")
+    if alignment is None:
+        return []
+    if not isinstance(alignment, dict) or not isinstance(alignment.get("segments"), list) or not alignment["segments"]:
+        return ["alignment needs a non-empty segments list"]
+    problems: List[str] = []
+    unit = alignment.get("unit", "word")
+    if unit not in ALIGNMENT_UNITS:
+        problems.append(f"alignment unit '{unit}' is not one of {', '.join(ALIGNMENT_UNITS)}")
+    src, duration = audio_src(example.get("audio"))
+    if not src:
+        problems.append("alignment without an audio reference")
+    elif duration is None:
+        duration = audio_duration(src, assets)
+    previous_end = 0.0
+    for idx, segment in enumerate(alignment["segments"]):
+        start = segment.get("start") if isinstance(segment, dict) else None
+        end = segment.get("end") if isinstance(segment, dict) else None
+        if not isinstance(start, (int, float)) or not isinstance(end, (int, float)) or not str(segment.get("text") or "").strip():
+            problems.append(f"segments[{idx}] needs text, start, and end")
+            continue
+        if start < 0 or end < start:
+            problems.append(f"segments[{idx}] runs from {start} to {end}")
+        if start + TOLERANCE < previous_end:
+            problems.append(f"segments[{idx}] starts at {start}, before the previous segment ends at {previous_end}")
+        if duration is not None and end > duration + TOLERANCE:
+            problems.append(f"segments[{idx}] ends at {end}, after the audio ends at {duration:.2f}")
+        previous_end = max(previous_end, end)
+    if unit == "word" and isinstance(example.get("es"), str) and not problems:
+        aligned = [word for segment in alignment["segments"] for word in words(str(segment["text"]))]
+        if aligned != words(example["es"]):
+            problems.append("word segments do not spell the example's es text")
+    return problems
+
+
+def alignment_rows(entries: List[Dict[str, Any]], assets: Path = AUDIO_DIR) -> Tuple[List[Dict[str, Any]], List[str]]:
+    """Player payload rows for every valid alignment, plus one problem line per invalid one (left out of the rows)."""
+    rows: List[Dict[str, Any]] = []
+    problems: List[str] = []
+    for entry in entries:
+        for path, example in iter_examples(entry):
+            if "alignment" not in example:
+                continue
+            found = check_alignment(example, assets)
+            if found:
+                problems += [f"{entry.get('id')} {path}: {problem}" for problem in found]
+                continue
+            src, _ = audio_src(example.get("audio"))
+            rows.append({"id": entry.get("id"), "path": path, "es": example.get("es"), "audio": src, "unit": example["alignment"].get("unit", "word"), "segments": example["alignment"]["segments"]})
+    return rows, problems
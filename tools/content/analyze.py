@@ -0,0 +1,300 @@
+#!/usr/bin/env python3
+"""Curriculum and vocabulary analyses that produce review reports."""
+
+from __future__ import annotations
+
+import argparse
+import itertools
+import re
+import sys
+from collections import Counter
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Set
+
+try:
+    from .common import CONFIG_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, LEVELS, LOCKED, Dataset, collect, describe, entry_level, lesson_order_key, load_id_scheme, load_json, slugify, spanish_texts, string_values, words, write_report
+    from .console import add_output_arguments, configure_output
+    from .export import build_entries
+    from .forms import DEFAULT_FORMS_BANK, entry_forms, load_form_bank, mentions
+    from .merge import merge_by_id
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONFIG_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, LEVELS, LOCKED, Dataset, collect, describe, entry_level, lesson_order_key, load_id_scheme, load_json, slugify, spanish_texts, string_values, words, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from export import build_entries  # type: ignore
+    from forms import DEFAULT_FORMS_BANK, entry_forms, load_form_bank, mentions  # type: ignore
+    from merge import merge_by_id  # type: ignore
+
+DEFAULT_SYLLABUS_PATH = CONFIG_DIR / "syllabus.json"
+SPANISH_LETTERS = "abcdefghijklmnopqrstuvwxyzáéíóúüñ"
+DEFINITION_STOPWORDS = {"a", "an", "the", "to", "of", "or", "and", "in", "on", "for", "used", "when", "is", "it", "that", "with", "as", "by", "be"}
+
+
+def definition_terms(entry: Dict[str, Any]) -> Set[str]:
+    text = f"{entry.get('english_gloss', '')} {entry.get('definition', '')}".lower()
+    return {word for word in re.findall(r"[a-z]+", text) if word not in DEFINITION_STOPWORDS and len(word) > 2}
+
+
+def jaccard(left: Set[str], right: Set[str]) -> float:
+    if not left or not right:
+        return 0.0
+    return len(left & right) / len(left | right)
+
+
+def entry_tags(entry: Dict[str, Any]) -> List[str]:
+    return sorted({t for t in entry.get("tags", []) if isinstance(t, str)})
+
+
+def tag_pairs(vocab: List[Dict[str, Any]]) -> Counter:
+    pairs: Counter = Counter()
+    for entry in vocab:
+        pairs.update(itertools.combinations(entry_tags(entry), 2))
+    return pairs
+
+
+def tag_clusters(vocab: List[Dict[str, Any]], min_cooccurrence: int) -> List[Set[str]]:
+    tags = {tag for entry in vocab for tag in entry_tags(entry)}
+    parent = {tag: tag for tag in tags}
+
+    def find(tag: str) -> str:
+        while parent[tag] != tag:
+            parent[tag] = parent[parent[tag]]
+            tag = parent[tag]
+        return tag
+
+    for (left, right), count in tag_pairs(vocab).items():
+        if count >= min_cooccurrence:
+            parent[find(left)] = find(right)
+    groups: Dict[str, Set[str]] = {}
+    for tag in tags:
+        groups.setdefault(find(tag), set()).add(tag)
+    return sorted(groups.values(), key=lambda group: sorted(group))
+
+
+def analyze_tags(vocab: List[Dict[str, Any]], args: argparse.Namespace) -> List[str]:
+    counts: Counter = Counter(tag for entry in vocab for tag in entry_tags(entry))
+    pairs = tag_pairs(vocab)
+    clusters = tag_clusters(vocab, args.min_cooccurrence)
+    cluster_terms: List[Set[str]] = []
+    for cluster in clusters:
+        terms: Set[str] = set()
+        for entry in vocab:
+            if cluster & set(entry_tags(entry)):
+                terms |= definition_terms(entry)
+        cluster_terms.append(terms)
+
+    untagged = [entry for entry in vocab if not entry.get("tags")]
+    out = [
+        "Tag analysis",
+        f"- entries: {len(vocab)}",
+        f"- untagged: {len(untagged)} ({(100 * len(untagged) // len(vocab)) if vocab else 0}%)",
+        f"- tags: {len(counts)}",
+        "## tag counts",
+    ]
+    out += [f"- {tag}: {count}" for tag, count in counts.most_common()]
+    if pairs:
+        out.append("## co-occurring tags")
+        out += [f"- {left} + {right}: {count}" for (left, right), count in pairs.most_common(args.top)]
+    out.append("## candidate topic clusters")
+    out += [f"- {', '.join(sorted(cluster))}" for cluster in clusters]
+    suggestions = []
+    for entry in untagged:
+        terms = definition_terms(entry)
+        scored = sorted(((jaccard(terms, ct), idx) for idx, ct in enumerate(cluster_terms)), reverse=True)
+        if scored and scored[0][0] >= args.min_similarity:
+            score, idx = scored[0]
+            suggestions.append(f"- {entry['id']}: {', '.join(sorted(clusters[idx]))} (similarity {score:.2f})")
+    if suggestions:
+        out.append("## tag suggestions for untagged entries")
+        out += suggestions
+    return out
+
+
+def topic_key(text: str) -> str:
+    """Compare titles by their head ("Gustar and Company — Verbs That..." -> gustar_and_company), ignoring quotes and accents."""
+    return slugify(text.split(" — ")[0])
+
+
+def load_syllabus(path: str) -> List[Dict[str, Any]]:
+    """Topics in syllabus order; a topic is a name or {"topic", "match": [aliases]} under its CEFR level."""
+    cfg_path = Path(path)
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    topics: List[Dict[str, Any]] = []
+    for level in LEVELS:
+        for item in data.get(level, []):
+            item = {"topic": item} if isinstance(item, str) else item
+            keys = {topic_key(item["topic"])} | {topic_key(alias) for alias in item.get("match", [])}
+            topics.append({"level": level, "topic": item["topic"], "keys": keys})
+    return topics
+
+
+def lesson_topics(lesson: Dict[str, Any], syllabus: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """Topics whose name or alias is the lesson's title head, one of its tags, or a whole-word run of either."""
+    candidates = [topic_key(str(lesson.get("title") or ""))] + [topic_key(tag) for tag in entry_tags(lesson)]
+    padded = [f"_{candidate}_" for candidate in candidates if candidate]
+    return [topic for topic in syllabus if any(f"_{key}_" in text for key in topic["keys"] for text in padded)]
+
+
+def analyze_syllabus(lessons: List[Dict[str, Any]], syllabus: List[Dict[str, Any]]) -> List[str]:
+    covered: Dict[int, List[str]] = {}
+    unmatched: List[str] = []
+    above: List[str] = []
+    for lesson in lessons:
+        level = entry_level(lesson)
+        topics = lesson_topics(lesson, syllabus)
+        if not topics:
+            unmatched.append(f"- {lesson['id']} ({level}): {lesson.get('title', '')}")
+        for topic in topics:
+            covered.setdefault(id(topic), []).append(lesson["id"])
+            if level in LEVELS and LEVELS.index(topic["level"]) > LEVELS.index(level):
+                above.append(f"- {lesson['id']} ({level}) covers {topic['topic']} ({topic['level']})")
+    out = ["Syllabus coverage", f"- topics: {len(syllabus)}", f"- covered: {len(covered)}", f"- lessons matching no topic: {len(unmatched)}"]
+    out += [f"- {level}: {sum(1 for t in syllabus if t['level'] == level and id(t) in covered)}/{sum(1 for t in syllabus if t['level'] == level)} topics covered" for level in LEVELS]
+    if above:
+        out.append("## lessons covering topics above their declared level")
+        out += above
+    for level in LEVELS:
+        gaps = [topic["topic"] for topic in syllabus if topic["level"] == level and id(topic) not in covered]
+        if gaps:
+            out.append(f"## {level} topics without a lesson")
+            out += [f"- {gap}" for gap in gaps]
+    if unmatched:
+        out.append("## lessons matching no topic")
+        out += unmatched
+    return out
+
+
+def edits1(word: str) -> Set[str]:
+    """Every string one insertion, deletion, or substitution away (Levenshtein distance 1)."""
+    splits = [(word[:idx], word[idx:]) for idx in range(len(word) + 1)]
+    deletes = {left + right[1:] for left, right in splits if right}
+    replaces = {left + ch + right[1:] for left, right in splits if right for ch in SPANISH_LETTERS}
+    inserts = {left + ch + right for left, right in splits for ch in SPANISH_LETTERS}
+    return (deletes | replaces | inserts) - {word}
+
+
+def analyze_typos(dataset: Dataset, args: argparse.Namespace) -> List[str]:
+    """Rare words one edit away from a much more frequent word, ranked by how lopsided the counts are."""
+    counts: Counter = Counter()
+    seen_at: Dict[str, List[str]] = {}
+    for record in dataset.vocab + dataset.lessons:
+        for path, text in spanish_texts(record.data):
+            for word in words(text):
+                counts[word] += 1
+                seen_at.setdefault(word, []).append(f"{describe(record)} {path}")
+    candidates = []
+    for word, count in counts.items():
+        if count > args.max_count or len(word) < args.min_length or word.isdigit():
+            continue
+        for neighbor in edits1(word) & counts.keys():
+            # Accent-only differences belong to the accents lint rule.
+            if slugify(neighbor) == slugify(word) or counts[neighbor] < args.min_ratio * count:
+                continue
+            confidence = counts[neighbor] / (counts[neighbor] + count)
+            candidates.append((confidence, word, neighbor))
+    candidates.sort(key=lambda c: (-c[0], c[1], c[2]))
+    out = ["Typo candidates", f"- distinct words: {len(counts)}", f"- candidates: {len(candidates)}"]
+    if candidates:
+        out.append("## candidates (rare word -> frequent neighbor, counts, confidence)")
+        for confidence, word, neighbor in candidates[: args.top]:
+            out.append(f"- {word} -> {neighbor} ({counts[word]} vs {counts[neighbor]}, {confidence:.2f}): {'; '.join(seen_at[word][:3])}")
+    return out
+
+
+def vocab_exposures(vocab: List[Dict[str, Any]], lessons: List[Dict[str, Any]], bank: List[Dict[str, str]]) -> Dict[str, List[str]]:
+    """Vocabulary ID -> IDs of the lessons (in the given order) whose text uses the headword or one of its forms."""
+    texts = [(lesson["id"], " ".join(string_values(lesson)).replace(LOCKED, " ")) for lesson in lessons]
+    return {entry["id"]: [lesson_id for lesson_id, text in texts if mentions(text, forms)] for entry in vocab for forms in [entry_forms(entry, bank)]}
+
+
+def analyze_pacing(vocab: List[Dict[str, Any]], lessons: List[Dict[str, Any]], args: argparse.Namespace) -> List[str]:
+    """New words per lesson (first use in curriculum order), cumulative words per level, and how many later lessons reuse each word."""
+    lessons = sorted(lessons, key=lesson_order_key)
+    exposures = vocab_exposures(vocab, lessons, load_form_bank(args.forms_bank))
+    introduced: Dict[str, List[str]] = {lesson["id"]: [] for lesson in lessons}
+    for entry in vocab:
+        if exposures[entry["id"]]:
+            introduced[exposures[entry["id"]][0]].append(str(entry.get("spanish", entry["id"])))
+    reexposed = {vocab_id: len(uses) - 1 for vocab_id, uses in exposures.items() if uses}
+    heavy = [(lesson, introduced[lesson["id"]]) for lesson in lessons if len(introduced[lesson["id"]]) > args.max_new]
+    unreinforced = sorted(str(entry.get("spanish", entry["id"])) for entry in vocab if reexposed.get(entry["id"]) is not None and reexposed[entry["id"]] < args.min_reuse)
+    unused = sorted(str(entry.get("spanish", entry["id"])) for entry in vocab if entry["id"] not in reexposed)
+    counts = [len(words_) for words_ in introduced.values()]
+
+    out = ["Vocabulary pacing", f"- lessons: {len(lessons)}", f"- vocabulary entries: {len(vocab)}", f"- introduced in a lesson: {len(reexposed)}"]
+    out.append(f"- new words per lesson: max {max(counts, default=0)}, mean {sum(counts) / len(counts) if counts else 0:.1f}")
+    out.append(f"- lessons over {args.max_new} new words: {len(heavy)}")
+    out.append(f"- words reused in fewer than {args.min_reuse} later lessons: {len(unreinforced)}")
+    out.append(f"- words no lesson uses: {len(unused)}")
+    out.append("## cumulative words per level")
+    total = 0
+    for level in LEVELS + ("UNSET",):
+        level_lessons = [lesson for lesson in lessons if entry_level(lesson) == level]
+        if level_lessons:
+            total += sum(len(introduced[lesson["id"]]) for lesson in level_lessons)
+            out.append(f"- {level}: {total} words after {len(level_lessons)} lessons")
+    out.append("## new words per lesson")
+    out += [f"- {lesson['id']}: {len(introduced[lesson['id']])}" + (f" ({', '.join(introduced[lesson['id']])})" if introduced[lesson["id"]] else "") for lesson in lessons]
+    if heavy:
+        out.append(f"## lessons introducing more than {args.max_new} new words")
+        out += [f"- {lesson['id']}: {len(new)} new words" for lesson, new in heavy]
+    if unreinforced:
+        out.append(f"## words reused in fewer than {args.min_reuse} later lessons")
+        out += [f"- {word}" for word in unreinforced]
+    if unused:
+        out.append("## words no lesson uses")
+        out += [f"- {word}" for word in unused]
+    return out
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    shared = argparse.ArgumentParser(add_help=False)
+    shared.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to collect")
+    shared.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser = argparse.ArgumentParser(description="Analyze the collected dataset and write reports under build/reports.")
+    sub = parser.add_subparsers(dest="analysis", required=True)
+
+    tags = sub.add_parser("tags", parents=[shared], help="Tag co-occurrence and topic clustering")
+    tags.add_argument("--min-cooccurrence", type=int, default=2, help="Pair count that links two tags into one cluster")
+    tags.add_argument("--min-similarity", type=float, default=0.1, help="Definition similarity needed to suggest a cluster")
+    tags.add_argument("--top", type=int, default=20, help="Number of tag pairs to list")
+    syllabus = sub.add_parser("syllabus", parents=[shared], help="Grammar syllabus topics without lessons, and lessons above their level")
+    syllabus.add_argument("--syllabus", default=str(DEFAULT_SYLLABUS_PATH), help="Grammar topics per CEFR level")
+    typos = sub.add_parser("typos", parents=[shared], help="Rare words one edit away from a frequent word in the dataset")
+    typos.add_argument("--max-count", type=int, default=1, help="Only words seen at most this often are candidates")
+    typos.add_argument("--min-ratio", type=float, default=5.0, help="How many times more frequent the neighbor must be")
+    typos.add_argument("--min-length", type=int, default=4, help="Shorter words have too many real neighbors to judge")
+    typos.add_argument("--top", type=int, default=100, help="Number of candidates to list")
+    pacing = sub.add_parser("pacing", parents=[shared], help="New words per lesson, cumulative words per level, and words never reused")
+    pacing.add_argument("--max-new", type=int, default=12, help="Flag lessons introducing more new words than this")
+    pacing.add_argument("--min-reuse", type=int, default=1, help="Flag words reused in fewer later lessons than this")
+    pacing.add_argument("--forms-bank", default=str(DEFAULT_FORMS_BANK), help="CSV of irregular forms that also count as uses of a word")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+
+    if args.analysis == "tags":
+        vocab, _ = merge_by_id(build_entries(dataset.vocab, scheme))
+        out = analyze_tags(vocab, args)
+        write_report("tags.md", out)
+    elif args.analysis == "syllabus":
+        lessons, _ = merge_by_id(build_entries(dataset.lessons, scheme))
+        out = analyze_syllabus(lessons, load_syllabus(args.syllabus))
+        write_report("syllabus.md", out)
+    elif args.analysis == "typos":
+        out = analyze_typos(dataset, args)
+        write_report("typos.md", out)
+    elif args.analysis == "pacing":
+        vocab, _ = merge_by_id(build_entries(dataset.vocab, scheme))
+        lessons, _ = merge_by_id(build_entries(dataset.lessons, scheme))
+        out = analyze_pacing(vocab, lessons, args)
+        write_report("pacing.md", out)
+    print("\n".join(out))
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
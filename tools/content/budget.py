@@ -0,0 +1,209 @@
+#!/usr/bin/env python3
+"""Size budget for the exported dataset, and a load test that parses every output the way the app does at start-up.
+
+The app bundles the export, so it has a hard size budget (25 MB on mobile). config/budget.json caps the total
+and each file's size, the number of entries per level of each kind, and the serialized size of a single
+entry; export fails the build when any cap is exceeded. `budget.py` checks an existing build and times how
+long each output takes to parse.
+"""
+
+from __future__ import annotations
+
+import argparse
+import json
+import sys
+import time
+from collections import Counter
+from dataclasses import dataclass, field
+from fnmatch import fnmatch
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple, Union
+
+try:
+    from .common import CONFIG_DIR, ENTRY_OUTPUTS, entry_level, load_json, write_report
+    from .console import add_output_arguments, configure_output
+    from .errors import ConfigError
+    from .storage import DEFAULT_STORAGE_PATH, load_storage
+    from .verify import unmark_generated
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONFIG_DIR, ENTRY_OUTPUTS, entry_level, load_json, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from storage import DEFAULT_STORAGE_PATH, load_storage  # type: ignore
+    from verify import unmark_generated  # type: ignore
+
+DEFAULT_BUDGET_PATH = CONFIG_DIR / "budget.json"
+LIMIT_KEYS = ("max_total_bytes", "max_file_bytes", "max_entries_per_level", "max_entry_bytes")
+# Kinds whose entries carry a level and are checked entry by entry.
+BUDGET_KINDS = ENTRY_OUTPUTS
+
+
+@dataclass
+class Budget:
+    max_total_bytes: Optional[int] = None
+    # Keyed by manifest name or glob pattern ("public/*.json"); "default" covers every other file.
+    max_file_bytes: Dict[str, int] = field(default_factory=dict)
+    # Keyed by kind; the cap applies to each level separately.
+    max_entries_per_level: Dict[str, int] = field(default_factory=dict)
+    # Keyed by kind, with "default" for kinds not listed.
+    max_entry_bytes: Dict[str, int] = field(default_factory=dict)
+
+    def file_limit(self, name: str) -> Optional[int]:
+        if name in self.max_file_bytes:
+            return self.max_file_bytes[name]
+        for pattern, limit in self.max_file_bytes.items():
+            if pattern != "default" and fnmatch(name, pattern):
+                return limit
+        return self.max_file_bytes.get("default")
+
+    def entry_limit(self, kind: str) -> Optional[int]:
+        return self.max_entry_bytes.get(kind, self.max_entry_bytes.get("default"))
+
+
+def load_budget(path: Optional[Union[str, Path]] = None) -> Budget:
+    """The budget in path; no file means no caps."""
+    cfg_path = Path(path) if path else DEFAULT_BUDGET_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    unknown = sorted(set(data) - set(LIMIT_KEYS))
+    if unknown:
+        raise ConfigError(f"budget: unknown setting(s) {', '.join(unknown)}", unknown[0], data[unknown[0]], list(LIMIT_KEYS))
+    budget = Budget()
+    total = data.get("max_total_bytes")
+    if total is not None:
+        if not isinstance(total, int) or total <= 0:
+            raise ConfigError("budget: max_total_bytes must be a positive number of bytes", "max_total_bytes", total)
+        budget.max_total_bytes = total
+    for key in LIMIT_KEYS[1:]:
+        limits = data.get(key, {})
+        if not isinstance(limits, dict) or any(not isinstance(value, int) or value <= 0 for value in limits.values()):
+            raise ConfigError(f"budget: {key} must map names to positive whole numbers", key, limits)
+        if key == "max_entries_per_level" and set(limits) - set(BUDGET_KINDS):
+            raise ConfigError(f"budget: {key} is keyed by kind", key, sorted(set(limits) - set(BUDGET_KINDS)), list(BUDGET_KINDS))
+        setattr(budget, key, dict(limits))
+    return budget
+
+
+def entry_bytes(entry: Any) -> int:
+    """Size of one entry as a line of JSON; the pretty-printed files add indentation on top."""
+    return len(json.dumps(entry, ensure_ascii=False).encode("utf-8"))
+
+
+def file_problems(budget: Budget, files: Dict[str, Dict[str, Any]]) -> List[Tuple[str, str]]:
+    """(target, problem) for the total and every file over its cap; files are manifest rows by name."""
+    problems: List[Tuple[str, str]] = []
+    total = sum(row["bytes"] for row in files.values())
+    if budget.max_total_bytes is not None and total > budget.max_total_bytes:
+        problems.append(("total", f"{format_bytes(total)} is over the {format_bytes(budget.max_total_bytes)} budget"))
+    for name, row in sorted(files.items()):
+        limit = budget.file_limit(name)
+        if limit is not None and row["bytes"] > limit:
+            problems.append((name, f"{format_bytes(row['bytes'])} is over its {format_bytes(limit)} cap"))
+    return problems
+
+
+def entry_problems(budget: Budget, kinds: Dict[str, List[Dict[str, Any]]]) -> List[Tuple[str, str]]:
+    """(target, problem) for every level with too many entries of a kind and every entry over its size cap."""
+    problems: List[Tuple[str, str]] = []
+    for kind, entries in kinds.items():
+        cap = budget.max_entries_per_level.get(kind)
+        if cap is not None:
+            levels = Counter(entry_level(entry) for entry in entries)
+            problems += [(f"{kind} {level}", f"{count} entries, over the cap of {cap} per level") for level, count in sorted(levels.items()) if count > cap]
+        limit = budget.entry_limit(kind)
+        if limit is None:
+            continue
+        for entry in entries:
+            size = entry_bytes(entry)
+            if size > limit:
+                problems.append((str(entry.get("id")), f"{format_bytes(size)} serialized, over the {format_bytes(limit)} cap for {kind}"))
+    return problems
+
+
+def check_budget(budget: Budget, files: Dict[str, Dict[str, Any]], kinds: Dict[str, List[Dict[str, Any]]]) -> List[Tuple[str, str]]:
+    return file_problems(budget, files) + entry_problems(budget, kinds)
+
+
+def format_bytes(size: int) -> str:
+    if size >= 1_000_000:
+        return f"{size / 1_000_000:.1f} MB"
+    if size >= 1_000:
+        return f"{size / 1_000:.1f} kB"
+    return f"{size} bytes"
+
+
+def usage_lines(budget: Budget, files: Dict[str, Dict[str, Any]]) -> List[str]:
+    """Report lines with each file's size against its cap, largest first."""
+    total = sum(row["bytes"] for row in files.values())
+    share = f" ({100 * total / budget.max_total_bytes:.1f}% of {format_bytes(budget.max_total_bytes)})" if budget.max_total_bytes else ""
+    lines = [f"- total: {format_bytes(total)}{share}"]
+    for name, row in sorted(files.items(), key=lambda item: -item[1]["bytes"]):
+        limit = budget.file_limit(name)
+        lines.append(f"- {name}: {format_bytes(row['bytes'])}" + (f" of {format_bytes(limit)}" if limit else ""))
+    return lines
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Check an exported build against the size budget and time how long each output takes to parse.")
+    parser.add_argument("--storage", default=str(DEFAULT_STORAGE_PATH), help="Path to the storage backend config")
+    parser.add_argument("--out", default="canonical", help="Location of canonical JSON inside the storage backend")
+    parser.add_argument("--budget", default=str(DEFAULT_BUDGET_PATH), help="Path to the size budget config")
+    parser.add_argument("--rounds", type=int, default=5, metavar="N", help="Parse each JSON output N times and report the slowest (default 5)")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    if args.rounds < 1:
+        parser.error("--rounds must be at least 1")
+
+    try:
+        budget = load_budget(args.budget)
+    except ValueError as exc:
+        parser.error(str(exc))
+    root = load_storage(args.storage, readonly=True)
+    out = root.child(args.out)
+    raw = out.read_bytes("manifest.json")
+    if raw is None:
+        print(f"[budget] No manifest in {out.describe()}; run export first", file=sys.stderr)
+        return 1
+    files = json.loads(raw.decode("utf-8")).get("files", {})
+
+    kinds: Dict[str, List[Dict[str, Any]]] = {}
+    timings: List[Tuple[str, float]] = []
+    for name in sorted(files):
+        if not name.endswith(".json"):
+            continue
+        data = (root if "/" in name else out).read_bytes(name)
+        if data is None:
+            print(f"[budget] {name} is in the manifest but missing; re-run export", file=sys.stderr)
+            return 1
+        slowest = 0.0
+        for _ in range(args.rounds):
+            started = time.perf_counter()
+            payload = json.loads(data.decode("utf-8"))
+            slowest = max(slowest, time.perf_counter() - started)
+        timings.append((name, slowest))
+        kind = name[:-5]
+        if kind in BUDGET_KINDS:
+            kinds[kind] = [entry for entry in unmark_generated(payload) if isinstance(entry, dict)]
+
+    problems = check_budget(budget, files, kinds)
+    lines = ["Size budget", *usage_lines(budget, files), "## parse time (slowest of each round)"]
+    lines += [f"- {name}: {seconds * 1000:.1f} ms" for name, seconds in sorted(timings, key=lambda item: -item[1])]
+    if problems:
+        lines.append("## over budget")
+        lines += [f"- {target}: {problem}" for target, problem in problems]
+    report = write_report("budget.md", lines)
+
+    total_ms = sum(seconds for _, seconds in timings) * 1000
+    total = sum(row["bytes"] for row in files.values())
+    if problems:
+        print(f"[budget] {out.describe()} is over budget ({len(problems)} problems):", file=sys.stderr)
+        for target, problem in problems:
+            print(f"    • {target}: {problem}", file=sys.stderr)
+        return 1
+    print(f"[budget] {out.describe()} is within budget at {format_bytes(total)}; parsing the JSON outputs takes {total_ms:.1f} ms (details in {report})")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,64 @@
+"""Timestamped build directories with a `latest` link and pruning of old builds."""
+
+from __future__ import annotations
+
+import datetime as dt
+import os
+import re
+import shutil
+from pathlib import Path
+from typing import List, Optional
+
+BUILD_STAMP_FORMAT = "%Y-%m-%dT%H%M%SZ"
+BUILD_STAMP_RE = re.compile(r"^(\d{4}-\d{2}-\d{2}T\d{6}Z)(?:-(\d+))?$")
+LATEST_NAME = "latest"
+
+
+def list_builds(root: Path) -> List[Path]:
+    """Timestamped build directories under root, oldest first."""
+    if not root.is_dir():
+        return []
+    return sorted((path for path in root.iterdir() if path.is_dir() and not path.is_symlink() and BUILD_STAMP_RE.match(path.name)), key=build_sort_key)
+
+
+def build_sort_key(path: Path) -> tuple:
+    match = BUILD_STAMP_RE.match(path.name)
+    return (match.group(1), int(match.group(2) or 0)) if match else (path.name, 0)
+
+
+def new_build_dir(root: Path, now: Optional[dt.datetime] = None) -> Path:
+    """Create build/<UTC timestamp>/; runs within the same second get a -1, -2, ... suffix."""
+    stamp = (now or dt.datetime.now(dt.timezone.utc)).strftime(BUILD_STAMP_FORMAT)
+    path = root / stamp
+    counter = 0
+    while path.exists():
+        counter += 1
+        path = root / f"{stamp}-{counter}"
+    path.mkdir(parents=True)
+    return path
+
+
+def latest_build(root: Path) -> Optional[Path]:
+    link = root / LATEST_NAME
+    return link if link.exists() else None
+
+
+def link_latest(root: Path, build: Path) -> None:
+    """Point root/latest at build with a relative symlink, swapped in atomically."""
+    link = root / LATEST_NAME
+    if link.exists() and not link.is_symlink():
+        raise OSError(f"{link} exists and is not a symlink; refusing to replace it")
+    tmp = root / f".{LATEST_NAME}.tmp"
+    if tmp.is_symlink() or tmp.exists():
+        tmp.unlink()
+    os.symlink(build.name, tmp, target_is_directory=True)
+    os.replace(tmp, link)
+
+
+def prune_builds(root: Path, keep: int) -> List[Path]:
+    """Delete all but the newest `keep` timestamped builds; returns what was removed."""
+    builds = list_builds(root)
+    removed = builds[: max(0, len(builds) - keep)]
+    for path in removed:
+        shutil.rmtree(path)
+    return removed
@@ -0,0 +1,185 @@
+#!/usr/bin/env python3
+"""Split the exported dataset into themed bundles (travel, food, work) for stand-alone mini-courses.
+
+A bundle holds the lessons tagged with its theme, the vocabulary their steps use plus vocabulary tagged
+with the theme, the culture notes attached to those lessons, and readings and pronunciation drills tagged
+with the theme. It has to stand on its own, so every cross-entry reference (see references.py) is checked
+against the bundle rather than the whole dataset:
+- an error-severity reference that leads out of the bundle, such as a prerequisite lesson, leaves the
+  bundle unwritten (--include-prerequisites pulls prerequisite lessons in instead);
+- a lesser one, such as a synonym, is dropped from the bundle's copy and listed in build/reports/bundles.md.
+References that dangle in the whole dataset are listed but left alone; references.md covers those.
+"""
+
+from __future__ import annotations
+
+import argparse
+import copy
+import json
+import re
+import sys
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Set, Tuple
+
+try:
+    from .common import slugify, write_report
+    from .console import add_output_arguments, configure_output
+    from .export import index_vocab, item_reference, write_json
+    from .references import DEFAULT_REFERENCES_PATH, Dangling, ReferenceType, build_index, load_reference_types, resolve_references
+    from .storage import DEFAULT_STORAGE_PATH, load_storage
+    from .verify import unmark_generated
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import slugify, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from export import index_vocab, item_reference, write_json  # type: ignore
+    from references import DEFAULT_REFERENCES_PATH, Dangling, ReferenceType, build_index, load_reference_types, resolve_references  # type: ignore
+    from storage import DEFAULT_STORAGE_PATH, load_storage  # type: ignore
+    from verify import unmark_generated  # type: ignore
+
+# Canonical file -> the record kind its entries are, as references.py names them. Units span the whole
+# course, so they are not bundled.
+BUNDLE_FILES = {"lessons": "lesson", "vocabulary": "vocab", "readings": "reading", "culture_notes": "culture_note", "pron_drills": "pron_drill"}
+Entries = Dict[str, List[Dict[str, Any]]]
+
+
+def has_tag(entry: Dict[str, Any], tag: str) -> bool:
+    tags = entry.get("tags")
+    return isinstance(tags, list) and tag.lower() in (str(item).strip().lower() for item in tags)
+
+
+def lesson_closure(lessons: List[Dict[str, Any]], chosen: Set[str]) -> Set[str]:
+    """chosen plus every lesson it needs first, however far back the prerequisites go."""
+    by_id = {lesson["id"]: lesson for lesson in lessons}
+    pending = list(chosen)
+    while pending:
+        for ref in by_id.get(pending.pop(), {}).get("prerequisites") or []:
+            if isinstance(ref, str) and ref in by_id and ref not in chosen:
+                chosen.add(ref)
+                pending.append(ref)
+    return chosen
+
+
+def bundle_members(dataset: Entries, tag: str, include_prerequisites: bool = False) -> Entries:
+    """The entries of one bundle, in their canonical order."""
+    lessons = {lesson["id"] for lesson in dataset["lessons"] if has_tag(lesson, tag)}
+    if include_prerequisites:
+        lessons = lesson_closure(dataset["lessons"], lessons)
+    vocab_index = index_vocab(dataset["vocabulary"])
+    vocab = {entry["id"] for entry in dataset["vocabulary"] if has_tag(entry, tag)}
+    notes: Set[str] = set()
+    for lesson in dataset["lessons"]:
+        if lesson["id"] not in lessons:
+            continue
+        notes.update(ref for ref in lesson.get("culture_notes") or [] if isinstance(ref, str))
+        for step in lesson.get("steps") or []:
+            for item in step.get("items") or [] if isinstance(step, dict) else []:
+                ref = item_reference(item)
+                target = vocab_index.get(ref) or vocab_index.get(ref.lower())
+                if target:
+                    vocab.add(target["id"])
+    notes.update(note["id"] for note in dataset["culture_notes"] if has_tag(note, tag) or lessons & set(note.get("lessons") or []))
+    chosen = {"lessons": lessons, "vocabulary": vocab, "culture_notes": notes}
+    return {name: [entry for entry in entries if entry["id"] in chosen[name] or (name not in chosen and has_tag(entry, tag))] for name, entries in dataset.items()}
+
+
+def by_kind(entries: Entries) -> Entries:
+    return {BUNDLE_FILES[name]: items for name, items in entries.items()}
+
+
+def leads_outside(item: Dangling, full_index: Dict[str, Set[str]]) -> bool:
+    return bool((full_index.get(item.ref, set()) | full_index.get(item.ref.lower(), set())) & set(item.type.targets))
+
+
+def path_steps(path: str) -> List[Any]:
+    """"steps[0].items[2]" as ["steps", 0, "items", 2]."""
+    return [int(index) if index else key for key, index in re.findall(r"([^.\[\]]+)|\[(\d+)\]", path)]
+
+
+def drop_reference(entry: Dict[str, Any], path: str) -> None:
+    *parents, last = path_steps(path)
+    target: Any = entry
+    for step in parents:
+        target = target[step]
+    del target[last]
+
+
+def check_bundle(members: Entries, full_index: Dict[str, Set[str]], types: List[ReferenceType]) -> Tuple[List[Dangling], List[Dangling], List[Dangling]]:
+    """(blocking, pruned, dangling everywhere) for one bundle. Pruned references are removed from members
+    in place, last index first, so the remaining paths in the same list stay valid."""
+    _, dangling = resolve_references(by_kind(members), types)
+    outside = [item for item in dangling if leads_outside(item, full_index)]
+    blocking = [item for item in outside if item.type.severity == "error"]
+    pruned = [item for item in outside if item.type.severity != "error"]
+    entries = {entry["id"]: entry for items in members.values() for entry in items}
+    for item in sorted(pruned, key=lambda item: (item.source, path_steps(item.path)), reverse=True):
+        drop_reference(entries[item.source], item.path)
+    return blocking, pruned, [item for item in dangling if not leads_outside(item, full_index)]
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Write themed sub-datasets (lessons, the vocabulary they use, their culture notes) that reference nothing outside themselves.")
+    parser.add_argument("--storage", default=str(DEFAULT_STORAGE_PATH), help="Path to the storage backend config")
+    parser.add_argument("--out", default="canonical", help="Location of canonical JSON inside the storage backend")
+    parser.add_argument("--by-tag", required=True, help="Comma-separated themes; one bundle per tag, e.g. travel,food,work")
+    parser.add_argument("--dest", default="bundles", help="Location inside the storage backend; each bundle goes in <dest>/<tag>/")
+    parser.add_argument("--include-prerequisites", action="store_true", help="Pull in the lessons a bundled lesson lists as prerequisites instead of refusing the bundle")
+    parser.add_argument("--references", default=str(DEFAULT_REFERENCES_PATH), help="Path to the cross-entry reference types config")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    tags = [tag.strip() for tag in args.by_tag.split(",") if tag.strip()]
+    if not tags:
+        parser.error("--by-tag needs at least one tag")
+    try:
+        types = load_reference_types(args.references)
+    except ValueError as exc:
+        parser.error(str(exc))
+
+    storage = load_storage(args.storage)
+    source = storage.child(args.out)
+    dataset: Entries = {}
+    for name in BUNDLE_FILES:
+        raw = source.read_bytes(f"{name}.json")
+        dataset[name] = [entry for entry in unmark_generated(json.loads(raw.decode("utf-8"))) if isinstance(entry, dict)] if raw is not None else []
+    if not dataset["lessons"] and not dataset["vocabulary"]:
+        print(f"[bundles] No lessons or vocabulary in {source.describe()}; run export first", file=sys.stderr)
+        return 1
+    full_index = build_index(by_kind(dataset))
+
+    lines = ["Bundles", f"- source: {source.describe()}", f"- tags: {', '.join(tags)}"]
+    written: List[str] = []
+    refused: List[str] = []
+    for tag in tags:
+        members = copy.deepcopy(bundle_members(dataset, tag, args.include_prerequisites))
+        counts = {name: len(items) for name, items in members.items()}
+        lines.append(f"## {tag}")
+        lines.append(f"- entries: {', '.join(f'{count} {name}' for name, count in counts.items())}")
+        if not members["lessons"]:
+            lines.append("- not written: no lesson carries this tag")
+            refused.append(tag)
+            continue
+        blocking, pruned, dangling = check_bundle(members, full_index, types)
+        lines += [f"- outside the bundle: {item.source} {item.path} ({item.type.name}) -> {item.ref}" for item in blocking]
+        lines += [f"- dropped: {item.source} {item.path} ({item.type.name}) -> {item.ref}" for item in pruned]
+        lines += [f"- dangling in the whole dataset: {item.source} {item.path} ({item.type.name}) -> {item.ref}" for item in dangling]
+        if blocking:
+            lines.append(f"- not written: {len(blocking)} references lead outside the bundle" + ("" if args.include_prerequisites else " (try --include-prerequisites)"))
+            refused.append(tag)
+            continue
+        out = storage.child(args.dest).child(slugify(tag))
+        files = {f"{name}.json": write_json(out, f"{name}.json", items) for name, items in members.items()}
+        write_json(out, "manifest.json", {"bundle": tag, "source": source.describe(), "counts": counts, "files": files, "dropped_references": len(pruned)})
+        lines.append(f"- written to {out.describe()}")
+        written.append(tag)
+    storage.close()
+    path = write_report("bundles.md", lines)
+    print(f"[bundles] Wrote {len(written)} of {len(tags)} bundles" + (f" ({', '.join(written)})" if written else "") + f"; see {path}")
+    if refused:
+        print(f"[bundles] Not written: {', '.join(refused)}", file=sys.stderr)
+        return 1
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,170 @@
+"""Side-by-side HTML diffs for canonical entries that changed between runs.
+
+The previous outputs are read twice as streams, never whole: once to hash each entry, once to pick out the
+entries whose hash changed. Only the hashes and one entry at a time are held, whatever the size of the files.
+"""
+
+from __future__ import annotations
+
+import difflib
+import hashlib
+import html
+import io
+import json
+from typing import IO, Any, Dict, Iterator, List, Optional, Tuple
+
+try:
+    from .common import REPORTS_DIR, slugify
+    from .storage import LocalStorage, Storage
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import REPORTS_DIR, slugify  # type: ignore
+    from storage import LocalStorage, Storage  # type: ignore
+
+CHANGES_DIR = REPORTS_DIR / "changes"
+# Characters of a previous output decoded at a time.
+READ_CHUNK_CHARS = 1 << 16
+NUMBER_CHARS = "0123456789+-.eE"
+
+
+def entry_lines(entry: Optional[Dict[str, Any]]) -> List[str]:
+    return json.dumps(entry, ensure_ascii=False, indent=2, sort_keys=True).splitlines() if entry is not None else []
+
+
+def changed_entries(previous: List[Dict[str, Any]], current: List[Dict[str, Any]]) -> List[Tuple[str, str, Optional[Dict[str, Any]], Optional[Dict[str, Any]]]]:
+    """Return (id, change, before, after) for every added, removed, or modified entry."""
+    before = {entry.get("id"): entry for entry in previous if isinstance(entry, dict)}
+    after = {entry.get("id"): entry for entry in current if isinstance(entry, dict)}
+    out = []
+    for entry_id in sorted(set(before) | set(after), key=str):
+        old, new = before.get(entry_id), after.get(entry_id)
+        if old == new:
+            continue
+        change = "added" if old is None else "removed" if new is None else "modified"
+        out.append((str(entry_id), change, old, new))
+    return out
+
+
+def iter_items(handle: IO[bytes], chunk_chars: int = READ_CHUNK_CHARS) -> Iterator[Any]:
+    """The items of a JSON array output, marked ({"_generated", "items"}) or not, decoded one at a time.
+
+    Only one chunk of text and the item being decoded are held; raises ValueError when the file is not such an array.
+    """
+    text = io.TextIOWrapper(handle, encoding="utf-8")
+    decoder = json.JSONDecoder()
+    buf, pos, eof = "", 0, False
+
+    def fill() -> bool:
+        nonlocal buf, pos, eof
+        chunk = text.read(chunk_chars)
+        buf, pos, eof = buf[pos:] + chunk, 0, not chunk
+        return not eof
+
+    def peek() -> str:
+        nonlocal pos
+        while True:
+            while pos < len(buf) and buf[pos].isspace():
+                pos += 1
+            if pos < len(buf):
+                return buf[pos]
+            if not fill():
+                return ""
+
+    def value() -> Any:
+        nonlocal pos
+        peek()
+        while True:
+            try:
+                item, end = decoder.raw_decode(buf, pos)
+            except json.JSONDecodeError:
+                if fill():
+                    continue
+                raise
+            # A number cut off by the end of the buffer ("12" of "123", "5" of "5e3") goes on in the next chunk.
+            if isinstance(item, (int, float)) and not isinstance(item, bool) and not buf[end:].strip(NUMBER_CHARS) and not eof and fill():
+                continue
+            pos = end
+            return item
+
+    def expect(char: str) -> None:
+        nonlocal pos
+        if peek() != char:
+            raise ValueError(f"expected {char!r} at character {pos}")
+        pos += 1
+
+    if peek() == "{":
+        expect("{")
+        while True:
+            key = value()
+            expect(":")
+            if key == "items":
+                break
+            value()
+            expect(",")
+    expect("[")
+    if peek() == "]":
+        return
+    while True:
+        yield value()
+        if peek() == "]":
+            return
+        expect(",")
+
+
+def entry_digest(entry: Any) -> str:
+    return hashlib.sha256(json.dumps(entry, ensure_ascii=False, sort_keys=True).encode("utf-8")).hexdigest()
+
+
+def previous_digests(previous: Storage, name: str) -> Optional[Dict[Any, str]]:
+    """id -> digest for every entry of the previous name; None when there is none, {} when it is unreadable."""
+    handle = previous.open_read(name)
+    if handle is None:
+        return None
+    try:
+        with handle:
+            return {entry.get("id"): entry_digest(entry) for entry in iter_items(handle) if isinstance(entry, dict)}
+    except ValueError:
+        return {}
+
+
+def write_change_report(previous: Storage, kinds: Dict[str, List[Dict[str, Any]]], out: Optional[Storage] = None) -> int:
+    """Write <id>.html per changed entry plus an index into out (default build/reports/changes), replacing the last
+    run's pages; kinds maps an output name in previous (vocabulary for vocabulary.json) to its current entries.
+
+    Kinds without a previous file are skipped: a first build has nothing to compare against.
+    """
+    out = out or LocalStorage(CHANGES_DIR)
+    out.delete("")
+    rows: List[str] = []
+    differ = difflib.HtmlDiff(wrapcolumn=80)
+
+    def page(kind: str, entry_id: str, change: str, old: Optional[Dict[str, Any]], new: Optional[Dict[str, Any]]) -> Tuple[str, str]:
+        name = f"{slugify(entry_id) or 'entry'}.html"
+        diff = differ.make_file(entry_lines(old), entry_lines(new), "previous", "current", context=change == "modified", numlines=3)
+        out.write_text(name, diff.replace("<title></title>", f"<title>{html.escape(entry_id)}</title>"))
+        return entry_id, f'<tr><td>{kind}</td><td>{change}</td><td><a href="{name}">{html.escape(entry_id)}</a></td></tr>'
+
+    for kind, current in kinds.items():
+        before = previous_digests(previous, f"{kind}.json")
+        if before is None:
+            continue
+        after = {entry.get("id"): entry for entry in current if isinstance(entry, dict)}
+        changed = {entry_id for entry_id, entry in after.items() if before.get(entry_id) != entry_digest(entry)} | (set(before) - set(after))
+        kind_rows = []
+        # Modified and removed entries are in the previous file; read it again for just those.
+        wanted = changed & set(before)
+        handle = previous.open_read(f"{kind}.json") if wanted else None
+        if handle is not None:
+            with handle:
+                for old in iter_items(handle):
+                    entry_id = old.get("id") if isinstance(old, dict) else None
+                    if entry_id in wanted:
+                        wanted.discard(entry_id)
+                        kind_rows.append(page(kind, str(entry_id), "modified" if entry_id in after else "removed", old, after.get(entry_id)))
+        kind_rows += [page(kind, str(entry_id), "added", None, after[entry_id]) for entry_id in changed if entry_id not in before]
+        rows += [row for _, row in sorted(kind_rows)]
+    if rows:
+        index = "<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>Changed entries</title></head><body>"
+        index += f"<h1>Changed entries ({len(rows)})</h1><table><tr><th>kind</th><th>change</th><th>entry</th></tr>"
+        index += "".join(rows) + "</table></body></html>\n"
+        out.write_text("index.html", index)
+    return len(rows)
@@ -0,0 +1,93 @@
+"""Stage checkpoints so an interrupted export (crash, Ctrl-C, CI timeout) can resume instead of starting over.
+
+Each stage's state is saved as <tool>/<stage>.json in a storage backend (build/checkpoints by default) along with a fingerprint of the inputs it was
+computed from and a SHA-256 of the state itself. A checkpoint is only reused when both still match: changed inputs
+make it stale, and a damaged or half-written file fails the integrity check; either way the stage simply runs again.
+"""
+
+from __future__ import annotations
+
+import base64
+import hashlib
+import json
+import time
+from dataclasses import asdict
+from pathlib import Path
+from typing import Any, Dict, Iterable, Optional, Tuple, Union
+
+try:
+    from .common import BUILD_DIR, TOOL_VERSION, Dataset, DecodeResult, Record, file_sha256
+    from .storage import LocalStorage, Storage
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import BUILD_DIR, TOOL_VERSION, Dataset, DecodeResult, Record, file_sha256  # type: ignore
+    from storage import LocalStorage, Storage  # type: ignore
+
+CHECKPOINT_DIR = BUILD_DIR / "checkpoints"
+RECORD_LISTS = ("lessons", "vocab", "readings", "units", "culture_notes", "pron_drills", "unclassified")
+
+
+def payload_sha256(payload: Any) -> str:
+    return hashlib.sha256(json.dumps(payload, ensure_ascii=False, sort_keys=True).encode("utf-8")).hexdigest()
+
+
+def input_fingerprint(content: Iterable[Union[str, Path]], configs: Iterable[Optional[Union[str, Path]]], settings: Dict[str, Any]) -> str:
+    """Fingerprint of everything a stage reads: content files by size and mtime (rehashing a large corpus would cost
+    what resuming saves), config files by content, and the flags that change the stage's result."""
+    files = []
+    for raw in content:
+        path = Path(raw)
+        for candidate in [path] if path.is_file() else sorted(path.rglob("*")) if path.is_dir() else []:
+            if candidate.is_file():
+                stat = candidate.stat()
+                files.append([str(candidate), stat.st_size, stat.st_mtime_ns])
+    hashes = {str(path): file_sha256(path) for path in configs if path}
+    return payload_sha256({"tool_version": TOOL_VERSION, "content": files, "configs": hashes, "settings": settings})
+
+
+def dataset_payload(dataset: Dataset) -> Dict[str, Any]:
+    payload: Dict[str, Any] = {name: [asdict(record) for record in getattr(dataset, name)] for name in RECORD_LISTS}
+    payload["decode_errors"] = {source: {**asdict(result), "tail": base64.b64encode(result.tail).decode("ascii"), "original": base64.b64encode(result.original).decode("ascii")} for source, result in dataset.decode_errors.items()}
+    payload["skipped"] = dict(dataset.skipped)
+    return payload
+
+
+def dataset_from_payload(payload: Dict[str, Any]) -> Dataset:
+    dataset = Dataset(**{name: [Record(**row) for row in payload.get(name, [])] for name in RECORD_LISTS})
+    dataset.decode_errors = {source: DecodeResult(**{**row, "tail": base64.b64decode(row["tail"]), "original": base64.b64decode(row.get("original", ""))}) for source, row in payload["decode_errors"].items()}
+    dataset.skipped = dict(payload.get("skipped", {}))
+    return dataset
+
+
+class Checkpoints:
+    """Save and load stage state for one tool; with enabled False nothing is written and nothing is found."""
+
+    def __init__(self, tool: str, enabled: bool = True, root: Optional[Storage] = None) -> None:
+        self.storage = (root or LocalStorage(CHECKPOINT_DIR)).child(tool)
+        self.enabled = enabled
+
+    def save(self, stage: str, fingerprint: str, payload: Any) -> None:
+        if not self.enabled:
+            return
+        document = {"stage": stage, "saved": time.strftime("%Y-%m-%dT%H:%M:%SZ", time.gmtime()), "fingerprint": fingerprint, "sha256": payload_sha256(payload), "payload": payload}
+        # The local backend writes then renames, so an interrupt mid-write leaves the previous checkpoint (or none).
+        self.storage.write_text(f"{stage}.json", json.dumps(document, ensure_ascii=False))
+
+    def load(self, stage: str, fingerprint: str) -> Tuple[Optional[Any], str]:
+        """(payload, note) when the checkpoint is intact and current; (None, why not) otherwise."""
+        raw = self.storage.read_bytes(f"{stage}.json") if self.enabled else None
+        if raw is None:
+            return None, f"No {stage} checkpoint; running {stage}"
+        try:
+            document = json.loads(raw.decode("utf-8"))
+            payload = document["payload"]
+            intact = payload_sha256(payload) == document["sha256"]
+        except (ValueError, KeyError, TypeError):
+            intact = False
+        if not intact:
+            return None, f"The {stage} checkpoint failed its integrity check; running {stage} again"
+        if document.get("fingerprint") != fingerprint:
+            return None, f"The {stage} checkpoint is stale (inputs or flags changed since {document.get('saved')}); running {stage} again"
+        return payload, f"Resumed {stage} from the checkpoint saved {document.get('saved')}"
+
+    def clear(self) -> None:
+        self.storage.delete("")
@@ -0,0 +1,283 @@
+#!/usr/bin/env python3
+"""Sync content with a headless CMS: pull its entries into content/cms, push validation results back to them.
+
+Editors who do not work in git author in the CMS (Strapi, Contentful, or anything with a similar REST API);
+config/cms.json says where each collection lives and how its attributes map to record fields. `pull` rewrites
+content/cms/<collection>.jsonl from the CMS, each record carrying a `cms` block with its collection and CMS ID,
+so the canonical build here picks it up like any other content. `push` validates those records and writes the
+outcome into each CMS entry's status and comment fields, so editors see what to fix where they work.
+"""
+
+from __future__ import annotations
+
+import argparse
+import json
+import os
+import sys
+import urllib.error
+import urllib.parse
+import urllib.request
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple, Union
+
+try:
+    from .common import CLASSIFY_NEEDS, CONFIG_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, Record, classify, display_path, load_id_scheme, load_json
+    from .console import add_output_arguments, configure_output
+    from .errors import ConfigError, SyncFailed
+    from .rules import DEFAULT_RULES_PATH, load_rules, rules_for
+    from .translations import save_objects, source_objects
+    from .validate import entry_for, format_issue, load_schemas, validate_all
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CLASSIFY_NEEDS, CONFIG_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, Record, classify, display_path, load_id_scheme, load_json  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from errors import ConfigError, SyncFailed  # type: ignore
+    from rules import DEFAULT_RULES_PATH, load_rules, rules_for  # type: ignore
+    from translations import save_objects, source_objects  # type: ignore
+    from validate import entry_for, format_issue, load_schemas, validate_all  # type: ignore
+
+DEFAULT_CMS_PATH = CONFIG_DIR / "cms.json"
+CMS_DIR = CONTENT_DIR / "cms"
+CMS_KEY = "cms"
+# Where each CMS keeps entries in a list response, its ID, its fields, and its timestamp, and how it pages.
+FLAVORS: Dict[str, Dict[str, Any]] = {
+    "strapi": {
+        "items_key": "data",
+        "id_key": "id",
+        "fields_key": "attributes",
+        "updated_key": "updatedAt",
+        "paging": "page",
+        "page_param": "pagination[page]",
+        "size_param": "pagination[pageSize]",
+        "update_method": "PUT",
+        "update_wrapper": "data",
+    },
+    "contentful": {
+        "items_key": "items",
+        "id_key": "sys.id",
+        "fields_key": "fields",
+        "updated_key": "sys.updatedAt",
+        "paging": "offset",
+        "page_param": "skip",
+        "size_param": "limit",
+        "update_method": "PATCH",
+        "update_wrapper": "fields",
+    },
+}
+# CMS bookkeeping attributes that are not content.
+SKIPPED_ATTRIBUTES = {"createdAt", "updatedAt", "publishedAt", "createdBy", "updatedBy", "locale", "localizations", "documentId"}
+# Kinds classify() recognises by a kind field rather than by their fields.
+KIND_FIELD = {"reading": "reading", "unit": "unit", "culture_note": "culture_note", "pron_drill": "pron_drill"}
+STATUSES = ("valid", "warnings", "invalid", "not collected")
+
+
+@dataclass
+class Collection:
+    name: str
+    kind: str
+    path: str
+    fields: Dict[str, str] = field(default_factory=dict)  # record field -> CMS attribute
+
+
+@dataclass
+class CmsConfig:
+    base_url: str
+    token_env: str = "CMS_TOKEN"
+    page_size: int = 100
+    status_field: str = "validation_status"
+    comment_field: str = "validation_notes"
+    flavor: Dict[str, Any] = field(default_factory=lambda: dict(FLAVORS["strapi"]))
+    collections: Dict[str, Collection] = field(default_factory=dict)
+
+
+def load_cms_config(path: Optional[Union[str, Path]] = None) -> CmsConfig:
+    cfg_path = Path(path) if path else DEFAULT_CMS_PATH
+    if not cfg_path.exists():
+        raise ConfigError(f"no CMS config at {cfg_path}", "path", str(cfg_path))
+    data = load_json(cfg_path)
+    if not data.get("base_url"):
+        raise ConfigError("cms: base_url is required", "base_url", None)
+    flavor_name = data.get("flavor", "strapi")
+    if flavor_name not in FLAVORS:
+        raise ConfigError(f"cms: unknown flavor {flavor_name}", "flavor", flavor_name, sorted(FLAVORS))
+    config = CmsConfig(base_url=str(data["base_url"]).rstrip("/"), flavor={**FLAVORS[flavor_name], **data.get("overrides", {})})
+    for key in ("token_env", "status_field", "comment_field"):
+        if key in data:
+            setattr(config, key, str(data[key]))
+    config.page_size = int(data.get("page_size", config.page_size))
+    for name, row in data.get("collections", {}).items():
+        if row.get("kind") not in CLASSIFY_NEEDS or not row.get("path"):
+            raise ConfigError(f"cms collection {name}: needs a kind and a path", f"collections.{name}", row, sorted(CLASSIFY_NEEDS))
+        config.collections[name] = Collection(name, row["kind"], "/" + str(row["path"]).lstrip("/"), dict(row.get("fields", {})))
+    if not config.collections:
+        raise ConfigError("cms: no collections configured", "collections", {})
+    return config
+
+
+def dotted(value: Any, path: str) -> Any:
+    for part in path.split("."):
+        value = value.get(part) if isinstance(value, dict) else None
+    return value
+
+
+class CmsClient:
+    """Minimal JSON-over-HTTP client for the CMS REST API, authenticated with a bearer token from the environment."""
+
+    def __init__(self, config: CmsConfig, token: Optional[str] = None) -> None:
+        self.config = config
+        self.token = token if token is not None else os.environ.get(config.token_env, "")
+
+    def request(self, method: str, path: str, query: Optional[Dict[str, Any]] = None, body: Any = None) -> Any:
+        url = self.config.base_url + path + ("?" + urllib.parse.urlencode(query) if query else "")
+        headers = {"Accept": "application/json"}
+        if self.token:
+            headers["Authorization"] = f"Bearer {self.token}"
+        data = None
+        if body is not None:
+            data = json.dumps(body, ensure_ascii=False).encode("utf-8")
+            headers["Content-Type"] = "application/json"
+        try:
+            with urllib.request.urlopen(urllib.request.Request(url, data=data, method=method, headers=headers), timeout=30) as response:
+                raw = response.read()
+        except urllib.error.HTTPError as exc:
+            raise SyncFailed(method, url, exc.code, exc.read().decode("utf-8", errors="replace")[:500]) from exc
+        except (urllib.error.URLError, OSError) as exc:
+            raise SyncFailed(method, url, None, str(getattr(exc, "reason", exc))) from exc
+        return json.loads(raw.decode("utf-8")) if raw.strip() else None
+
+    def entries(self, collection: Collection) -> Iterable[Dict[str, Any]]:
+        """Every entry of a collection, page by page until a short page."""
+        flavor = self.config.flavor
+        page = 0
+        while True:
+            position = page + 1 if flavor["paging"] == "page" else page * self.config.page_size
+            payload = self.request("GET", collection.path, {flavor["page_param"]: position, flavor["size_param"]: self.config.page_size})
+            items = dotted(payload, flavor["items_key"]) or []
+            yield from items
+            if len(items) < self.config.page_size:
+                return
+            page += 1
+
+    def update(self, collection: Collection, cms_id: Any, values: Dict[str, Any]) -> None:
+        flavor = self.config.flavor
+        body = {flavor["update_wrapper"]: values} if flavor["update_wrapper"] else values
+        self.request(flavor["update_method"], f"{collection.path}/{urllib.parse.quote(str(cms_id))}", body=body)
+
+
+def to_record(item: Dict[str, Any], collection: Collection, config: CmsConfig) -> Dict[str, Any]:
+    """A content record from one CMS entry: mapped attributes under their record names, the rest as they are."""
+    flavor = config.flavor
+    attributes = dotted(item, flavor["fields_key"])
+    if not isinstance(attributes, dict):
+        attributes = {key: value for key, value in item.items() if key != "sys"}
+    mapped = set(collection.fields.values())
+    record: Dict[str, Any] = {}
+    for name, attribute in collection.fields.items():
+        value = dotted(attributes, attribute)
+        if value is not None:
+            record[name] = value
+    skipped = SKIPPED_ATTRIBUTES | {config.status_field, config.comment_field, flavor["id_key"]}
+    for key, value in attributes.items():
+        if key not in mapped and key not in skipped and key not in record and value is not None:
+            record[key] = value
+    if collection.kind in KIND_FIELD:
+        record.setdefault("kind", KIND_FIELD[collection.kind])
+    record[CMS_KEY] = {"collection": collection.name, "id": dotted(item, flavor["id_key"]), "updated_at": dotted(item, flavor["updated_key"]) or attributes.get("updatedAt")}
+    return record
+
+
+def pull(client: CmsClient, cms_dir: Path = CMS_DIR, dry_run: bool = False) -> List[Tuple[str, int, List[str]]]:
+    """Rewrite content/cms/<collection>.jsonl from the CMS; (collection, records, problems) per collection."""
+    results: List[Tuple[str, int, List[str]]] = []
+    for collection in client.config.collections.values():
+        records = [to_record(item, collection, client.config) for item in client.entries(collection)]
+        problems = [f"CMS entry {record[CMS_KEY]['id']} would not be collected as {collection.kind}: it needs {CLASSIFY_NEEDS[collection.kind]}" for record in records if classify(record) != collection.kind]
+        if not dry_run:
+            cms_dir.mkdir(parents=True, exist_ok=True)
+            save_objects(cms_dir / f"{collection.name}.jsonl", "jsonl", records)
+        results.append((collection.name, len(records), problems))
+    return results
+
+
+def validation_status(record: Dict[str, Any], collection: Collection, source: str, index: int, scheme, schemas: Dict[str, Dict[str, Any]], rules) -> Tuple[str, List[str]]:
+    """(status, comment lines) for one pulled record, judged as export --validate would."""
+    kind = classify(record)
+    if kind != collection.kind:
+        return "not collected", [f"Not collected as {collection.kind}: it needs {CLASSIFY_NEEDS[collection.kind]}."]
+    entry = entry_for(Record(kind=kind, data=record, source=source, index=index), scheme)
+    issues = validate_all(entry, schemas[kind], rules_for(rules, kind))
+    lines = [format_issue(issue) for issue in issues]
+    if any(issue.severity == "error" for issue in issues):
+        return "invalid", lines
+    return ("warnings" if issues else "valid"), lines
+
+
+def push(client: CmsClient, scheme, schemas: Dict[str, Dict[str, Any]], rules, cms_dir: Path = CMS_DIR, dry_run: bool = False) -> List[Tuple[str, Any, str]]:
+    """Write each pulled record's validation status and issues back to its CMS entry; (collection, CMS ID, status) per entry."""
+    config = client.config
+    results: List[Tuple[str, Any, str]] = []
+    for collection in config.collections.values():
+        path = cms_dir / f"{collection.name}.jsonl"
+        if not path.exists():
+            continue
+        _, records = source_objects(path)
+        for index, record in enumerate(records):
+            link = record.get(CMS_KEY) if isinstance(record, dict) else None
+            if not isinstance(link, dict) or link.get("id") is None:
+                continue
+            status, lines = validation_status(record, collection, display_path(path), index, scheme, schemas, rules)
+            if not dry_run:
+                client.update(collection, link["id"], {config.status_field: status, config.comment_field: "\n".join(lines)})
+            results.append((collection.name, link["id"], status))
+    return results
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Pull content from a headless CMS into content/cms, and push validation results back to it.")
+    parser.add_argument("--config", default=str(DEFAULT_CMS_PATH), help="Path to the CMS config (base URL, collections, field mapping)")
+    parser.add_argument("--dir", default=str(CMS_DIR), help="Where pulled collections are written")
+    shared = argparse.ArgumentParser(add_help=False)
+    shared.add_argument("--dry-run", action="store_true", help="Talk to the CMS but change nothing: no files written, no entries updated")
+    sub = parser.add_subparsers(dest="command", required=True)
+    sub.add_parser("pull", parents=[shared], help="Rewrite content/cms/<collection>.jsonl from the CMS")
+    push_parser = sub.add_parser("push", parents=[shared], help="Validate the pulled records and write status and issues back to their CMS entries")
+    push_parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    push_parser.add_argument("--rules", default=str(DEFAULT_RULES_PATH), help="Custom validation rules config")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    try:
+        config = load_cms_config(args.config)
+    except ValueError as exc:
+        parser.error(str(exc))
+    client = CmsClient(config)
+    if not client.token:
+        print(f"[cms] {config.token_env} is not set; requests go out without a token", file=sys.stderr)
+    cms_dir = Path(args.dir)
+    suffix = " (dry run, nothing changed)" if args.dry_run else ""
+    try:
+        if args.command == "pull":
+            results = pull(client, cms_dir, args.dry_run)
+            print(f"[cms] Pulled {', '.join(f'{count} {name}' for name, count, _ in results)} from {config.base_url} into {display_path(cms_dir)}{suffix}")
+            problems = [problem for _, _, found in results for problem in found]
+            if problems:
+                print(f"[cms] {len(problems)} entries will be rejected by the next export:", file=sys.stderr)
+                for problem in problems:
+                    print(f"    • {problem}", file=sys.stderr)
+            return 0
+        statuses = push(client, load_id_scheme(args.ids), load_schemas(), load_rules(args.rules), cms_dir, args.dry_run)
+    except SyncFailed as exc:
+        print(f"[cms] {exc}", file=sys.stderr)
+        return 1
+    counts = {status: sum(1 for _, _, found in statuses if found == status) for status in STATUSES}
+    print(f"[cms] Pushed validation results for {len(statuses)} entries to {config.base_url}: " + ", ".join(f"{count} {status}" for status, count in counts.items() if count) + suffix)
+    for name, cms_id, status in statuses:
+        if status in ("invalid", "not collected"):
+            print(f"    • {name} {cms_id}: {status}")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,208 @@
+#!/usr/bin/env python3
+"""Propose vocabulary collocations from a plain-text corpus and apply the reviewed ones."""
+
+from __future__ import annotations
+
+import argparse
+import json
+import re
+import sys
+from collections import Counter
+from pathlib import Path
+from typing import Any, Dict, Iterable, Iterator, List, Tuple
+
+try:
+    from .common import BUILD_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, ROOT, Record, collect, in_archive, load_id_scheme, record_id, write_report
+    from .console import add_output_arguments, configure_output
+    from .forms import build_forms_index
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import BUILD_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, ROOT, Record, collect, in_archive, load_id_scheme, record_id, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from forms import build_forms_index  # type: ignore
+
+DEFAULT_PROPOSALS_PATH = BUILD_DIR / "collocations" / "proposals.json"
+TOKEN_RE = re.compile(r"[^\W\d_]+")
+SENTENCE_RE = re.compile(r"[.!?¡¿;:\n]+")
+# A collocation may contain these but never start or end with one ("tomar una decisión", not "tomar una").
+FUNCTION_WORDS = {
+    "a", "al", "con", "de", "del", "el", "en", "es", "la", "las", "lo", "los", "me", "mi", "no", "nos", "o", "para",
+    "pero", "por", "que", "se", "si", "su", "sus", "te", "tu", "un", "una", "unas", "unos", "y",
+}
+
+
+def corpus_sentences(root: Path) -> Iterator[List[str]]:
+    files = [root] if root.is_file() else sorted(root.rglob("*.txt"))
+    for path in files:
+        text = path.read_text(encoding="utf-8", errors="replace")
+        for sentence in SENTENCE_RE.split(text):
+            tokens = [token.lower() for token in TOKEN_RE.findall(sentence)]
+            if len(tokens) > 1:
+                yield tokens
+
+
+def count_collocations(sentences: Iterable[List[str]], forms: Dict[str, List[Dict[str, Any]]], max_words: int) -> Dict[str, Counter]:
+    """Count word spans of 2..max_words around each headword occurrence, with the form replaced by its lemma."""
+    counts: Dict[str, Counter] = {}
+    for tokens in sentences:
+        for pos, token in enumerate(tokens):
+            for match in forms.get(token, []):
+                lemma = match["lemma"].strip().lower()
+                if " " in lemma:
+                    continue
+                seen = set()
+                for size in range(2, max_words + 1):
+                    for start in range(max(0, pos - size + 1), min(pos, len(tokens) - size) + 1):
+                        span = tokens[start : start + size]
+                        if span[0] in FUNCTION_WORDS or span[-1] in FUNCTION_WORDS:
+                            continue
+                        span[pos - start] = lemma
+                        phrase = " ".join(span)
+                        if phrase not in seen:
+                            seen.add(phrase)
+                            counts.setdefault(match["id"], Counter())[phrase] += 1
+    return counts
+
+
+def existing_phrases(entry: Dict[str, Any]) -> set:
+    return {str(item.get("phrase", "")).lower() for item in entry.get("collocations", []) if isinstance(item, dict)}
+
+
+def propose(records: List[Record], counts: Dict[str, Counter], scheme, min_count: int, top: int) -> List[Dict[str, Any]]:
+    """One proposal per vocabulary ID, pointing at the first source record that defines it."""
+    proposals: List[Dict[str, Any]] = []
+    seen = set()
+    for record in records:
+        entry_id = record_id(record, scheme)
+        if entry_id in seen or entry_id not in counts:
+            continue
+        seen.add(entry_id)
+        known = existing_phrases(record.data)
+        ranked = [(phrase, n) for phrase, n in counts[entry_id].most_common() if n >= min_count and phrase not in known]
+        # Drop spans already covered by a longer span that occurs just as often.
+        kept = [(phrase, n) for phrase, n in ranked if not any(n == m and f" {phrase} " in f" {other} " and other != phrase for other, m in ranked)]
+        if kept:
+            proposals.append({
+                "id": entry_id,
+                "spanish": record.data.get("spanish"),
+                "source": record.source,
+                "index": record.index,
+                "collocations": [{"phrase": phrase, "count": n, "approved": False} for phrase, n in kept[:top]],
+            })
+    return proposals
+
+
+def apply_to_source(path: Path, updates: Dict[int, Tuple[str, List[Dict[str, Any]]]]) -> Tuple[int, List[str]]:
+    """Add approved collocations to records of a one-object-per-line file; returns (added, problems)."""
+    if path.suffix != ".jsonl":
+        return 0, [f"{path}: only .jsonl sources can be updated in place"]
+    lines = path.read_text(encoding="utf-8").splitlines()
+    problems: List[str] = []
+    added = 0
+    index = -1
+    for lineno, line in enumerate(lines):
+        if not line.strip():
+            continue
+        index += 1
+        if index not in updates:
+            continue
+        spanish, items = updates[index]
+        try:
+            record = json.loads(line)
+        except ValueError:
+            problems.append(f"{path}:{lineno + 1}: not a JSON object, left unchanged")
+            continue
+        if not isinstance(record, dict) or record.get("spanish") != spanish:
+            problems.append(f"{path}:{lineno + 1}: record no longer matches '{spanish}', left unchanged")
+            continue
+        known = existing_phrases(record)
+        new = [item for item in items if item["phrase"].lower() not in known]
+        if not new:
+            continue
+        record["collocations"] = record.get("collocations", []) + new
+        added += len(new)
+        lines[lineno] = json.dumps(record, ensure_ascii=False, separators=(",", ":"))
+    if added:
+        path.write_text("\n".join(lines) + "\n", encoding="utf-8")
+    return added, problems
+
+
+def extract(args: argparse.Namespace) -> int:
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+    entries = [dict(record.data, id=record_id(record, scheme)) for record in dataset.vocab]
+    corpus = Path(args.corpus)
+    if not corpus.exists():
+        print(f"[collocations] {corpus} does not exist", file=sys.stderr)
+        return 1
+    counts = count_collocations(corpus_sentences(corpus), build_forms_index(entries), args.max_words)
+    proposals = propose(dataset.vocab, counts, scheme, args.min_count, args.top)
+
+    out_path = Path(args.proposals)
+    out_path.parent.mkdir(parents=True, exist_ok=True)
+    out_path.write_text(json.dumps(proposals, ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+
+    total = sum(len(p["collocations"]) for p in proposals)
+    out = ["Collocation proposals", f"- corpus: {corpus}", f"- headwords with proposals: {len(proposals)}", f"- collocations proposed: {total}", f"- proposals file: {out_path}"]
+    for proposal in proposals:
+        out.append(f"## {proposal['spanish']} ({proposal['id']})")
+        out += [f"- {item['phrase']}: {item['count']}" for item in proposal["collocations"]]
+    write_report("collocations.md", out)
+    print("\n".join(out[:5]))
+    print("[collocations] Set \"approved\": true on the collocations to keep, then run `collocations.py apply`.")
+    return 0
+
+
+def apply(args: argparse.Namespace) -> int:
+    proposals_path = Path(args.proposals)
+    if not proposals_path.exists():
+        print(f"[collocations] {proposals_path} does not exist; run `collocations.py extract` first", file=sys.stderr)
+        return 1
+    by_source: Dict[str, Dict[int, Tuple[str, List[Dict[str, Any]]]]] = {}
+    for proposal in json.loads(proposals_path.read_text(encoding="utf-8")):
+        approved = [{"phrase": item["phrase"], "count": item["count"]} for item in proposal.get("collocations", []) if item.get("approved") is True]
+        if approved:
+            by_source.setdefault(proposal["source"], {})[proposal["index"]] = (proposal["spanish"], approved)
+    problems: List[str] = []
+    applied = 0
+    for source, updates in by_source.items():
+        if in_archive(source):
+            problems.append(f"{source}: sources inside an archive cannot be updated in place")
+            continue
+        # Sources inside the repo are recorded relative to ROOT; anything else as it was given.
+        path = ROOT / source if (ROOT / source).exists() else Path(source)
+        added, issues = apply_to_source(path, updates)
+        applied += added
+        problems += issues
+    print(f"[collocations] Applied {applied} approved collocations to {len(by_source)} source files")
+    for problem in problems:
+        print(f"    • {problem}", file=sys.stderr)
+    return 1 if problems else 0
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Extract vocabulary collocations from a corpus for review.")
+    sub = parser.add_subparsers(dest="command", required=True)
+
+    ext = sub.add_parser("extract", help="Count collocations per headword and write proposals for review")
+    ext.add_argument("--corpus", required=True, help="Directory of plain-text (.txt) Spanish files, or a single file")
+    ext.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to collect")
+    ext.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    ext.add_argument("--proposals", default=str(DEFAULT_PROPOSALS_PATH), help="Where to write the proposals file")
+    ext.add_argument("--min-count", type=int, default=3, help="Minimum corpus frequency for a collocation")
+    ext.add_argument("--max-words", type=int, default=3, help="Longest collocation in words")
+    ext.add_argument("--top", type=int, default=5, help="Most collocations proposed per headword")
+
+    app = sub.add_parser("apply", help="Write approved collocations into the source vocabulary files")
+    app.add_argument("--proposals", default=str(DEFAULT_PROPOSALS_PATH), help="Reviewed proposals file")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    if args.command == "extract" and args.max_words < 2:
+        parser.error("--max-words must be at least 2")
+    return extract(args) if args.command == "extract" else apply(args)
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,78 @@
+"""Author notes kept in source records: `_comments` never reaches the outputs but is collected into todos.md.
+
+A record may carry `"_comments": ["TODO: add a second example", {"author": "ana", "text": "check the gloss"}]`;
+a bare string is one comment with no author. Export drops the key from every entry it builds and lists every
+comment in build/reports/todos.md, grouped by source file and author, so TODOs written in content stay in view.
+A comment is a TODO when it starts with TODO or FIXME, or says `"todo": true`.
+"""
+
+from __future__ import annotations
+
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+
+try:
+    from .common import Record
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import Record  # type: ignore
+
+COMMENTS_KEY = "_comments"
+UNATTRIBUTED = "(no author)"
+TODO_PREFIXES = ("todo", "fixme")
+
+
+@dataclass
+class Comment:
+    source: str
+    index: int
+    id: Optional[str]
+    author: str
+    text: str
+    todo: bool
+
+
+def parse_comment(item: Any) -> Optional[Dict[str, Any]]:
+    """{author, text, todo} for one comment, or None when it holds no text."""
+    if isinstance(item, str):
+        author, text, flagged = None, item, None
+    elif isinstance(item, dict):
+        author, text, flagged = item.get("author"), item.get("text"), item.get("todo")
+    else:
+        return None
+    if not isinstance(text, str) or not text.strip():
+        return None
+    text = " ".join(text.split())
+    todo = flagged if isinstance(flagged, bool) else text.lower().startswith(TODO_PREFIXES)
+    return {"author": author.strip() if isinstance(author, str) and author.strip() else UNATTRIBUTED, "text": text, "todo": todo}
+
+
+def record_comments(record: Record, entry_id: Optional[str] = None) -> List[Comment]:
+    """The record's comments, labelled with entry_id (or the ID the record states, for records with no kind)."""
+    if entry_id is None and isinstance(record.data.get("id"), str):
+        entry_id = record.data["id"]
+    raw = record.data.get(COMMENTS_KEY)
+    items = raw if isinstance(raw, list) else [raw] if raw is not None else []
+    comments = []
+    for item in items:
+        parsed = parse_comment(item)
+        if parsed is not None:
+            comments.append(Comment(record.source, record.index, entry_id, **parsed))
+    return comments
+
+
+def strip_comments(entry: Dict[str, Any]) -> Dict[str, Any]:
+    return {key: value for key, value in entry.items() if key != COMMENTS_KEY} if COMMENTS_KEY in entry else entry
+
+
+def todo_report(comments: List[Comment]) -> List[str]:
+    lines = ["Source comments", f"- comments: {len(comments)}", f"- TODOs: {sum(1 for comment in comments if comment.todo)}"]
+    groups: Dict[str, Dict[str, List[Comment]]] = {}
+    for comment in comments:
+        groups.setdefault(comment.source, {}).setdefault(comment.author, []).append(comment)
+    for source in sorted(groups):
+        lines.append(f"## {source}")
+        for author in sorted(groups[source], key=lambda name: (name == UNATTRIBUTED, name.lower())):
+            lines.append(f"### {author}")
+            for comment in sorted(groups[source][author], key=lambda comment: (not comment.todo, comment.index)):
+                lines.append(f"- {'[ ] ' if comment.todo else ''}{comment.id or f'record {comment.index}'}: {comment.text}")
+    return lines
@@ -0,0 +1,764 @@
+"""Shared helpers for the content tools."""
+
+from __future__ import annotations
+
+import hashlib
+import io
+import json
+import os
+import platform
+import re
+import socket
+import subprocess
+import sys
+import tarfile
+import time
+import unicodedata
+import zipfile
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import IO, Any, Dict, Iterable, Iterator, List, Optional, Tuple, Union
+
+ROOT = Path(__file__).resolve().parents[2]
+CONTENT_DIR = ROOT / "content"
+CONFIG_DIR = ROOT / "config"
+BUILD_DIR = ROOT / "build"
+REPORTS_DIR = BUILD_DIR / "reports"
+DEFAULT_IDS_PATH = CONFIG_DIR / "ids.json"
+DEFAULT_EVENTS_PATH = REPORTS_DIR / "events.jsonl"
+DEFAULT_CONFLICT_CACHE = CONFIG_DIR / "conflict-resolutions.json"
+DEFAULT_LINT_PATH = CONFIG_DIR / "lint.json"
+# Lesson phases that present new vocabulary, and those where learners use it; lint config lesson_flow overrides either.
+DEFAULT_LESSON_FLOW = {
+    "presentation": ["english_anchor", "system_logic", "meaning_depth", "presentation"],
+    "practice": ["context_scene", "examples", "practice", "production"],
+}
+# Per-directory settings (e.g. third-party licensing) that are never content themselves.
+META_NAME = "_meta.json"
+# Declares one unit (title, theme, ordered lessons); collected as a record of kind "unit".
+UNIT_NAME = "_unit.json"
+# Partner deliveries are read without unpacking; their records' sources read archive.zip!/inner/path.json.
+ARCHIVE_SUFFIXES = (".zip", ".tar", ".tar.gz", ".tgz")
+ARCHIVE_SEPARATOR = "!/"
+# Files past these limits are skipped, not rejected: a stray media dump or a generated file nested thousands
+# deep would otherwise exhaust memory or the decoder's recursion limit.
+MAX_SOURCE_BYTES = 16_000_000
+MAX_JSON_DEPTH = 64
+# How much of a file binary sniffing looks at, and the share of control or undecodable characters that marks it binary.
+SNIFF_BYTES = 8192
+BINARY_RATIO = 0.1
+BINARY_SIGNATURES = {b"\x89PNG": "PNG image", b"\xff\xd8\xff": "JPEG image", b"GIF8": "GIF image", b"%PDF": "PDF", b"\x1f\x8b": "gzip data", b"ID3": "MP3 audio", b"OggS": "Ogg audio", b"SQLite format 3": "SQLite database"}
+SKIP_CATEGORY = "skipped: not a text JSON file"
+JSON_STRUCTURE = re.compile(r'"(?:[^"\\]|\\.)*"|[\[\]{}]')
+# Lesson text held back until its vocabulary unlocks.
+LOCKED = "[locked]"
+
+TOOL_VERSION = "0.2.0"
+
+try:
+    from . import conflicts
+    from .errors import ConfigError
+    from .fieldnames import CORRECTIONS_KEY, correct_fields
+except ImportError:  # pragma: no cover - allow running as a script
+    import conflicts  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from fieldnames import CORRECTIONS_KEY, correct_fields  # type: ignore
+
+KSUID_EPOCH = 1400000000
+BASE62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+FALLBACK_GENERATORS = ("content-hash", "uuid7", "ksuid")
+# Canonical files (without .json) holding the built-in kinds' entries; plugin kinds add their own beside them.
+ENTRY_OUTPUTS = ("vocabulary", "lessons", "readings", "units", "culture_notes", "pron_drills")
+
+
+@dataclass
+class Record:
+    kind: str
+    data: Dict[str, Any]
+    source: str
+    index: int
+
+
+@dataclass
+class DecodeResult:
+    objects: List[Any]
+    error: Optional[str] = None
+    error_offset: Optional[int] = None
+    byte_offset: Optional[int] = None
+    tail: bytes = b""
+    # The file exactly as read. tail and byte_offset refer to the decoded text, which differs once conflicts are resolved.
+    original: bytes = b""
+
+
+@dataclass
+class Dataset:
+    lessons: List[Record] = field(default_factory=list)
+    vocab: List[Record] = field(default_factory=list)
+    readings: List[Record] = field(default_factory=list)
+    units: List[Record] = field(default_factory=list)
+    culture_notes: List[Record] = field(default_factory=list)
+    pron_drills: List[Record] = field(default_factory=list)
+    unclassified: List[Record] = field(default_factory=list)
+    decode_errors: Dict[str, DecodeResult] = field(default_factory=dict)
+    # Source -> why it was not read as content (binary, too large, nested too deep); never a reject.
+    skipped: Dict[str, str] = field(default_factory=dict)
+
+    def classified(self) -> List[Record]:
+        """Every record a built-in kind claimed."""
+        return self.vocab + self.lessons + self.readings + self.units + self.culture_notes + self.pron_drills
+
+
+@dataclass
+class IdScheme:
+    prefix: str = "mmspanish"
+    separator: str = "__"
+    fallback: str = "ksuid"
+    hash_algorithm: str = "sha256"
+    hash_length: int = 16
+    kinds: Dict[str, str] = field(default_factory=lambda: {"lesson": "lesson", "vocab": "vocab", "reading": "reading", "unit": "unit", "culture_note": "culture", "pron_drill": "drill"})
+
+    def kind_prefix(self, kind: str) -> str:
+        return f"{self.prefix}{self.separator}{self.kinds.get(kind, kind)}_"
+
+    def pattern(self, kind: str) -> "re.Pattern[str]":
+        return re.compile("^" + re.escape(self.kind_prefix(kind)) + r"[A-Za-z0-9_\-]+$")
+
+
+class EventLog:
+    """Append-only JSONL log of pipeline decisions; a no-op when no path is given."""
+
+    def __init__(self, path: Optional[Union[str, Path]] = None) -> None:
+        self.path = Path(path) if path else None
+        self._handle = None
+        if self.path:
+            self.path.parent.mkdir(parents=True, exist_ok=True)
+            self._handle = open(self.path, "w", encoding="utf-8")
+
+    def emit(self, event: str, source: str, reason: str = "", **details: Any) -> None:
+        if not self._handle:
+            return
+        payload = {"ts": time.strftime("%Y-%m-%dT%H:%M:%SZ", time.gmtime()), "event": event, "source": source, "reason": reason}
+        payload.update(details)
+        self._handle.write(json.dumps(payload, ensure_ascii=False) + "\n")
+
+    def close(self) -> None:
+        if self._handle:
+            self._handle.close()
+            self._handle = None
+
+
+def load_json(path: Union[str, Path]) -> Any:
+    with open(path, "r", encoding="utf-8") as handle:
+        return json.load(handle)
+
+
+def load_id_scheme(path: Optional[Union[str, Path]] = None) -> IdScheme:
+    cfg_path = Path(path) if path else DEFAULT_IDS_PATH
+    scheme = IdScheme()
+    if not cfg_path.exists():
+        return scheme
+    data = load_json(cfg_path)
+    scheme.prefix = str(data.get("prefix", scheme.prefix))
+    scheme.separator = str(data.get("separator", scheme.separator))
+    scheme.fallback = str(data.get("fallback", scheme.fallback))
+    scheme.hash_algorithm = str(data.get("hash_algorithm", scheme.hash_algorithm))
+    scheme.hash_length = int(data.get("hash_length", scheme.hash_length))
+    kinds = data.get("kinds")
+    if isinstance(kinds, dict):
+        scheme.kinds.update({str(k): str(v) for k, v in kinds.items()})
+    if scheme.fallback not in FALLBACK_GENERATORS:
+        raise ConfigError(f"Unknown ID fallback '{scheme.fallback}'. Expected one of: {', '.join(FALLBACK_GENERATORS)}", "fallback", scheme.fallback, list(FALLBACK_GENERATORS))
+    if scheme.hash_algorithm not in hashlib.algorithms_available:
+        raise ConfigError(f"Unknown hash algorithm '{scheme.hash_algorithm}'", "hash_algorithm", scheme.hash_algorithm)
+    return scheme
+
+
+def load_lesson_flow(path: Optional[Union[str, Path]] = None) -> Dict[str, List[str]]:
+    """The lint config's lesson_flow over the defaults: which step phases present vocabulary, and which practice it."""
+    cfg_path = Path(path) if path else DEFAULT_LINT_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    flow = {**DEFAULT_LESSON_FLOW, **(data.get("lesson_flow") or {})}
+    for group, phases in flow.items():
+        if not isinstance(phases, list) or not all(isinstance(phase, str) for phase in phases):
+            raise ConfigError(f"lesson_flow.{group} must be a list of step phases", f"lesson_flow.{group}", phases)
+    return flow
+
+
+def slugify(text: str) -> str:
+    folded = unicodedata.normalize("NFKD", text)
+    folded = "".join(ch for ch in folded if not unicodedata.combining(ch))
+    folded = re.sub(r"[^A-Za-z0-9]+", "_", folded.lower())
+    return folded.strip("_")
+
+
+def base62(value: int, width: int) -> str:
+    digits = []
+    while value:
+        value, rem = divmod(value, 62)
+        digits.append(BASE62[rem])
+    return "".join(reversed(digits)).rjust(width, "0")
+
+
+def new_ksuid() -> str:
+    timestamp = int(time.time()) - KSUID_EPOCH
+    payload = timestamp.to_bytes(4, "big") + os.urandom(16)
+    return base62(int.from_bytes(payload, "big"), 27)
+
+
+def new_uuid7() -> str:
+    millis = int(time.time() * 1000) & ((1 << 48) - 1)
+    rand = int.from_bytes(os.urandom(10), "big")
+    value = (millis << 80) | (0x7 << 76) | ((rand >> 62) & 0xFFF) << 64 | (0b10 << 62) | (rand & ((1 << 62) - 1))
+    raw = f"{value:032x}"
+    return f"{raw[:8]}-{raw[8:12]}-{raw[12:16]}-{raw[16:20]}-{raw[20:]}"
+
+
+def content_hash(data: Dict[str, Any], scheme: IdScheme) -> str:
+    canonical = json.dumps({k: v for k, v in data.items() if k != "id"}, ensure_ascii=False, sort_keys=True)
+    digest = hashlib.new(scheme.hash_algorithm, canonical.encode("utf-8")).hexdigest()
+    return digest[: scheme.hash_length]
+
+
+def fallback_id(data: Dict[str, Any], scheme: IdScheme) -> str:
+    if scheme.fallback == "content-hash":
+        return content_hash(data, scheme)
+    if scheme.fallback == "uuid7":
+        return new_uuid7().replace("-", "")
+    return new_ksuid()
+
+
+def unit_id(level: str, number: int, scheme: IdScheme) -> str:
+    return scheme.kind_prefix("unit") + f"{level.lower()}_{number:02d}"
+
+
+def generate_id(record: Record, scheme: IdScheme) -> str:
+    """Slug the headword or title; a vocab `sense_key` (como conj. vs. como verb) keeps homographs apart as <slug>__<sense_key>.
+
+    Units with a level and number are named after them, so declared and inferred units agree.
+    """
+    if record.kind == "unit" and entry_level(record.data) in LEVELS and isinstance(record.data.get("number"), int):
+        return unit_id(entry_level(record.data), record.data["number"], scheme)
+    headword = record.data.get("spanish") if record.kind == "vocab" else record.data.get("title")
+    if record.kind == "pron_drill" and not headword:
+        # Drills are often named only by their drill field ({"drill": "r vs rr"}).
+        headword = record.data.get("drill")
+    slug = slugify(headword) if isinstance(headword, str) else ""
+    sense_key = record.data.get("sense_key") if record.kind == "vocab" else None
+    if slug and isinstance(sense_key, str) and slugify(sense_key):
+        slug += scheme.separator + slugify(sense_key)
+    return scheme.kind_prefix(record.kind) + (slug or fallback_id(record.data, scheme))
+
+
+def record_id(record: Record, scheme: IdScheme) -> str:
+    existing = record.data.get("id")
+    if isinstance(existing, str) and existing.strip():
+        return existing.strip()
+    return generate_id(record, scheme)
+
+
+def skip_whitespace(text: str, idx: int) -> int:
+    while idx < len(text) and text[idx].isspace():
+        idx += 1
+    return idx
+
+
+def skip_separators(text: str, idx: int) -> int:
+    while idx < len(text) and (text[idx].isspace() or text[idx] == ","):
+        idx += 1
+    return idx
+
+
+def decode_error(objects: List[Any], exc: json.JSONDecodeError, start: int) -> DecodeResult:
+    """Describe a failure; error_offset is where the damaged value starts, the message says where parsing broke."""
+    message = f"{exc.msg} (line {exc.lineno}, column {exc.colno}, char {exc.pos})"
+    return DecodeResult(objects=objects, error=message, error_offset=start)
+
+
+def decode_array_items(text: str, idx: int, objects: List[Any]) -> Tuple[int, Optional[DecodeResult]]:
+    """Decode the elements of a top-level array one at a time, stopping at the first damaged element.
+
+    Elements must be separated by exactly one comma, as in strict JSON: recovery salvages what comes before
+    the damage, it does not repair a missing, doubled, or trailing comma and carry on past it.
+    """
+    decoder = json.JSONDecoder()
+    idx = skip_whitespace(text, idx + 1)
+    if idx < len(text) and text[idx] == "]":
+        return idx + 1, None
+    while True:
+        if idx >= len(text):
+            return idx, DecodeResult(objects=objects, error="unterminated top-level array", error_offset=idx)
+        try:
+            value, idx = decoder.raw_decode(text, idx)
+        except json.JSONDecodeError as exc:
+            return idx, decode_error(objects, exc, idx)
+        objects.append(value)
+        start, idx = idx, skip_whitespace(text, idx)
+        if idx >= len(text):
+            return idx, DecodeResult(objects=objects, error="unterminated top-level array", error_offset=idx)
+        if text[idx] == "]":
+            return idx + 1, None
+        if text[idx] != ",":
+            line, column = text.count("\n", 0, idx) + 1, idx - text.rfind("\n", 0, idx)
+            return start, DecodeResult(objects=objects, error=f"Expecting ',' delimiter (line {line}, column {column}, char {idx})", error_offset=start)
+        idx = skip_whitespace(text, idx + 1)
+
+
+def decode_objects(text: str, recover: bool = False) -> DecodeResult:
+    """Decode a file holding one JSON value, JSON lines, or back-to-back objects.
+
+    With recover set, a damaged top-level array is decoded element by element so
+    every complete object before the corruption point is kept.
+    """
+    decoder = json.JSONDecoder()
+    objects: List[Any] = []
+    idx = 0
+    while True:
+        idx = skip_separators(text, idx)
+        if idx >= len(text):
+            return DecodeResult(objects=objects)
+        try:
+            value, end = decoder.raw_decode(text, idx)
+        except json.JSONDecodeError as exc:
+            if not (recover and text[idx] == "["):
+                return decode_error(objects, exc, idx)
+            idx, failure = decode_array_items(text, idx, objects)
+            if failure:
+                return failure
+            continue
+        if isinstance(value, list):
+            objects.extend(value)
+        else:
+            objects.append(value)
+        idx = end
+
+
+# What classify() looks for in each kind, for messages about records it did not recognise.
+CLASSIFY_NEEDS = {
+    "lesson": "a steps list",
+    "vocab": "spanish plus english_gloss or pos",
+    "reading": "kind: reading, or text plus chapter",
+    "unit": "kind: unit, or the _unit.json file name",
+    "culture_note": "kind: culture_note",
+    "pron_drill": "kind: pron_drill, or a phoneme or drill field",
+}
+
+
+def classify(obj: Any) -> Optional[str]:
+    if not isinstance(obj, dict):
+        return None
+    if obj.get("kind") in ("unit", "culture_note", "pron_drill"):
+        return obj["kind"]
+    if isinstance(obj.get("steps"), list):
+        return "lesson"
+    if "phoneme" in obj or "drill" in obj:
+        return "pron_drill"
+    if obj.get("kind") == "reading" or (isinstance(obj.get("text"), str) and "chapter" in obj):
+        return "reading"
+    if isinstance(obj.get("spanish"), str) and ("english_gloss" in obj or "pos" in obj):
+        return "vocab"
+    return None
+
+
+def is_content_path(parts: Tuple[str, ...]) -> bool:
+    return parts[-1] != META_NAME and not any(part.startswith(".") for part in parts)
+
+
+def iter_source_files(paths: Iterable[Union[str, Path]]) -> Iterator[Path]:
+    for raw in paths:
+        path = Path(raw)
+        if path.is_file():
+            yield path
+            continue
+        for candidate in sorted(path.rglob("*")):
+            if candidate.is_file() and is_content_path(candidate.relative_to(path).parts):
+                yield candidate
+
+
+def is_archive(path: Union[str, Path]) -> bool:
+    return str(path).lower().endswith(ARCHIVE_SUFFIXES)
+
+
+def iter_archive(path: Union[Path, IO[bytes]], max_bytes: Optional[int] = None, skipped: Optional[Dict[str, str]] = None) -> Iterator[Tuple[str, bytes]]:
+    """(inner path, bytes) for every content file in a zip or tar archive (a path or an open binary file), in name
+    order; nested archives are not opened. Members over max_bytes are left unread and listed in skipped."""
+    skipped = {} if skipped is None else skipped
+    if zipfile.is_zipfile(path):
+        with zipfile.ZipFile(path) as archive:
+            for info in sorted(archive.infolist(), key=lambda info: info.filename):
+                parts = tuple(part for part in info.filename.split("/") if part)
+                if not info.is_dir() and parts and is_content_path(parts) and not is_archive(info.filename):
+                    if too_large(info.file_size, max_bytes):
+                        skipped["/".join(parts)] = size_reason(info.file_size, max_bytes)
+                        continue
+                    yield "/".join(parts), archive.read(info)
+        return
+    if not isinstance(path, Path):
+        path.seek(0)
+    with tarfile.open(path, "r:*") if isinstance(path, Path) else tarfile.open(fileobj=path, mode="r:*") as archive:
+        for member in sorted(archive.getmembers(), key=lambda member: member.name):
+            parts = tuple(part for part in member.name.split("/") if part not in ("", "."))
+            if member.isfile() and parts and is_content_path(parts) and not is_archive(member.name):
+                if too_large(member.size, max_bytes):
+                    skipped["/".join(parts)] = size_reason(member.size, max_bytes)
+                    continue
+                handle = archive.extractfile(member)
+                if handle is not None:
+                    yield "/".join(parts), handle.read()
+
+
+def too_large(size: int, max_bytes: Optional[int]) -> bool:
+    return max_bytes is not None and size > max_bytes
+
+
+def size_reason(size: int, max_bytes: Optional[int]) -> str:
+    return f"{size} bytes, over the {max_bytes}-byte limit"
+
+
+def binary_reason(raw: bytes) -> Optional[str]:
+    """Why raw is not text, judged from its first SNIFF_BYTES; None when it reads as text."""
+    for signature, name in BINARY_SIGNATURES.items():
+        if raw.startswith(signature):
+            return f"binary content ({name})"
+    head = raw[:SNIFF_BYTES]
+    if b"\0" in head:
+        return "binary content (NUL bytes)"
+    text = head.decode("utf-8", errors="replace")
+    odd = sum(1 for char in text if char == "\ufffd" or (char < " " and char not in "\t\n\r\f"))
+    if text and odd / len(text) > BINARY_RATIO:
+        return f"binary content ({odd} of the first {len(text)} characters are control bytes or not UTF-8)"
+    return None
+
+
+def nesting_depth(text: str, limit: Optional[int] = None) -> int:
+    """Deepest array/object nesting in text, ignoring brackets inside strings; stops counting once past limit."""
+    depth = deepest = 0
+    for match in JSON_STRUCTURE.finditer(text):
+        token = match.group()
+        if token in "[{":
+            depth += 1
+            deepest = max(deepest, depth)
+            if limit is not None and deepest > limit:
+                break
+        elif token in "]}":
+            depth = max(depth - 1, 0)
+    return deepest
+
+
+def iter_sources(paths: Iterable[Union[str, Path]], max_bytes: Optional[int] = None, skipped: Optional[Dict[str, str]] = None) -> Iterator[Tuple[str, bytes]]:
+    """(source, bytes) for every content file; archives found among them are read member by member.
+
+    Files (or archive members) over max_bytes are never read; they go into skipped with the reason.
+    """
+    skipped = {} if skipped is None else skipped
+    for path in iter_source_files(paths):
+        if not is_archive(path):
+            size = path.stat().st_size
+            if too_large(size, max_bytes):
+                skipped[display_path(path)] = size_reason(size, max_bytes)
+                continue
+            yield display_path(path), path.read_bytes()
+            continue
+        oversized: Dict[str, str] = {}
+        try:
+            members = list(iter_archive(path, max_bytes, oversized))
+        except (OSError, tarfile.TarError, zipfile.BadZipFile) as exc:
+            # Surfaces as a decode error (and a reject) rather than aborting the whole collection.
+            yield display_path(path), f"unreadable archive: {exc}".encode("utf-8")
+            continue
+        skipped.update({f"{display_path(path)}{ARCHIVE_SEPARATOR}{inner}": reason for inner, reason in oversized.items()})
+        for inner, data in members:
+            yield f"{display_path(path)}{ARCHIVE_SEPARATOR}{inner}", data
+
+
+def resolve_revision(ref: str, cwd: Path = ROOT) -> str:
+    """The full commit hash a git ref (branch, tag, hash, HEAD~3) names; ConfigError when git does not know it."""
+    try:
+        done = subprocess.run(["git", "rev-parse", "--verify", "--quiet", f"{ref}^{{commit}}"], cwd=cwd, capture_output=True, text=True, timeout=10)
+    except (OSError, subprocess.SubprocessError) as exc:
+        raise ConfigError(f"cannot run git to resolve {ref}: {exc}", "at", ref) from None
+    if done.returncode != 0 or not done.stdout.strip():
+        raise ConfigError(f"unknown git revision '{ref}'", "at", ref)
+    return done.stdout.strip()
+
+
+def iter_git_sources(
+    paths: Iterable[Union[str, Path]],
+    commit: str,
+    cwd: Path = ROOT,
+    max_bytes: Optional[int] = None,
+    skipped: Optional[Dict[str, str]] = None,
+) -> Iterator[Tuple[str, bytes]]:
+    """iter_sources as of a past commit: the same files and archive members, read from the commit's tree instead of
+    the working directory. Paths outside the repository have no history and yield nothing."""
+    skipped = {} if skipped is None else skipped
+    names: List[str] = []
+    for raw in paths:
+        base = display_path(Path(raw))
+        if Path(base).is_absolute():
+            continue
+        # -l adds each blob's size: "<mode> blob <hash> <size>\t<name>".
+        listed = subprocess.run(["git", "ls-tree", "-r", "-z", "-l", commit, "--", base], cwd=cwd, capture_output=True, timeout=60, check=True)
+        for row in sorted(listed.stdout.decode("utf-8").split("\0")):
+            meta, _, name = row.partition("\t")
+            relative = Path(name).parts[len(Path(base).parts) :] or (Path(name).name,)
+            if not name or name in names or not is_content_path(relative):
+                continue
+            size = int(meta.split()[3]) if meta.split()[3].isdigit() else 0
+            if not is_archive(name) and too_large(size, max_bytes):
+                skipped[name] = size_reason(size, max_bytes)
+                continue
+            names.append(name)
+    if not names:
+        return
+    # One cat-file process for the whole tree: each blob comes back as "<sha> blob <size>\n<bytes>\n".
+    # ls-tree names are relative to cwd, hence the ./ when asking for them.
+    request = "".join(f"{commit}:./{name}\n" for name in names).encode("utf-8")
+    output = subprocess.run(["git", "cat-file", "--batch"], cwd=cwd, input=request, capture_output=True, timeout=300, check=True).stdout
+    offset = 0
+    for name in names:
+        header_end = output.index(b"\n", offset)
+        size = int(output[offset:header_end].split()[2])
+        data = output[header_end + 1 : header_end + 1 + size]
+        offset = header_end + 1 + size + 1
+        if not is_archive(name):
+            yield name, data
+            continue
+        oversized: Dict[str, str] = {}
+        try:
+            members = list(iter_archive(io.BytesIO(data), max_bytes, oversized))
+        except (OSError, tarfile.TarError, zipfile.BadZipFile) as exc:
+            yield name, f"unreadable archive: {exc}".encode("utf-8")
+            continue
+        skipped.update({f"{name}{ARCHIVE_SEPARATOR}{inner}": reason for inner, reason in oversized.items()})
+        for inner, member in members:
+            yield f"{name}{ARCHIVE_SEPARATOR}{inner}", member
+
+
+def display_path(path: Path) -> str:
+    try:
+        return str(path.resolve().relative_to(ROOT))
+    except ValueError:
+        return str(path)
+
+
+def in_archive(source: str) -> bool:
+    return ARCHIVE_SEPARATOR in source
+
+
+def source_file(source: str) -> Path:
+    """The file on disk a record source lives in; for archive members, the archive itself."""
+    source = source.split(ARCHIVE_SEPARATOR, 1)[0]
+    return ROOT / source if (ROOT / source).exists() else Path(source)
+
+
+def collect(
+    paths: Iterable[Union[str, Path]],
+    events: Optional[EventLog] = None,
+    recover: bool = False,
+    resolve_conflicts: bool = False,
+    conflict_cache: Optional[Union[str, Path]] = None,
+    at: Optional[str] = None,
+    max_bytes: Optional[int] = MAX_SOURCE_BYTES,
+    max_depth: int = MAX_JSON_DEPTH,
+) -> Dataset:
+    """Collect records from every source file; see decode_objects for what recover salvages.
+
+    With at (a commit hash, see resolve_revision) the files are read as they were in that commit rather than
+    from the working directory.
+
+    Files that are not text JSON (binary, over max_bytes, or nested deeper than max_depth) are not decoded:
+    they land in dataset.skipped with the reason instead of becoming decode errors and rejects.
+
+    With resolve_conflicts set, merge-conflict markers are resolved before decoding, applying
+    resolutions recorded in the conflict cache (see triage_conflicts.py) where choose() would guess.
+    Misspelled field names are renamed to the schema field they resemble and listed in the
+    record's field_corrections (see fieldnames.py); pinned records are left as written.
+    """
+    events = events or EventLog()
+    dataset = Dataset()
+    cache = conflicts.ConflictCache(conflict_cache or DEFAULT_CONFLICT_CACHE) if resolve_conflicts else None
+    sources = iter_git_sources(paths, at, max_bytes=max_bytes, skipped=dataset.skipped) if at else iter_sources(paths, max_bytes, dataset.skipped)
+    for source, raw in sources:
+        reason = binary_reason(raw)
+        if reason:
+            dataset.skipped[source] = reason
+            continue
+        text = raw.decode("utf-8", errors="replace")
+        if resolve_conflicts and conflicts.has_conflicts(text):
+            text, notes = conflicts.resolve_conflicts(text, cache)
+            for note in notes:
+                events.emit("conflict_resolved", source, note)
+        if nesting_depth(text, max_depth) > max_depth:
+            dataset.skipped[source] = f"nested deeper than {max_depth} levels"
+            continue
+        try:
+            result = decode_objects(text, recover)
+        except RecursionError:
+            dataset.skipped[source] = "nested too deep to decode"
+            continue
+        events.emit("file_parsed", source, f"{len(result.objects)} objects decoded")
+        if result.error:
+            result.byte_offset = len(text[: result.error_offset].encode("utf-8"))
+            result.tail = text.encode("utf-8")[result.byte_offset :]
+            result.original = raw
+            dataset.decode_errors[source] = result
+            events.emit("entry_rejected", source, result.error, offset=result.byte_offset, kept=len(result.objects))
+        for index, obj in enumerate(result.objects):
+            if isinstance(obj, dict) and not is_pinned(obj):
+                fixed = correct_fields(obj)
+                for typo, name in fixed.get(CORRECTIONS_KEY, {}).items() if fixed is not obj else ():
+                    events.emit("field_corrected", source, f"{typo} -> {name}", index=index)
+                obj = fixed
+            kind = "unit" if source.rsplit("/", 1)[-1] == UNIT_NAME and isinstance(obj, dict) else classify(obj)
+            record = Record(kind=kind or "unknown", data=obj if isinstance(obj, dict) else {"value": obj}, source=source, index=index)
+            if kind == "lesson":
+                dataset.lessons.append(record)
+            elif kind == "vocab":
+                dataset.vocab.append(record)
+            elif kind == "reading":
+                dataset.readings.append(record)
+            elif kind == "unit":
+                dataset.units.append(record)
+            elif kind == "culture_note":
+                dataset.culture_notes.append(record)
+            elif kind == "pron_drill":
+                dataset.pron_drills.append(record)
+            else:
+                dataset.unclassified.append(record)
+            events.emit("record_classified", source, "matched no known kind" if kind is None else f"classified as {kind}", index=index, kind=record.kind)
+    for source, reason in sorted(dataset.skipped.items()):
+        events.emit("file_skipped", source, reason)
+    return dataset
+
+
+def write_report(name: str, lines: List[str], reports_dir: Path = REPORTS_DIR) -> Path:
+    reports_dir.mkdir(parents=True, exist_ok=True)
+    path = reports_dir / name
+    path.write_text("\n".join(lines) + "\n", encoding="utf-8")
+    return path
+
+
+def level_of(record: Record) -> str:
+    level = record.data.get("level")
+    if isinstance(level, str) and level:
+        return level.upper()
+    match = re.search(r"_(A1|A2|B1|B2|C1|C2)(?:_|$)", str(record.data.get("id", "")))
+    return match.group(1) if match else "UNSET"
+
+
+def describe(record: Record) -> str:
+    return f"{record.source}#{record.index}"
+
+
+def is_spanish_key(key: Any) -> bool:
+    key_str = str(key).lower()
+    return key_str in {"es", "you", "spanish"} or key_str.endswith("_es")
+
+
+def spanish_texts(value: Any, path: str = "") -> Iterator[Tuple[str, str]]:
+    """Yield (path, text) for every Spanish-language string inside a record."""
+    if isinstance(value, dict):
+        for key, item in value.items():
+            child = f"{path}.{key}" if path else str(key)
+            if isinstance(item, str):
+                if is_spanish_key(key) and item.strip() and item.strip() != LOCKED:
+                    yield child, item
+            else:
+                yield from spanish_texts(item, child)
+    elif isinstance(value, list):
+        for idx, item in enumerate(value):
+            yield from spanish_texts(item, f"{path}[{idx}]")
+
+
+def is_pinned(entry: Dict[str, Any]) -> bool:
+    """A source record marked `"locked": true` (legally reviewed text, say) that no merge, enrichment, or auto-fix may alter."""
+    return entry.get("locked") is True
+
+
+def string_values(value: Any) -> Iterator[str]:
+    """Yield every string nested anywhere inside a record."""
+    if isinstance(value, str):
+        yield value
+    elif isinstance(value, dict):
+        for item in value.values():
+            yield from string_values(item)
+    elif isinstance(value, list):
+        for item in value:
+            yield from string_values(item)
+
+
+def words(text: str) -> List[str]:
+    return [word.lower() for word in re.findall(r"\w+", text)]
+
+
+LEVELS = ("A1", "A2", "B1", "B2", "C1", "C2")
+
+
+def entry_level(entry: Dict[str, Any]) -> str:
+    level = entry.get("level")
+    if isinstance(level, str) and level.upper() in LEVELS:
+        return level.upper()
+    match = re.search(r"_(A1|A2|B1|B2|C1|C2)(?:_|$)", str(entry.get("id", "")))
+    return match.group(1) if match else "UNSET"
+
+
+def lesson_order_key(entry: Dict[str, Any]) -> tuple:
+    """Sort lessons by level, unit, then lesson number (falling back to the number in the ID)."""
+    level = entry_level(entry)
+    level_rank = LEVELS.index(level) if level in LEVELS else len(LEVELS)
+    number = entry.get("lesson_number")
+    if not isinstance(number, int):
+        match = re.search(r"_(?:A1|A2|B1|B2|C1|C2)_(\d+)", str(entry.get("id", "")))
+        number = int(match.group(1)) if match else 0
+    unit = entry.get("unit") if isinstance(entry.get("unit"), int) else 0
+    return (level_rank, unit, number, str(entry.get("id", "")))
+
+
+def file_sha256(path: Union[str, Path]) -> Optional[str]:
+    path = Path(path)
+    if not path.is_file():
+        return None
+    return hashlib.sha256(path.read_bytes()).hexdigest()
+
+
+def git_state(cwd: Path = ROOT) -> Dict[str, Any]:
+    """Commit and dirty flag of the content repo, or nulls when git is unavailable."""
+    def git(*args: str) -> Optional[str]:
+        try:
+            done = subprocess.run(["git", *args], cwd=cwd, capture_output=True, text=True, timeout=10)
+        except (OSError, subprocess.SubprocessError):
+            return None
+        return done.stdout.strip() if done.returncode == 0 else None
+
+    status = git("status", "--porcelain")
+    return {"commit": git("rev-parse", "HEAD"), "dirty": bool(status) if status is not None else None}
+
+
+def run_metadata(args: Any, configs: Iterable[Union[str, Path]] = ()) -> Dict[str, Any]:
+    """Everything needed to reconstruct a run: tool version, content commit, host, flags, and config hashes."""
+    return {
+        "tool_version": TOOL_VERSION,
+        "started_at": time.strftime("%Y-%m-%dT%H:%M:%SZ", time.gmtime()),
+        "git": git_state(),
+        "hostname": socket.gethostname(),
+        "python": platform.python_version(),
+        "argv": sys.argv[1:],
+        "flags": {key: value for key, value in sorted(vars(args).items())},
+        "configs": {display_path(Path(path)): file_sha256(path) for path in configs},
+    }
+
+
+def run_report_lines(run: Dict[str, Any]) -> List[str]:
+    git = run["git"]
+    out = [
+        "## run",
+        f"- tool version: {run['tool_version']}",
+        f"- started: {run['started_at']}",
+        f"- content commit: {git['commit'] or 'unknown'}" + (" (dirty)" if git["dirty"] else ""),
+    ]
+    if run.get("at"):
+        out.append(f"- content read from git revision {run['at']['ref']} ({run['at']['commit']})")
+    out.append(f"- host: {run['hostname']} (python {run['python']})")
+    out += [f"- flag {key}: {value}" for key, value in run["flags"].items()]
+    out += [f"- config {path}: {digest or 'missing'}" for path, digest in run["configs"].items()]
+    return out
@@ -0,0 +1,149 @@
+#!/usr/bin/env python3
+"""Shell completion for the content tools, with entry IDs, tags, and levels read from the canonical build."""
+
+from __future__ import annotations
+
+import argparse
+import importlib
+import json
+import sys
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+
+try:
+    from .common import BUILD_DIR, LEVELS
+    from .console import add_output_arguments, configure_output
+    from .verify import unmark_generated
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import BUILD_DIR, LEVELS  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from verify import unmark_generated  # type: ignore
+
+TOOLS_DIR = Path(__file__).resolve().parent
+CANONICAL_FILES = ("vocabulary.json", "lessons.json", "readings.json", "units.json", "culture_notes.json", "pron_drills.json")
+# Argument dests whose values come from the dataset rather than the parser; choices, when set, win.
+DYNAMIC_VALUES = {"id": "ids", "replaced_by": "ids", "tag": "tags", "tags": "tags", "level": "levels", "levels": "levels"}
+
+BASH_SCRIPT = """# Content tool completion; load with: source <(python3 {script} bash)
+_content_tools() {{
+    local IFS=$'\\n'
+    local start=1
+    [[ ${{COMP_WORDS[0]}} == python* ]] && start=2
+    if (( COMP_CWORD < start )) || [[ ${{COMP_WORDS[start - 1]}} != *.py ]]; then
+        return 0
+    fi
+    COMPREPLY=($(python3 {script} complete -- "${{COMP_WORDS[@]:start - 1:COMP_CWORD - start + 2}}" 2>/dev/null))
+}}
+complete -o default -F _content_tools python3 python {tools}
+"""
+ZSH_PREFIX = "# zsh runs the bash completion through bashcompinit\nautoload -U +X bashcompinit && bashcompinit\n"
+
+
+class ParserCaptured(Exception):
+    def __init__(self, parser: argparse.ArgumentParser) -> None:
+        super().__init__(parser.prog)
+        self.parser = parser
+
+
+def tool_names() -> List[str]:
+    """Every script in tools/content with a command line."""
+    return sorted(path.stem for path in TOOLS_DIR.glob("*.py") if 'if __name__ == "__main__":' in path.read_text(encoding="utf-8"))
+
+
+def tool_parser(name: str) -> argparse.ArgumentParser:
+    """The tool's argument parser, captured by running main() up to parse_args without running the tool."""
+    module = importlib.import_module(f"{__package__}.{name}" if __package__ else name)
+    original = argparse.ArgumentParser.parse_args
+
+    def capture(self: argparse.ArgumentParser, *args: Any, **kwargs: Any) -> Any:
+        raise ParserCaptured(self)
+
+    argparse.ArgumentParser.parse_args = capture  # type: ignore[method-assign]
+    try:
+        module.main([])
+    except ParserCaptured as captured:
+        return captured.parser
+    finally:
+        argparse.ArgumentParser.parse_args = original  # type: ignore[method-assign]
+    raise ValueError(f"{name}.main() returned without parsing arguments")
+
+
+def dataset_values(kind: str, canonical: Path) -> List[str]:
+    """Entry IDs, tags, or levels across the canonical files; an unbuilt tree completes levels only."""
+    entries: List[Dict[str, Any]] = []
+    for name in CANONICAL_FILES:
+        path = canonical / name
+        if path.is_file():
+            entries += [entry for entry in unmark_generated(json.loads(path.read_text(encoding="utf-8"))) if isinstance(entry, dict)]
+    if kind == "ids":
+        return sorted({str(entry["id"]) for entry in entries if entry.get("id")})
+    if kind == "tags":
+        return sorted({str(tag) for entry in entries for tag in entry.get("tags") or []})
+    return sorted({str(entry["level"]) for entry in entries if entry.get("level")} | set(LEVELS), key=lambda level: (level not in LEVELS, level))
+
+
+def action_values(action: argparse.Action, canonical: Path) -> List[str]:
+    if action.choices is not None and not isinstance(action, argparse._SubParsersAction):
+        return [str(choice) for choice in action.choices]
+    kind = DYNAMIC_VALUES.get(action.dest)
+    return dataset_values(kind, canonical) if kind else []
+
+
+def candidates(words: List[str], canonical: Path) -> List[str]:
+    """Completions for the last of words, where words[0] is the tool script and the last word may be empty."""
+    name = Path(words[0]).stem if words else ""
+    if name not in tool_names():
+        return []
+    parser = tool_parser(name)
+    pending: Optional[argparse.Action] = None
+    positionals = 0
+    for word in words[1:-1]:
+        options = {option: action for action in parser._actions for option in action.option_strings}
+        if word in options:
+            pending = options[word] if options[word].nargs != 0 else None
+            continue
+        if pending is not None and not word.startswith("-"):
+            if pending.nargs not in ("+", "*"):
+                pending = None
+            continue
+        pending = None
+        positional = [action for action in parser._actions if not action.option_strings][positionals:positionals + 1]
+        if positional and isinstance(positional[0], argparse._SubParsersAction) and word in positional[0].choices:
+            parser, positionals = positional[0].choices[word], 0
+        elif positional:
+            positionals += 0 if positional[0].nargs in ("+", "*") else 1
+    current = words[-1] if len(words) > 1 else ""
+    if pending is not None and not current.startswith("-"):
+        found = action_values(pending, canonical)
+    elif current.startswith("-"):
+        found = sorted(option for action in parser._actions for option in action.option_strings if option.startswith("--") or len(action.option_strings) == 1)
+    else:
+        positional = [action for action in parser._actions if not action.option_strings][positionals:positionals + 1]
+        found = list(positional[0].choices) if positional and isinstance(positional[0], argparse._SubParsersAction) else action_values(positional[0], canonical) if positional else []
+    return [value for value in found if value.startswith(current)]
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Print shell completion for the content tools, or answer one completion request.")
+    sub = parser.add_subparsers(dest="command", required=True)
+    sub.add_parser("bash", help="Print the bash completion script")
+    sub.add_parser("zsh", help="Print the completion script for zsh (through bashcompinit)")
+    complete = sub.add_parser("complete", help="Print completions for a partial command line, one per line (used by the scripts)")
+    complete.add_argument("words", nargs="*", help="The tool script, its arguments so far, and the word being completed")
+    complete.add_argument("--canonical", default=str(BUILD_DIR / "canonical"), help="Directory holding canonical JSON for ID, tag, and level values")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    if args.command == "complete":
+        for value in candidates(args.words, Path(args.canonical)):
+            print(value)
+        return 0
+    script = BASH_SCRIPT.format(script=Path(__file__).resolve(), tools=" ".join(f"{name}.py" for name in tool_names()))
+    print((ZSH_PREFIX if args.command == "zsh" else "") + script, end="")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
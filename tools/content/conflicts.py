@@ -0,0 +1,172 @@
+"""Resolve merge-conflict markers left in content files before decoding them."""
+
+from __future__ import annotations
+
+import datetime as dt
+import hashlib
+import json
+import re
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple, Union
+
+try:
+    from .errors import ConflictUnresolvable
+except ImportError:  # pragma: no cover - allow running as a script
+    from errors import ConflictUnresolvable  # type: ignore
+
+MARKER_RE = re.compile(r"^(<{7}|\|{7}|={7}|>{7})(?:[ \t].*)?$")
+
+
+@dataclass
+class Chunk:
+    """A conflict choose() could only settle by keeping both sides."""
+
+    line: int
+    ours: List[str]
+    base: Optional[List[str]]
+    theirs: List[str]
+
+
+class ConflictCache:
+    """Recorded manual resolutions (rerere-style), keyed by the two conflicting sides in either order."""
+
+    def __init__(self, path: Optional[Union[str, Path]] = None) -> None:
+        self.path = Path(path) if path else None
+        self.entries: Dict[str, Dict[str, Any]] = {}
+        if self.path and self.path.exists():
+            self.entries = json.loads(self.path.read_text(encoding="utf-8"))
+
+    @staticmethod
+    def key(ours: List[str], theirs: List[str]) -> str:
+        sides = sorted("\n".join(line.strip() for line in side if line.strip()) for side in (ours, theirs))
+        return hashlib.sha256("\n\0\n".join(sides).encode("utf-8")).hexdigest()[:16]
+
+    def lookup(self, ours: List[str], theirs: List[str]) -> Optional[List[str]]:
+        entry = self.entries.get(self.key(ours, theirs))
+        return list(entry["resolution"]) if entry else None
+
+    def record(self, chunk: Chunk, resolution: List[str], source: str) -> None:
+        self.entries[self.key(chunk.ours, chunk.theirs)] = {
+            "resolution": resolution,
+            "ours": chunk.ours,
+            "theirs": chunk.theirs,
+            "first_seen": f"{source}:{chunk.line}",
+            "recorded_at": dt.datetime.now(dt.timezone.utc).isoformat(timespec="seconds"),
+        }
+
+    def save(self) -> None:
+        if self.path:
+            self.path.parent.mkdir(parents=True, exist_ok=True)
+            self.path.write_text(json.dumps(self.entries, ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+
+
+def has_conflicts(text: str) -> bool:
+    """Whether any line is a conflict marker; a stray separator or closing marker needs resolving as much as a block."""
+    return any(MARKER_RE.match(line) for line in text.splitlines())
+
+
+def clean_choice(ours: List[str], base: Optional[List[str]], theirs: List[str]) -> bool:
+    """Whether choose() can pick a side outright instead of keeping both."""
+    return ours == theirs or (base is not None and (ours == base or theirs == base))
+
+
+def choose(ours: List[str], base: Optional[List[str]], theirs: List[str]) -> List[str]:
+    """Pick a side when the base shows only one changed; otherwise keep ours plus theirs' new lines.
+
+    Content files are JSON records, so keeping both variants is safe: duplicates merge by ID later.
+    """
+    if base is not None:
+        if ours == base:
+            return theirs
+        if theirs == base:
+            return ours
+    if ours == theirs:
+        return ours
+    known = set(ours) | set(base or [])
+    return ours + [line for line in theirs if line not in known]
+
+
+def resolve_block(
+    lines: List[str],
+    start: int,
+    notes: List[str],
+    cache: Optional[ConflictCache] = None,
+    pending: Optional[List[Chunk]] = None,
+) -> Tuple[List[str], int]:
+    """Resolve the conflict opened at lines[start]; returns the chosen lines and the index after the block.
+
+    A recorded resolution in cache wins over keeping both sides; conflicts without one are appended to pending.
+    """
+    sections: List[List[str]] = [[]]
+    kinds = ["ours"]
+    idx = start + 1
+    while idx < len(lines):
+        line = lines[idx]
+        marker = MARKER_RE.match(line)
+        token = marker.group(1)[0] if marker else ""
+        if token == "<":
+            notes.append(f"line {idx + 1}: nested inside the conflict at line {start + 1}")
+            nested, idx = resolve_block(lines, idx, notes, cache, pending)
+            sections[-1].extend(nested)
+            continue
+        if token == "|" and kinds == ["ours"]:
+            sections.append([])
+            kinds.append("base")
+        elif token == "=" and kinds[-1] != "theirs":
+            sections.append([])
+            kinds.append("theirs")
+        elif token == ">" and kinds[-1] == "theirs":
+            break
+        else:
+            sections[-1].append(line)
+        idx += 1
+    else:
+        notes.append(f"line {start + 1}: conflict never closed; resolved with the sections present")
+    parts = dict(zip(kinds, sections))
+    ours, base, theirs = parts["ours"], parts.get("base"), parts.get("theirs", [])
+    style = "diff3" if "base" in parts else "two-way"
+    recorded = None if clean_choice(ours, base, theirs) or cache is None else cache.lookup(ours, theirs)
+    if recorded is not None:
+        notes.append(f"line {start + 1}: {style} conflict resolved from the conflict cache")
+        return recorded, idx + 1
+    if not clean_choice(ours, base, theirs):
+        if pending is not None:
+            pending.append(Chunk(start + 1, ours, base, theirs))
+        notes.append(f"line {start + 1}: {style} conflict kept both sides (no recorded resolution)")
+    else:
+        notes.append(f"line {start + 1}: {style} conflict resolved")
+    return choose(ours, base, theirs), idx + 1
+
+
+def resolve_conflicts(text: str, cache: Optional[ConflictCache] = None, pending: Optional[List[Chunk]] = None) -> Tuple[str, List[str]]:
+    """Resolve two-way and diff3 conflict blocks, including nested and unterminated ones.
+
+    Stray closing or separator markers outside a block are dropped and noted.
+    """
+    lines = text.splitlines()
+    out: List[str] = []
+    notes: List[str] = []
+    idx = 0
+    while idx < len(lines):
+        marker = MARKER_RE.match(lines[idx])
+        if marker and marker.group(1)[0] == "<":
+            chosen, idx = resolve_block(lines, idx, notes, cache, pending)
+            out.extend(chosen)
+            continue
+        # A separator with no opening marker is as stray as a lone base or closing marker; no JSON line looks like one.
+        if marker and marker.group(1)[0] in "|=>":
+            notes.append(f"line {idx + 1}: stray {marker.group(1)} marker dropped")
+        else:
+            out.append(lines[idx])
+        idx += 1
+    return "\n".join(out) + ("\n" if text.endswith("\n") else ""), notes
+
+
+def require_resolved(text: str, source: str, cache: Optional[ConflictCache] = None) -> Tuple[str, List[str]]:
+    """resolve_conflicts, but raise ConflictUnresolvable instead of keeping both sides of any conflict."""
+    pending: List[Chunk] = []
+    resolved, notes = resolve_conflicts(text, cache, pending)
+    if pending:
+        raise ConflictUnresolvable(source, pending)
+    return resolved, notes
@@ -0,0 +1,97 @@
+"""Output modes shared by every tool: --no-color, --no-emoji, and --ascii, plus the NO_COLOR convention.
+
+CI logs and some terminals mangle the bullets and emoji the tools print, and screen readers read them out as
+noise. Rather than threading a mode through every print, configure_output() wraps stdout and stderr so each
+line is rewritten on its way out; config/output.json holds the palette and the symbol replacements.
+"""
+
+from __future__ import annotations
+
+import argparse
+import os
+import re
+import sys
+import unicodedata
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Optional, TextIO, Union
+
+try:
+    from .common import CONFIG_DIR, load_json
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, load_json  # type: ignore
+
+DEFAULT_OUTPUT_PATH = CONFIG_DIR / "output.json"
+ANSI_ESCAPE = re.compile(r"\x1b\[[0-9;]*m")
+VARIATION_SELECTOR = "️"
+
+
+@dataclass
+class OutputMode:
+    color: bool = True
+    emoji: bool = True
+    ascii: bool = False
+    palette: Dict[str, str] = field(default_factory=dict)
+    emoji_words: Dict[str, str] = field(default_factory=dict)
+    ascii_symbols: Dict[str, str] = field(default_factory=dict)
+
+    @property
+    def passthrough(self) -> bool:
+        return self.color and self.emoji and not self.ascii
+
+    def render(self, text: str) -> str:
+        if not self.color:
+            text = ANSI_ESCAPE.sub("", text)
+        if not self.emoji or self.ascii:
+            for symbol, word in self.emoji_words.items():
+                text = text.replace(symbol, word)
+            # Whatever pictographs the table does not name are dropped rather than read out.
+            text = "".join(ch for ch in text if ch != VARIATION_SELECTOR and unicodedata.category(ch) != "So")
+        if self.ascii:
+            for symbol, plain in self.ascii_symbols.items():
+                text = text.replace(symbol, plain)
+            folded = unicodedata.normalize("NFKD", text)
+            text = "".join(ch for ch in folded if not unicodedata.combining(ch)).encode("ascii", "replace").decode("ascii")
+        return text
+
+
+class ModeStream:
+    """A text stream that renders everything written to it through an OutputMode."""
+
+    def __init__(self, stream: TextIO, mode: OutputMode) -> None:
+        self.stream = stream
+        self.mode = mode
+
+    def write(self, text: str) -> int:
+        self.stream.write(self.mode.render(text))
+        return len(text)
+
+    def __getattr__(self, name: str) -> Any:
+        return getattr(self.stream, name)
+
+
+def load_output_mode(path: Optional[Union[str, Path]] = None) -> OutputMode:
+    cfg_path = Path(path) if path else DEFAULT_OUTPUT_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    return OutputMode(palette=dict(data.get("palette", {})), emoji_words=dict(data.get("emoji", {})), ascii_symbols=dict(data.get("ascii", {})))
+
+
+def add_output_arguments(parser: argparse.ArgumentParser) -> None:
+    group = parser.add_argument_group("output")
+    group.add_argument("--no-color", action="store_true", help="Never print ANSI colors (also set by a non-empty NO_COLOR)")
+    group.add_argument("--no-emoji", action="store_true", help="Replace emoji and pictographs with words, or drop them")
+    group.add_argument("--ascii", action="store_true", help="Print ASCII only: no emoji, plain bullets and dashes, accents folded")
+    group.add_argument("--output-config", default=str(DEFAULT_OUTPUT_PATH), help="Palette and symbol replacements for the output modes")
+
+
+def configure_output(args: argparse.Namespace) -> OutputMode:
+    """Apply the output flags to sys.stdout and sys.stderr; returns the mode so tools with their own colors can follow it."""
+    mode = load_output_mode(getattr(args, "output_config", None))
+    mode.color = not (getattr(args, "no_color", False) or os.environ.get("NO_COLOR") or os.environ.get("TERM") == "dumb")
+    mode.emoji = not getattr(args, "no_emoji", False)
+    mode.ascii = getattr(args, "ascii", False)
+    if not mode.passthrough:
+        for name in ("stdout", "stderr"):
+            stream = getattr(sys, name)
+            setattr(sys, name, ModeStream(stream.stream if isinstance(stream, ModeStream) else stream, mode))
+    return mode
@@ -0,0 +1,39 @@
+"""Culture notes: cultural sidebars as their own entries, linked to the lessons they accompany."""
+
+from __future__ import annotations
+
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+REMOTE_PREFIXES = ("http://", "https://")
+
+
+def check_culture_notes(notes: List[Dict[str, Any]], lessons: List[Dict[str, Any]], assets: Optional[Path] = None) -> List[Tuple[str, str]]:
+    """(note ID, problem) for related lessons that do not exist and local media files missing under assets.
+
+    Links and remote media are not fetched; pass assets=None to skip the file check entirely.
+    """
+    known = {lesson["id"] for lesson in lessons}
+    problems: List[Tuple[str, str]] = []
+    for note in notes:
+        for ref in note.get("lessons") or []:
+            if ref not in known:
+                problems.append((note["id"], f"lists unknown lesson {ref}"))
+        for item in note.get("media") or []:
+            if not isinstance(item, dict):
+                continue
+            src = str(item.get("src", "")).strip()
+            if not src:
+                problems.append((note["id"], f"{item.get('type', 'media')} reference has no src"))
+            elif assets is not None and item.get("type") != "link" and not src.startswith(REMOTE_PREFIXES) and not (assets / src).is_file():
+                problems.append((note["id"], f"{item.get('type', 'media')} {src} not found under {assets}"))
+    return problems
+
+
+def notes_by_lesson(notes: List[Dict[str, Any]]) -> Dict[str, List[str]]:
+    """Lesson ID -> IDs of the culture notes that list it, in note order."""
+    index: Dict[str, List[str]] = {}
+    for note in notes:
+        for ref in note.get("lessons") or []:
+            index.setdefault(str(ref), []).append(note["id"])
+    return index
@@ -0,0 +1,165 @@
+#!/usr/bin/env python3
+"""Curate balanced study decks from the exported vocabulary.
+
+`decks.py build --level A2 --size 500 --max-per-tag 40` picks the most useful words first (frequency rank,
+then difficulty), skips any word whose tags already fill their share of the deck, and keeps to a difficulty
+range when one is given. Entries without tags share one "(untagged)" share. The deck is written under
+build/decks/<name>/ in canonical JSON and every flashcard format asked for (see exporters.py), ordered easiest
+first; build/reports/decks.md lists how it is balanced and, for a short deck, which constraint ran out.
+"""
+
+from __future__ import annotations
+
+import argparse
+import json
+import sys
+from collections import Counter
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+try:
+    from .common import LEVELS, entry_level, slugify, write_report
+    from .console import add_output_arguments, configure_output
+    from .difficulty import DEFAULT_FREQUENCY_PATH, dataset_ranks, difficulty, load_frequency_ranks
+    from .export import write_json
+    from .exporters import EXPORTERS
+    from .storage import DEFAULT_STORAGE_PATH, load_storage
+    from .verify import manifest_row, unmark_generated
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import LEVELS, entry_level, slugify, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from difficulty import DEFAULT_FREQUENCY_PATH, dataset_ranks, difficulty, load_frequency_ranks  # type: ignore
+    from export import write_json  # type: ignore
+    from exporters import EXPORTERS  # type: ignore
+    from storage import DEFAULT_STORAGE_PATH, load_storage  # type: ignore
+    from verify import manifest_row, unmark_generated  # type: ignore
+
+UNTAGGED = "(untagged)"
+
+
+def entry_tags(entry: Dict[str, Any]) -> List[str]:
+    tags = entry.get("tags")
+    found = sorted({str(tag).strip().lower() for tag in tags if str(tag).strip()}) if isinstance(tags, list) else []
+    return found or [UNTAGGED]
+
+
+def scored(vocab: List[Dict[str, Any]], ranks: Dict[str, int]) -> List[Tuple[Dict[str, Any], Optional[int], float]]:
+    """(entry, frequency rank, difficulty score); the export's difficulty is used when it ran with --difficulty."""
+    rows = []
+    for entry in vocab:
+        info = entry.get("difficulty") if isinstance(entry.get("difficulty"), dict) else difficulty(entry, ranks)
+        rank = entry.get("frequency_rank") if isinstance(entry.get("frequency_rank"), int) else info.get("frequency_rank")
+        rows.append((entry, rank if isinstance(rank, int) else None, float(info.get("score", 0.5))))
+    return rows
+
+
+def select_deck(
+    rows: List[Tuple[Dict[str, Any], Optional[int], float]], size: int, max_per_tag: Optional[int], low: float, high: float
+) -> Tuple[List[Tuple[Dict[str, Any], Optional[int], float]], Counter]:
+    """The deck, easiest first, and why candidates were passed over ("difficulty", or the tag whose share was full)."""
+    skipped: Counter = Counter()
+    per_tag: Counter = Counter()
+    picked = []
+    # Ranked words first, most frequent first; unranked ones by difficulty; the ID settles ties so decks are reproducible.
+    for row in sorted(rows, key=lambda row: (row[1] is None, row[1] or 0, row[2], str(row[0].get("id")))):
+        if len(picked) == size:
+            break
+        entry, _, score = row
+        if not low <= score <= high:
+            skipped["difficulty"] += 1
+            continue
+        tags = entry_tags(entry)
+        full = [tag for tag in tags if max_per_tag is not None and per_tag[tag] >= max_per_tag]
+        if full:
+            skipped[f"tag {full[0]}"] += 1
+            continue
+        per_tag.update(tags)
+        picked.append(row)
+    return sorted(picked, key=lambda row: (row[2], row[1] is None, row[1] or 0, str(row[0].get("id")))), skipped
+
+
+def deck_report(name: str, constraints: Dict[str, Any], candidates: int, deck: List[Tuple[Dict[str, Any], Optional[int], float]], skipped: Counter) -> List[str]:
+    scores = [score for _, _, score in deck]
+    lines = ["Study deck", f"- deck: {name}", f"- constraints: {', '.join(f'{key} {value}' for key, value in constraints.items() if value is not None)}"]
+    lines += [f"- entries: {len(deck)} of {constraints['size']} asked for, from {candidates} candidates"]
+    if scores:
+        lines.append(f"- difficulty: {min(scores):.2f} to {max(scores):.2f}, mean {sum(scores) / len(scores):.2f}")
+        lines.append(f"- with a frequency rank: {sum(1 for _, rank, _ in deck if rank is not None)}")
+    if len(deck) < constraints["size"]:
+        lines.append("## passed over")
+        lines += [f"- {reason}: {count}" for reason, count in skipped.most_common()] or ["- nothing: the level has no more entries"]
+    lines.append("## by tag")
+    lines += [f"- {tag}: {count}" for tag, count in sorted(Counter(tag for entry, _, _ in deck for tag in entry_tags(entry)).items(), key=lambda kv: (-kv[1], kv[0]))]
+    lines.append("## by part of speech")
+    lines += [f"- {pos}: {count}" for pos, count in Counter(str(entry.get("pos") or "(none)") for entry, _, _ in deck).most_common()]
+    return lines
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Curate balanced study decks from the exported vocabulary.")
+    parser.add_argument("--storage", default=str(DEFAULT_STORAGE_PATH), help="Path to the storage backend config")
+    parser.add_argument("--out", default="canonical", help="Location of canonical JSON inside the storage backend")
+    sub = parser.add_subparsers(dest="command", required=True)
+    build = sub.add_parser("build", help="Select a deck under frequency, tag, and difficulty constraints and write it")
+    build.add_argument("--level", required=True, help="Comma-separated CEFR levels the deck draws from, e.g. A2 or A1,A2")
+    build.add_argument("--size", type=int, required=True, help="Number of entries in the deck")
+    build.add_argument("--max-per-tag", type=int, metavar="N", help="At most N entries carrying any one tag")
+    build.add_argument("--min-difficulty", type=float, default=0.0, help="Leave out entries easier than this (0-1)")
+    build.add_argument("--max-difficulty", type=float, default=1.0, help="Leave out entries harder than this (0-1)")
+    build.add_argument("--format", action="append", choices=sorted(EXPORTERS), help="Flashcard or data format to write besides vocabulary.json (repeatable; default anki)")
+    build.add_argument("--name", help="Deck name and directory (default <levels>-<size>)")
+    build.add_argument("--dest", default="decks", help="Location inside the storage backend; each deck goes in <dest>/<name>/")
+    build.add_argument(
+        "--frequency",
+        default=str(DEFAULT_FREQUENCY_PATH),
+        help="Word frequency list used when the export has no frequency ranks; without it, ranks come from the dataset's own usage",
+    )
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    levels = [level.strip().upper() for level in args.level.split(",") if level.strip()]
+    unknown = [level for level in levels if level not in LEVELS]
+    if not levels or unknown:
+        parser.error(f"--level must list CEFR levels ({', '.join(LEVELS)})")
+    if args.size < 1:
+        parser.error("--size must be at least 1")
+    if args.max_per_tag is not None and args.max_per_tag < 1:
+        parser.error("--max-per-tag must be at least 1")
+    if not 0 <= args.min_difficulty <= args.max_difficulty <= 1:
+        parser.error("--min-difficulty and --max-difficulty must satisfy 0 <= min <= max <= 1")
+
+    storage = load_storage(args.storage)
+    source = storage.child(args.out)
+    raw = source.read_bytes("vocabulary.json")
+    if raw is None:
+        print(f"[decks] No vocabulary.json in {source.describe()}; run export first", file=sys.stderr)
+        return 1
+    vocab = [entry for entry in unmark_generated(json.loads(raw.decode("utf-8"))) if isinstance(entry, dict)]
+    lessons_raw = source.read_bytes("lessons.json")
+    lessons = [entry for entry in unmark_generated(json.loads(lessons_raw.decode("utf-8"))) if isinstance(entry, dict)] if lessons_raw is not None else []
+    ranks = load_frequency_ranks(args.frequency) or dataset_ranks(vocab, lessons)
+
+    candidates = [entry for entry in vocab if entry_level(entry) in levels]
+    deck, skipped = select_deck(scored(candidates, ranks), args.size, args.max_per_tag, args.min_difficulty, args.max_difficulty)
+    name = args.name or f"{'-'.join(levels)}-{args.size}"
+    constraints = {"levels": ",".join(levels), "size": args.size, "max_per_tag": args.max_per_tag, "difficulty": f"{args.min_difficulty:g}-{args.max_difficulty:g}"}
+    entries = [entry for entry, _, _ in deck]
+    out = storage.child(args.dest).child(slugify(name))
+    files = {"vocabulary.json": write_json(out, "vocabulary.json", entries)}
+    for fmt in dict.fromkeys(args.format or ["anki"]):
+        for file_name, data in EXPORTERS[fmt]({"vocabulary": entries}).items():
+            out.write_bytes(file_name, data)
+            files[file_name] = manifest_row(data)
+    write_json(out, "manifest.json", {"deck": name, "source": source.describe(), "constraints": constraints, "counts": {"vocabulary": len(entries)}, "files": files})
+    storage.close()
+    path = write_report("decks.md", deck_report(name, constraints, len(candidates), deck, skipped))
+    print(f"[decks] Wrote deck {name} with {len(entries)} of {args.size} entries ({', '.join(files)}) to {out.describe()}; see {path}")
+    if len(entries) < args.size:
+        print(f"[decks] The deck is short by {args.size - len(entries)}: the constraints left too few candidates (see {path})", file=sys.stderr)
+        return 1
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,56 @@
+"""Change feed between releases: what a sync client applies instead of downloading the whole dataset again.
+
+`export.py --since <previous manifest>` compares the new build with the release the manifest describes (its
+files must still match its hashes) and writes delta.json beside the full outputs: one operation per entry that
+was added, updated, or deleted, in compact JSON. Added and updated entries carry the whole new entry; deletes
+carry the ID, and the tombstone's reason when the entry was retired.
+"""
+
+from __future__ import annotations
+
+from pathlib import Path
+from typing import Any, Dict, List, Tuple, Union
+
+try:
+    from .changes import changed_entries
+    from .common import ENTRY_OUTPUTS
+    from .publish import dataset_version
+    from .verify import load_release
+except ImportError:  # pragma: no cover - allow running as a script
+    from changes import changed_entries  # type: ignore
+    from common import ENTRY_OUTPUTS  # type: ignore
+    from publish import dataset_version  # type: ignore
+    from verify import load_release  # type: ignore
+
+DELTA_NAME = "delta.json"
+# Output files (without .json) the feed covers, in the order their operations are listed.
+DELTA_FILES = ENTRY_OUTPUTS
+OPS = {"added": "add", "modified": "update", "removed": "delete"}
+
+
+def load_previous(path: Union[str, Path]) -> Tuple[Dict[str, Any], Dict[str, List[Dict[str, Any]]]]:
+    """(what the feed says it starts from, output file -> entries) for the release whose manifest is at path."""
+    manifest, entries = load_release(path, DELTA_FILES, "since", "previous release")
+    run = manifest.get("run") or {}
+    since = {"version": dataset_version(manifest.get("files", {})), "commit": (run.get("git") or {}).get("commit"), "exported_at": run.get("started_at")}
+    return since, entries
+
+
+def delta_changes(previous: Dict[str, List[Dict[str, Any]]], current: Dict[str, List[Dict[str, Any]]], tombstones: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """One operation per added, updated, or deleted entry; a file the previous release lacks counts as empty."""
+    retired = {row["id"]: row for row in tombstones}
+    changes: List[Dict[str, Any]] = []
+    for name in DELTA_FILES:
+        for entry_id, change, _, after in changed_entries(previous.get(name, []), current.get(name, [])):
+            op: Dict[str, Any] = {"op": OPS[change], "file": name, "id": entry_id}
+            if after is not None:
+                op["entry"] = after
+            elif entry_id in retired:
+                op["retired"] = retired[entry_id].get("reason") or True
+            changes.append(op)
+    return changes
+
+
+def delta_payload(since: Dict[str, Any], changes: List[Dict[str, Any]]) -> Dict[str, Any]:
+    counts = {op: sum(1 for item in changes if item["op"] == op) for op in OPS.values()}
+    return {"since": since, "counts": counts, "changes": changes}
@@ -0,0 +1,113 @@
+"""Continuous vocabulary difficulty from frequency, spelling, cognate status, and polysemy, for ordering within a CEFR level."""
+
+from __future__ import annotations
+
+import difflib
+import math
+import re
+from collections import Counter
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Union
+
+try:
+    from .common import CONFIG_DIR, slugify, string_values
+    from .senses import entry_senses
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, slugify, string_values  # type: ignore
+    from senses import entry_senses  # type: ignore
+
+DEFAULT_FREQUENCY_PATH = CONFIG_DIR / "frequency.txt"
+WEIGHTS = {"frequency": 0.4, "irregularity": 0.2, "cognate": 0.25, "polysemy": 0.15}
+# Spellings that do not map letter-for-letter onto English sounds.
+TRICKY_SPELLINGS = re.compile(r"ñ|ll|rr|qu|gu(?=[eiéí])|gü|^h|[^cs]h|j|g(?=[eiéí])|c(?=[eiéí])|z|x|v|[áéíóú]")
+# Spanish -> English suffix correspondences that make cognates line up (nación/nation, ciudad/city).
+COGNATE_SUFFIXES = (("ción", "tion"), ("sión", "sion"), ("dad", "ty"), ("tad", "ty"), ("oso", "ous"), ("osa", "ous"), ("mente", "ly"), ("ista", "ist"), ("ismo", "ism"), ("ico", "ic"), ("ica", "ic"), ("ar", ""), ("er", ""), ("ir", ""), ("o", ""), ("a", ""), ("e", ""))
+
+
+def load_frequency_ranks(path: Optional[Union[str, Path]] = None) -> Dict[str, int]:
+    """Word -> 1-based rank from a list with one word per line (most frequent first; "word,count" lines also work)."""
+    freq_path = Path(path) if path else DEFAULT_FREQUENCY_PATH
+    if not freq_path.exists():
+        return {}
+    ranks: Dict[str, int] = {}
+    for line in freq_path.read_text(encoding="utf-8").splitlines():
+        word = line.split(",")[0].split("\t")[0].strip().lower()
+        if word and not word.startswith("#"):
+            ranks.setdefault(word, len(ranks) + 1)
+    return ranks
+
+
+def dataset_ranks(vocab: List[Dict[str, Any]], lessons: List[Dict[str, Any]]) -> Dict[str, int]:
+    """Fallback ranks from how often lessons and examples use each headword."""
+    text = " ".join(" ".join(string_values(entry)) for entry in lessons + [{"examples": e.get("examples")} for e in vocab]).lower()
+    counts = Counter()
+    for entry in vocab:
+        headword = str(entry.get("spanish", "")).strip().lower()
+        if headword:
+            counts[headword] = len(re.findall(r"(?<!\w)" + re.escape(headword) + r"(?!\w)", text))
+    return {word: rank for rank, (word, _) in enumerate(sorted(counts.items(), key=lambda kv: (-kv[1], kv[0])), start=1)}
+
+
+def frequency_ranks(vocab: List[Dict[str, Any]], ranks: Dict[str, int], forms: Dict[str, List[Dict[str, Any]]]) -> Dict[str, int]:
+    """Entry ID -> best rank among its headword and inflected forms, so a list counting "está" and "estaba" still ranks estar.
+
+    forms is a forms index (see forms.build_forms_index); a form shared by several lemmas counts for each of them.
+    """
+    best: Dict[str, int] = {}
+    for entry in vocab:
+        rank = ranks.get(str(entry.get("spanish", "")).strip().lower())
+        if rank is not None:
+            best[entry["id"]] = rank
+    for form, owners in forms.items():
+        rank = ranks.get(form)
+        if rank is None:
+            continue
+        for owner in owners:
+            best[owner["id"]] = min(rank, best.get(owner["id"], rank))
+    return best
+
+
+def frequency_score(headword: str, ranks: Dict[str, int], rank: Optional[int] = None) -> float:
+    """Log-scaled rank: 0 for the most frequent word, 1 for the rarest or unlisted; 0.5 without usable ranks."""
+    if len(ranks) < 2:
+        return 0.5
+    rank = rank if rank is not None else ranks.get(headword.lower())
+    return 1.0 if rank is None else math.log(rank) / math.log(len(ranks))
+
+
+def irregularity_score(headword: str) -> float:
+    return min(1.0, len(TRICKY_SPELLINGS.findall(headword.lower())) / 3)
+
+
+def cognate_ratio(headword: str, glosses: List[str]) -> float:
+    """Best spelling similarity between the headword (suffix-mapped to English) and any gloss word."""
+    folded = slugify(headword).replace("_", " ")
+    stem = folded
+    for spanish, english in COGNATE_SUFFIXES:
+        if folded.endswith(spanish) and len(folded) > len(spanish) + 2:
+            stem = folded[: -len(spanish)] + english
+            break
+    candidates = {word for gloss in glosses for word in re.findall(r"[a-z]+", gloss.lower()) if len(word) > 2}
+    return max((difflib.SequenceMatcher(None, stem, word).ratio() for word in candidates), default=0.0)
+
+
+def difficulty(entry: Dict[str, Any], ranks: Dict[str, int]) -> Dict[str, Any]:
+    """0 (easiest) to 1 (hardest), with the parts it was built from; a lemmatized frequency_rank on the entry wins over the headword's own rank."""
+    headword = str(entry.get("spanish", "")).strip()
+    rank = entry.get("frequency_rank") if isinstance(entry.get("frequency_rank"), int) else ranks.get(headword.lower())
+    senses = entry_senses(entry)
+    glosses = [str(sense.get("gloss") or "") for sense in senses] or [str(entry.get("english_gloss") or "")]
+    cognate = cognate_ratio(headword, glosses)
+    parts = {
+        "frequency": frequency_score(headword, ranks, rank),
+        "irregularity": irregularity_score(headword),
+        "cognate": 1.0 - cognate,
+        "polysemy": min(1.0, (max(len(senses), 1) - 1) / 3),
+    }
+    return {
+        "score": round(sum(WEIGHTS[name] * value for name, value in parts.items()), 3),
+        "frequency_rank": rank,
+        "irregularity": round(parts["irregularity"], 3),
+        "cognate": cognate >= 0.75,
+        "senses": max(len(senses), 1),
+    }
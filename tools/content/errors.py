@@ -0,0 +1,76 @@
+"""Typed pipeline errors, so library callers can branch on the failure category instead of parsing messages.
+
+Every error derives from ContentError. ConfigError and InvalidEntry also derive from ValueError, so
+callers that caught ValueError before these existed keep working.
+"""
+
+from __future__ import annotations
+
+from typing import Any, List, Optional
+
+
+class ContentError(Exception):
+    """Base class for failures raised by the content tools."""
+
+
+class ConfigError(ContentError, ValueError):
+    """A config value (ID scheme, storage backend, profile, policy, format) is unknown or incomplete."""
+
+    def __init__(self, message: str, setting: Optional[str] = None, value: Any = None, expected: Optional[List[str]] = None) -> None:
+        super().__init__(message)
+        self.setting = setting
+        self.value = value
+        self.expected = list(expected) if expected else []
+
+
+class InvalidEntry(ContentError, ValueError):
+    """An entry failed schema validation; issues holds every validate.Issue with error severity."""
+
+    def __init__(self, entry_id: str, source_files: List[str], issues: List[Any]) -> None:
+        first = issues[0] if issues else None
+        detail = f": {first.path}: {first.message}" if first is not None else ""
+        more = f" (+{len(issues) - 1} more)" if len(issues) > 1 else ""
+        super().__init__(f"{entry_id} is invalid{detail}{more}")
+        self.entry_id = entry_id
+        self.source_files = list(source_files)
+        self.issues = list(issues)
+
+
+class ConflictUnresolvable(ContentError):
+    """Merge conflicts with no clean side and no recorded resolution; chunks holds each conflicts.Chunk."""
+
+    def __init__(self, source: str, chunks: List[Any]) -> None:
+        lines = ", ".join(str(chunk.line) for chunk in chunks)
+        super().__init__(f"{source}: {len(chunks)} conflicts need a manual resolution (lines {lines}); run triage_conflicts.py")
+        self.source = source
+        self.chunks = list(chunks)
+
+
+class StrictFailureError(ContentError):
+    """A strict-mode gate failed; failures holds each strict.StrictFailure and report the JSON file listing them."""
+
+    def __init__(self, tool: str, failures: List[Any], report: Optional[str] = None) -> None:
+        entries = len({(f.file, f.index) for f in failures})
+        super().__init__(f"{tool}: strict mode failed with {len(failures)} problems in {entries} entries")
+        self.tool = tool
+        self.failures = list(failures)
+        self.report = report
+
+
+class PublishFailed(ContentError):
+    """A git step of export --commit failed; command is the git invocation and output what it printed."""
+
+    def __init__(self, command: List[str], output: str) -> None:
+        super().__init__(f"git {' '.join(command)} failed: {output.strip() or 'no output'}")
+        self.command = list(command)
+        self.output = output
+
+
+class SyncFailed(ContentError):
+    """A request to the headless CMS failed; status is the HTTP status, or None when the server was unreachable."""
+
+    def __init__(self, method: str, url: str, status: Optional[int], message: str) -> None:
+        super().__init__(f"{method} {url} failed" + (f" with HTTP {status}" if status else "") + f": {message.strip() or 'no details'}")
+        self.method = method
+        self.url = url
+        self.status = status
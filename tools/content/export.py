@@ -0,0 +1,1617 @@
+#!/usr/bin/env python3
+"""Export collected lessons, vocabulary, readings, units, culture notes, and pronunciation drills into canonical JSON files."""
+
+from __future__ import annotations
+
+import argparse
+import copy
+import hashlib
+import itertools
+import json
+import sys
+from collections import Counter
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterable, Iterator, List, Optional, Sequence, Set, Tuple
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_CONFLICT_CACHE, DEFAULT_EVENTS_PATH, DEFAULT_IDS_PATH, DEFAULT_LINT_PATH, ENTRY_OUTPUTS, LEVELS, MAX_JSON_DEPTH, MAX_SOURCE_BYTES, REPORTS_DIR, SKIP_CATEGORY, Dataset, EventLog, IdScheme, Record, collect, entry_level, is_pinned, lesson_order_key, load_id_scheme, load_lesson_flow, record_id, resolve_revision, run_metadata, run_report_lines, slugify, spanish_texts, words
+    from .accents import DEFAULT_ACCENTS_PATH, load_accent_dictionary, restore_accents
+    from .alignment import AUDIO_DIR, alignment_rows
+    from .budget import DEFAULT_BUDGET_PATH, Budget, check_budget, format_bytes, load_budget, usage_lines
+    from .builds import latest_build, link_latest, new_build_dir, prune_builds
+    from .changes import write_change_report
+    from .checkpoints import Checkpoints, dataset_from_payload, dataset_payload, input_fingerprint, payload_sha256
+    from .console import add_output_arguments, configure_output
+    from .comments import Comment, record_comments, strip_comments, todo_report
+    from .culture import check_culture_notes, notes_by_lesson
+    from .delta import DELTA_NAME, delta_changes, delta_payload, load_previous
+    from .difficulty import DEFAULT_FREQUENCY_PATH, dataset_ranks, difficulty, frequency_ranks, load_frequency_ranks
+    from .errors import ConfigError, PublishFailed
+    from .exporters import EXPORTERS, ExporterResult, run_exporters
+    from .fieldnames import CORRECTIONS_KEY, typo_table
+    from .forms import DEFAULT_FORMS_BANK, build_forms_index, load_form_bank, pluralize
+    from .freeze import FreezeViolation, check_frozen, freeze_report, load_frozen_ids
+    from .headwords import DEFAULT_HEADWORDS_PATH, HeadwordRules, load_headword_rules, normalize_headword
+    from .history import DEFAULT_HISTORY_PATH, Gate, GateResult, check_gates, last_run, parse_gates, record_run
+    from .images import ASSETS_DIR
+    from .licenses import license_violations, mark_third_party, missing_license_fields
+    from .locales import DEFAULT_LOCALES_PATH, LocaleCheck, LocaleConfig, load_locales, locales_for
+    from .media import DEFAULT_MEDIA_PATH, MediaType, check_reachable, check_step_media, load_media_types, remote_media
+    from .merge import DEFAULT_LESSON_REVIEW_THRESHOLD, DEFAULT_PROSE_THRESHOLD, DUPLICATE_POLICIES, DuplicateCluster, LessonMatch, merge_similar_lessons, resolve_duplicates, review_status, similar_lessons
+    from .normalize import normalization_warnings, normalize_lesson, normalize_vocab
+    from .numerals import DEFAULT_NUMERALS_PATH, NumeralStyle, load_numeral_style, normalize_numerals
+    from .plugins import DEFAULT_PLUGINS_PATH, Plugins, load_plugins
+    from .profiles import DEFAULT_PROFILES_PATH, ExportProfile, apply_profile, load_profiles
+    from .pron import check_drills, normalize_drill
+    from .publish import DEFAULT_BRANCH, PublishResult, commit_message, commit_outputs, dataset_version
+    from .quarantine import split_unclassified, write_quarantine
+    from .readings import resolve_glosses
+    from .reconcile import DEFAULT_RECONCILE_PATH, load_critical_fields, reconcile, reconciliation_report
+    from .references import DEFAULT_REFERENCES_PATH, Dangling, ReferenceType, load_reference_types, reference_report, rename_references, resolve_references
+    from .relations import asymmetric_relations, relations_graph, resolve_relations
+    from .rejects import REJECT_FORMATS, Reject, collect_rejects, write_rejects
+    from .retire import DEFAULT_TOMBSTONES_PATH, Tombstone, drop_retired, load_tombstones, tombstone_rows
+    from .rules import DEFAULT_RULES_PATH, CustomRule, load_rules, rules_for
+    from .search import KEYWORDS_FIELD, build_search_index, english_keywords
+    from .senses import normalize_senses
+    from .srs import introduction_lessons, srs_metadata
+    from .storage import DEFAULT_STORAGE_PATH, LocalStorage, StagingStorage, Storage, load_storage
+    from .studytime import DEFAULT_STUDY_TIME_PATH, StudyTimeConfig, estimated_minutes, load_study_time, over_cap
+    from .summaries import DEFAULT_SUMMARY_LIMIT, Summarizer, add_summaries, load_summarizer
+    from .syllables import pronunciation, stress_index
+    from .telemetry import Reporter, peak_rss_bytes, stage
+    from .units import build_units, check_units
+    from .validate import SCHEMAS_DIR, load_schemas, validate_all
+    from .verify import manifest_row, mark_generated, modified_files
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_CONFLICT_CACHE, DEFAULT_EVENTS_PATH, DEFAULT_IDS_PATH, DEFAULT_LINT_PATH, ENTRY_OUTPUTS, LEVELS, MAX_JSON_DEPTH, MAX_SOURCE_BYTES, REPORTS_DIR, SKIP_CATEGORY, Dataset, EventLog, IdScheme, Record, collect, entry_level, is_pinned, lesson_order_key, load_id_scheme, load_lesson_flow, record_id, resolve_revision, run_metadata, run_report_lines, slugify, spanish_texts, words  # type: ignore
+    from accents import DEFAULT_ACCENTS_PATH, load_accent_dictionary, restore_accents  # type: ignore
+    from alignment import AUDIO_DIR, alignment_rows  # type: ignore
+    from budget import DEFAULT_BUDGET_PATH, Budget, check_budget, format_bytes, load_budget, usage_lines  # type: ignore
+    from builds import latest_build, link_latest, new_build_dir, prune_builds  # type: ignore
+    from changes import write_change_report  # type: ignore
+    from checkpoints import Checkpoints, dataset_from_payload, dataset_payload, input_fingerprint, payload_sha256  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from comments import Comment, record_comments, strip_comments, todo_report  # type: ignore
+    from culture import check_culture_notes, notes_by_lesson  # type: ignore
+    from delta import DELTA_NAME, delta_changes, delta_payload, load_previous  # type: ignore
+    from difficulty import DEFAULT_FREQUENCY_PATH, dataset_ranks, difficulty, frequency_ranks, load_frequency_ranks  # type: ignore
+    from errors import ConfigError, PublishFailed  # type: ignore
+    from exporters import EXPORTERS, ExporterResult, run_exporters  # type: ignore
+    from fieldnames import CORRECTIONS_KEY, typo_table  # type: ignore
+    from forms import DEFAULT_FORMS_BANK, build_forms_index, load_form_bank, pluralize  # type: ignore
+    from freeze import FreezeViolation, check_frozen, freeze_report, load_frozen_ids  # type: ignore
+    from headwords import DEFAULT_HEADWORDS_PATH, HeadwordRules, load_headword_rules, normalize_headword  # type: ignore
+    from history import DEFAULT_HISTORY_PATH, Gate, GateResult, check_gates, last_run, parse_gates, record_run  # type: ignore
+    from images import ASSETS_DIR  # type: ignore
+    from licenses import license_violations, mark_third_party, missing_license_fields  # type: ignore
+    from locales import DEFAULT_LOCALES_PATH, LocaleCheck, LocaleConfig, load_locales, locales_for  # type: ignore
+    from media import DEFAULT_MEDIA_PATH, MediaType, check_reachable, check_step_media, load_media_types, remote_media  # type: ignore
+    from merge import DEFAULT_LESSON_REVIEW_THRESHOLD, DEFAULT_PROSE_THRESHOLD, DUPLICATE_POLICIES, DuplicateCluster, LessonMatch, merge_similar_lessons, resolve_duplicates, review_status, similar_lessons  # type: ignore
+    from normalize import normalization_warnings, normalize_lesson, normalize_vocab  # type: ignore
+    from numerals import DEFAULT_NUMERALS_PATH, NumeralStyle, load_numeral_style, normalize_numerals  # type: ignore
+    from plugins import DEFAULT_PLUGINS_PATH, Plugins, load_plugins  # type: ignore
+    from profiles import DEFAULT_PROFILES_PATH, ExportProfile, apply_profile, load_profiles  # type: ignore
+    from pron import check_drills, normalize_drill  # type: ignore
+    from publish import DEFAULT_BRANCH, PublishResult, commit_message, commit_outputs, dataset_version  # type: ignore
+    from quarantine import split_unclassified, write_quarantine  # type: ignore
+    from readings import resolve_glosses  # type: ignore
+    from reconcile import DEFAULT_RECONCILE_PATH, load_critical_fields, reconcile, reconciliation_report  # type: ignore
+    from references import DEFAULT_REFERENCES_PATH, Dangling, ReferenceType, load_reference_types, reference_report, rename_references, resolve_references  # type: ignore
+    from relations import asymmetric_relations, relations_graph, resolve_relations  # type: ignore
+    from rejects import REJECT_FORMATS, Reject, collect_rejects, write_rejects  # type: ignore
+    from retire import DEFAULT_TOMBSTONES_PATH, Tombstone, drop_retired, load_tombstones, tombstone_rows  # type: ignore
+    from rules import DEFAULT_RULES_PATH, CustomRule, load_rules, rules_for  # type: ignore
+    from search import KEYWORDS_FIELD, build_search_index, english_keywords  # type: ignore
+    from senses import normalize_senses  # type: ignore
+    from srs import introduction_lessons, srs_metadata  # type: ignore
+    from storage import DEFAULT_STORAGE_PATH, LocalStorage, StagingStorage, Storage, load_storage  # type: ignore
+    from studytime import DEFAULT_STUDY_TIME_PATH, StudyTimeConfig, estimated_minutes, load_study_time, over_cap  # type: ignore
+    from summaries import DEFAULT_SUMMARY_LIMIT, Summarizer, add_summaries, load_summarizer  # type: ignore
+    from syllables import pronunciation, stress_index  # type: ignore
+    from telemetry import Reporter, peak_rss_bytes, stage  # type: ignore
+    from units import build_units, check_units  # type: ignore
+    from validate import SCHEMAS_DIR, load_schemas, validate_all  # type: ignore
+    from verify import manifest_row, mark_generated, modified_files  # type: ignore
+
+SNAPSHOT_FIELDS = ("spanish", "english_gloss", "gender")
+REFERENCE_KEYS = ("ref", "vocab_id", "vocab")
+SORT_KEYS = ("source", "frequency", "level", "headword")
+# A declared unit's lesson list: units.py checks it rather than references.py, but it follows merged lessons too.
+UNIT_LESSONS = ReferenceType("unit-lesson", "unit", "lessons[]", ["lesson"])
+# Characters of output encoded and handed to the backend at a time (see Storage.open_write for what each keeps).
+WRITE_BUFFER_CHARS = 1 << 16
+
+
+def build_entries(records: List[Record], scheme) -> List[Dict[str, Any]]:
+    entries: List[Dict[str, Any]] = []
+    for record in records:
+        entry = strip_comments(dict(record.data))
+        entry["id"] = record_id(record, scheme)
+        entry.setdefault("source_files", [record.source])
+        entries.append(entry)
+    return entries
+
+
+def drop_invalid(entries: List[Dict[str, Any]], schema: Dict[str, Any], events: EventLog, rules: Sequence[CustomRule] = (), locales: Optional[LocaleCheck] = None) -> Tuple[List[Dict[str, Any]], List[Reject]]:
+    """Split off entries with schema, custom-rule, or locale errors; each reject lists every issue, not just the first."""
+    kept: List[Dict[str, Any]] = []
+    rejects: List[Reject] = []
+    for entry in entries:
+        issues = validate_all(entry, schema, rules, locales=locales)
+        errors = [issue for issue in issues if issue.severity == "error"]
+        if not errors:
+            kept.append(entry)
+            continue
+        source = ", ".join(entry.get("source_files", []))
+        reason = f"failed schema validation with {len(errors)} errors"
+        rejects.append(Reject(source=source, reason=reason, record=entry, issues=[asdict(issue) for issue in issues]))
+        events.emit("entry_rejected", source, reason, id=entry["id"], rules=sorted({issue.rule for issue in errors}))
+    return kept, rejects
+
+
+def duplicate_rejects(clusters: List[DuplicateCluster], policy: str) -> List[Reject]:
+    """Under the reject policy, every entry of a cluster except the kept one goes to the rejects output."""
+    rejects: List[Reject] = []
+    for cluster in clusters:
+        for idx, entry in enumerate(cluster.entries):
+            if policy == "reject" and idx != cluster.kept:
+                reason = f"duplicate of {cluster.id} ({', '.join(cluster.entries[cluster.kept].get('source_files', []))}); differs in {', '.join(cluster.differing_fields()) or 'nothing'}"
+                rejects.append(Reject(source=", ".join(entry.get("source_files", [])), reason=reason, record=entry))
+    return rejects
+
+
+def duplicate_report(clusters: List[DuplicateCluster], policy: str) -> List[str]:
+    out = ["Duplicate IDs", f"- policy: {policy}", f"- clusters: {len(clusters)}"]
+    for cluster in clusters:
+        out.append(f"## {cluster.id} ({len(cluster.entries)} entries{', pinned' if cluster.pinned else ''})")
+        out.append(f"- differing fields: {', '.join(cluster.differing_fields()) or 'none'}")
+        for idx, entry in enumerate(cluster.entries):
+            action = "merged" if cluster.kept is None else "kept" if idx == cluster.kept else "rejected" if policy == "reject" else "needs review" if policy == "review" else "dropped"
+            out.append(f"- {', '.join(entry.get('source_files', []))}: {action}")
+    return out
+
+
+def field_typo_report(records: List[Record]) -> List[str]:
+    """Misspelled field names collect() renamed, per canonical field, with the records they came from."""
+    corrected = [record for record in records if isinstance(record.data.get(CORRECTIONS_KEY), dict)]
+    rows = typo_table([record.data[CORRECTIONS_KEY] for record in corrected])
+    out = ["Field name typos", f"- records corrected: {len(corrected)}", f"- fields corrected: {sum(count for _, count, _ in rows)}", "## corrections per field"]
+    out += [f"- {field}: {count} ({', '.join(f'{typo} x{seen}' for typo, seen in sorted(typos.items(), key=lambda item: (-item[1], item[0])))})" for field, count, typos in rows]
+    if corrected:
+        out.append("## records")
+        out += [f"- {record.source}#{record.index}: {', '.join(f'{typo} -> {name}' for typo, name in record.data[CORRECTIONS_KEY].items())}" for record in corrected]
+    return out
+
+
+def restore_pinned(entries: List[Dict[str, Any]], pinned: Dict[str, Dict[str, Any]]) -> Tuple[List[Dict[str, Any]], List[str]]:
+    """Put back the as-written copy of every pinned entry; also returns the IDs the transforms had changed."""
+    altered = [entry["id"] for entry in entries if entry["id"] in pinned and entry != pinned[entry["id"]]]
+    return [copy.deepcopy(pinned[entry["id"]]) if entry["id"] in pinned else entry for entry in entries], altered
+
+
+def index_vocab(vocab: List[Dict[str, Any]]) -> Dict[str, Dict[str, Any]]:
+    index: Dict[str, Dict[str, Any]] = {}
+    for entry in vocab:
+        index.setdefault(entry["id"], entry)
+        headword = entry.get("spanish")
+        if isinstance(headword, str):
+            index.setdefault(headword.strip().lower(), entry)
+    return index
+
+
+def item_reference(item: Any) -> str:
+    if isinstance(item, str):
+        return item.strip()
+    if isinstance(item, dict):
+        for key in REFERENCE_KEYS:
+            value = item.get(key)
+            if isinstance(value, str) and value.strip():
+                return value.strip()
+    return ""
+
+
+def step_vocab_ids(step: Dict[str, Any], vocab_index: Dict[str, Dict[str, Any]]) -> Set[str]:
+    """IDs of the vocabulary a step uses, via item references or headwords in Spanish text."""
+    used = set()
+    for item in step.get("items", []) if isinstance(step.get("items"), list) else []:
+        ref = item_reference(item)
+        target = vocab_index.get(ref) or vocab_index.get(ref.lower())
+        if target:
+            used.add(target["id"])
+    text = " " + " ".join(" ".join(words(value)) for _, value in spanish_texts(step)) + " "
+    for entry in vocab_index.values():
+        headword = " ".join(words(str(entry.get("spanish", ""))))
+        if headword and f" {headword} " in text:
+            used.add(entry["id"])
+    return used
+
+
+def intro_order_problems(lessons: List[Dict[str, Any]], vocab: List[Dict[str, Any]], presentation: Iterable[str]) -> List[Tuple[str, str]]:
+    """(lesson ID, problem) for each word a lesson uses before the lesson introducing it, or that no lesson introduces.
+
+    A lesson introduces the words in its `introduces` list and a vocabulary entry may name its lesson in `intro_lesson`;
+    otherwise the first lesson whose presentation steps use a word introduces it. Plain use is not enough, as it is for
+    srs.introduction_lessons, or no lesson could use a word too early. Lessons are ordered by level, unit, and number.
+    """
+    vocab_index = index_vocab(vocab)
+    phases = set(presentation)
+    ordered = sorted(lessons, key=lesson_order_key)
+    position: Dict[str, int] = {}
+    for idx, lesson in enumerate(ordered):
+        position.setdefault(lesson["id"], idx)
+
+    introduced: Dict[str, int] = {}
+    presented: Dict[str, int] = {}
+    uses: List[Set[str]] = []
+    for idx, lesson in enumerate(ordered):
+        for item in lesson.get("introduces", []) if isinstance(lesson.get("introduces"), list) else []:
+            ref = item_reference(item)
+            target = vocab_index.get(ref) or vocab_index.get(ref.lower())
+            if target:
+                introduced.setdefault(target["id"], idx)
+        used: Set[str] = set()
+        for step in lesson.get("steps", []) if isinstance(lesson.get("steps"), list) else []:
+            if isinstance(step, dict):
+                step_uses = step_vocab_ids(step, vocab_index)
+                used |= step_uses
+                if step.get("phase") in phases:
+                    for key in step_uses:
+                        presented.setdefault(key, idx)
+        uses.append(used)
+    for entry in vocab:
+        intro = entry.get("intro_lesson")
+        if isinstance(intro, str) and intro in position:
+            introduced[entry["id"]] = min(introduced.get(entry["id"], position[intro]), position[intro])
+    for key, idx in presented.items():
+        introduced.setdefault(key, idx)
+
+    problems: List[Tuple[str, str]] = []
+    unintroduced: Set[str] = set()
+    for idx, lesson in enumerate(ordered):
+        for key in sorted(uses[idx]):
+            headword = vocab_index[key].get("spanish")
+            taught = introduced.get(key)
+            if taught is None and key not in unintroduced:
+                unintroduced.add(key)
+                problems.append((lesson["id"], f"uses '{headword}', which no lesson introduces"))
+            elif taught is not None and taught > idx:
+                problems.append((lesson["id"], f"uses '{headword}' before it is introduced in {ordered[taught]['id']}"))
+    return problems
+
+
+def embed_vocab(lessons: List[Dict[str, Any]], vocab_index: Dict[str, Dict[str, Any]]) -> int:
+    """Replace step item references with inline vocabulary snapshots; returns the count expanded."""
+    expanded = 0
+    for lesson in lessons:
+        for step in lesson.get("steps", []):
+            if not isinstance(step, dict) or not isinstance(step.get("items"), list):
+                continue
+            for idx, item in enumerate(step["items"]):
+                ref = item_reference(item)
+                target = vocab_index.get(ref) or vocab_index.get(ref.lower())
+                if not target:
+                    continue
+                snapshot = dict(item) if isinstance(item, dict) else {}
+                snapshot["ref"] = target["id"]
+                for key in SNAPSHOT_FIELDS:
+                    snapshot[key] = target.get(key)
+                step["items"][idx] = snapshot
+                expanded += 1
+    return expanded
+
+
+def lesson_duplicate_report(matches: List[LessonMatch], merge_threshold: float | None) -> List[str]:
+    out = ["Similar lessons", f"- candidate pairs: {len(matches)}"]
+    if matches:
+        out.append("## candidates (title similarity / step similarity)")
+        for match in sorted(matches, key=lambda m: -m.score):
+            merged = merge_threshold is not None and match.score >= merge_threshold
+            out.append(
+                f"- {match.keep} ~ {match.duplicate}: {match.score:.2f} ({match.title_score:.2f} / {match.step_score:.2f})"
+                + (" [merged]" if merged else "")
+            )
+    return out
+
+
+def homograph_report(vocab: List[Dict[str, Any]]) -> List[str]:
+    """Headwords with more than one entry after merging, for editorial review; entries without a sense_key are flagged."""
+    groups: Dict[str, List[Dict[str, Any]]] = {}
+    for entry in vocab:
+        if isinstance(entry.get("spanish"), str) and slugify(entry["spanish"]):
+            groups.setdefault(slugify(entry["spanish"]), []).append(entry)
+    shared = {key: entries for key, entries in sorted(groups.items()) if len(entries) > 1}
+    out = ["Homographs", f"- headwords with multiple entries: {len(shared)}"]
+    for entries in shared.values():
+        out.append(f"## {entries[0]['spanish']}")
+        for entry in entries:
+            key = f"sense_key {entry['sense_key']}" if entry.get("sense_key") else "no sense_key"
+            out.append(f"- {entry['id']} [{entry.get('pos', '?')}, {key}]: {entry.get('english_gloss', '')}")
+    return out
+
+
+def batched_text(chunks: Iterable[str], limit: int) -> Iterator[str]:
+    """Join small chunks into pieces of at least limit characters (the last may be shorter)."""
+    pending: List[str] = []
+    count = 0
+    for chunk in chunks:
+        pending.append(chunk)
+        count += len(chunk)
+        if count >= limit:
+            yield "".join(pending)
+            pending, count = [], 0
+    if pending:
+        yield "".join(pending)
+
+
+def write_stream(storage: Storage, name: str, chunks: Iterable[str]) -> Dict[str, Any]:
+    """Write text chunks to name a buffer at a time, hashing as they go, and return the manifest row.
+
+    Only one buffer of encoded output is held here, and each write waits until the backend has taken the previous
+    one; the memory backend still keeps the whole file (see Storage.open_write).
+    """
+    digest = hashlib.sha256()
+    size = 0
+    with storage.open_write(name) as handle:
+        for text in batched_text(chunks, WRITE_BUFFER_CHARS):
+            data = text.encode("utf-8")
+            handle.write(data)
+            digest.update(data)
+            size += len(data)
+    return {"sha256": digest.hexdigest(), "bytes": size}
+
+
+def write_json(storage: Storage, name: str, payload: Any, marked: bool = False) -> Dict[str, Any]:
+    """Write payload as JSON (wrapped with the do-not-edit marker when marked) and return its manifest row.
+
+    The encoder streams the same bytes json.dumps would produce, so a large list is never held twice in memory.
+    """
+    encoder = json.JSONEncoder(ensure_ascii=False, indent=2)
+    return write_stream(storage, name, itertools.chain(encoder.iterencode(mark_generated(payload) if marked else payload), ["\n"]))
+
+
+def sort_vocab(vocab: List[Dict[str, Any]], key: str) -> List[Dict[str, Any]]:
+    """Stable sort, so entries that tie (or lack the key) keep their source order."""
+    if key == "frequency":
+        return sorted(vocab, key=lambda entry: (entry.get("frequency_rank") is None, entry.get("frequency_rank") or 0))
+    if key == "level":
+        return sorted(vocab, key=lambda entry: LEVELS.index(entry["level"]) if entry.get("level") in LEVELS else len(LEVELS))
+    return sorted(vocab, key=lambda entry: slugify(str(entry.get("spanish", ""))))
+
+
+def write_jsonl(storage: Storage, name: str, entries: List[Dict[str, Any]]) -> Dict[str, Any]:
+    return write_stream(storage, name, (json.dumps(entry, ensure_ascii=False) + "\n" for entry in entries))
+
+
+def save_report(reports: Storage, name: str, lines: List[str]) -> None:
+    reports.write_text(name, "\n".join(lines) + "\n")
+
+
+def write_profile(storage: Storage, profile: ExportProfile, kinds: Dict[str, List[Dict[str, Any]]], tombstones: List[Dict[str, Any]], marked: bool = False) -> Dict[str, Dict[str, Any]]:
+    """Write each kind through the profile in every format it asks for, plus the tombstones; manifest rows are keyed by path from the storage root."""
+    out = storage.child(profile.path)
+    files: Dict[str, Dict[str, Any]] = {f"{profile.path}/tombstones.json": write_json(out, "tombstones.json", tombstones, marked)}
+    for kind, entries in kinds.items():
+        published = apply_profile(entries, profile)
+        if "json" in profile.formats:
+            files[f"{profile.path}/{kind}.json"] = write_json(out, f"{kind}.json", published, marked)
+        if "jsonl" in profile.formats:
+            # One entry per line has nowhere to carry the do-not-edit marker; the manifest hash still guards it.
+            files[f"{profile.path}/{kind}.jsonl"] = write_jsonl(out, f"{kind}.jsonl", published)
+    return files
+
+
+def build_parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(description="Export canonical lessons and vocabulary.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files, directories, or .zip/.tar.gz archives to collect")
+    parser.add_argument("--storage", default=str(DEFAULT_STORAGE_PATH), help="Path to the storage backend config")
+    parser.add_argument(
+        "--at",
+        metavar="REF",
+        help="Read the content files as they were at a git revision (branch, tag, or commit) instead of the working directory; configs still come from the working directory",
+    )
+    parser.add_argument("--out", help="Location of canonical JSON inside the storage backend (default canonical, or canonical-<commit> with --at)")
+    parser.add_argument(
+        "--keep-builds",
+        type=int,
+        metavar="N",
+        help="Write each run to a timestamped build/<UTC time>/ directory, point build/latest at it, and prune all but the newest N (local storage only)",
+    )
+    parser.add_argument(
+        "--profile",
+        action="append",
+        default=[],
+        help="Also write the copy this export profile describes (fields, levels, licenses, formats) under its output path, default <profile>/ (repeatable)",
+    )
+    parser.add_argument("--profiles", default=str(DEFAULT_PROFILES_PATH), help="Path to the export profiles config")
+    parser.add_argument("--tombstones", default=str(DEFAULT_TOMBSTONES_PATH), help="Tombstone registry (see retire.py); retired IDs are left out and listed in tombstones.json")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument(
+        "--embed-vocab",
+        action="store_true",
+        help="Expand step item references into inline vocabulary snapshots (spanish, gloss, gender)",
+    )
+    parser.add_argument(
+        "--on-duplicate",
+        choices=DUPLICATE_POLICIES,
+        default="merge",
+        help="What to do with entries sharing an ID: merge them, keep the first or newest untouched, reject the rest, or keep the first and list the cluster in duplicates.md for review",
+    )
+    parser.add_argument(
+        "--prose-threshold",
+        type=float,
+        default=DEFAULT_PROSE_THRESHOLD,
+        help="Word similarity (0-1) above which definition/story/origin variants are merged inline",
+    )
+    parser.add_argument(
+        "--lesson-review-threshold",
+        type=float,
+        default=DEFAULT_LESSON_REVIEW_THRESHOLD,
+        help="Title/step similarity (0-1) at which lessons with different IDs are listed in the similar-lessons report",
+    )
+    parser.add_argument(
+        "--merge-similar-lessons",
+        type=float,
+        metavar="THRESHOLD",
+        help="Also merge similar lessons scoring at or above this similarity (off by default)",
+    )
+    parser.add_argument("--forms-index", action="store_true", help="Also write forms_index.json mapping inflected forms to vocabulary entries")
+    parser.add_argument(
+        "--search-index",
+        action="store_true",
+        help="Also write search-index.json, a prebuilt MiniSearch index (diacritic-folded Spanish and English fields) for offline client search",
+    )
+    parser.add_argument(
+        "--exporter",
+        action="append",
+        choices=sorted(EXPORTERS),
+        default=[],
+        help="Also render this format (Anki notes, NDJSON, SQLite) from the same canonical data; several render concurrently and one failing does not stop the rest (repeatable)",
+    )
+    parser.add_argument("--jobs", type=int, metavar="N", help="Exporters rendered at once (default: one per exporter, up to the CPU count)")
+    parser.add_argument(
+        "--alignment",
+        action="store_true",
+        help="Also write alignment.json with the word/sentence audio timings of examples for the player; invalid alignments are left out",
+    )
+    parser.add_argument("--audio", default=str(AUDIO_DIR), help="Root directory that example audio paths are relative to")
+    parser.add_argument("--assets", default=str(ASSETS_DIR), help="Root directory that culture note and lesson step media paths are relative to")
+    parser.add_argument("--media-types", default=str(DEFAULT_MEDIA_PATH), help="Path to the registry of lesson step media types (see media.py)")
+    parser.add_argument("--online-checks", action="store_true", help="Also request every lesson step media URL and report the ones that do not answer")
+    parser.add_argument("--forms-bank", default=str(DEFAULT_FORMS_BANK), help="CSV of form/lemma/features rows adding irregular forms to the index")
+    parser.add_argument(
+        "--publish-only",
+        action="store_true",
+        help="Leave out entries whose review_status is draft or missing",
+    )
+    parser.add_argument(
+        "--fix-accents",
+        action="store_true",
+        help="Restore missing accents that the accent dictionary marks as unambiguous; every change is logged to accents.md",
+    )
+    parser.add_argument("--accents", default=str(DEFAULT_ACCENTS_PATH), help="Path to the accent dictionary config")
+    parser.add_argument(
+        "--fix-numerals",
+        action="store_true",
+        help="Spell out small numbers for the entry's level and standardize dates and times in Spanish text; every change is logged to numerals.md",
+    )
+    parser.add_argument("--numerals", default=str(DEFAULT_NUMERALS_PATH), help="Path to the number, date, and time style config")
+    parser.add_argument(
+        "--fix-headwords",
+        action="store_true",
+        help="Lowercase, strip trailing punctuation from, and move leading articles out of vocabulary headwords before IDs are derived; every change is logged to headwords.md",
+    )
+    parser.add_argument("--headwords", default=str(DEFAULT_HEADWORDS_PATH), help="Path to the headword capitalization, punctuation, and article rules")
+    parser.add_argument("--references", default=str(DEFAULT_REFERENCES_PATH), help="Path to the cross-entry reference types config")
+    parser.add_argument("--lint-config", default=str(DEFAULT_LINT_PATH), help="Lint config whose lesson_flow names the step phases that introduce vocabulary (curriculum order audit)")
+    parser.add_argument(
+        "--double-entry",
+        action="store_true",
+        help="Mark an entry verified when two independent source files agree on its critical fields; disagreements go to build/reports/reconciliation.md",
+    )
+    parser.add_argument("--reconcile", default=str(DEFAULT_RECONCILE_PATH), help="Path to the critical fields config for --double-entry")
+    parser.add_argument("--plugins", default=str(DEFAULT_PLUGINS_PATH), help="Path to the plugin config: team-specific record kinds and enrichers (see plugins.py)")
+    parser.add_argument("--history", default=str(DEFAULT_HISTORY_PATH), help="SQLite file each run's metrics are recorded in (see history.py)")
+    parser.add_argument("--no-history", action="store_true", help="Do not record this run's metrics")
+    parser.add_argument(
+        "--frozen",
+        metavar="MANIFEST",
+        help="Release-branch mode: fail (and commit nothing) if the build adds entries or removes IDs compared with the frozen release whose manifest.json (or its directory) is given",
+    )
+    parser.add_argument(
+        "--since",
+        metavar="MANIFEST",
+        help="Also write delta.json: the entries added, updated, or deleted since the release whose manifest.json (or its directory) is given, for clients that sync changes only",
+    )
+    parser.add_argument(
+        "--gate",
+        action="append",
+        default=[],
+        help="Fail the build unless this expression over the run's metrics holds, e.g. 'rejects <= last_run.rejects' (repeatable; last_run is the previous passing run)",
+    )
+    parser.add_argument("--plurals", action="store_true", help="Fill in a generated plural on nouns that do not supply one")
+    parser.add_argument("--syllables", action="store_true", help="Fill in computed syllables and stress_index on single-word headwords that do not supply them")
+    parser.add_argument(
+        "--summaries",
+        type=int,
+        nargs="?",
+        const=DEFAULT_SUMMARY_LIMIT,
+        metavar="N",
+        help=f"Fill in a summary of each definition capped at N characters (default {DEFAULT_SUMMARY_LIMIT}); compact profiles publish it in place of the definition",
+    )
+    parser.add_argument("--summarizer", metavar="MODULE:FUNCTION", help="Summarizer hook called with (text, limit) instead of first-sentence extraction")
+    parser.add_argument(
+        "--difficulty",
+        action="store_true",
+        help="Add a 0-1 difficulty score (frequency rank, spelling irregularity, cognate status, polysemy) to vocabulary; SRS orders introduction by it within a level",
+    )
+    parser.add_argument(
+        "--frequency",
+        default=str(DEFAULT_FREQUENCY_PATH),
+        help="Word frequency list (most frequent first) for each entry's frequency_rank, matched through inflected forms; without it, difficulty ranks come from the dataset's own usage",
+    )
+    parser.add_argument("--sort-by", choices=SORT_KEYS, default="source", help="Order of vocabulary.json: source order (default), frequency rank (unranked last), level, or headword")
+    parser.add_argument(
+        "--study-time",
+        default=str(DEFAULT_STUDY_TIME_PATH),
+        help="Per-phase reading and practice rates for each lesson's estimated_minutes, and the cap above which lessons are flagged",
+    )
+    parser.add_argument("--srs", action="store_true", help="Add SRS scheduling hints (ease, introduction lesson, intervals) to vocabulary")
+    parser.add_argument(
+        "--recover",
+        action="store_true",
+        help="Decode damaged top-level arrays element by element, keeping complete objects and rejecting only the tail",
+    )
+    parser.add_argument(
+        "--resolve-conflicts",
+        action="store_true",
+        help="Resolve two-way, diff3, and nested merge-conflict markers before decoding content files",
+    )
+    parser.add_argument("--conflict-cache", default=str(DEFAULT_CONFLICT_CACHE), help="Manual conflict resolutions recorded by triage_conflicts.py, applied by --resolve-conflicts")
+    parser.add_argument(
+        "--max-file-bytes",
+        type=int,
+        default=MAX_SOURCE_BYTES,
+        metavar="N",
+        help=f"Skip content files (and archive members) larger than N bytes without reading them (default {MAX_SOURCE_BYTES}; 0 for no limit)",
+    )
+    parser.add_argument("--max-depth", type=int, default=MAX_JSON_DEPTH, metavar="N", help=f"Skip files whose JSON nests deeper than N arrays/objects (default {MAX_JSON_DEPTH})")
+    parser.add_argument(
+        "--validate",
+        action="store_true",
+        help="Reject entries that fail tools/schemas validation or an error-severity custom rule; each reject lists every issue with its rule and severity",
+    )
+    parser.add_argument("--rules", default=str(DEFAULT_RULES_PATH), help="Custom validation rules config, checked by --validate")
+    parser.add_argument("--locales", default=str(DEFAULT_LOCALES_PATH), help="Locale config: instruction fields and the locales --validate requires of them")
+    parser.add_argument(
+        "--mark-generated",
+        action="store_true",
+        help='Embed a "_generated" do-not-edit marker in every output (lists are wrapped as {"_generated", "items"})',
+    )
+    parser.add_argument("--force", action="store_true", help="Overwrite outputs even when they were edited by hand since the last export")
+    parser.add_argument("--rejects", default="rejects", help="Location of rejected content inside the storage backend")
+    parser.add_argument("--quarantine", default="quarantine", help="Location of near-miss records (unclassified, but close to a kind) inside the storage backend; see quarantine.py")
+    parser.add_argument(
+        "--reject-format",
+        choices=REJECT_FORMATS,
+        default="yaml",
+        help="yaml/json wrap the reject with metadata; raw keeps the original bytes plus a .meta.json sidecar",
+    )
+    parser.add_argument(
+        "--resume",
+        action="store_true",
+        help="Reuse the collect and validate checkpoints an interrupted run left in build/checkpoints, when intact and still current",
+    )
+    parser.add_argument(
+        "--commit",
+        nargs="?",
+        const=DEFAULT_BRANCH,
+        metavar="BRANCH",
+        help=f"Commit the outputs to BRANCH (default {DEFAULT_BRANCH}) in a worktree under build/worktrees, with the dataset version and counts in the message",
+    )
+    parser.add_argument("--push", nargs="?", const="origin", metavar="REMOTE", help="With --commit, push the branch to REMOTE (default origin) after committing")
+    parser.add_argument(
+        "--budget",
+        default=str(DEFAULT_BUDGET_PATH),
+        help="Size budget config (total and per-file bytes, entries per level, bytes per entry); exceeding it fails the export and blocks --commit",
+    )
+    parser.add_argument("--no-checkpoints", action="store_true", help="Do not save stage checkpoints (a later --resume then starts from zero)")
+    parser.add_argument(
+        "--events",
+        nargs="?",
+        const=str(DEFAULT_EVENTS_PATH),
+        help=f"Write a JSONL log of every pipeline decision (default path: {DEFAULT_EVENTS_PATH})",
+    )
+    add_output_arguments(parser)
+    return parser
+
+
+@dataclass
+class Settings:
+    """Every config one export reads, loaded before any content is touched so a bad one stops the run at once."""
+
+    profiles: Dict[str, ExportProfile]
+    summarizer: Summarizer
+    study_time: StudyTimeConfig
+    rules: List[CustomRule]
+    budget: Budget
+    numeral_style: NumeralStyle
+    headword_rules: HeadwordRules
+    reference_types: List[ReferenceType]
+    gates: List[Gate]
+    plugins: Plugins
+    critical_fields: Dict[str, List[str]]
+    locales: LocaleConfig
+    media_types: Dict[str, MediaType]
+    tombstones: List[Tombstone]
+    scheme: IdScheme
+    lesson_flow: Dict[str, List[str]]
+    at_commit: Optional[str] = None
+    frozen: Optional[Dict[str, Set[str]]] = None
+    since: Optional[Dict[str, Any]] = None
+    previous_release: Dict[str, List[Dict[str, Any]]] = field(default_factory=dict)
+
+
+def check_arguments(args: argparse.Namespace) -> None:
+    """Raise ConfigError for flags that contradict each other."""
+    if args.keep_builds is not None and args.keep_builds < 1:
+        raise ConfigError("--keep-builds must be at least 1", "keep_builds", args.keep_builds)
+    if args.jobs is not None and args.jobs < 1:
+        raise ConfigError("--jobs must be at least 1", "jobs", args.jobs)
+    if args.push and not args.commit:
+        raise ConfigError("--push needs --commit", "push", args.push)
+    if args.max_file_bytes < 0 or args.max_depth < 1:
+        raise ConfigError("--max-file-bytes must not be negative and --max-depth must be at least 1", "max_depth", args.max_depth)
+    if args.at and args.commit:
+        raise ConfigError("--at rebuilds a past dataset for inspection; it cannot be committed", "at", args.at)
+    if args.summaries is not None and args.summaries < 10:
+        raise ConfigError("--summaries must be at least 10 characters", "summaries", args.summaries)
+
+
+def check_storage(args: argparse.Namespace, storage: Storage) -> None:
+    """Raise ConfigError when a flag needs a directory on disk and the outputs go elsewhere."""
+    if (args.keep_builds or args.commit) and not isinstance(storage, LocalStorage):
+        raise ConfigError(f"--{'keep-builds' if args.keep_builds else 'commit'} needs the local storage backend", "storage", storage.describe(), ["local"])
+
+
+def load_settings(args: argparse.Namespace) -> Settings:
+    """Load every config the flags point at; raises ConfigError (or another ValueError) for the first bad one."""
+    try:
+        summarizer = load_summarizer(args.summarizer)
+    except (ImportError, AttributeError, ValueError) as exc:
+        raise ConfigError(f"cannot load summarizer: {exc}", "summarizer", args.summarizer) from exc
+    at_commit = resolve_revision(args.at) if args.at else None
+    since, previous_release = load_previous(args.since) if args.since else (None, {})
+    settings = Settings(
+        profiles=load_profiles(args.profiles),
+        summarizer=summarizer,
+        study_time=load_study_time(args.study_time),
+        rules=load_rules(args.rules),
+        budget=load_budget(args.budget),
+        numeral_style=load_numeral_style(args.numerals),
+        headword_rules=load_headword_rules(args.headwords),
+        reference_types=load_reference_types(args.references),
+        gates=parse_gates(args.gate),
+        plugins=load_plugins(args.plugins),
+        critical_fields=load_critical_fields(args.reconcile),
+        locales=load_locales(args.locales),
+        media_types=load_media_types(args.media_types),
+        tombstones=load_tombstones(args.tombstones),
+        scheme=load_id_scheme(args.ids),
+        lesson_flow=load_lesson_flow(args.lint_config),
+        at_commit=at_commit,
+        frozen=load_frozen_ids(args.frozen) if args.frozen else None,
+        since=since,
+        previous_release=previous_release,
+    )
+    unknown = [name for name in args.profile if name not in settings.profiles]
+    if unknown:
+        raise ConfigError(f"unknown export profile(s): {', '.join(unknown)} (known: {', '.join(sorted(settings.profiles)) or 'none'})", "profile", unknown, sorted(settings.profiles))
+    return settings
+
+
+@dataclass
+class Collected:
+    dataset: Dataset
+    key: str
+    plugin_records: Dict[str, List[Record]]
+    all_records: List[Record]
+    source_comments: List[Comment]
+    field_typos: int
+
+
+def collect_phase(args: argparse.Namespace, settings: Settings, reporter: Reporter, checkpoints: Checkpoints, events: EventLog) -> Tuple[Dataset, str]:
+    """(dataset, fingerprint of what it was read from): the content as collect reads it, or the collect checkpoint."""
+    at_commit = settings.at_commit
+    settings_key = {"recover": args.recover, "resolve_conflicts": args.resolve_conflicts, "max_file_bytes": args.max_file_bytes, "max_depth": args.max_depth}
+    # A past revision never changes, so its commit stands in for the working-tree files.
+    collect_key = input_fingerprint([], [args.conflict_cache], {**settings_key, "at": at_commit, "content": args.content}) if at_commit else input_fingerprint(args.content, [args.conflict_cache], settings_key)
+    saved, note = checkpoints.load("collect", collect_key) if args.resume else (None, "")
+    if note:
+        print(f"[export] {note}")
+    with stage(reporter, "collect", resumed=saved is not None):
+        dataset = dataset_from_payload(saved) if saved is not None else collect(args.content, events, args.recover, args.resolve_conflicts, args.conflict_cache, at_commit, args.max_file_bytes or None, args.max_depth)
+    if saved is None:
+        checkpoints.save("collect", collect_key, dataset_payload(dataset))
+    return dataset, collect_key
+
+
+def classify_phase(dataset: Dataset, key: str, settings: Settings, reporter: Reporter) -> Collected:
+    """Count the collected records and sort plugin kinds out of the unclassified ones."""
+    scheme, plugins = settings.scheme, settings.plugins
+    reporter.metric("records_collected", len(dataset.lessons), kind="lesson")
+    reporter.metric("records_collected", len(dataset.vocab), kind="vocab")
+    reporter.metric("records_collected", len(dataset.readings), kind="reading")
+    reporter.metric("records_collected", len(dataset.units), kind="unit")
+    reporter.metric("records_collected", len(dataset.culture_notes), kind="culture_note")
+    reporter.metric("records_collected", len(dataset.pron_drills), kind="pron_drill")
+    plugin_records, dataset.unclassified = plugins.claim(dataset.unclassified)
+    for kind, records in plugin_records.items():
+        reporter.metric("records_collected", len(records), kind=kind)
+    classified = dataset.classified()
+    all_records = classified + dataset.unclassified
+    # Plugin and unclassified records' notes count too; those records are the likeliest to need someone's attention.
+    source_comments = [comment for record in classified for comment in record_comments(record, record_id(record, scheme))]
+    source_comments += [comment for record in [record for records in plugin_records.values() for record in records] + dataset.unclassified for comment in record_comments(record)]
+    field_typos = sum(len(record.data[CORRECTIONS_KEY]) for record in all_records if isinstance(record.data.get(CORRECTIONS_KEY), dict))
+    return Collected(dataset, key, plugin_records, all_records, source_comments, field_typos)
+
+
+@dataclass
+class Validated:
+    # Keyed like the validate checkpoint: vocab, lessons, readings, units, culture_notes, pron_drills.
+    kinds: Dict[str, List[Dict[str, Any]]]
+    plugin_entries: Dict[str, List[Dict[str, Any]]]
+    invalid: List[Reject]
+    headword_log: List[Dict[str, Any]]
+    coerced: List[Tuple[str, Dict[str, Any]]]
+
+
+def validate_phase(args: argparse.Namespace, settings: Settings, collected: Collected, checkpoints: Checkpoints, events: EventLog) -> Validated:
+    """Build an entry per record, normalized, with --validate's rejects split off (or the validate checkpoint)."""
+    dataset, scheme, rules, locales, plugins = collected.dataset, settings.scheme, settings.rules, settings.locales, settings.plugins
+    validate_key = input_fingerprint([], [args.ids, args.rules, args.locales, args.plugins, args.headwords, *sorted(SCHEMAS_DIR.glob("*.json"))], {"collect": collected.key, "validate": args.validate, "fix_headwords": args.fix_headwords})
+    saved, note = checkpoints.load("validate", validate_key) if args.resume else (None, "")
+    if note:
+        print(f"[export] {note}")
+    if saved is not None:
+        kinds = {kind: saved.get(kind, []) for kind in ("vocab", "lessons", "readings", "units", "culture_notes", "pron_drills")}
+        plugin_entries: Dict[str, List[Dict[str, Any]]] = saved.get("plugins", {})
+        invalid: List[Reject] = [Reject(**row) for row in saved["invalid"]]
+        headword_log: List[Dict[str, Any]] = saved.get("headwords", [])
+    else:
+        headword_log = []
+        if args.fix_headwords:
+            # Before IDs are derived, so "el perro" and "perro" become one entry.
+            for record in dataset.vocab:
+                if is_pinned(record.data):
+                    continue
+                fixes = normalize_headword(record.data, settings.headword_rules, fix=True)
+                headword_log += [{"id": record_id(record, scheme), **asdict(fix)} for fix in fixes]
+                for fix in fixes:
+                    if not fix.ambiguous:
+                        events.emit("headword_normalized", record.source, f"{fix.found} -> {fix.suggestion}", id=record_id(record, scheme), path=fix.path)
+        kinds = {
+            "vocab": [entry if is_pinned(entry) else normalize_senses(normalize_vocab(entry)) for entry in build_entries(dataset.vocab, scheme)],
+            "lessons": [entry if is_pinned(entry) else normalize_lesson(entry) for entry in build_entries(dataset.lessons, scheme)],
+            "readings": build_entries(dataset.readings, scheme),
+            "units": build_entries(dataset.units, scheme),
+            "culture_notes": build_entries(dataset.culture_notes, scheme),
+            "pron_drills": [entry if is_pinned(entry) else normalize_drill(entry) for entry in build_entries(dataset.pron_drills, scheme)],
+        }
+        plugin_entries = {kind: build_entries(records, scheme) for kind, records in collected.plugin_records.items()}
+        mark_third_party([entry for kind in ("vocab", "lessons", "readings", "culture_notes", "pron_drills") for entry in kinds[kind] if not is_pinned(entry)])
+        invalid = []
+        if args.validate:
+            schemas = load_schemas()
+            for kind, schema in (("vocab", "vocab"), ("lessons", "lesson"), ("readings", "reading"), ("units", "unit"), ("culture_notes", "culture_note"), ("pron_drills", "pron_drill")):
+                kinds[kind], bad = drop_invalid(kinds[kind], schemas[schema], events, rules_for(rules, schema), locales_for(locales, schema))
+                invalid += bad
+            for kind, entries in plugin_entries.items():
+                if plugins.kinds[kind].schema is not None:
+                    plugin_entries[kind], bad = drop_invalid(entries, plugins.kinds[kind].schema, events, rules_for(rules, kind), locales_for(locales, kind))
+                    invalid += bad
+        checkpoints.save("validate", validate_key, {**kinds, "plugins": plugin_entries, "invalid": [asdict(reject) for reject in invalid], "headwords": headword_log})
+    # Taken before merging, so every copy's coercions are listed, rejected entries' included.
+    shaped = kinds["lessons"] + kinds["vocab"] + [reject.record for reject in invalid if isinstance(reject.record, dict)]
+    coerced = [(str(entry.get("id", "")), note) for entry in shaped for note in normalization_warnings(entry)]
+    return Validated(kinds, plugin_entries, invalid, headword_log, coerced)
+
+
+@dataclass
+class Merged:
+    vocab: List[Dict[str, Any]]
+    lessons: List[Dict[str, Any]]
+    readings: List[Dict[str, Any]]
+    declared_units: List[Dict[str, Any]]
+    culture_notes: List[Dict[str, Any]]
+    drills: List[Dict[str, Any]]
+    plugin_kinds: Dict[str, List[Dict[str, Any]]]
+    clusters: List[DuplicateCluster]
+    duplicates: int
+    # Validation rejects plus, under --on-duplicate reject, the duplicates.
+    invalid: List[Reject]
+    retired: List[Tombstone]
+    matches: List[LessonMatch]
+    similar_merged: int
+    pinned: Dict[str, Dict[str, Any]]
+    refused: List[str]
+    verdicts: Dict[str, Dict[str, Any]]
+
+
+def merge_phase(args: argparse.Namespace, settings: Settings, validated: Validated, reports: Storage, reporter: Reporter, events: EventLog) -> Merged:
+    """Resolve duplicate IDs and similar lessons, drop retired entries, and reconcile double entry."""
+    tombstones = settings.tombstones
+    with stage(reporter, "merge"):
+        vocab, vocab_clusters = resolve_duplicates(validated.kinds["vocab"], args.on_duplicate, args.prose_threshold, events)
+        lessons, lesson_clusters = resolve_duplicates(validated.kinds["lessons"], args.on_duplicate, args.prose_threshold, events)
+        readings, reading_clusters = resolve_duplicates(validated.kinds["readings"], args.on_duplicate, args.prose_threshold, events)
+        declared_units, unit_clusters = resolve_duplicates(validated.kinds["units"], args.on_duplicate, args.prose_threshold, events)
+        culture_notes, note_clusters = resolve_duplicates(validated.kinds["culture_notes"], args.on_duplicate, args.prose_threshold, events)
+        drills, drill_clusters = resolve_duplicates(validated.kinds["pron_drills"], args.on_duplicate, args.prose_threshold, events)
+        clusters = vocab_clusters + lesson_clusters + reading_clusters + unit_clusters + note_clusters + drill_clusters
+        plugin_kinds: Dict[str, List[Dict[str, Any]]] = {}
+        for kind, entries in validated.plugin_entries.items():
+            plugin_kinds[kind], kind_clusters = resolve_duplicates(entries, args.on_duplicate, args.prose_threshold, events)
+            plugin_kinds[kind] = drop_retired(plugin_kinds[kind], tombstones)
+            clusters += kind_clusters
+        duplicates = sum(len(cluster.entries) - 1 for cluster in clusters if not cluster.pinned)
+        invalid = validated.invalid + duplicate_rejects(clusters, args.on_duplicate)
+        before = {entry["id"]: entry for entry in vocab + lessons + readings + declared_units + culture_notes + drills}
+        vocab, lessons, readings, declared_units, culture_notes, drills = (drop_retired(entries, tombstones) for entries in (vocab, lessons, readings, declared_units, culture_notes, drills))
+        retired = [stone for stone in tombstones if stone.id in before]
+        for stone in retired:
+            events.emit("entry_retired", ", ".join(before[stone.id].get("source_files", [])), stone.reason, id=stone.id)
+        matches = similar_lessons(lessons, min(args.lesson_review_threshold, args.merge_similar_lessons or 1.0))
+        lessons, folded = merge_similar_lessons(lessons, matches, args.merge_similar_lessons, args.prose_threshold, events)
+        similar_merged = len(folded)
+        # Pinned entries leave the pipeline exactly as they were written; attempts to change them are reported instead.
+        pinned = {entry["id"]: copy.deepcopy(entry) for entry in vocab + lessons + readings + declared_units + culture_notes + drills if is_pinned(entry)}
+        if folded:
+            # Prerequisites, intro lessons, note and unit lesson lists follow a merged-away lesson to the one holding it.
+            vocab, lessons, declared_units, culture_notes = copy.deepcopy((vocab, lessons, declared_units, culture_notes))
+            rename_references({"vocab": vocab, "lesson": lessons, "unit": declared_units, "culture_note": culture_notes}, settings.reference_types + [UNIT_LESSONS], "lesson", folded)
+        refused = [f"{cluster.id}: {', '.join(entry.get('source_files', []))} not merged in ({args.on_duplicate})" for cluster in clusters if cluster.pinned for idx, entry in enumerate(cluster.entries) if idx != cluster.kept]
+        verdicts: Dict[str, Dict[str, Any]] = {}
+        if args.double_entry:
+            merged_kinds = {"vocab": (vocab, vocab_clusters), "lesson": (lessons, lesson_clusters), "reading": (readings, reading_clusters), "unit": (declared_units, unit_clusters), "culture_note": (culture_notes, note_clusters), "pron_drill": (drills, drill_clusters)}
+            verdicts = {kind: reconcile(*merged_kinds[kind], names) for kind, names in settings.critical_fields.items()}
+            for kind, per_kind in verdicts.items():
+                for entry in merged_kinds[kind][0]:
+                    entry["verified"] = entry["id"] in per_kind and per_kind[entry["id"]].status == "verified"
+            save_report(reports, "reconciliation.md", reconciliation_report(verdicts))
+        if args.merge_similar_lessons is not None:
+            refused += [f"{pin}: similar lesson {other} not merged (score {match.score:.2f})" for match in matches if match.score >= args.merge_similar_lessons for pin, other in ((match.keep, match.duplicate), (match.duplicate, match.keep)) if pin in pinned]
+    return Merged(vocab, lessons, readings, declared_units, culture_notes, drills, plugin_kinds, clusters, duplicates, invalid, retired, matches, similar_merged, pinned, refused, verdicts)
+
+
+@dataclass
+class Fixes:
+    accents: int = 0
+    headwords: int = 0
+    numerals: int = 0
+
+
+def fix_phase(args: argparse.Namespace, settings: Settings, validated: Validated, merged: Merged, reports: Storage, events: EventLog) -> Fixes:
+    """Apply --fix-accents and --fix-numerals to the merged entries and report them with --fix-headwords' changes."""
+    fixes = Fixes(headwords=sum(1 for fix in validated.headword_log if not fix["ambiguous"]))
+    editable = [entry for entry in merged.vocab + merged.lessons if not is_pinned(entry)]
+    if args.fix_accents:
+        dictionary = load_accent_dictionary(args.accents)
+        log = ["Accent restoration"]
+        for entry in editable:
+            for fix in restore_accents(entry, dictionary, fix=True):
+                action = "left for review" if fix.ambiguous else "fixed"
+                log.append(f"- {entry['id']} {fix.path}: {fix.word} -> {fix.suggestion} ({action})")
+                if not fix.ambiguous:
+                    fixes.accents += 1
+                    events.emit("accent_fixed", ", ".join(entry.get("source_files", [])), f"{fix.word} -> {fix.suggestion}", id=entry["id"], path=fix.path)
+        log.insert(1, f"- fixed: {fixes.accents}")
+        save_report(reports, "accents.md", log)
+    if args.fix_headwords:
+        log = ["Headword normalization", f"- fixed: {fixes.headwords}"]
+        log += [f"- {fix['id']} {fix['path']}: {fix['found']!r} -> {fix['suggestion']!r} ({fix['reason']}; {'left for review' if fix['ambiguous'] else 'fixed'})" for fix in validated.headword_log]
+        save_report(reports, "headwords.md", log)
+    if args.fix_numerals:
+        log = ["Number, date, and time normalization"]
+        for entry in editable:
+            for fix in normalize_numerals(entry, settings.numeral_style, entry_level(entry), fix=True):
+                action = "left for review" if fix.ambiguous else "fixed"
+                log.append(f"- {entry['id']} {fix.path}: {fix.found} -> {fix.suggestion} ({fix.reason}; {action})")
+                if not fix.ambiguous:
+                    fixes.numerals += 1
+                    events.emit("numeral_normalized", ", ".join(entry.get("source_files", [])), f"{fix.found} -> {fix.suggestion}", id=entry["id"], path=fix.path)
+        log.insert(1, f"- fixed: {fixes.numerals}")
+        save_report(reports, "numerals.md", log)
+    return fixes
+
+
+@dataclass
+class Transformed:
+    vocab: List[Dict[str, Any]]
+    lessons: List[Dict[str, Any]]
+    readings: List[Dict[str, Any]]
+    units: List[Dict[str, Any]]
+    culture_notes: List[Dict[str, Any]]
+    drills: List[Dict[str, Any]]
+    plugin_kinds: Dict[str, List[Dict[str, Any]]]
+    statuses: Counter
+    held_back: int
+    expanded: int
+    summarized: int
+    # Entries given a frequency rank; None when there is no frequency list.
+    ranked: Optional[int]
+    too_long: List[Dict[str, Any]]
+    dangling: List[Dangling]
+    unresolved: List[str]
+    relation_problems: List[str]
+    unit_problems: List[Tuple[str, str]]
+    intro_problems: List[Tuple[str, str]]
+    note_problems: List[Tuple[str, str]]
+    drill_problems: List[Tuple[str, str]]
+    media_problems: List[Tuple[str, str]]
+    enriched: int
+    altered: List[str]
+
+    def kinds(self) -> Dict[str, List[Dict[str, Any]]]:
+        """The built-in kinds keyed by output file, as exporters, profiles, and the budget take them."""
+        return {"vocabulary": self.vocab, "lessons": self.lessons, "readings": self.readings, "units": self.units, "culture_notes": self.culture_notes, "pron_drills": self.drills}
+
+
+def transform_phase(args: argparse.Namespace, settings: Settings, merged: Merged, reports: Storage, reporter: Reporter) -> Transformed:
+    """Everything export adds to or derives from the merged entries: generated fields, references, units, sidebars."""
+    scheme, study_time, summarizer, plugins, pinned = settings.scheme, settings.study_time, settings.summarizer, settings.plugins, merged.pinned
+    reference_types, media_types = settings.reference_types, settings.media_types
+    vocab, lessons, readings, declared_units, culture_notes, drills, plugin_kinds = merged.vocab, merged.lessons, merged.readings, merged.declared_units, merged.culture_notes, merged.drills, merged.plugin_kinds
+    statuses = Counter(f"{kind} {review_status(entry)}" for kind, entries in (("culture_note", culture_notes), ("lesson", lessons), ("pron_drill", drills), ("reading", readings), ("unit", declared_units), ("vocab", vocab)) for entry in entries)
+    held_back = 0
+    expanded = 0
+    summarized = 0
+    ranked: Optional[int] = None
+    with stage(reporter, "transform"):
+        if args.publish_only:
+            held_back = sum(1 for entry in vocab + lessons + readings + culture_notes + drills + [entry for entries in plugin_kinds.values() for entry in entries] if review_status(entry) == "draft")
+            vocab = [entry for entry in vocab if review_status(entry) != "draft"]
+            lessons = [entry for entry in lessons if review_status(entry) != "draft"]
+            readings = [entry for entry in readings if review_status(entry) != "draft"]
+            declared_units = [entry for entry in declared_units if review_status(entry) != "draft"]
+            culture_notes = [entry for entry in culture_notes if review_status(entry) != "draft"]
+            drills = [entry for entry in drills if review_status(entry) != "draft"]
+            plugin_kinds = {kind: [entry for entry in entries if review_status(entry) != "draft"] for kind, entries in plugin_kinds.items()}
+
+        if args.plurals:
+            for entry in vocab:
+                if entry.get("pos") == "noun" and isinstance(entry.get("spanish"), str) and not entry.get("plural"):
+                    entry["plural"] = pluralize(entry["spanish"])
+
+        if args.syllables:
+            for entry in vocab:
+                computed = pronunciation(entry)
+                if computed and "syllables" not in entry:
+                    entry["syllables"] = computed[0]
+                    entry.setdefault("stress_index", computed[1])
+                elif isinstance(entry.get("syllables"), list) and "stress_index" not in entry:
+                    entry["stress_index"] = stress_index(entry["syllables"])
+
+        # Seeded on every build (pinned entries stay as written; the search index still seeds theirs).
+        for entry in (entry for entry in vocab if not is_pinned(entry)):
+            keywords = english_keywords(entry)
+            if keywords:
+                entry[KEYWORDS_FIELD] = keywords
+            else:
+                entry.pop(KEYWORDS_FIELD, None)
+
+        if args.summaries:
+            summarized = add_summaries(vocab, args.summaries, summarizer)
+
+        corpus_ranks = load_frequency_ranks(args.frequency)
+        if corpus_ranks:
+            best = frequency_ranks(vocab, corpus_ranks, build_forms_index(vocab, load_form_bank(args.forms_bank)))
+            for entry in vocab:
+                entry["frequency_rank"] = best.get(entry["id"])
+            ranked = len(best)
+
+        for lesson in lessons:
+            lesson["estimated_minutes"] = estimated_minutes(lesson, study_time)
+        too_long = over_cap(lessons, study_time)
+
+        if args.difficulty:
+            ranks = corpus_ranks or dataset_ranks(vocab, lessons)
+            for entry in vocab:
+                entry["difficulty"] = difficulty(entry, ranks)
+
+        if args.srs:
+            intro = introduction_lessons(vocab, lessons)
+            for entry in vocab:
+                entry["srs"] = srs_metadata(entry, intro.get(entry["id"]))
+
+        if args.sort_by != "source":
+            vocab = sort_vocab(vocab, args.sort_by)
+
+        # Before embedding, gloss and relation resolution, and lesson pruning drop or rewrite what they cannot follow.
+        references_checked, dangling = resolve_references({"lesson": lessons, "vocab": vocab, "reading": readings, "unit": declared_units, "culture_note": culture_notes, "pron_drill": drills}, reference_types)
+        save_report(reports, "references.md", reference_report(references_checked, dangling))
+        # Before embedding, which turns item references into snapshots.
+        intro_problems = intro_order_problems(lessons, vocab, settings.lesson_flow["presentation"])
+
+        if args.embed_vocab:
+            lessons = copy.deepcopy(lessons)
+            expanded = embed_vocab(lessons, index_vocab(vocab))
+
+        readings = copy.deepcopy(readings)
+        unresolved = resolve_glosses(readings, index_vocab(vocab))
+        relation_problems = resolve_relations(vocab, index_vocab(vocab))
+        relation_problems += asymmetric_relations(vocab)
+
+        units = build_units(declared_units, lessons, scheme)
+        unit_problems = check_units(units, lessons)
+        # The app follows these references, so units only list lessons this export publishes.
+        exported = {lesson["id"] for lesson in lessons}
+        for unit in units:
+            unit["lessons"] = [ref for ref in unit["lessons"] if ref in exported]
+
+        note_problems = check_culture_notes(culture_notes, lessons, Path(args.assets))
+        culture_notes = copy.deepcopy(culture_notes)
+        for note in culture_notes:
+            note["lessons"] = [ref for ref in note.get("lessons") or [] if ref in exported]
+        sidebars = notes_by_lesson(culture_notes)
+        for lesson in lessons:
+            if lesson["id"] in sidebars:
+                lesson["culture_notes"] = sidebars[lesson["id"]]
+        drill_problems = check_drills(drills, Path(args.audio))
+        media_problems = check_step_media(lessons, media_types, Path(args.assets))
+        if args.online_checks:
+            media_problems += check_reachable(remote_media(lessons, media_types))
+        enriched = plugins.enrich({"vocab": vocab, "lesson": lessons, "reading": readings, "unit": units, "culture_note": culture_notes, "pron_drill": drills, **plugin_kinds}) if plugins.enrichers else 0
+
+        vocab, vocab_altered = restore_pinned(vocab, pinned)
+        lessons, lesson_altered = restore_pinned(lessons, pinned)
+        readings, reading_altered = restore_pinned(readings, pinned)
+        units, unit_altered = restore_pinned(units, pinned)
+        culture_notes, note_altered = restore_pinned(culture_notes, pinned)
+        drills, drill_altered = restore_pinned(drills, pinned)
+        altered = vocab_altered + lesson_altered + reading_altered + unit_altered + note_altered + drill_altered
+    return Transformed(
+        vocab, lessons, readings, units, culture_notes, drills, plugin_kinds, statuses, held_back, expanded, summarized, ranked, too_long,
+        dangling, unresolved, relation_problems, unit_problems, intro_problems, note_problems, drill_problems, media_problems, enriched, altered,
+    )
+
+
+def license_problems(args: argparse.Namespace, settings: Settings, transformed: Transformed) -> List[str]:
+    """Third-party entries missing license fields, and entries a gating profile's licenses do not allow."""
+    entries = transformed.vocab + transformed.lessons + transformed.readings + transformed.culture_notes + transformed.drills
+    problems = [f"{entry['id']}: third-party entry without {', '.join(missing_license_fields(entry))}" for entry in entries if missing_license_fields(entry)]
+    for name in args.profile:
+        if settings.profiles[name].license_mode == "gate":
+            problems += [f"profile {name}: {problem}" for problem in license_violations(entries, settings.profiles[name].licenses)]
+    return problems
+
+
+def hand_edits(args: argparse.Namespace, storage: Storage) -> Tuple[Optional[Path], Storage, List[Tuple[str, str]]]:
+    """(--keep-builds root, previous outputs, hand-edited files); only read, so a backend is never opened for writing here."""
+    last_root = storage
+    build_root = None
+    if args.keep_builds:
+        build_root = last_root.root
+        if latest_build(build_root):
+            last_root = LocalStorage(latest_build(build_root))
+    last = last_root.child(args.out)
+    return build_root, last, modified_files(last_root, last)
+
+
+@dataclass
+class Written:
+    out: Storage
+    files: Dict[str, Dict[str, Any]]
+    counts: Dict[str, int]
+    rejects: List[Dict[str, Any]]
+    quarantined: List[Dict[str, Any]]
+    delta: List[Dict[str, Any]]
+    relations: Dict[str, Any]
+    forms: Dict[str, Any]
+    aligned: List[Dict[str, Any]]
+    misaligned: List[str]
+    results: List[ExporterResult]
+
+
+def write_phase(args: argparse.Namespace, settings: Settings, run: Dict[str, Any], collected: Collected, merged: Merged, transformed: Transformed, storage: StagingStorage, reporter: Reporter) -> Written:
+    """Write every output, the rejects, and the manifest into staging; nothing reaches the destination yet."""
+    profiles, plugins, since, previous_release, tombstones = settings.profiles, settings.plugins, settings.since, settings.previous_release, settings.tombstones
+    dataset, invalid = collected.dataset, merged.invalid
+    vocab, lessons, readings, units, culture_notes, drills, plugin_kinds = transformed.vocab, transformed.lessons, transformed.readings, transformed.units, transformed.culture_notes, transformed.drills, transformed.plugin_kinds
+    aligned: List[Dict[str, Any]] = []
+    misaligned: List[str] = []
+    with stage(reporter, "write"):
+        out = storage.child(args.out)
+        marked = args.mark_generated
+        files = {"vocabulary.json": write_json(out, "vocabulary.json", vocab, marked), "lessons.json": write_json(out, "lessons.json", lessons, marked)}
+        files["readings.json"] = write_json(out, "readings.json", readings, marked)
+        files["units.json"] = write_json(out, "units.json", units, marked)
+        files["culture_notes.json"] = write_json(out, "culture_notes.json", culture_notes, marked)
+        files["pron_drills.json"] = write_json(out, "pron_drills.json", drills, marked)
+        for kind, entries in plugin_kinds.items():
+            files[f"{plugins.kinds[kind].output}.json"] = write_json(out, f"{plugins.kinds[kind].output}.json", entries, marked)
+        files["tombstones.json"] = write_json(out, "tombstones.json", tombstone_rows(tombstones), marked)
+        delta: List[Dict[str, Any]] = []
+        if since is not None:
+            delta = delta_changes(previous_release, {"vocabulary": vocab, "lessons": lessons, "readings": readings, "units": units, "culture_notes": culture_notes, "pron_drills": drills}, tombstone_rows(tombstones))
+            payload = delta_payload(since, delta)
+            # Sync clients fetch this on every run, so it is written without indentation.
+            files[DELTA_NAME] = write_stream(out, DELTA_NAME, [json.dumps(mark_generated(payload) if marked else payload, ensure_ascii=False, separators=(",", ":")), "\n"])
+        relations = relations_graph(vocab)
+        files["relations.json"] = write_json(out, "relations.json", relations, marked)
+        forms: Dict[str, Any] = {}
+        if args.forms_index:
+            forms = build_forms_index(vocab, load_form_bank(args.forms_bank))
+            files["forms_index.json"] = write_json(out, "forms_index.json", forms, marked)
+        if args.alignment:
+            aligned, misaligned = alignment_rows(vocab, Path(args.audio))
+            files["alignment.json"] = write_json(out, "alignment.json", aligned, marked)
+            if misaligned:
+                print(f"[export] Invalid example alignments left out of alignment.json ({len(misaligned)} problems):", file=sys.stderr)
+                for problem in misaligned:
+                    print(f"    • {problem}", file=sys.stderr)
+        if args.search_index:
+            files["search-index.json"] = write_json(out, "search-index.json", build_search_index(vocab, lessons), marked)
+        for name in args.profile:
+            files.update(write_profile(storage, profiles[name], {"vocabulary": vocab, "lessons": lessons, "readings": readings, "units": units, "culture_notes": culture_notes, "pron_drills": drills}, tombstone_rows(tombstones), marked))
+        with reporter.span("exporters", exporters=",".join(args.exporter)):
+            results = run_exporters(list(dict.fromkeys(args.exporter)), {"vocabulary": vocab, "lessons": lessons, "readings": readings, "units": units, "culture_notes": culture_notes, "pron_drills": drills}, args.jobs)
+        for result in results:
+            for name, data in result.files.items():
+                out.write_bytes(name, data)
+                files[name] = manifest_row(data)
+            reporter.metric("exporter_seconds", result.seconds, exporter=result.name, failed=result.error is not None)
+        near_misses, _ = split_unclassified(dataset.unclassified, load_schemas())
+        quarantined = write_quarantine(near_misses, storage.child(args.quarantine))
+        rejects = write_rejects(collect_rejects(dataset, {(record.source, record.index) for record, _ in near_misses}) + invalid, storage.child(args.rejects), args.reject_format)
+        counts = {"lessons": len(lessons), "vocabulary": len(vocab), "readings": len(readings), "units": len(units), "culture_notes": len(culture_notes), "pron_drills": len(drills), "tombstones": len(tombstones), "rejects": len(rejects), "quarantined": len(quarantined)}
+        counts.update({plugins.kinds[kind].output: len(entries) for kind, entries in plugin_kinds.items()})
+        write_json(out, "manifest.json", {"run": run, "files": files, "counts": counts})
+    return Written(out, files, counts, rejects, quarantined, delta, relations, forms, aligned, misaligned, results)
+
+
+@dataclass
+class Checked:
+    changed: int
+    over_budget: List[Tuple[str, str]]
+    freeze_violations: List[FreezeViolation]
+    metrics: Dict[str, Any]
+    gate_results: List[GateResult]
+    failed_gates: List[GateResult]
+
+    @property
+    def held(self) -> bool:
+        """An over-budget, regressed, or freeze-breaking dataset must not reach the app: it is neither written nor committed."""
+        return bool(self.over_budget or self.failed_gates or self.freeze_violations)
+
+
+def check_phase(
+    args: argparse.Namespace,
+    settings: Settings,
+    collected: Collected,
+    validated: Validated,
+    merged: Merged,
+    transformed: Transformed,
+    written: Written,
+    last: Storage,
+    reports: Storage,
+    reporter: Reporter,
+) -> Checked:
+    """Diff against the previous outputs in last (still in place), then judge the staged build against the size budget, the freeze, and the gates."""
+    frozen, files, counts = settings.frozen, written.files, written.counts
+    dangling, verdicts = transformed.dangling, merged.verdicts
+    kinds = transformed.kinds()
+    changed = write_change_report(last, {name: kinds[name] for name in ENTRY_OUTPUTS}, reports.child("changes"))
+    reporter.metric("entries_written", len(transformed.lessons), kind="lesson")
+    reporter.metric("entries_written", len(transformed.vocab), kind="vocab")
+    reporter.metric("entries_written", len(transformed.readings), kind="reading")
+    reporter.metric("entries_written", len(transformed.units), kind="unit")
+    reporter.metric("entries_written", len(transformed.culture_notes), kind="culture_note")
+    reporter.metric("entries_written", len(transformed.drills), kind="pron_drill")
+    reporter.metric("rejects_written", len(written.rejects))
+    reporter.metric("bytes_written", sum(row["bytes"] for row in files.values()))
+    over_budget = check_budget(settings.budget, files, kinds)
+    freeze_violations = check_frozen(frozen, kinds) if frozen is not None else []
+    if frozen is not None:
+        save_report(reports, "freeze.md", freeze_report(args.frozen, frozen, freeze_violations))
+    metrics = dict(counts, duplicates=merged.duplicates, similar_lessons=len(merged.matches), dangling_references=len(dangling), reference_errors=sum(1 for item in dangling if item.type.severity == "error"))
+    metrics.update(
+        skipped_files=len(collected.dataset.skipped),
+        normalization_warnings=len(validated.coerced),
+        relation_problems=len(transformed.relation_problems),
+        unit_problems=len(transformed.unit_problems),
+        intro_order_problems=len(transformed.intro_problems),
+        culture_note_problems=len(transformed.note_problems),
+        step_media_problems=len(transformed.media_problems),
+        drill_problems=len(transformed.drill_problems),
+        unresolved_glosses=len(transformed.unresolved),
+    )
+    if args.double_entry:
+        metrics.update(double_entry_verified=sum(verdict.status == "verified" for per_kind in verdicts.values() for verdict in per_kind.values()), double_entry_conflicts=sum(verdict.status == "conflict" for per_kind in verdicts.values() for verdict in per_kind.values()))
+    metrics.update(lessons_over_cap=len(transformed.too_long), bytes_written=sum(row["bytes"] for row in files.values()), over_budget=len(over_budget), freeze_violations=len(freeze_violations), delta_changes=len(written.delta))
+    gate_results = check_gates(settings.gates, metrics, last_run(args.history, "export") if settings.gates else None)
+    failed_gates = [result for result in gate_results if result.status == "failed"]
+    return Checked(changed, over_budget, freeze_violations, metrics, gate_results, failed_gates)
+
+
+def promote_phase(args: argparse.Namespace, staging: StagingStorage, build_root: Optional[Path], held: bool, reporter: Reporter) -> Tuple[Optional[Storage], List[Path]]:
+    """Move the staged outputs into place unless the checks held them back; (destination, pruned builds)."""
+    if held:
+        staging.discard()
+        return staging.target, []
+    pruned: List[Path] = []
+    with stage(reporter, "promote"):
+        storage = LocalStorage(new_build_dir(build_root)) if build_root is not None else staging.target
+        staging.promote(storage)
+        storage.close()
+        if build_root is not None:
+            link_latest(build_root, storage.root)
+            pruned = prune_builds(build_root, args.keep_builds)
+    return storage, pruned
+
+
+def report_phase(
+    args: argparse.Namespace,
+    settings: Settings,
+    run: Dict[str, Any],
+    collected: Collected,
+    validated: Validated,
+    merged: Merged,
+    fixes: Fixes,
+    transformed: Transformed,
+    written: Written,
+    checked: Checked,
+    pruned: List[Path],
+    published: Optional[PublishResult],
+    reports: Storage,
+) -> bool:
+    """Print the summary, write export.md, and list what needs attention; True when the run failed."""
+    study_time, budget, gates, plugins, frozen, since, at_commit = settings.study_time, settings.budget, settings.gates, settings.plugins, settings.frozen, settings.since, settings.at_commit
+    dataset, source_comments, field_typos, coerced = collected.dataset, collected.source_comments, collected.field_typos, validated.coerced
+    duplicates, matches, similar_merged, verdicts, pinned, refused, retired = merged.duplicates, merged.matches, merged.similar_merged, merged.verdicts, merged.pinned, merged.refused, merged.retired
+    accent_fixes, numeral_fixes, headword_fixes = fixes.accents, fixes.numerals, fixes.headwords
+    vocab, lessons, readings, units, culture_notes, drills, plugin_kinds = transformed.vocab, transformed.lessons, transformed.readings, transformed.units, transformed.culture_notes, transformed.drills, transformed.plugin_kinds
+    statuses, held_back, expanded, summarized, ranked, too_long, enriched, altered = transformed.statuses, transformed.held_back, transformed.expanded, transformed.summarized, transformed.ranked, transformed.too_long, transformed.enriched, transformed.altered
+    dangling, unresolved, relation_problems = transformed.dangling, transformed.unresolved, transformed.relation_problems
+    unit_problems, intro_problems, note_problems, drill_problems, media_problems = transformed.unit_problems, transformed.intro_problems, transformed.note_problems, transformed.drill_problems, transformed.media_problems
+    out, files, rejects, quarantined, delta, relations, forms, aligned, misaligned, results = written.out, written.files, written.rejects, written.quarantined, written.delta, written.relations, written.forms, written.aligned, written.misaligned, written.results
+    held, changed, over_budget, freeze_violations, gate_results, failed_gates = checked.held, checked.changed, checked.over_budget, checked.freeze_violations, checked.gate_results, checked.failed_gates
+    summary = f"[export] {'Built' if held else 'Wrote'} {len(lessons)} lessons, {len(readings)} readings, and {len(vocab)} vocabulary entries {'for' if held else 'to'} {out.describe()}"
+    verb = {"merge": "merged", "review": "left for review", "reject": "rejected"}.get(args.on_duplicate, f"dropped ({args.on_duplicate})")
+    summary += f", {duplicates} duplicates {verb}, {len(rejects)} rejects"
+    if dataset.skipped:
+        summary += f", {len(dataset.skipped)} files skipped (not text JSON)"
+    if source_comments:
+        summary += f", {len(source_comments)} source comments ({sum(1 for comment in source_comments if comment.todo)} TODOs) in todos.md"
+    if coerced:
+        summary += f", {len(coerced)} badly shaped fields normalized or flagged"
+    if quarantined:
+        summary += f", {len(quarantined)} quarantined as near misses"
+    if matches:
+        summary += f", {len(matches)} similar lesson pairs ({similar_merged} merged)"
+    if args.embed_vocab:
+        summary += f" ({expanded} step items embedded)"
+    if args.publish_only:
+        summary += f", {held_back} drafts held back"
+    if args.double_entry:
+        statuses_seen = Counter(verdict.status for per_kind in verdicts.values() for verdict in per_kind.values())
+        summary += f", {statuses_seen['verified']} entries verified by double entry ({statuses_seen['conflict']} disagreements to reconcile)"
+    if args.fix_accents:
+        summary += f", {accent_fixes} accents restored"
+    if args.fix_numerals:
+        summary += f", {numeral_fixes} numerals normalized"
+    if args.fix_headwords:
+        summary += f", {headword_fixes} headword changes"
+    if args.summaries:
+        summary += f", {summarized} definitions summarized"
+    if ranked is not None:
+        summary += f", {ranked} entries with a frequency rank"
+    if too_long:
+        summary += f", {len(too_long)} lessons over the {study_time.cap_minutes:g}-minute cap"
+    if field_typos:
+        summary += f", {field_typos} misspelled field names corrected"
+    if pinned:
+        summary += f", {len(pinned)} pinned entries kept as written ({len(refused)} merges refused)"
+    if retired:
+        summary += f", {len(retired)} retired entries left out"
+    if units:
+        summary += f", {len(units)} units ({sum(1 for unit in units if unit.get('inferred'))} inferred)"
+    if unit_problems:
+        summary += f", {len(unit_problems)} unit numbering problems"
+    if intro_problems:
+        summary += f", {len(intro_problems)} vocabulary uses out of curriculum order"
+    if culture_notes:
+        summary += f", {len(culture_notes)} culture notes"
+    if note_problems:
+        summary += f", {len(note_problems)} culture note problems"
+    if media_problems:
+        summary += f", {len(media_problems)} lesson step media problems"
+    if drills:
+        summary += f", {len(drills)} pronunciation drills"
+    if drill_problems:
+        summary += f", {len(drill_problems)} pronunciation drill problems"
+    for kind, entries in plugin_kinds.items():
+        summary += f", {len(entries)} {kind} entries (plugin)"
+    if enriched:
+        summary += f", {enriched} entries enriched by plugins"
+    if unresolved:
+        summary += f", {len(unresolved)} glossed words without a vocabulary entry"
+    if relations["edges"]:
+        summary += f", {len(relations['edges'])} synonym/antonym links"
+    if relation_problems:
+        summary += f", {len(relation_problems)} relation problems"
+    if dangling:
+        errors = sum(1 for item in dangling if item.type.severity == "error")
+        summary += f", {len(dangling)} dangling references ({errors} errors)"
+    if changed:
+        summary += f"; {changed} changed entries diffed in {reports.child('changes').describe()}"
+    if args.keep_builds:
+        summary += f"; {len(pruned)} older builds pruned"
+    if args.forms_index:
+        summary += f"; {len(forms)} forms indexed"
+    if args.alignment:
+        summary += f"; {len(aligned)} example alignments ({len(misaligned)} alignment problems)"
+    if args.search_index:
+        summary += f"; {len(vocab) + len(lessons)} documents in the search index"
+    if args.profile:
+        summary += f"; profiles: {', '.join(args.profile)}"
+    if since is not None:
+        ops = Counter(item["op"] for item in delta)
+        summary += f"; {DELTA_NAME} since {since['version']}: {ops['add']} added, {ops['update']} updated, {ops['delete']} deleted"
+    if at_commit:
+        summary += f"; content as of {args.at} ({at_commit[:10]})"
+    if published:
+        summary += f"; dataset {dataset_version(files)} " + (f"committed to {published.branch} as {published.commit[:10]}" if published.commit else f"already on {published.branch}, nothing committed")
+        summary += f" and pushed to {published.pushed}" if published.pushed else ""
+    if over_budget:
+        summary += f"; OVER BUDGET ({len(over_budget)} problems), nothing written"
+    if failed_gates:
+        summary += f"; {len(failed_gates)} of {len(gates)} gates FAILED" + (", nothing written" if not over_budget else "")
+    elif gates:
+        summary += f"; {sum(1 for result in gate_results if result.status == 'passed')} gates passed"
+    if freeze_violations:
+        added = sum(1 for item in freeze_violations if item.change == "added")
+        summary += f"; CONTENT FREEZE BROKEN ({added} added, {len(freeze_violations) - added} removed)" + (", nothing written" if not over_budget and not failed_gates else "")
+    elif frozen is not None:
+        summary += "; content freeze held"
+    audit = ["Export audit", f"- lessons: {len(lessons)}", f"- vocabulary: {len(vocab)}", f"- readings: {len(readings)}", f"- units: {len(units)}", f"- culture notes: {len(culture_notes)}", f"- pronunciation drills: {len(drills)}", f"- rejects: {len(rejects)}"]
+    audit[-1:-1] = [f"- {kind} (plugin, {plugins.kinds[kind].output}.json): {len(entries)}" for kind, entries in plugin_kinds.items()]
+    peak = peak_rss_bytes()
+    if peak is not None:
+        audit.append(f"- peak memory: {format_bytes(peak)}")
+    if args.publish_only:
+        audit.append(f"- drafts held back: {held_back}")
+    if coerced:
+        audit.append("## normalization warnings")
+        audit += [f"- {entry_id} {note.get('path')}: {note.get('problem')}; {note.get('action')}" for entry_id, note in coerced]
+    if dataset.skipped:
+        audit.append(f"## {SKIP_CATEGORY}")
+        audit += [f"- {source}: {reason}" for source, reason in sorted(dataset.skipped.items())]
+    audit.append("## review status")
+    audit += [f"- {key}: {count}" for key, count in sorted(statuses.items())]
+    if too_long:
+        audit.append(f"## lessons over the {study_time.cap_minutes:g}-minute cap")
+        audit += [f"- {lesson['id']}: about {lesson['estimated_minutes']} minutes" for lesson in too_long]
+    if refused or altered:
+        audit.append("## pinned entries")
+        audit += [f"- {problem}" for problem in refused]
+        audit += [f"- {entry_id}: generated fields and fixes left out" for entry_id in altered]
+    if unit_problems:
+        audit.append("## unit problems")
+        audit += [f"- {target}: {problem}" for target, problem in unit_problems]
+    if intro_problems:
+        audit.append("## curriculum order")
+        audit += [f"- {target}: {problem}" for target, problem in intro_problems]
+    if note_problems:
+        audit.append("## culture note problems")
+        audit += [f"- {target}: {problem}" for target, problem in note_problems]
+    if media_problems:
+        audit.append("## lesson step media problems")
+        audit += [f"- {target}: {problem}" for target, problem in media_problems]
+    if drill_problems:
+        audit.append("## pronunciation drill problems")
+        audit += [f"- {target}: {problem}" for target, problem in drill_problems]
+    if dangling:
+        audit.append("## dangling references")
+        audit += [f"- {item.source} {item.path} ({item.type.name}, {item.type.severity}): {item.ref} {item.reason}" for item in dangling]
+    if quarantined:
+        audit.append("## quarantined (fix, then quarantine.py promote NAME)")
+        audit += [f"- {row['name']}: {row['source']}#{row['index']} looks like {row['suggested_kind']}, missing {', '.join(row['missing_fields']) or 'nothing required'}" for row in quarantined]
+    if unresolved:
+        audit.append("## unresolved glosses")
+        audit += [f"- {ref}" for ref in unresolved]
+    if relation_problems:
+        audit.append("## relation problems")
+        audit += [f"- {target}: {problem}" for target, problem in relation_problems]
+    audit.append("## size budget")
+    audit += usage_lines(budget, files)
+    if over_budget:
+        audit.append("## over budget")
+        audit += [f"- {target}: {problem}" for target, problem in over_budget]
+    if args.double_entry:
+        audit.append("## double-entry disagreements")
+        audit += [f"- {verdict.id}: {', '.join(verdict.conflicts)}" for per_kind in verdicts.values() for verdict in per_kind.values() if verdict.status == "conflict"]
+    if freeze_violations:
+        audit.append("## content freeze")
+        audit += [f"- {item.describe()}" for item in freeze_violations]
+    if gate_results:
+        audit.append("## gates")
+        audit += [f"- {result.gate.text}: {result.status}" + (f" ({result.detail})" if result.detail else "") for result in gate_results]
+    if results:
+        audit.append("## exporters")
+        audit += [f"- {result.name}: {'failed: ' + result.error if result.error else ', '.join(sorted(result.files))} ({result.seconds:.2f}s)" for result in results]
+    save_report(reports, "export.md", audit + run_report_lines(run))
+    print(summary)
+    for result in results:
+        if result.error:
+            print(f"[export] {result.name} exporter failed after {result.seconds:.2f}s; its files were not written: {result.error}", file=sys.stderr)
+        else:
+            print(f"[export] {result.name}: {', '.join(sorted(result.files))} ({result.bytes} bytes) in {result.seconds:.2f}s")
+    if over_budget:
+        print(f"[export] Over the size budget in {args.budget}; nothing was written ({len(over_budget)} problems):", file=sys.stderr)
+        for target, problem in over_budget:
+            print(f"    • {target}: {problem}", file=sys.stderr)
+    if failed_gates:
+        print(f"[export] Quality gates failed; nothing was written ({len(failed_gates)} gates):", file=sys.stderr)
+        for result in failed_gates:
+            print(f"    • {result.gate.text}: {result.detail}", file=sys.stderr)
+    if freeze_violations:
+        print(f"[export] The build breaks the content freeze of {args.frozen}, so nothing was written; only in-place fixes are allowed ({len(freeze_violations)} changes, see build/reports/freeze.md):", file=sys.stderr)
+        for item in freeze_violations:
+            print(f"    • {item.describe()}", file=sys.stderr)
+    failed = bool(over_budget or failed_gates or freeze_violations or any(result.error for result in results))
+    return failed
+
+
+@dataclass
+class ExportResult:
+    """What one export did; failed is the CLI's exit status, written whether the outputs reached the storage."""
+
+    failed: bool
+    written: bool
+    counts: Dict[str, int] = field(default_factory=dict)
+    files: Dict[str, Dict[str, Any]] = field(default_factory=dict)
+    metrics: Dict[str, Any] = field(default_factory=dict)
+
+
+def start_run(args: argparse.Namespace, settings: Settings) -> Dict[str, Any]:
+    """Settle the output location and return the run metadata every manifest and report carries."""
+    at_commit = settings.at_commit
+    args.out = args.out or (f"canonical-{at_commit[:10]}" if at_commit else "canonical")
+    run = run_metadata(args, [args.ids, args.storage, args.profiles, args.forms_bank, args.accents, args.frequency, args.tombstones, args.study_time, args.rules, args.budget, args.numerals, args.headwords, args.references, args.plugins, args.reconcile, args.locales, args.media_types, args.lint_config])
+    if at_commit:
+        run["at"] = {"ref": args.at, "commit": at_commit}
+    return run
+
+
+def run_export(
+    args: argparse.Namespace,
+    settings: Settings,
+    run: Dict[str, Any],
+    collected: Collected,
+    storage: Storage,
+    reports: Storage,
+    checkpoints: Checkpoints,
+    events: EventLog,
+    reporter: Reporter,
+) -> ExportResult:
+    """Everything after collecting: validate, merge, transform, write into staging, check, then promote and commit."""
+    validated = validate_phase(args, settings, collected, checkpoints, events)
+    merged = merge_phase(args, settings, validated, reports, reporter, events)
+    fixes = fix_phase(args, settings, validated, merged, reports, events)
+    events.close()
+    reporter.metric("duplicates_merged", (merged.duplicates if args.on_duplicate == "merge" else 0) + merged.similar_merged)
+    save_report(reports, "similar-lessons.md", lesson_duplicate_report(merged.matches, args.merge_similar_lessons))
+    save_report(reports, "duplicates.md", duplicate_report(merged.clusters, args.on_duplicate))
+    save_report(reports, "todos.md", todo_report(collected.source_comments))
+    save_report(reports, "homographs.md", homograph_report(merged.vocab))
+    save_report(reports, "field-typos.md", field_typo_report(collected.all_records))
+
+    transformed = transform_phase(args, settings, merged, reports, reporter)
+    gate = license_problems(args, settings, transformed)
+    if gate:
+        print(f"[export] License gate failed; nothing was written ({len(gate)} problems):", file=sys.stderr)
+        for problem in gate:
+            print(f"    • {problem}", file=sys.stderr)
+        return ExportResult(failed=True, written=False)
+    build_root, last, edited = hand_edits(args, storage)
+    if edited and not args.force:
+        print(f"[export] Refusing to overwrite hand-edited outputs in {last.describe()} (edit content/ instead, or pass --force):", file=sys.stderr)
+        for name, problem in edited:
+            print(f"    • {name}: {problem}", file=sys.stderr)
+        return ExportResult(failed=True, written=False)
+
+    # Everything is built in staging; it reaches the destination only once the budget, freeze, and gates pass.
+    staging = StagingStorage(storage, storage.root if isinstance(storage, LocalStorage) else None)
+    try:
+        written = write_phase(args, settings, run, collected, merged, transformed, staging, reporter)
+        checkpoints.clear()
+        checked = check_phase(args, settings, collected, validated, merged, transformed, written, last, reports, reporter)
+        storage, pruned = promote_phase(args, staging, build_root, checked.held, reporter)
+    except BaseException:
+        staging.discard()
+        raise
+    result = ExportResult(failed=False, written=not checked.held, counts=written.counts, files=written.files, metrics=checked.metrics)
+    published = None
+    if args.commit and not checked.held:
+        try:
+            published = commit_outputs(storage.root, args.out, sorted(written.files), commit_message(dataset_version(written.files), written.counts, run, checked.changed), args.commit, args.push)
+        except PublishFailed as exc:
+            print(f"[export] Outputs were written but not committed to {args.commit}: {exc}", file=sys.stderr)
+            result.failed = True
+            return result
+
+    result.failed = report_phase(args, settings, run, collected, validated, merged, fixes, transformed, written, checked, pruned, published, reports)
+    # A rebuild of the past is not a new run; recording it would skew every trend gate.
+    if not args.no_history and not settings.at_commit:
+        record_run(args.history, "export", run, checked.metrics, not result.failed)
+    return result
+
+
+def export_options(**overrides: Any) -> argparse.Namespace:
+    """The command line's defaults with overrides by flag name, e.g. export_options(validate=True, profile=["public"]).
+
+    Unlike the command line, a library run records no history unless no_history=False is passed.
+    """
+    options = build_parser().parse_args([])
+    options.no_history = True
+    for name, value in overrides.items():
+        if not hasattr(options, name):
+            raise ConfigError(f"unknown export option: {name}", name, value, sorted(vars(options)))
+        setattr(options, name, value)
+    return options
+
+
+def export(
+    dataset: Dataset,
+    storage: Storage,
+    options: Optional[argparse.Namespace] = None,
+    reporter: Optional[Reporter] = None,
+    reports: Optional[Storage] = None,
+    checkpoints: Optional[Storage] = None,
+) -> ExportResult:
+    """Export an already collected dataset into storage, the library counterpart of the command line.
+
+    Reports go to reports (default: reports/ in storage), and stage checkpoints are saved (and, with resume,
+    reused) only when a storage is given for them; history is recorded only when options ask for it. With a
+    MemoryStorage nothing touches the disk. Raises ConfigError for contradictory options or a bad config.
+    """
+    args = argparse.Namespace(**vars(options or export_options()))
+    reporter = reporter or Reporter()
+    check_arguments(args)
+    check_storage(args, storage)
+    settings = load_settings(args)
+    run = start_run(args, settings)
+    saved = Checkpoints("export", checkpoints is not None, checkpoints)
+    collected = classify_phase(dataset, payload_sha256(dataset_payload(dataset)) if saved.enabled else "", settings, reporter)
+    return run_export(args, settings, run, collected, storage, reports or storage.child("reports"), saved, EventLog(args.events), reporter)
+
+
+def main(argv: Iterable[str] | None = None, reporter: Reporter | None = None) -> int:
+    """Run the export; embedders pass a Reporter to receive a span per stage (collect, merge, transform, write, promote)."""
+    reporter = reporter or Reporter()
+    parser = build_parser()
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    try:
+        check_arguments(args)
+        settings = load_settings(args)
+        storage = load_storage(args.storage)
+        check_storage(args, storage)
+    except ValueError as exc:
+        parser.error(str(exc))
+    run = start_run(args, settings)
+    events = EventLog(args.events)
+    checkpoints = Checkpoints("export", not args.no_checkpoints)
+    dataset, key = collect_phase(args, settings, reporter, checkpoints, events)
+    collected = classify_phase(dataset, key, settings, reporter)
+    # Reports stay in build/reports whichever backend the outputs go to; the CLI's messages point there.
+    result = run_export(args, settings, run, collected, storage, LocalStorage(REPORTS_DIR), checkpoints, events, reporter)
+    return 1 if result.failed else 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,110 @@
+"""Extra output formats rendered concurrently from the shared canonical dataset.
+
+Each exporter turns {"vocabulary": [...], "lessons": [...], ...} into {file name: bytes} without
+touching the entries, so they can share one in-memory copy. Rendering runs in a thread pool; the
+caller writes the files afterwards, since not every storage backend takes concurrent writes. An
+exporter that raises is reported on its own and never stops the others.
+"""
+
+from __future__ import annotations
+
+import json
+import os
+import sqlite3
+import tempfile
+import time
+from concurrent.futures import ThreadPoolExecutor
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional
+
+Kinds = Dict[str, List[Dict[str, Any]]]
+# Columns pulled out of each entry next to its full JSON, so apps can query without parsing.
+SQLITE_COLUMNS = {
+    "vocabulary": ("spanish", "english_gloss", "pos", "level", "frequency_rank"),
+    "lessons": ("title", "level", "unit", "lesson_number", "estimated_minutes"),
+    "readings": ("title", "book", "chapter", "level"),
+    "units": ("title", "level", "number"),
+    "culture_notes": ("title", "region", "level"),
+    "pron_drills": ("title", "phoneme", "level"),
+}
+
+
+@dataclass
+class ExporterResult:
+    name: str
+    files: Dict[str, bytes] = field(default_factory=dict)
+    seconds: float = 0.0
+    error: Optional[str] = None
+
+    @property
+    def bytes(self) -> int:
+        return sum(len(data) for data in self.files.values())
+
+
+def render_ndjson(kinds: Kinds) -> Dict[str, bytes]:
+    return {f"{kind}.ndjson": "".join(json.dumps(entry, ensure_ascii=False) + "\n" for entry in entries).encode("utf-8") for kind, entries in kinds.items()}
+
+
+def render_sqlite(kinds: Kinds) -> Dict[str, bytes]:
+    """One table per kind: id, the SQLITE_COLUMNS for that kind, and the entry as JSON in `data`."""
+    handle, name = tempfile.mkstemp(suffix=".sqlite")
+    os.close(handle)
+    try:
+        conn = sqlite3.connect(name)
+        with conn:
+            for kind, entries in kinds.items():
+                columns = SQLITE_COLUMNS.get(kind, ())
+                conn.execute(f'CREATE TABLE "{kind}" (id TEXT PRIMARY KEY, {"".join(f"{column}, " for column in columns)}data TEXT NOT NULL)')
+                for column in columns:
+                    conn.execute(f'CREATE INDEX "{kind}_{column}" ON "{kind}" ({column})')
+                rows = [(entry["id"], *(scalar(entry.get(column)) for column in columns), json.dumps(entry, ensure_ascii=False)) for entry in entries]
+                conn.executemany(f'INSERT OR REPLACE INTO "{kind}" VALUES ({", ".join("?" * (len(columns) + 2))})', rows)
+        conn.close()
+        return {"content.sqlite": Path(name).read_bytes()}
+    finally:
+        os.unlink(name)
+
+
+def scalar(value: Any) -> Any:
+    return value if value is None or isinstance(value, (str, int, float)) else json.dumps(value, ensure_ascii=False)
+
+
+def anki_field(value: Any) -> str:
+    return str(value or "").replace("\t", " ").replace("\r", " ").replace("\n", "<br>")
+
+
+def render_anki(kinds: Kinds) -> Dict[str, bytes]:
+    """A tab-separated notes file for Anki's File > Import (Basic note type); the entry ID is the note GUID so re-imports update."""
+    lines = ["#separator:tab", "#html:true", "#notetype:Basic", "#deck:Spanish", "#guid column:1", "#tags column:4"]
+    for entry in kinds.get("vocabulary", []):
+        example = next((item for item in entry.get("examples") or [] if isinstance(item, dict) and item.get("es")), None)
+        back = anki_field(entry.get("english_gloss"))
+        if example:
+            back += f"<br><i>{anki_field(example['es'])}</i>" + (f" — {anki_field(example.get('en'))}" if example.get("en") else "")
+        tags = [str(tag).replace(" ", "_") for tag in entry.get("tags") or []] + ([str(entry["level"])] if entry.get("level") else [])
+        lines.append("\t".join([anki_field(entry["id"]), anki_field(entry.get("spanish")), back, " ".join(tags)]))
+    return {"anki.txt": ("\n".join(lines) + "\n").encode("utf-8")}
+
+
+EXPORTERS: Dict[str, Callable[[Kinds], Dict[str, bytes]]] = {
+    "anki": render_anki,
+    "ndjson": render_ndjson,
+    "sqlite": render_sqlite,
+}
+
+
+def run_exporter(name: str, kinds: Kinds) -> ExporterResult:
+    started = time.perf_counter()
+    try:
+        return ExporterResult(name, EXPORTERS[name](kinds), time.perf_counter() - started)
+    except Exception as exc:  # noqa: BLE001 - one broken exporter must not lose the others' output
+        return ExporterResult(name, {}, time.perf_counter() - started, f"{type(exc).__name__}: {exc}")
+
+
+def run_exporters(names: List[str], kinds: Kinds, jobs: Optional[int] = None) -> List[ExporterResult]:
+    """Render every named exporter, up to jobs at a time; results come back in the order the names were given."""
+    if not names:
+        return []
+    with ThreadPoolExecutor(max_workers=jobs or min(len(names), os.cpu_count() or 1)) as pool:
+        return list(pool.map(lambda name: run_exporter(name, kinds), names))
@@ -0,0 +1,72 @@
+"""Recover records whose field names are misspelled (spansih, defintion, englsh_gloss) by matching them to schema fields."""
+
+from __future__ import annotations
+
+import json
+from functools import lru_cache
+from pathlib import Path
+from typing import Any, Dict, FrozenSet, List, Optional, Tuple
+
+SCHEMAS_DIR = Path(__file__).resolve().parents[1] / "schemas"
+CORRECTIONS_KEY = "field_corrections"
+
+
+@lru_cache(maxsize=None)
+def known_fields(schemas_dir: Path = SCHEMAS_DIR) -> FrozenSet[str]:
+    """Top-level property names across every entry schema."""
+    names = {CORRECTIONS_KEY}
+    for path in schemas_dir.glob("*.schema.json"):
+        names.update(json.loads(path.read_text(encoding="utf-8")).get("properties", {}))
+    return frozenset(names)
+
+
+def typo_distance(a: str, b: str) -> int:
+    """Edit distance counting a swap of neighbouring letters as one edit, the commonest typing slip."""
+    rows = [list(range(len(b) + 1))] + [[i] + [0] * len(b) for i in range(1, len(a) + 1)]
+    for i in range(1, len(a) + 1):
+        for j in range(1, len(b) + 1):
+            cost = 0 if a[i - 1] == b[j - 1] else 1
+            rows[i][j] = min(rows[i - 1][j] + 1, rows[i][j - 1] + 1, rows[i - 1][j - 1] + cost)
+            if i > 1 and j > 1 and a[i - 1] == b[j - 2] and a[i - 2] == b[j - 1]:
+                rows[i][j] = min(rows[i][j], rows[i - 2][j - 2] + 1)
+    return rows[len(a)][len(b)]
+
+
+def likely_field(name: str, known: FrozenSet[str]) -> Optional[str]:
+    """The one known field within reach of name: one edit for short names, two from seven letters up; ties match nothing."""
+    if name in known or name.startswith("_"):
+        return None
+    limit = 1 if len(name) < 7 else 2
+    scored = sorted((typo_distance(name.lower(), field), field) for field in known)
+    best = [field for distance, field in scored if distance <= limit and distance == scored[0][0]]
+    return best[0] if len(best) == 1 else None
+
+
+def correct_fields(obj: Dict[str, Any], known: Optional[FrozenSet[str]] = None) -> Dict[str, Any]:
+    """A copy of obj with likely typos renamed in place, keeping key order; the renames go in field_corrections.
+
+    A typo is left alone when the record already has the field it resembles, so nothing is overwritten.
+    """
+    known = known if known is not None else known_fields()
+    renames: List[Tuple[str, str]] = []
+    for key in obj:
+        target = likely_field(str(key), known)
+        if target is not None and target not in obj and target not in (new for _, new in renames):
+            renames.append((key, target))
+    if not renames:
+        return obj
+    mapping = dict(renames)
+    fixed = {mapping.get(key, key): value for key, value in obj.items()}
+    earlier = obj.get(CORRECTIONS_KEY)
+    fixed[CORRECTIONS_KEY] = {**(earlier if isinstance(earlier, dict) else {}), **mapping}
+    return fixed
+
+
+def typo_table(corrections: List[Dict[str, str]]) -> List[Tuple[str, int, Dict[str, int]]]:
+    """(canonical field, corrections, {misspelling: count}) from each record's field_corrections, most corrected first."""
+    table: Dict[str, Dict[str, int]] = {}
+    for mapping in corrections:
+        for typo, field in mapping.items():
+            table.setdefault(field, {}).setdefault(typo, 0)
+            table[field][typo] += 1
+    return sorted(((field, sum(typos.values()), typos) for field, typos in table.items()), key=lambda row: (-row[1], row[0]))
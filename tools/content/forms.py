@@ -0,0 +1,247 @@
+"""Rule-based Spanish inflection used to map inflected forms back to vocabulary entries."""
+
+from __future__ import annotations
+
+import csv
+import re
+import unicodedata
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple, Union
+
+try:
+    from .common import ROOT
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import ROOT  # type: ignore
+
+DEFAULT_FORMS_BANK = ROOT / "vocab" / "bank.csv"
+# Object and reflexive pronouns attached to infinitives, gerunds, and imperatives (llamarse, dámelo).
+ENCLITICS = re.compile(r"(?:me|te|se|nos|os|lo|la|los|las|le|les)$")
+PERSONS = ("1sg", "2sg", "3sg", "1pl", "2pl", "3pl")
+REFLEXIVE_PRONOUNS = ("me", "te", "se", "nos", "os", "se")
+UNACCENTED = str.maketrans("áéíóú", "aeiou")
+ACCENTED = {"a": "á", "e": "é", "i": "í", "o": "ó", "u": "ú"}
+# Plurals whose stress shifts in ways the accent rules below cannot infer.
+PLURAL_EXCEPTIONS = {"carácter": "caracteres", "régimen": "regímenes", "espécimen": "especímenes", "club": "clubes", "sándwich": "sándwiches"}
+
+# Endings appended to the stem (infinitive minus -ar/-er/-ir).
+STEM_ENDINGS = {
+    "ar": {
+        "pres": ("o", "as", "a", "amos", "áis", "an"),
+        "pret": ("é", "aste", "ó", "amos", "asteis", "aron"),
+        "impf": ("aba", "abas", "aba", "ábamos", "abais", "aban"),
+        "subj": ("e", "es", "e", "emos", "éis", "en"),
+    },
+    "er": {
+        "pres": ("o", "es", "e", "emos", "éis", "en"),
+        "pret": ("í", "iste", "ió", "imos", "isteis", "ieron"),
+        "impf": ("ía", "ías", "ía", "íamos", "íais", "ían"),
+        "subj": ("a", "as", "a", "amos", "áis", "an"),
+    },
+    "ir": {
+        "pres": ("o", "es", "e", "imos", "ís", "en"),
+        "pret": ("í", "iste", "ió", "imos", "isteis", "ieron"),
+        "impf": ("ía", "ías", "ía", "íamos", "íais", "ían"),
+        "subj": ("a", "as", "a", "amos", "áis", "an"),
+    },
+}
+# Endings appended to the whole infinitive.
+INFINITIVE_ENDINGS = {
+    "fut": ("é", "ás", "á", "emos", "éis", "án"),
+    "cond": ("ía", "ías", "ía", "íamos", "íais", "ían"),
+}
+# Verbs the rules above would conjugate wrongly (es for ir, so for ser); their forms come from the form bank.
+IRREGULAR_VERBS = {"andar", "caber", "caer", "dar", "estar", "haber", "ir", "poder", "querer", "saber", "salir", "ser", "valer", "ver"}
+# Irregular verbs whose derivatives conjugate like them (mantener, proponer, deshacer, producir).
+IRREGULAR_BASES = ("decir", "ducir", "hacer", "poner", "tener", "traer", "venir")
+NON_FINITE = {"ar": {"ger": "ando", "part": "ado"}, "er": {"ger": "iendo", "part": "ido"}, "ir": {"ger": "iendo", "part": "ido"}}
+
+
+def spell_stem(stem: str, ending: str, klass: str) -> str:
+    """Keep the stem's sound: -ar stems change before e (busqué, pague, empiece), -er/-ir before a/o (cojo, elija)."""
+    if klass == "ar":
+        if ending[:1] not in ("e", "é"):
+            return stem
+        for plain, spelled in (("gu", "gü"), ("c", "qu"), ("g", "gu"), ("z", "c")):
+            if stem.endswith(plain):
+                return stem[: -len(plain)] + spelled
+        return stem
+    if ending[:1] in ("a", "o") and stem.endswith("g"):
+        return stem[:-1] + "j"
+    return stem
+
+
+def is_irregular(verb: str) -> bool:
+    return verb in IRREGULAR_VERBS or verb.endswith(IRREGULAR_BASES)
+
+
+def conjugate(infinitive: str) -> List[Tuple[str, str]]:
+    """Return (feature, form) pairs for a regular -ar/-er/-ir verb, including -se reflexives; none for irregular verbs."""
+    verb = infinitive.strip().lower()
+    reflexive = verb.endswith("se") and verb[:-2][-2:] in STEM_ENDINGS
+    if reflexive:
+        verb = verb[:-2]
+    if " " in verb or verb[-2:] not in STEM_ENDINGS or len(verb) < 3 or is_irregular(verb):
+        return []
+    klass, stem = verb[-2:], verb[:-2]
+    forms: List[Tuple[str, str]] = []
+    for tense, endings in STEM_ENDINGS[klass].items():
+        for person, ending in zip(PERSONS, endings):
+            forms.append((f"{tense}.{person}", spell_stem(stem, ending, klass) + ending))
+    for tense, endings in INFINITIVE_ENDINGS.items():
+        forms += [(f"{tense}.{person}", verb + ending) for person, ending in zip(PERSONS, endings)]
+    forms += [(feature, stem + ending) for feature, ending in NON_FINITE[klass].items()]
+    forms.append(("imp.2sg", dict(forms)["pres.3sg"]))
+    forms.append(("imp.2pl", verb[:-1] + "d"))
+    if reflexive:
+        finite = [(feature, form) for feature, form in forms if feature[-3:] in PERSONS]
+        forms += [(f"{feature}.refl", f"{REFLEXIVE_PRONOUNS[PERSONS.index(feature[-3:])]} {form}") for feature, form in finite]
+    return forms
+
+
+def accent_penultimate(word: str) -> str:
+    """Write an accent on the vowel that becomes antepenultimate once -es is added (examen -> exámen-es)."""
+    groups = [m for m in re.finditer(r"[aeiou]+", word)]
+    if len(groups) < 2:
+        return word
+    group = groups[-2]
+    strong = [i for i, ch in enumerate(group.group()) if ch in "aeo"]
+    offset = group.start() + (strong[-1] if strong else len(group.group()) - 1)
+    return word[:offset] + ACCENTED[word[offset]] + word[offset + 1 :]
+
+
+def unaccent_last_syllable(word: str) -> str:
+    """Drop the written accent from the last syllable's vowel (camión -> camion-es), unless it marks a hiatus (país)."""
+    groups = list(re.finditer(r"[aeiouáéíóúü]+", word.lower()))
+    if not groups:
+        return word
+    group = groups[-1]
+    for offset, ch in enumerate(group.group(), group.start()):
+        if ch in "áéíóú" and not (ch in "íú" and len(group.group()) > 1):
+            return word[:offset] + word[offset].translate(UNACCENTED) + word[offset + 1 :]
+    return word
+
+
+def pluralize(word: str) -> str:
+    """Return the plural of a noun or adjective.
+
+    Follows the RAE rules: vowels take -s (stressed í takes -es, stressed ú -s), consonants take -es,
+    z becomes c (luz -> luces), unstressed -s/-x endings stay invariant (crisis), and the
+    written accent is dropped or added so the stress stays on the same syllable.
+    """
+    word = word.strip()
+    lower = word.lower()
+    if not lower:
+        return word
+    if lower in PLURAL_EXCEPTIONS:
+        return PLURAL_EXCEPTIONS[lower]
+    vowels = [ch for ch in lower if ch in "aeiouáéíóú"]
+    accented = bool(vowels) and vowels[-1] in "áéíóú"
+    if lower[-1] in "sx":
+        if accented:
+            return unaccent_last_syllable(word) + "es"
+        return word + "es" if len(vowels) <= 1 else word
+    if lower[-1] == "í":
+        return word + "es"
+    if lower[-1] in "aeiouáéóú":
+        return word + "s"
+    if lower.endswith("z"):
+        return word[:-1] + "ces"
+    if lower[-1] == "n" and accented:
+        return unaccent_last_syllable(word) + "es"
+    if lower[-1] == "n" and not any(ch in "áéíóú" for ch in lower):
+        return accent_penultimate(word) + "es"
+    return word + "es"
+
+
+def adjective_forms(word: str) -> List[Tuple[str, str]]:
+    word = word.strip().lower()
+    if word.endswith("o"):
+        return [("m.sg", word), ("f.sg", word[:-1] + "a"), ("m.pl", word + "s"), ("f.pl", word[:-1] + "as")]
+    return [("sg", word), ("pl", pluralize(word))]
+
+
+def inflect(entry: Dict[str, Any]) -> List[Tuple[str, str]]:
+    """Inflected (feature, form) pairs for one vocabulary entry, by part of speech."""
+    headword = entry.get("spanish")
+    if not isinstance(headword, str) or not headword.strip():
+        return []
+    pos = entry.get("pos")
+    if pos == "verb":
+        return [] if entry.get("irregular") is True else conjugate(headword)
+    if pos == "adj" and isinstance(entry.get("feminine"), str) and entry["feminine"].strip():
+        masculine, feminine = headword.strip().lower(), entry["feminine"].strip().lower()
+        return [("m.sg", masculine), ("f.sg", feminine), ("m.pl", pluralize(masculine)), ("f.pl", pluralize(feminine))]
+    if pos == "adj":
+        return adjective_forms(headword)
+    if pos == "noun" and " " not in headword.strip():
+        plural = entry.get("plural") if isinstance(entry.get("plural"), str) else pluralize(headword)
+        return [("sg", headword.strip().lower()), ("pl", plural.strip().lower())]
+    return []
+
+
+def load_form_bank(path: Optional[Union[str, Path]]) -> List[Dict[str, str]]:
+    """Rows from a form/lemma/features CSV; irregular forms the rules cannot produce come from here."""
+    if not path or not Path(path).exists():
+        return []
+    with open(path, newline="", encoding="utf-8") as handle:
+        return list(csv.DictReader(handle))
+
+
+def build_forms_index(vocab: Iterable[Dict[str, Any]], bank: Optional[List[Dict[str, str]]] = None) -> Dict[str, List[Dict[str, Any]]]:
+    """Map each lowercase form to the entries it inflects, with the features it carries."""
+    index: Dict[str, Dict[str, Dict[str, Any]]] = {}
+
+    def add(form: str, entry: Dict[str, Any], feature: str) -> None:
+        slot = index.setdefault(form.strip().lower(), {}).setdefault(entry["id"], {"id": entry["id"], "lemma": entry["spanish"], "features": []})
+        if feature and feature not in slot["features"]:
+            slot["features"].append(feature)
+
+    by_lemma: Dict[str, List[Dict[str, Any]]] = {}
+    for entry in vocab:
+        if not isinstance(entry.get("spanish"), str):
+            continue
+        by_lemma.setdefault(entry["spanish"].strip().lower(), []).append(entry)
+        add(entry["spanish"], entry, "lemma")
+        for feature, form in inflect(entry):
+            add(form, entry, feature)
+    for row in bank or []:
+        for entry in by_lemma.get(str(row.get("lemma", "")).strip().lower(), []):
+            if row.get("form", "").strip():
+                add(row["form"], entry, str(row.get("features", "")).strip() or "bank")
+    return {form: list(entries.values()) for form, entries in sorted(index.items())}
+
+
+def fold(text: str) -> str:
+    decomposed = unicodedata.normalize("NFD", text.lower())
+    return "".join(ch for ch in decomposed if not unicodedata.combining(ch))
+
+
+def entry_forms(entry: Dict[str, Any], bank: Optional[List[Dict[str, str]]] = None) -> set:
+    """Accent-folded headword and every form inflect() or the form bank gives it; multi-word forms stay space-separated."""
+    headword = entry.get("spanish")
+    if not isinstance(headword, str) or not headword.strip():
+        return set()
+    lemma = headword.strip().lower()
+    forms = {lemma} | {form for _, form in inflect(entry)}
+    if entry.get("pos") == "verb" and lemma.endswith("se"):
+        forms.add(lemma[:-2])
+    forms |= {row["form"] for row in bank or [] if str(row.get("lemma", "")).strip().lower() == lemma and row.get("form", "").strip()}
+    return {" ".join(re.findall(r"\w+", fold(form))) for form in forms} - {""}
+
+
+def mentions(text: str, forms: set) -> bool:
+    """Whether text uses any of the forms: whole words or word sequences, accent-insensitive, with up to two enclitics peeled off."""
+    tokens = re.findall(r"\w+", fold(text))
+    joined = f" {' '.join(tokens)} "
+    for form in forms:
+        if " " in form and f" {form} " in joined:
+            return True
+    for token in tokens:
+        for _ in range(3):
+            if token in forms:
+                return True
+            stripped = ENCLITICS.sub("", token)
+            if stripped == token or len(stripped) < 2:
+                break
+            token = stripped
+    return False
@@ -0,0 +1,60 @@
+"""Content freeze for release branches: only in-place fixes, no entries added and no IDs removed.
+
+`export.py --frozen <release manifest>` reads the entry IDs of the frozen release from the output files its
+manifest lists (the files must still match the manifest's hashes), then compares the new build against them.
+An ID the release does not have is scope creep; an ID the build lost (deleted, retired, renamed) breaks what
+shipped. Either fails the build, keeps the outputs from being written, and is listed in build/reports/freeze.md.
+"""
+
+from __future__ import annotations
+
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Set, Union
+
+try:
+    from .verify import load_release
+except ImportError:  # pragma: no cover - allow running as a script
+    from verify import load_release  # type: ignore
+
+# Output file (without .json) -> what the freeze report calls its entries.
+FROZEN_FILES = {"vocabulary": "vocabulary", "lessons": "lesson", "readings": "reading", "units": "unit", "culture_notes": "culture note", "pron_drills": "pronunciation drill"}
+CHANGES = ("added", "removed")
+
+
+@dataclass
+class FreezeViolation:
+    file: str
+    id: str
+    change: str
+
+    def describe(self) -> str:
+        label = FROZEN_FILES.get(self.file, self.file)
+        return f"{label} {self.id} " + ("is new since the freeze" if self.change == "added" else "was in the frozen release and is gone")
+
+
+def load_frozen_ids(path: Union[str, Path]) -> Dict[str, Set[str]]:
+    """Output file -> entry IDs in the frozen release; path is its manifest.json or the directory holding it."""
+    _, entries = load_release(path, FROZEN_FILES, "frozen", "frozen release")
+    return {name: {str(entry["id"]) for entry in rows if entry.get("id")} for name, rows in entries.items()}
+
+
+def check_frozen(frozen: Dict[str, Set[str]], current: Dict[str, Iterable[Dict[str, Any]]]) -> List[FreezeViolation]:
+    """Entries added or removed since the freeze, per output file the frozen release has."""
+    violations: List[FreezeViolation] = []
+    for name, before in frozen.items():
+        after = {str(entry.get("id")) for entry in current.get(name, []) if entry.get("id")}
+        violations += [FreezeViolation(name, entry_id, "added") for entry_id in sorted(after - before)]
+        violations += [FreezeViolation(name, entry_id, "removed") for entry_id in sorted(before - after)]
+    return violations
+
+
+def freeze_report(manifest: Union[str, Path], frozen: Dict[str, Set[str]], violations: List[FreezeViolation]) -> List[str]:
+    lines = ["Content freeze", f"- frozen release: {manifest}", f"- entries frozen: {sum(len(ids) for ids in frozen.values())}"]
+    lines += [f"- {change}: {sum(1 for item in violations if item.change == change)}" for change in CHANGES]
+    for change in CHANGES:
+        found = [item for item in violations if item.change == change]
+        if found:
+            lines.append(f"## {change} since the freeze")
+            lines += [f"- {item.file}.json: {item.id}" for item in found]
+    return lines
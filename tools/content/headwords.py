@@ -0,0 +1,126 @@
+"""Normalize how vocabulary headwords are written, so the same word is one entry and search finds it.
+
+"El perro", "perro." and "perro" slug to different IDs or read differently in search. config/headwords.json
+sets the rules: headwords are lowercase unless the entry is tagged as a proper noun, trailing punctuation is
+stripped (a ¿…? or ¡…! pair is kept when keep_paired_marks is set), and a noun written with its article keeps
+the bare noun as the headword and moves the article to an `article` field. What cannot be settled from the
+entry is left for review: an all-capitals headword (ONU) and an article that disagrees with the one given.
+"""
+
+from __future__ import annotations
+
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Union
+
+try:
+    from .common import CONFIG_DIR, load_json
+    from .errors import ConfigError
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+
+DEFAULT_HEADWORDS_PATH = CONFIG_DIR / "headwords.json"
+PAIRED_MARKS = {"?": "¿", "!": "¡"}
+SPACES_RE = re.compile(r"\s+")
+
+
+@dataclass
+class HeadwordRules:
+    lowercase: bool = True
+    proper_noun_tags: List[str] = field(default_factory=lambda: ["proper_noun"])
+    trailing_punctuation: str = ".,;:…"
+    keep_paired_marks: bool = True
+    articles: List[str] = field(default_factory=list)
+    # Parts of speech whose headword may lose a leading article; others ("lo bueno", "el que") keep it.
+    article_pos: List[str] = field(default_factory=lambda: ["noun"])
+
+
+@dataclass
+class HeadwordFix:
+    path: str
+    found: str
+    suggestion: str
+    reason: str
+    ambiguous: bool
+
+
+def load_headword_rules(path: Optional[Union[str, Path]] = None) -> HeadwordRules:
+    """The rules in path; no file means lowercase and punctuation only, with no articles moved."""
+    cfg_path = Path(path) if path else DEFAULT_HEADWORDS_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    rules = HeadwordRules()
+    for key in ("lowercase", "keep_paired_marks"):
+        value = data.get(key, getattr(rules, key))
+        if not isinstance(value, bool):
+            raise ConfigError(f"headwords: {key} must be true or false", key, value, "boolean")
+        setattr(rules, key, value)
+    punctuation = data.get("trailing_punctuation", rules.trailing_punctuation)
+    if not isinstance(punctuation, str) or any(ch.isalnum() or ch.isspace() for ch in punctuation):
+        raise ConfigError("headwords: trailing_punctuation must be a string of punctuation characters", "trailing_punctuation", punctuation)
+    rules.trailing_punctuation = punctuation
+    for key in ("proper_noun_tags", "articles", "article_pos"):
+        value = data.get(key, getattr(rules, key))
+        if not isinstance(value, list) or not all(isinstance(item, str) and item.strip() for item in value):
+            raise ConfigError(f"headwords: {key} must be a list of words", key, value, "list of strings")
+        setattr(rules, key, [item.strip().lower() if key == "articles" else item.strip() for item in value])
+    return rules
+
+
+def is_proper_noun(entry: Dict[str, Any], rules: HeadwordRules) -> bool:
+    tags = entry.get("tags")
+    return isinstance(tags, list) and any(tag in rules.proper_noun_tags for tag in tags)
+
+
+def strip_punctuation(word: str, rules: HeadwordRules) -> str:
+    while word:
+        last = word[-1]
+        if last in rules.trailing_punctuation:
+            word = word[:-1].rstrip()
+        elif last in PAIRED_MARKS and not (rules.keep_paired_marks and word.startswith(PAIRED_MARKS[last])):
+            word = word[:-1].rstrip()
+            if word.startswith(PAIRED_MARKS[last]):
+                word = word[1:].lstrip()
+        else:
+            break
+    return word
+
+
+def normalize_headword(entry: Dict[str, Any], rules: HeadwordRules, fix: bool = False) -> List[HeadwordFix]:
+    """What the rules change in a vocabulary entry's headword; fix=True applies the unambiguous ones in place."""
+    headword = entry.get("spanish")
+    if not isinstance(headword, str) or not headword.strip():
+        return []
+    fixes: List[HeadwordFix] = []
+    word = SPACES_RE.sub(" ", headword).strip()
+    if word != headword:
+        fixes.append(HeadwordFix("spanish", headword, word, "extra whitespace", False))
+    stripped = strip_punctuation(word, rules)
+    if stripped and stripped != word:
+        fixes.append(HeadwordFix("spanish", word, stripped, "trailing punctuation", False))
+        word = stripped
+    article = None
+    first, _, rest = word.partition(" ")
+    if rest and first.lower() in rules.articles and entry.get("pos") in rules.article_pos:
+        given = entry.get("article")
+        if isinstance(given, str) and given.strip() and given.strip().lower() != first.lower():
+            fixes.append(HeadwordFix("spanish", word, rest, f"written with '{first}' but article is '{given}'", True))
+        else:
+            article = first.lower()
+            fixes.append(HeadwordFix("spanish", word, rest, f"article '{article}' moved to the article field", False))
+            if given != article:
+                fixes.append(HeadwordFix("article", str(given) if given is not None else "", article, "article taken from the headword", False))
+            word = rest
+    if rules.lowercase and word != word.lower() and not is_proper_noun(entry, rules):
+        letters = [ch for ch in word if ch.isalpha()]
+        acronym = len(letters) > 1 and all(ch.isupper() for ch in letters)
+        reason = "all capitals, may be an acronym; tag it " + " or ".join(rules.proper_noun_tags) + " to keep it" if acronym else "capitalized but not tagged as a proper noun"
+        fixes.append(HeadwordFix("spanish", word, word.lower(), reason, acronym))
+        if not acronym:
+            word = word.lower()
+    if fix and word != headword:
+        entry["spanish"] = word
+        if article is not None:
+            entry["article"] = article
+    return fixes
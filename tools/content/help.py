@@ -0,0 +1,66 @@
+#!/usr/bin/env python3
+"""Help topics with worked examples, beyond each tool's own --help."""
+
+from __future__ import annotations
+
+import argparse
+import sys
+from pathlib import Path
+from typing import Dict, Iterable, List
+
+try:
+    from .completion import tool_names, tool_parser
+    from .console import add_output_arguments, configure_output
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from completion import tool_names, tool_parser  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+
+TOPICS_DIR = Path(__file__).resolve().parent / "help"
+
+
+def load_topics(topics_dir: Path = TOPICS_DIR) -> Dict[str, str]:
+    """Topic name -> text; each help/<topic>.md starts with "# <topic>: <summary>"."""
+    return {path.stem: path.read_text(encoding="utf-8") for path in sorted(topics_dir.glob("*.md"))}
+
+
+def summary(text: str) -> str:
+    first = text.splitlines()[0] if text else ""
+    return first.split(":", 1)[1].strip() if ":" in first else first.lstrip("# ")
+
+
+def tools_topic() -> List[str]:
+    """Generated from the tools themselves, so it never falls behind: one line per script and its subcommands."""
+    out = ["# tools: every content tool and what it does", ""]
+    for name in tool_names():
+        parser = tool_parser(name)
+        out.append(f"    {name + '.py':<24}{parser.description or ''}")
+        for action in parser._actions:
+            if isinstance(action, argparse._SubParsersAction):
+                out += [f"      {choice:<22}{choice_action.help or ''}" for choice_action in action._choices_actions for choice in [choice_action.dest]]
+    out += ["", "Run any of them with --help for every option."]
+    return out
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    topics = load_topics()
+    parser = argparse.ArgumentParser(description="Show a help topic with worked examples, or list the topics.")
+    parser.add_argument("topic", nargs="?", choices=sorted(topics) + ["tools"], help="Topic to show; omit to list them")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    if args.topic == "tools":
+        print("\n".join(tools_topic()))
+    elif args.topic:
+        print(topics[args.topic], end="")
+    else:
+        print("Help topics (python3 tools/content/help.py <topic>):")
+        for name, text in sorted(topics.items()):
+            print(f"    {name:<12}{summary(text)}")
+        print(f"    {'tools':<12}every content tool and what it does")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
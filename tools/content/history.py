@@ -0,0 +1,158 @@
+#!/usr/bin/env python3
+"""Per-run quality metrics kept in a small SQLite file, and trend gates that compare a run with the last one.
+
+Export records what each run produced (entry counts, rejects, duplicates, dangling references, problems
+found, bytes written) in build/history.sqlite. A gate is an expression over those metrics, in the syntax
+of config/rules.json, where last_run holds the previous passing run's values:
+
+    python3 tools/content/export.py --gate "rejects <= last_run.rejects" --gate "vocabulary >= last_run.vocabulary"
+    python3 tools/content/export.py --gate "bytes_written <= last_run.bytes_written * 1.05"
+
+A failed gate fails the build the way an absolute threshold does, and the run is recorded as failed, so
+the next run is still compared with the last good one. A gate that reads last_run is skipped when there
+is no earlier passing run. `history.py` prints the recorded runs and how each metric moved.
+"""
+
+from __future__ import annotations
+
+import argparse
+import ast
+import sqlite3
+import sys
+from contextlib import closing
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple, Union
+
+try:
+    from .common import BUILD_DIR, write_report
+    from .console import add_output_arguments, configure_output
+    from .rules import compile_expression, evaluate
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import BUILD_DIR, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from rules import compile_expression, evaluate  # type: ignore
+
+DEFAULT_HISTORY_PATH = BUILD_DIR / "history.sqlite"
+LAST_RUN = "last_run"
+SCHEMA = (
+    "CREATE TABLE IF NOT EXISTS runs (id INTEGER PRIMARY KEY AUTOINCREMENT, tool TEXT NOT NULL, started_at TEXT NOT NULL, git_commit TEXT, passed INTEGER NOT NULL)",
+    "CREATE TABLE IF NOT EXISTS metrics (run_id INTEGER NOT NULL REFERENCES runs(id), name TEXT NOT NULL, value REAL NOT NULL, PRIMARY KEY (run_id, name))",
+)
+
+
+@dataclass
+class Gate:
+    text: str
+    tree: ast.Expression
+
+    @property
+    def trend(self) -> bool:
+        return any(isinstance(node, ast.Name) and node.id == LAST_RUN for node in ast.walk(self.tree))
+
+
+@dataclass
+class GateResult:
+    gate: Gate
+    status: str
+    detail: str = ""
+
+
+def parse_gates(texts: Iterable[str]) -> List[Gate]:
+    """Compile each gate; a ConfigError (a ValueError) names the one that does not parse."""
+    return [Gate(text, compile_expression(text, "gate")) for text in texts]
+
+
+def connect(path: Union[str, Path]) -> sqlite3.Connection:
+    Path(path).parent.mkdir(parents=True, exist_ok=True)
+    conn = sqlite3.connect(str(path))
+    for statement in SCHEMA:
+        conn.execute(statement)
+    return conn
+
+
+def last_run(path: Union[str, Path], tool: str) -> Optional[Dict[str, float]]:
+    """The metrics of the newest passing run of tool, or None before the first one."""
+    if not Path(path).exists():
+        return None
+    with closing(connect(path)) as conn:
+        row = conn.execute("SELECT id FROM runs WHERE tool = ? AND passed = 1 ORDER BY id DESC LIMIT 1", (tool,)).fetchone()
+        if row is None:
+            return None
+        return dict(conn.execute("SELECT name, value FROM metrics WHERE run_id = ?", (row[0],)).fetchall())
+
+
+def record_run(path: Union[str, Path], tool: str, run: Dict[str, Any], metrics: Dict[str, float], passed: bool) -> int:
+    with closing(connect(path)) as conn, conn:
+        cursor = conn.execute("INSERT INTO runs (tool, started_at, git_commit, passed) VALUES (?, ?, ?, ?)", (tool, run["started_at"], run.get("git", {}).get("commit"), int(passed)))
+        conn.executemany("INSERT INTO metrics (run_id, name, value) VALUES (?, ?, ?)", [(cursor.lastrowid, name, float(value)) for name, value in sorted(metrics.items())])
+        return int(cursor.lastrowid)
+
+
+def check_gates(gates: List[Gate], metrics: Dict[str, float], previous: Optional[Dict[str, float]]) -> List[GateResult]:
+    """passed, failed, or skipped (a trend gate with no earlier run, or one reading a metric that run lacks) for each gate."""
+    scope: Dict[str, Any] = {**metrics, LAST_RUN: previous}
+    results: List[GateResult] = []
+    for gate in gates:
+        if gate.trend and previous is None:
+            results.append(GateResult(gate, "skipped", "no earlier passing run to compare with"))
+            continue
+        missing = sorted({node.attr for node in ast.walk(gate.tree) if isinstance(node, ast.Attribute) and previous is not None and node.attr not in previous})
+        if missing:
+            results.append(GateResult(gate, "skipped", f"the last run did not record {', '.join(missing)}"))
+            continue
+        names = sorted({node.id for node in ast.walk(gate.tree) if isinstance(node, ast.Name)} - {LAST_RUN})
+        values = [f"{name} = {metrics[name]:g}" for name in names if name in metrics]
+        values += [f"{LAST_RUN}.{node.attr} = {previous[node.attr]:g}" for node in ast.walk(gate.tree) if isinstance(node, ast.Attribute) and previous and node.attr in previous]
+        unknown = [name for name in names if name not in metrics]
+        if unknown:
+            results.append(GateResult(gate, "failed", f"unknown metric {', '.join(unknown)}"))
+        else:
+            results.append(GateResult(gate, "passed" if evaluate(gate.tree.body, scope) else "failed", ", ".join(values)))
+    return results
+
+
+def history_rows(path: Union[str, Path], tool: str, limit: int) -> List[Tuple[int, str, Optional[str], bool, Dict[str, float]]]:
+    """(id, started_at, commit, passed, metrics) for the newest runs of tool, oldest first."""
+    with closing(connect(path)) as conn:
+        runs = conn.execute("SELECT id, started_at, git_commit, passed FROM runs WHERE tool = ? ORDER BY id DESC LIMIT ?", (tool, limit)).fetchall()
+        return [(run_id, started, commit, bool(passed), dict(conn.execute("SELECT name, value FROM metrics WHERE run_id = ?", (run_id,)).fetchall())) for run_id, started, commit, passed in reversed(runs)]
+
+
+def trend_report(rows: List[Tuple[int, str, Optional[str], bool, Dict[str, float]]], names: List[str]) -> List[str]:
+    lines = ["Run history", f"- runs: {len(rows)}"]
+    for run_id, started, commit, passed, _ in rows:
+        lines.append(f"- #{run_id} {started} {(commit or 'no commit')[:10]} {'passed' if passed else 'FAILED'}")
+    if len(rows) < 2:
+        return lines
+    first, last = rows[0][4], rows[-1][4]
+    lines.append("## metrics (first -> last shown run)")
+    for name in names or sorted(set(first) | set(last)):
+        before, after = first.get(name), last.get(name)
+        change = f" ({after - before:+g})" if before is not None and after is not None and after != before else ""
+        lines.append(f"- {name}: {'-' if before is None else f'{before:g}'} -> {'-' if after is None else f'{after:g}'}{change}")
+    return lines
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Show the recorded per-run metrics and how they moved.")
+    parser.add_argument("--history", default=str(DEFAULT_HISTORY_PATH), help="SQLite file the runs are recorded in")
+    parser.add_argument("--tool", default="export", help="Whose runs to show (default export)")
+    parser.add_argument("--last", type=int, default=10, metavar="N", help="Show the newest N runs (default 10)")
+    parser.add_argument("--metric", action="append", default=[], help="Show only this metric (repeatable)")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    if not Path(args.history).exists():
+        print(f"[history] No runs recorded in {args.history} yet", file=sys.stderr)
+        return 1
+    lines = trend_report(history_rows(args.history, args.tool, args.last), args.metric)
+    path = write_report("history.md", lines)
+    print("\n".join(lines))
+    print(f"[history] Wrote {path}")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,64 @@
+#!/usr/bin/env python3
+"""Check that lesson and vocabulary IDs follow the configured ID scheme."""
+
+from __future__ import annotations
+
+import argparse
+import sys
+from pathlib import Path
+from typing import Iterable, List, Tuple
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_IDS_PATH, Record, collect, describe, generate_id, load_id_scheme
+    from .console import add_output_arguments, configure_output
+    from .strict import StrictFailure, fail_strict
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_IDS_PATH, Record, collect, describe, generate_id, load_id_scheme  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from strict import StrictFailure, fail_strict  # type: ignore
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Validate content IDs against the configured ID scheme.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to scan")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument("--show-generated", action="store_true", help="List IDs that would be generated for records without one")
+    parser.add_argument("--strict", action="store_true", help="Exit non-zero when any ID does not match the scheme")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+
+    invalid: List[Tuple[Record, str]] = []
+    generated: List[Tuple[Record, str]] = []
+    for record in dataset.lessons + dataset.vocab:
+        existing = record.data.get("id")
+        if isinstance(existing, str) and existing.strip():
+            if not scheme.pattern(record.kind).match(existing.strip()):
+                invalid.append((record, existing))
+        else:
+            generated.append((record, generate_id(record, scheme)))
+
+    expected = f"{scheme.prefix}{scheme.separator}<kind>_<slug|{scheme.fallback}>[{scheme.separator}<sense_key>]"
+    print(f"[ids] Scheme: {expected}")
+    print(f"[ids] {len(dataset.lessons)} lessons, {len(dataset.vocab)} vocabulary entries, {len(generated)} without IDs")
+    if args.show_generated:
+        for record, new_id in generated:
+            print(f"    + {describe(record)} -> {new_id}")
+    if invalid:
+        print(f"[ids] {len(invalid)} IDs do not match the configured scheme:", file=sys.stderr)
+        for record, bad_id in invalid:
+            print(f"    • {describe(record)}: {bad_id} (expected prefix {scheme.kind_prefix(record.kind)})", file=sys.stderr)
+        if args.strict:
+            failures = [StrictFailure("ids.scheme", record.source, f"expected prefix {scheme.kind_prefix(record.kind)}", bad_id, record.index) for record, bad_id in invalid]
+            return fail_strict("ids", failures)
+        return 0
+    print("[ids] All IDs match the configured scheme.")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,189 @@
+#!/usr/bin/env python3
+"""Validate image references on vocabulary and lesson steps and build an asset manifest."""
+
+from __future__ import annotations
+
+import argparse
+import json
+import struct
+import sys
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, Iterator, List, Optional, Tuple
+
+try:
+    from .common import BUILD_DIR, CONTENT_DIR, ROOT, Dataset, collect, describe, write_report
+    from .console import add_output_arguments, configure_output
+    from .strict import StrictFailure, fail_strict
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import BUILD_DIR, CONTENT_DIR, ROOT, Dataset, collect, describe, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from strict import StrictFailure, fail_strict  # type: ignore
+
+ASSETS_DIR = ROOT / "assets"
+IMAGE_FORMATS = ("png", "jpeg", "gif", "webp")
+DEFAULT_MIN_SIZE = 64
+DEFAULT_MAX_SIZE = 4096
+
+
+@dataclass
+class ImageRef:
+    target: str
+    path: str
+    src: str
+    alt: Optional[str] = None
+    source: str = ""
+    index: Optional[int] = None
+    id: Optional[str] = None
+
+
+def image_src(value: Any) -> Tuple[str, Optional[str]]:
+    """Accept either a bare path or an object with src/alt."""
+    if isinstance(value, str):
+        return value.strip(), None
+    if isinstance(value, dict):
+        alt = value.get("alt")
+        return str(value.get("src", "")).strip(), alt if isinstance(alt, str) else None
+    return "", None
+
+
+def iter_image_refs(dataset: Dataset) -> Iterator[ImageRef]:
+    for record in dataset.vocab:
+        if "image" in record.data:
+            src, alt = image_src(record.data["image"])
+            yield ImageRef(describe(record), "image", src, alt, record.source, record.index, record.data.get("id"))
+    for record in dataset.lessons:
+        for idx, step in enumerate(record.data.get("steps", [])):
+            if isinstance(step, dict) and "image" in step:
+                src, alt = image_src(step["image"])
+                yield ImageRef(describe(record), f"steps[{idx}].image", src, alt, record.source, record.index, record.data.get("id"))
+
+
+def sniff_image(data: bytes) -> Optional[Tuple[str, int, int]]:
+    """Return (format, width, height) from the file header, or None when unrecognised."""
+    if data.startswith(b"\x89PNG\r\n\x1a\n") and len(data) >= 24:
+        width, height = struct.unpack(">II", data[16:24])
+        return "png", width, height
+    if data[:6] in (b"GIF87a", b"GIF89a") and len(data) >= 10:
+        width, height = struct.unpack("<HH", data[6:10])
+        return "gif", width, height
+    if data[:4] == b"RIFF" and data[8:12] == b"WEBP" and len(data) >= 30:
+        chunk = data[12:16]
+        if chunk == b"VP8 ":
+            width, height = struct.unpack("<HH", data[26:30])
+            return "webp", width & 0x3FFF, height & 0x3FFF
+        if chunk == b"VP8L":
+            bits = int.from_bytes(data[21:25], "little")
+            return "webp", (bits & 0x3FFF) + 1, ((bits >> 14) & 0x3FFF) + 1
+        if chunk == b"VP8X":
+            return "webp", int.from_bytes(data[24:27], "little") + 1, int.from_bytes(data[27:30], "little") + 1
+    if data[:2] == b"\xff\xd8":
+        idx = 2
+        while idx + 9 < len(data):
+            if data[idx] != 0xFF:
+                idx += 1
+                continue
+            marker = data[idx + 1]
+            if marker in (0xD8, 0x01) or 0xD0 <= marker <= 0xD7 or marker == 0xFF:
+                idx += 1 if marker == 0xFF else 2
+                continue
+            length = struct.unpack(">H", data[idx + 2 : idx + 4])[0]
+            if 0xC0 <= marker <= 0xCF and marker not in (0xC4, 0xC8, 0xCC):
+                height, width = struct.unpack(">HH", data[idx + 5 : idx + 9])
+                return "jpeg", width, height
+            idx += 2 + length
+    return None
+
+
+def check_image(ref: ImageRef, assets: Path, min_size: int, max_size: int) -> Tuple[Optional[Dict[str, Any]], Optional[str]]:
+    """Return (manifest entry, problem) for one reference."""
+    if not ref.src:
+        return None, "empty image reference"
+    path = (assets / ref.src).resolve()
+    if assets.resolve() not in path.parents:
+        return None, f"{ref.src} points outside the assets root"
+    if not path.is_file():
+        return None, f"{ref.src} does not exist"
+    info = sniff_image(path.read_bytes())
+    if info is None:
+        return None, f"{ref.src} is not a {'/'.join(IMAGE_FORMATS)} image"
+    fmt, width, height = info
+    entry = {"src": ref.src, "format": fmt, "width": width, "height": height, "bytes": path.stat().st_size}
+    if min(width, height) < min_size:
+        return entry, f"{ref.src} is {width}x{height}, smaller than {min_size}px"
+    if max(width, height) > max_size:
+        return entry, f"{ref.src} is {width}x{height}, larger than {max_size}px"
+    return entry, None
+
+
+def write_derivatives(entry: Dict[str, Any], assets: Path, out_dir: Path, widths: List[int]) -> List[Dict[str, Any]]:
+    """Downscale one image to each requested width; needs Pillow."""
+    try:
+        from PIL import Image  # type: ignore
+    except ImportError as exc:  # pragma: no cover - optional dependency
+        raise SystemExit("[images] --derive needs Pillow (pip install pillow)") from exc
+    derived: List[Dict[str, Any]] = []
+    source = Path(entry["src"])
+    with Image.open(assets / source) as image:
+        for width in sorted(widths):
+            if width >= entry["width"]:
+                continue
+            height = round(entry["height"] * width / entry["width"])
+            name = source.with_name(f"{source.stem}@{width}w{source.suffix}")
+            (out_dir / name).parent.mkdir(parents=True, exist_ok=True)
+            image.resize((width, height)).save(out_dir / name)
+            derived.append({"src": name.as_posix(), "width": width, "height": height})
+    return derived
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Validate image references and write an asset manifest.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to scan")
+    parser.add_argument("--assets", default=str(ASSETS_DIR), help="Root directory that image paths are relative to")
+    parser.add_argument("--out", default=str(BUILD_DIR / "assets"), help="Output directory for the manifest and derivatives")
+    parser.add_argument("--min-size", type=int, default=DEFAULT_MIN_SIZE, help="Smallest allowed edge in pixels")
+    parser.add_argument("--max-size", type=int, default=DEFAULT_MAX_SIZE, help="Largest allowed edge in pixels")
+    parser.add_argument("--derive", type=int, nargs="+", metavar="WIDTH", help="Also write downscaled copies at these widths (needs Pillow)")
+    parser.add_argument("--strict", action="store_true", help="Exit non-zero when any image is missing or invalid")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    assets = Path(args.assets)
+    out_dir = Path(args.out)
+    dataset = collect(args.content)
+
+    manifest: Dict[str, Dict[str, Any]] = {}
+    problems: List[Tuple[ImageRef, str]] = []
+    refs = list(iter_image_refs(dataset))
+    for ref in refs:
+        entry, problem = check_image(ref, assets, args.min_size, args.max_size)
+        if problem:
+            problems.append((ref, problem))
+        if entry:
+            kept = manifest.setdefault(ref.src, entry)
+            kept.setdefault("used_by", []).append(f"{ref.target} {ref.path}")
+            if ref.alt:
+                kept.setdefault("alt", ref.alt)
+
+    if args.derive:
+        for entry in manifest.values():
+            entry["derivatives"] = write_derivatives(entry, assets, out_dir, args.derive)
+
+    out_dir.mkdir(parents=True, exist_ok=True)
+    (out_dir / "manifest.json").write_text(json.dumps(sorted(manifest.values(), key=lambda e: e["src"]), ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+
+    out = ["Image audit", f"- references: {len(refs)}", f"- images in manifest: {len(manifest)}", f"- problems: {len(problems)}"]
+    if problems:
+        out.append("## missing or invalid images")
+        out += [f"- {ref.target} {ref.path}: {problem}" for ref, problem in problems]
+    write_report("images.md", out)
+    print("\n".join(out))
+    if args.strict:
+        return fail_strict("images", [StrictFailure("images.reference", ref.source, f"{ref.path}: {problem}", ref.id, ref.index) for ref, problem in problems])
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
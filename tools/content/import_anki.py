@@ -0,0 +1,155 @@
+#!/usr/bin/env python3
+"""Import Anki .apkg decks as vocabulary JSONL content."""
+
+from __future__ import annotations
+
+import argparse
+import html
+import json
+import re
+import sqlite3
+import sys
+import tempfile
+import zipfile
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+
+try:
+    from .common import CONFIG_DIR, CONTENT_DIR, load_json
+    from .console import add_output_arguments, configure_output
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONFIG_DIR, CONTENT_DIR, load_json  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+
+DEFAULT_ANKI_PATH = CONFIG_DIR / "anki.json"
+COLLECTION_NAMES = ("collection.anki21", "collection.anki2")
+SOUND_RE = re.compile(r"\[sound:([^\]]+)\]")
+IMG_RE = re.compile(r"<img[^>]*src=[\"']([^\"']+)[\"'][^>]*>", re.I)
+TAG_RE = re.compile(r"<[^>]+>")
+
+
+def clean_field(value: str) -> str:
+    value = SOUND_RE.sub("", value)
+    value = IMG_RE.sub("", value)
+    value = re.sub(r"<br\s*/?>", " ", value, flags=re.I)
+    value = TAG_RE.sub("", value)
+    return " ".join(html.unescape(value).split())
+
+
+def media_refs(fields: List[str]) -> List[str]:
+    refs: List[str] = []
+    for value in fields:
+        refs += SOUND_RE.findall(value) + IMG_RE.findall(value)
+    return refs
+
+
+def pick(named: Dict[str, str], candidates: List[str]) -> str:
+    lowered = {name.lower(): value for name, value in named.items()}
+    for candidate in candidates:
+        value = lowered.get(candidate.lower())
+        if value:
+            return value
+    return ""
+
+
+def load_models(conn: sqlite3.Connection) -> Dict[str, List[str]]:
+    row = conn.execute("SELECT models FROM col").fetchone()
+    models = json.loads(row[0]) if row and row[0] else {}
+    return {str(mid): [f["name"] for f in sorted(model.get("flds", []), key=lambda f: f.get("ord", 0))] for mid, model in models.items()}
+
+
+def note_to_record(named: Dict[str, str], tags: List[str], media: List[str], config: Dict[str, Any], deck: str) -> Optional[Dict[str, Any]]:
+    """One vocabulary record per note; deck names the .apkg it came from.
+
+    source_files is left to export, which records the JSONL file the record is written to: that file is
+    what orphans, the serve write API, and everything else that edits sources need, not the deck.
+    """
+    fields = config.get("fields", {})
+    defaults = config.get("defaults", {})
+    cleaned = {name: clean_field(value) for name, value in named.items()}
+    spanish = pick(cleaned, fields.get("spanish", []))
+    if not spanish:
+        return None
+    level_tags = {tag.upper() for tag in config.get("level_tags", [])}
+    level = next((tag.upper() for tag in tags if tag.upper() in level_tags), defaults.get("level", "UNSET"))
+    record: Dict[str, Any] = {
+        "spanish": spanish,
+        "pos": pick(cleaned, fields.get("pos", [])) or defaults.get("pos", "expr"),
+        "english_gloss": pick(cleaned, fields.get("english_gloss", [])),
+        "definition": pick(cleaned, fields.get("definition", [])),
+        "examples": [],
+        "level": level,
+        "tags": [tag for tag in tags if tag.upper() not in level_tags],
+        "anki_deck": deck,
+    }
+    gender = pick(cleaned, fields.get("gender", []))
+    if gender:
+        record["gender"] = gender
+    example_es = pick(cleaned, fields.get("example_es", []))
+    if example_es:
+        record["examples"].append({"es": example_es, "en": pick(cleaned, fields.get("example_en", []))})
+    if media:
+        record["media"] = media
+    return record
+
+
+def read_deck(apkg: Path, config: Dict[str, Any]) -> List[Dict[str, Any]]:
+    with zipfile.ZipFile(apkg) as archive:
+        names = set(archive.namelist())
+        collection = next((name for name in COLLECTION_NAMES if name in names), None)
+        if not collection:
+            raise ValueError(f"{apkg} has no {' or '.join(COLLECTION_NAMES)} (newer zstd-compressed decks must be re-exported as legacy)")
+        media_map = json.loads(archive.read("media") or b"{}") if "media" in names else {}
+        with tempfile.TemporaryDirectory() as tmp:
+            db_path = Path(archive.extract(collection, tmp))
+            conn = sqlite3.connect(db_path)
+            try:
+                models = load_models(conn)
+                rows = conn.execute("SELECT mid, flds, tags FROM notes ORDER BY id").fetchall()
+            finally:
+                conn.close()
+    known_media = set(media_map.values())
+    records: List[Dict[str, Any]] = []
+    for mid, flds, tags in rows:
+        values = flds.split("\x1f")
+        names_for_model = models.get(str(mid)) or [f"Field {i + 1}" for i in range(len(values))]
+        named = dict(zip(names_for_model, values))
+        media = [ref for ref in media_refs(values) if not known_media or ref in known_media]
+        record = note_to_record(named, tags.split(), media, config, apkg.name)
+        if record:
+            records.append(record)
+    return records
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Import Anki decks (.apkg) into vocabulary JSONL content.")
+    parser.add_argument("decks", nargs="+", help="Paths to .apkg files")
+    parser.add_argument("--config", default=str(DEFAULT_ANKI_PATH), help="Path to the Anki field mapping config")
+    parser.add_argument("--out", default=str(CONTENT_DIR / "imports" / "anki"), help="Directory for imported JSONL files")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    config = load_json(args.config)
+    out_dir = Path(args.out)
+    out_dir.mkdir(parents=True, exist_ok=True)
+    status = 0
+    for deck in args.decks:
+        apkg = Path(deck)
+        try:
+            records = read_deck(apkg, config)
+        except (OSError, ValueError, zipfile.BadZipFile, sqlite3.Error) as exc:
+            print(f"[anki] Skipping {apkg}: {exc}", file=sys.stderr)
+            status = 1
+            continue
+        dest = out_dir / f"{apkg.stem}.jsonl"
+        with open(dest, "w", encoding="utf-8") as handle:
+            for record in records:
+                handle.write(json.dumps(record, ensure_ascii=False) + "\n")
+        print(f"[anki] Imported {len(records)} notes from {apkg} into {dest}")
+    return status
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
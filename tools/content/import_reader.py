@@ -0,0 +1,226 @@
+#!/usr/bin/env python3
+"""Ingest graded-reader manuscripts (EPUB, or Markdown chapters) as reading entries."""
+
+from __future__ import annotations
+
+import argparse
+import json
+import posixpath
+import re
+import sys
+import zipfile
+import xml.etree.ElementTree as ET
+from html.parser import HTMLParser
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_IDS_PATH, LEVELS, IdScheme, load_id_scheme, slugify
+    from .console import add_output_arguments, configure_output
+    from .readings import extract_glosses
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_IDS_PATH, LEVELS, IdScheme, load_id_scheme, slugify  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from readings import extract_glosses  # type: ignore
+
+READINGS_DIR = CONTENT_DIR / "readings"
+QUESTION_HEADINGS = {"preguntas", "questions", "comprension", "comprensión", "comprehension", "comprehension questions", "preguntas de comprension", "preguntas de comprensión"}
+# "- ¿Dónde vive Ana? :: En Madrid." (the answer is optional)
+ANSWER_SEPARATOR = "::"
+OPF_NS = {"opf": "http://www.idpf.org/2007/opf", "dc": "http://purl.org/dc/elements/1.1/"}
+BLOCK_TAGS = {"p", "div", "section", "blockquote", "br", "tr"}
+HEADING_LEVELS = {"h1": "# ", "h2": "## ", "h3": "## ", "h4": "## "}
+
+
+class XhtmlToMarkdown(HTMLParser):
+    """Flatten one EPUB content document into the Markdown shape the chapter parser reads."""
+
+    def __init__(self) -> None:
+        super().__init__(convert_charrefs=True)
+        self.lines: List[str] = []
+        self.current = ""
+        self.prefix = ""
+        self.skip = 0
+
+    def flush(self) -> None:
+        text = " ".join(self.current.split())
+        if text:
+            self.lines.append(self.prefix + text)
+        self.current, self.prefix = "", ""
+
+    def handle_starttag(self, tag: str, attrs: List[Tuple[str, Optional[str]]]) -> None:
+        if tag in ("head", "script", "style"):
+            self.skip += 1
+        elif tag in HEADING_LEVELS or tag == "li":
+            self.flush()
+            self.prefix = HEADING_LEVELS.get(tag, "- ")
+        elif tag in BLOCK_TAGS:
+            self.flush()
+
+    def handle_endtag(self, tag: str) -> None:
+        if tag in ("head", "script", "style"):
+            self.skip = max(0, self.skip - 1)
+        elif tag in HEADING_LEVELS or tag == "li" or tag in BLOCK_TAGS:
+            self.flush()
+            if tag in ("p", "div", "section", "blockquote") or tag in HEADING_LEVELS:
+                self.lines.append("")
+
+    def handle_data(self, data: str) -> None:
+        if not self.skip:
+            self.current += data
+
+
+def xhtml_to_markdown(markup: str) -> str:
+    parser = XhtmlToMarkdown()
+    parser.feed(markup)
+    parser.close()
+    parser.flush()
+    return "\n".join(parser.lines)
+
+
+def read_epub(path: Path) -> Tuple[Optional[str], List[str]]:
+    """Book title from the OPF metadata and one Markdown document per spine item, in reading order."""
+    with zipfile.ZipFile(path) as book:
+        container = ET.fromstring(book.read("META-INF/container.xml"))
+        rootfile = next((el.get("full-path") for el in container.iter() if el.tag.endswith("rootfile")), None)
+        if not rootfile:
+            raise ValueError("META-INF/container.xml names no rootfile")
+        opf = ET.fromstring(book.read(rootfile))
+        title = opf.findtext(".//dc:title", namespaces=OPF_NS)
+        manifest = {item.get("id"): item for item in opf.iterfind(".//opf:manifest/opf:item", OPF_NS)}
+        base = posixpath.dirname(rootfile)
+        documents: List[str] = []
+        for itemref in opf.iterfind(".//opf:spine/opf:itemref", OPF_NS):
+            item = manifest.get(itemref.get("idref"))
+            if item is None or itemref.get("linear") == "no" or "html" not in (item.get("media-type") or ""):
+                continue
+            name = posixpath.normpath(posixpath.join(base, item.get("href", "")))
+            documents.append(xhtml_to_markdown(book.read(name).decode("utf-8", errors="replace")))
+    return (title.strip() if title and title.strip() else None), documents
+
+
+def split_chapters(markdown: str) -> List[Tuple[Optional[str], List[str]]]:
+    """(heading, body lines) per "# " chapter; text before the first heading becomes an untitled chapter."""
+    chapters: List[Tuple[Optional[str], List[str]]] = []
+    heading: Optional[str] = None
+    body: List[str] = []
+    for line in markdown.splitlines():
+        if line.startswith("# "):
+            if heading is not None or any(part.strip() for part in body):
+                chapters.append((heading, body))
+            heading, body = line[2:].strip(), []
+        else:
+            body.append(line)
+    if heading is not None or any(part.strip() for part in body):
+        chapters.append((heading, body))
+    return chapters
+
+
+def is_question_heading(line: str) -> bool:
+    return line.startswith("## ") and line[3:].strip().rstrip(":").lower() in QUESTION_HEADINGS
+
+
+def parse_questions(lines: List[str]) -> List[Dict[str, str]]:
+    questions: List[Dict[str, str]] = []
+    for line in lines:
+        item = re.sub(r"^\s*(?:[-*+]|\d+[.)])\s+", "", line)
+        if item == line or not item.strip():
+            continue
+        question, _, answer = item.partition(ANSWER_SEPARATOR)
+        entry = {"question": question.strip()}
+        if answer.strip():
+            entry["answer"] = answer.strip()
+        questions.append(entry)
+    return questions
+
+
+def chapter_text(lines: List[str]) -> str:
+    """Paragraphs joined by blank lines; lines inside a paragraph are joined with spaces."""
+    paragraphs: List[str] = []
+    current: List[str] = []
+    for line in lines + [""]:
+        if line.strip():
+            current.append(line.strip())
+        elif current:
+            paragraphs.append(" ".join(current))
+            current = []
+    return "\n\n".join(paragraphs)
+
+
+def build_reading(book: str, number: int, heading: Optional[str], lines: List[str], level: str, source: str, fmt: str, scheme: IdScheme) -> Dict[str, Any]:
+    split = next((idx for idx, line in enumerate(lines) if is_question_heading(line)), len(lines))
+    # Any later "## " heading ends the question list and resumes chapter text.
+    after = next((idx for idx in range(split + 1, len(lines)) if lines[idx].startswith("## ")), len(lines))
+    text, glossed = extract_glosses(chapter_text(lines[:split] + lines[after:]), scheme)
+    title = extract_glosses(heading, scheme)[0] if heading else f"{book} {number}"
+    return {
+        "id": scheme.kind_prefix("reading") + slugify(book) + scheme.separator + f"{number:02d}",
+        "kind": "reading",
+        "title": title,
+        "book": book,
+        "chapter": number,
+        "level": level,
+        "text": text,
+        "glossed": glossed,
+        "questions": parse_questions(lines[split + 1 : after]),
+        "review_status": "draft",
+        "manuscript": {"source": source, "format": fmt},
+    }
+
+
+def manuscript_chapters(path: Path) -> Tuple[Optional[str], str, List[Tuple[Optional[str], List[str]]]]:
+    """(book title if the manuscript names one, format, chapters) for an .epub file, a Markdown file, or a directory of Markdown files."""
+    if path.suffix.lower() == ".epub":
+        title, documents = read_epub(path)
+        chapters = [chapter for document in documents for chapter in split_chapters(document)]
+        return title, "epub", chapters
+    files = sorted(path.glob("*.md")) if path.is_dir() else [path]
+    chapters = []
+    for file in files:
+        found = split_chapters(file.read_text(encoding="utf-8"))
+        # A chapter-per-file manuscript without headings is titled by its file name.
+        chapters += [(heading or (file.stem.replace("_", " ").replace("-", " ").strip().capitalize() if path.is_dir() else None), body) for heading, body in found]
+    return None, "markdown", chapters
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Convert graded-reader manuscripts into reading entries (one JSONL file per book).")
+    parser.add_argument("manuscripts", nargs="+", help="EPUB files, Markdown files, or directories of Markdown chapter files")
+    parser.add_argument("--book", help="Book title (default: the EPUB title, or the manuscript file name)")
+    parser.add_argument("--level", default="UNSET", choices=LEVELS + ("UNSET",), help="CEFR level to stamp on every chapter")
+    parser.add_argument("--out", default=str(READINGS_DIR), help="Directory for the <book>.jsonl files")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    scheme = load_id_scheme(args.ids)
+    out_dir = Path(args.out)
+    out_dir.mkdir(parents=True, exist_ok=True)
+    status = 0
+    for name in args.manuscripts:
+        path = Path(name)
+        try:
+            title, fmt, chapters = manuscript_chapters(path)
+        except (OSError, KeyError, ValueError, zipfile.BadZipFile, ET.ParseError) as exc:
+            print(f"[reader] Skipping {path}: {exc}", file=sys.stderr)
+            status = 1
+            continue
+        book = args.book or title or path.stem.replace("_", " ").replace("-", " ").strip().capitalize()
+        readings = [build_reading(book, number, heading, body, args.level, path.name, fmt, scheme) for number, (heading, body) in enumerate(chapters, 1)]
+        readings = [reading for reading in readings if reading["text"]]
+        if not readings:
+            print(f"[reader] Skipping {path}: no chapter text found", file=sys.stderr)
+            status = 1
+            continue
+        dest = out_dir / f"{slugify(book) or 'book'}.jsonl"
+        dest.write_text("".join(json.dumps(reading, ensure_ascii=False) + "\n" for reading in readings), encoding="utf-8")
+        glossed = sum(len(reading["glossed"]) for reading in readings)
+        questions = sum(len(reading["questions"]) for reading in readings)
+        print(f"[reader] Wrote {len(readings)} chapters of '{book}' ({glossed} glossed words, {questions} questions) to {dest}")
+    return status
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
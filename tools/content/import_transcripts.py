@@ -0,0 +1,153 @@
+#!/usr/bin/env python3
+"""Import timestamped audio transcripts (Whisper, Otter, or similar JSON) as draft dialogue lessons for review."""
+
+from __future__ import annotations
+
+import argparse
+import datetime as dt
+import json
+import sys
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+try:
+    from .common import LEVELS, ROOT, load_json
+    from .console import add_output_arguments, configure_output
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import LEVELS, ROOT, load_json  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+
+REVIEW_DIR = ROOT / "review" / "transcripts"
+EN_PLACEHOLDER = "TODO: translate"
+SEGMENT_KEYS = ("segments", "transcripts", "monologues", "utterances")
+TEXT_KEYS = ("text", "transcript")
+SPEAKER_KEYS = ("speaker", "speaker_name", "speaker_id", "speaker_label")
+# (key, divisor): millisecond fields are converted to seconds.
+START_KEYS = (("start", 1), ("start_time", 1), ("start_ms", 1000), ("start_offset", 1000))
+END_KEYS = (("end", 1), ("end_time", 1), ("end_ms", 1000), ("end_offset", 1000))
+
+
+def first_value(segment: Dict[str, Any], keys: Iterable[str]) -> Any:
+    return next((segment[key] for key in keys if segment.get(key) not in (None, "")), None)
+
+
+def seconds(segment: Dict[str, Any], keys: Iterable[Tuple[str, int]]) -> Optional[float]:
+    for key, divisor in keys:
+        if isinstance(segment.get(key), (int, float)):
+            return round(segment[key] / divisor, 2)
+    return None
+
+
+def detect_tool(data: Dict[str, Any]) -> str:
+    if "segments" in data and ("language" in data or "text" in data):
+        return "whisper"
+    if "speakers" in data or "transcripts" in data:
+        return "otter"
+    return "generic"
+
+
+def read_segments(data: Any) -> List[Dict[str, Any]]:
+    """Normalise a transcript into [{speaker, text, start, end}] in spoken order."""
+    raw = data if isinstance(data, list) else next((data[key] for key in SEGMENT_KEYS if isinstance(data.get(key), list)), None)
+    if raw is None:
+        raise ValueError(f"no segment list found (looked for {', '.join(SEGMENT_KEYS)})")
+    speakers = {}
+    if isinstance(data, dict) and isinstance(data.get("speakers"), list):
+        speakers = {str(s.get("id")): s.get("name") for s in data["speakers"] if isinstance(s, dict)}
+    segments: List[Dict[str, Any]] = []
+    for item in raw:
+        if not isinstance(item, dict):
+            continue
+        text = " ".join(str(first_value(item, TEXT_KEYS) or "").split())
+        if not text:
+            continue
+        speaker = first_value(item, SPEAKER_KEYS)
+        speaker = speakers.get(str(speaker), speaker) if speaker is not None else None
+        segments.append({"speaker": str(speaker) if speaker is not None else None, "text": text, "start": seconds(item, START_KEYS), "end": seconds(item, END_KEYS)})
+    return segments
+
+
+def join_turns(segments: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """Merge consecutive segments from the same known speaker into one turn."""
+    turns: List[Dict[str, Any]] = []
+    for segment in segments:
+        last = turns[-1] if turns else None
+        if last and segment["speaker"] and segment["speaker"] == last["speaker"]:
+            last["text"] += " " + segment["text"]
+            last["end"] = segment["end"] if segment["end"] is not None else last["end"]
+        else:
+            turns.append(dict(segment))
+    return turns
+
+
+def transcript_to_lesson(path: Path, data: Any, level: str, join: bool) -> Dict[str, Any]:
+    segments = read_segments(data)
+    turns = join_turns(segments) if join else segments
+    speaker_names: Dict[Optional[str], str] = {}
+    steps: List[Dict[str, Any]] = []
+    for turn in turns:
+        name = speaker_names.setdefault(turn["speaker"], turn["speaker"] or f"speaker_{len(speaker_names) + 1}")
+        step: Dict[str, Any] = {"phase": "dialogue", "npc": name, "es": turn["text"], "en": EN_PLACEHOLDER}
+        if turn["start"] is not None:
+            step["start"] = turn["start"]
+        if turn["end"] is not None:
+            step["end"] = turn["end"]
+        steps.append(step)
+    ends = [step["end"] for step in steps if "end" in step]
+    title = path.stem.replace("_", " ").replace("-", " ").strip().capitalize()
+    return {
+        "title": title,
+        "nickname": title,
+        "mode": "dialogue",
+        "level": level,
+        "tags": ["dialogue", "transcript"],
+        "review_status": "draft",
+        "transcript": {
+            "source": path.name,
+            "tool": detect_tool(data) if isinstance(data, dict) else "generic",
+            "language": data.get("language") if isinstance(data, dict) else None,
+            "duration": max(ends) if ends else None,
+            "speakers": sorted(set(speaker_names.values())),
+        },
+        "steps": steps,
+    }
+
+
+def queue_draft(queue_path: Path, draft: Path, source: Path, turns: int) -> None:
+    queue = load_json(queue_path) if queue_path.exists() else []
+    queue = [item for item in queue if item.get("file") != draft.name]
+    queue.append({"file": draft.name, "source": str(source), "turns": turns, "status": "pending", "queued_at": dt.datetime.now(dt.timezone.utc).isoformat(timespec="seconds")})
+    queue_path.write_text(json.dumps(queue, ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Convert audio transcripts into draft dialogue lessons queued for review.")
+    parser.add_argument("transcripts", nargs="+", help="Transcript JSON files (Whisper, Otter, or a plain segment list)")
+    parser.add_argument("--out", default=str(REVIEW_DIR), help="Review bucket for the drafts; move reviewed files under content/ to publish them")
+    parser.add_argument("--level", default="UNSET", choices=LEVELS + ("UNSET",), help="CEFR level to stamp on the drafts")
+    parser.add_argument("--no-join", action="store_true", help="Keep every transcript segment as its own step instead of joining a speaker's consecutive segments")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    out_dir = Path(args.out)
+    out_dir.mkdir(parents=True, exist_ok=True)
+    status = 0
+    for name in args.transcripts:
+        path = Path(name)
+        try:
+            lesson = transcript_to_lesson(path, load_json(path), args.level, not args.no_join)
+        except (OSError, ValueError) as exc:
+            print(f"[transcripts] Skipping {path}: {exc}", file=sys.stderr)
+            status = 1
+            continue
+        dest = out_dir / f"{path.stem}.json"
+        dest.write_text(json.dumps(lesson, ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+        queue_draft(out_dir / "queue.json", dest, path, len(lesson["steps"]))
+        print(f"[transcripts] Queued {len(lesson['steps'])} dialogue turns from {path} as {dest}")
+    return status
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
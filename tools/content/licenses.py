@@ -0,0 +1,74 @@
+"""Third-party content subtrees marked by _meta.json files, and the license gate for export profiles."""
+
+from __future__ import annotations
+
+import json
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+try:
+    from .common import META_NAME, ROOT, source_file
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import META_NAME, ROOT, source_file  # type: ignore
+
+REQUIRED_LICENSE_FIELDS = ("license", "attribution")
+
+
+class MetaLookup:
+    """Finds the _meta.json settings governing a source file: the nearest file up the tree wins, key by key."""
+
+    def __init__(self) -> None:
+        self._cache: Dict[Path, Dict[str, Any]] = {}
+
+    def for_dir(self, directory: Path) -> Dict[str, Any]:
+        if directory in self._cache:
+            return self._cache[directory]
+        parent = directory.parent
+        inherited = self.for_dir(parent) if parent != directory and directory != ROOT else {}
+        meta = dict(inherited)
+        path = directory / META_NAME
+        if path.is_file():
+            own = json.loads(path.read_text(encoding="utf-8"))
+            if isinstance(own.get("third_party"), dict):
+                meta["third_party"] = {**inherited.get("third_party", {}), **own["third_party"]}
+            elif own.get("third_party") is False:
+                meta.pop("third_party", None)
+        self._cache[directory] = meta
+        return meta
+
+    def for_source(self, source: str) -> Dict[str, Any]:
+        # Archive members take their settings from the directory holding the archive.
+        return self.for_dir(source_file(source).resolve().parent)
+
+
+def mark_third_party(entries: List[Dict[str, Any]], lookup: Optional[MetaLookup] = None) -> int:
+    """Fill each entry's `third_party` block from its subtree's _meta.json; the entry's own keys win. Returns the count marked."""
+    lookup = lookup or MetaLookup()
+    marked = 0
+    for entry in entries:
+        defaults: Dict[str, Any] = {}
+        for source in entry.get("source_files", []):
+            defaults.update(lookup.for_source(source).get("third_party", {}))
+        own = entry.get("third_party")
+        if defaults or isinstance(own, dict):
+            entry["third_party"] = {**defaults, **(own if isinstance(own, dict) else {})}
+            marked += 1
+    return marked
+
+
+def missing_license_fields(entry: Dict[str, Any]) -> List[str]:
+    block = entry.get("third_party")
+    if not isinstance(block, dict):
+        return []
+    return [key for key in REQUIRED_LICENSE_FIELDS if not str(block.get(key) or "").strip()]
+
+
+def license_violations(entries: List[Dict[str, Any]], allowed: Optional[List[str]]) -> List[str]:
+    """Third-party entries whose license is not in allowed; a profile without a licenses list allows none."""
+    problems: List[str] = []
+    permitted = set(allowed or [])
+    for entry in entries:
+        block = entry.get("third_party")
+        if isinstance(block, dict) and block.get("license") not in permitted:
+            problems.append(f"{entry['id']}: license {block.get('license') or 'unknown'} is not permitted")
+    return problems
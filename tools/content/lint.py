@@ -0,0 +1,586 @@
+#!/usr/bin/env python3
+"""Lint lessons, vocabulary, readings, units, culture notes, and pronunciation drills for content problems that validation cannot see."""
+
+from __future__ import annotations
+
+import argparse
+import csv
+import re
+import sys
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Callable, Dict, Iterable, List
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_LESSON_FLOW, DEFAULT_LINT_PATH, ROOT, Dataset, Record, collect, describe, entry_level, load_id_scheme, load_json, record_id, spanish_texts, words, write_report
+    from .accents import load_accent_dictionary, restore_accents
+    from .alignment import AUDIO_DIR, check_alignment, iter_examples
+    from .console import add_output_arguments, configure_output
+    from .culture import check_culture_notes
+    from .errors import ConfigError
+    from .export import index_vocab, intro_order_problems, item_reference
+    from .forms import DEFAULT_FORMS_BANK, entry_forms, load_form_bank, mentions, pluralize
+    from .headwords import load_headword_rules, normalize_headword
+    from .images import ASSETS_DIR
+    from .locales import load_locales, locales_for
+    from .media import check_step_media, load_media_types
+    from .owners import DEFAULT_OWNERS_PATH, UNOWNED, check_ownership, entry_owner, load_owners
+    from .numerals import load_numeral_style, mixed_numerals, normalize_numerals
+    from .postag import POS_NAMES, check_usage
+    from .pron import check_drills
+    from .readings import lookup
+    from .references import load_reference_types, resolve_references
+    from .relations import asymmetric_relations, resolve_relations
+    from .rejects import collect_rejects
+    from .rules import load_rules, rules_for
+    from .strict import StrictFailure, fail_strict
+    from .studytime import estimated_minutes, load_study_time
+    from .syllables import check_pronunciation
+    from .units import build_units, check_units
+    from .validate import entry_for, load_schemas, validate_all
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_LESSON_FLOW, DEFAULT_LINT_PATH, ROOT, Dataset, Record, collect, describe, entry_level, load_id_scheme, load_json, record_id, spanish_texts, words, write_report  # type: ignore
+    from accents import load_accent_dictionary, restore_accents  # type: ignore
+    from alignment import AUDIO_DIR, check_alignment, iter_examples  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from culture import check_culture_notes  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from export import index_vocab, intro_order_problems, item_reference  # type: ignore
+    from forms import DEFAULT_FORMS_BANK, entry_forms, load_form_bank, mentions, pluralize  # type: ignore
+    from headwords import load_headword_rules, normalize_headword  # type: ignore
+    from images import ASSETS_DIR  # type: ignore
+    from locales import load_locales, locales_for  # type: ignore
+    from media import check_step_media, load_media_types  # type: ignore
+    from owners import DEFAULT_OWNERS_PATH, UNOWNED, check_ownership, entry_owner, load_owners  # type: ignore
+    from numerals import load_numeral_style, mixed_numerals, normalize_numerals  # type: ignore
+    from postag import POS_NAMES, check_usage  # type: ignore
+    from pron import check_drills  # type: ignore
+    from readings import lookup  # type: ignore
+    from references import load_reference_types, resolve_references  # type: ignore
+    from relations import asymmetric_relations, resolve_relations  # type: ignore
+    from rejects import collect_rejects  # type: ignore
+    from rules import load_rules, rules_for  # type: ignore
+    from strict import StrictFailure, fail_strict  # type: ignore
+    from studytime import estimated_minutes, load_study_time  # type: ignore
+    from syllables import check_pronunciation  # type: ignore
+    from units import build_units, check_units  # type: ignore
+    from validate import entry_for, load_schemas, validate_all  # type: ignore
+
+PARADIGM_LABELS = {"tu": "tú", "vos": "vos", "vosotros": "vosotros"}
+GLOSS_STOPWORDS = {"a", "an", "the", "to", "of", "for", "and", "or", "be", "is", "am", "are", "one", "someone", "something", "used"}
+
+
+@dataclass
+class Finding:
+    rule: str
+    severity: str
+    target: str
+    message: str
+
+
+def label(record: Record) -> str:
+    entry_id = record.data.get("id") or record.data.get("spanish") or record.data.get("title")
+    return f"{describe(record)} ({entry_id})" if entry_id else describe(record)
+
+
+def check_dialect(config: Dict[str, Any]) -> None:
+    """Raise ConfigError when the target dialect is not one the config describes; it would silently check nothing."""
+    dialect = config.get("dialect")
+    dialects = config.get("dialects", {})
+    if dialect and dialect not in dialects:
+        raise ConfigError(f"lint: unknown dialect {dialect!r}; the config describes {', '.join(sorted(dialects)) or 'none'}", "dialect", dialect, sorted(dialects))
+
+
+def rule_second_person(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag lessons or example sets mixing tú, vos, and vosotros, or using a paradigm outside the target dialect."""
+    check_dialect(config)
+    markers = {name: set(forms) for name, forms in config.get("second_person", {}).items()}
+    dialect = config.get("dialect")
+    allowed = set(config.get("dialects", {}).get(dialect, markers))
+    findings: List[Finding] = []
+    for record in dataset.lessons + dataset.vocab:
+        seen: Dict[str, set] = {}
+        for _, text in spanish_texts(record.data):
+            for word in words(text):
+                for name, forms in markers.items():
+                    if word in forms:
+                        seen.setdefault(name, set()).add(word)
+        if not seen:
+            continue
+        names = sorted(seen)
+        evidence = "; ".join(f"{PARADIGM_LABELS.get(n, n)}: {', '.join(sorted(seen[n]))}" for n in names)
+        if len(names) > 1:
+            findings.append(Finding("second-person-mix", "warning", label(record), f"mixes second-person paradigms ({evidence})"))
+        outside = [n for n in names if n not in allowed]
+        if dialect and outside:
+            used = ", ".join(PARADIGM_LABELS.get(n, n) for n in outside)
+            findings.append(Finding("second-person-dialect", "warning", label(record), f"uses {used} but target dialect is {dialect} ({evidence})"))
+    return findings
+
+
+def gloss_terms(text: str) -> set:
+    terms = set()
+    for word in re.findall(r"[a-z]+", text.lower()):
+        if word in GLOSS_STOPWORDS:
+            continue
+        terms.add(word[:-1] if len(word) > 3 and word.endswith("s") else word)
+    return terms
+
+
+def load_gloss_dictionary(paths: List[str]) -> Dict[str, set]:
+    """Load Spanish form -> English terms from CSV files with form/lemma/english columns."""
+    dictionary: Dict[str, set] = {}
+    for raw in paths:
+        path = Path(raw) if Path(raw).is_absolute() else ROOT / raw
+        if not path.exists():
+            continue
+        with open(path, newline="", encoding="utf-8") as handle:
+            for row in csv.DictReader(handle):
+                english = gloss_terms(row.get("english", ""))
+                for key in (row.get("form", ""), row.get("lemma", "")):
+                    key = key.strip().lower()
+                    if key and english:
+                        dictionary.setdefault(key, set()).update(english)
+    return dictionary
+
+
+def rule_alignment(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag example audio alignments with malformed, overlapping, or backwards segments, or segments past the audio's end."""
+    assets = Path(config.get("audio_dir") or AUDIO_DIR)
+    findings: List[Finding] = []
+    for record in dataset.vocab:
+        for path, example in iter_examples(record.data):
+            for problem in check_alignment(example, assets):
+                findings.append(Finding("alignment", "error", label(record), f"{path}: {problem}"))
+    return findings
+
+
+def rule_example_headword(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag examples whose Spanish never uses the headword or one of its inflected forms (usually copied from another entry)."""
+    bank = load_form_bank(config.get("forms_bank") or DEFAULT_FORMS_BANK)
+    findings: List[Finding] = []
+    for record in dataset.vocab:
+        forms = entry_forms(record.data, bank)
+        if not forms:
+            continue
+        for path, example in iter_examples(record.data):
+            text = example.get("es")
+            if isinstance(text, str) and text.strip() and not mentions(text, forms):
+                findings.append(Finding("example-headword", "warning", label(record), f"{path}: '{text}' does not use '{record.data['spanish']}' or any of its forms"))
+    return findings
+
+
+def rule_gloss_consistency(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag English glosses sharing no terms with the bilingual dictionary translation of the headword."""
+    dictionary = load_gloss_dictionary(config.get("gloss_dictionaries", []))
+    reverse: Dict[str, str] = {}
+    for headword, terms in dictionary.items():
+        for term in terms:
+            reverse.setdefault(term, headword)
+    findings: List[Finding] = []
+    for record in dataset.vocab:
+        headword = str(record.data.get("spanish", "")).strip().lower()
+        expected = dictionary.get(headword)
+        gloss = str(record.data.get("english_gloss", ""))
+        actual = gloss_terms(gloss)
+        if not expected or not actual or expected & actual:
+            continue
+        message = f"gloss '{gloss}' shares nothing with dictionary '{', '.join(sorted(expected))}'"
+        shifted = sorted({reverse[term] for term in actual if term in reverse and reverse[term] != headword})
+        if shifted:
+            message += f"; it matches {', '.join(shifted)} (possible row shift)"
+        findings.append(Finding("gloss-consistency", "warning", label(record), message))
+    return findings
+
+
+def headword_index(dataset: Dataset) -> Dict[str, Record]:
+    """Vocabulary by lowercased ID and by headword, the two ways lessons refer to it."""
+    headwords: Dict[str, Record] = {}
+    for record in dataset.vocab:
+        for key in (record.data.get("id"), " ".join(words(str(record.data.get("spanish", ""))))):
+            if isinstance(key, str) and key.strip():
+                headwords.setdefault(key.strip().lower(), record)
+    return headwords
+
+
+def step_item_refs(step: Dict[str, Any], headwords: Dict[str, Record]) -> Dict[int, Record]:
+    """Vocabulary a step lists in its items."""
+    used: Dict[int, Record] = {}
+    for item in step.get("items", []) if isinstance(step.get("items"), list) else []:
+        target = headwords.get(item_reference(item).lower())
+        if target:
+            used[id(target)] = target
+    return used
+
+
+def step_vocab_uses(step: Dict[str, Any], headwords: Dict[str, Record]) -> Dict[int, Record]:
+    """Vocabulary a step uses, via item references or headwords in Spanish text."""
+    used = step_item_refs(step, headwords)
+    text = " " + " ".join(" ".join(words(value)) for _, value in spanish_texts(step)) + " "
+    for headword, target in headwords.items():
+        if f" {headword} " in text:
+            used[id(target)] = target
+    return used
+
+
+def rule_headwords(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag vocabulary headwords written against the headword rules (case, trailing punctuation, leading article);
+    all-capitals headwords and articles that disagree with the entry's are info."""
+    rules = load_headword_rules(config.get("headwords"))
+    findings: List[Finding] = []
+    for record in dataset.vocab:
+        for fix in normalize_headword(record.data, rules):
+            severity = "info" if fix.ambiguous else "warning"
+            findings.append(Finding("headwords", severity, label(record), f"{fix.path}: '{fix.found}' should probably be '{fix.suggestion}' ({fix.reason})"))
+    return findings
+
+
+def rule_intro_order(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag lessons using vocabulary before the lesson that introduces it, and vocabulary no lesson introduces.
+
+    A lesson introduces words through its optional `introduces` list, a vocabulary entry may instead name its lesson in
+    `intro_lesson`, and otherwise the first lesson presenting a word (config lesson_flow) introduces it.
+    """
+    scheme = load_id_scheme(config.get("ids"))
+    flow = {**DEFAULT_LESSON_FLOW, **config.get("lesson_flow", {})}
+    lessons = {record_id(record, scheme): record for record in reversed(dataset.lessons)}
+    problems = intro_order_problems([entry_for(record, scheme) for record in dataset.lessons], [entry_for(record, scheme) for record in dataset.vocab], flow["presentation"])
+    return [Finding("intro-order", "warning", label(lessons[lesson_id]), problem) for lesson_id, problem in problems]
+
+
+def rule_lesson_flow(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag vocabulary a lesson's presentation steps list in their items but no practice step of the lesson uses.
+
+    Which phases present and which practice comes from config lesson_flow; a lesson with no practice steps at all
+    is reported once rather than word by word.
+    """
+    flow = {**DEFAULT_LESSON_FLOW, **config.get("lesson_flow", {})}
+    presentation, practice = set(flow["presentation"]), set(flow["practice"])
+    headwords = headword_index(dataset)
+    findings: List[Finding] = []
+    for lesson in dataset.lessons:
+        steps = [step for step in lesson.data.get("steps", []) if isinstance(step, dict)] if isinstance(lesson.data.get("steps"), list) else []
+        presented: Dict[int, Record] = {}
+        for step in steps:
+            if step.get("phase") in presentation:
+                presented.update(step_item_refs(step, headwords))
+        if not presented:
+            continue
+        practice_steps = [step for step in steps if step.get("phase") in practice]
+        if not practice_steps:
+            findings.append(Finding("lesson-flow", "warning", label(lesson), f"presents vocabulary but has no practice step ({', '.join(sorted(practice))})"))
+            continue
+        practiced: Dict[int, Record] = {}
+        for step in practice_steps:
+            practiced.update(step_vocab_uses(step, headwords))
+        for key, target in presented.items():
+            if key not in practiced:
+                findings.append(Finding("lesson-flow", "warning", label(lesson), f"presents '{target.data.get('spanish')}' but no practice step uses it"))
+    return findings
+
+
+def rule_plural(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag noun plurals that disagree with the generated plural; config plural_exceptions lists accepted ones."""
+    accepted = {str(k).lower(): str(v).lower() for k, v in config.get("plural_exceptions", {}).items()}
+    findings: List[Finding] = []
+    for record in dataset.vocab:
+        plural = record.data.get("plural")
+        headword = record.data.get("spanish")
+        if record.data.get("pos") != "noun" or not isinstance(plural, str) or not isinstance(headword, str):
+            continue
+        expected = pluralize(headword)
+        if plural.strip().lower() not in (expected.lower(), accepted.get(headword.strip().lower())):
+            findings.append(Finding("plural", "warning", label(record), f"plural '{plural}' differs from generated '{expected}'"))
+    return findings
+
+
+def rule_accents(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag Spanish words missing an obligatory accent; ambiguous ones (esta/está) are reported as info."""
+    dictionary = load_accent_dictionary(config.get("accents"))
+    findings: List[Finding] = []
+    for record in dataset.vocab + dataset.lessons:
+        for fix in restore_accents(record.data, dictionary):
+            severity = "info" if fix.ambiguous else "warning"
+            hint = " (ambiguous, check context)" if fix.ambiguous else ""
+            findings.append(Finding("accents", severity, label(record), f"{fix.path}: '{fix.word}' should probably be '{fix.suggestion}'{hint}"))
+    return findings
+
+
+def rule_numerals(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag numbers, dates, and times written against the numerals style for the entry's level, and numbers
+    an entry writes both as digits and as words; undecidable ones (un/una, month-first dates) are info."""
+    style = load_numeral_style(config.get("numerals"))
+    findings: List[Finding] = []
+    for record in dataset.vocab + dataset.lessons:
+        for fix in normalize_numerals(record.data, style, entry_level(record.data)):
+            severity = "info" if fix.ambiguous else "warning"
+            hint = " (ambiguous, check context)" if fix.ambiguous else ""
+            findings.append(Finding("numerals", severity, label(record), f"{fix.path}: '{fix.found}' should probably be '{fix.suggestion}' ({fix.reason}){hint}"))
+        for mixed in mixed_numerals(record.data):
+            findings.append(Finding("numerals", "warning", label(record), f"{mixed.number} is written as digits in {', '.join(mixed.digits)} and spelled out in {', '.join(mixed.words)}"))
+    return findings
+
+
+def rule_schema(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Report every schema, custom-rule, and locale issue per entry (not just the first), with the validator's rule ID and severity."""
+    schemas = load_schemas()
+    rules = load_rules(config.get("rules"))
+    locales = load_locales(config.get("locales"))
+    scheme = load_id_scheme(config.get("ids"))
+    findings: List[Finding] = []
+    for record in dataset.vocab + dataset.lessons + dataset.readings + dataset.units + dataset.culture_notes + dataset.pron_drills:
+        for issue in validate_all(entry_for(record, scheme), schemas[record.kind], rules_for(rules, record.kind), locales=locales_for(locales, record.kind)):
+            findings.append(Finding(issue.rule, issue.severity, label(record), f"{issue.path}: {issue.message}"))
+    return findings
+
+
+def rule_skipped_files(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag files collection skipped as not text JSON: binary, too large, or nested too deep."""
+    return [Finding("skipped-files", "warning", source, reason) for source, reason in sorted(dataset.skipped.items())]
+
+
+def rule_step_media(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag lesson step media of an unknown type, with the wrong extension or host, or missing under the assets directory."""
+    scheme = load_id_scheme(config.get("ids"))
+    lessons = {record_id(record, scheme): record for record in dataset.lessons}
+    findings: List[Finding] = []
+    for target, problem in check_step_media([entry_for(record, scheme) for record in dataset.lessons], load_media_types(config.get("media")), Path(config.get("assets_dir") or ASSETS_DIR)):
+        findings.append(Finding("step-media", "warning", label(lessons[target]), problem))
+    return findings
+
+
+def rule_study_time(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag lessons whose estimated completion time exceeds the study-time cap, and authored minutes far from the estimate."""
+    study_time = load_study_time(config.get("study_time"))
+    findings: List[Finding] = []
+    for record in dataset.lessons:
+        estimate = estimated_minutes(record.data, study_time)
+        if study_time.cap_minutes is not None and estimate > study_time.cap_minutes:
+            findings.append(Finding("study-time", "warning", label(record), f"estimated {estimate} minutes exceeds the {study_time.cap_minutes:g}-minute cap; consider splitting the lesson"))
+        authored = record.data.get("minutes")
+        if isinstance(authored, (int, float)) and authored > 0 and not estimate / 2 <= authored <= estimate * 2:
+            findings.append(Finding("study-time", "info", label(record), f"minutes says {authored:g} but the steps suggest about {estimate}"))
+    return findings
+
+
+def rule_syllables(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag hand-written syllables/stress_index that do not spell the headword or disagree with the computed split."""
+    findings: List[Finding] = []
+    for record in dataset.vocab:
+        for problem in check_pronunciation(record.data):
+            findings.append(Finding("syllables", "warning", label(record), problem))
+    return findings
+
+
+def rule_culture_notes(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag culture notes listing lessons that do not exist, and local media missing under the assets directory."""
+    scheme = load_id_scheme(config.get("ids"))
+    notes = {record_id(record, scheme): record for record in dataset.culture_notes}
+    lessons = [entry_for(record, scheme) for record in dataset.lessons]
+    findings: List[Finding] = []
+    for target, problem in check_culture_notes([entry_for(record, scheme) for record in dataset.culture_notes], lessons, Path(config.get("assets_dir") or ASSETS_DIR)):
+        findings.append(Finding("culture-notes", "warning", label(notes[target]), problem))
+    return findings
+
+
+def rule_pron_drills(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag drill words without IPA or whose IPA lacks the drilled phonemes, and local audio missing under the audio directory."""
+    scheme = load_id_scheme(config.get("ids"))
+    drills = {record_id(record, scheme): record for record in dataset.pron_drills}
+    findings: List[Finding] = []
+    for target, problem in check_drills([entry_for(record, scheme) for record in dataset.pron_drills], Path(config.get("audio_dir") or AUDIO_DIR)):
+        findings.append(Finding("pron-drills", "warning", label(drills[target]), problem))
+    return findings
+
+
+def rule_owners(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag owner/reviewers fields naming people the owners config does not know, or an owner outside the entry's path owners."""
+    owners = load_owners(config.get("owners"))
+    findings: List[Finding] = []
+    for record in dataset.vocab + dataset.lessons + dataset.readings + dataset.units + dataset.culture_notes + dataset.pron_drills:
+        for problem in check_ownership(record.data, record.source, owners):
+            findings.append(Finding("owners", "warning", label(record), problem))
+    return findings
+
+
+def rule_pos_usage(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag noun, verb, and adjective entries whose examples only use the headword as another part of speech,
+    most confident first; below config pos_usage_confidence (default 0.6) the finding is info."""
+    bank = load_form_bank(config.get("forms_bank") or DEFAULT_FORMS_BANK)
+    threshold = float(config.get("pos_usage_confidence", 0.6))
+    ranked = []
+    for record in dataset.vocab:
+        lemma = str(record.data.get("spanish", "")).strip().lower()
+        extra = {str(record.data.get("pos")): {row["form"].strip().lower() for row in bank if str(row.get("lemma", "")).strip().lower() == lemma and row.get("form", "").strip()}}
+        examples = [(path, example["es"]) for path, example in iter_examples(record.data) if isinstance(example.get("es"), str) and example["es"].strip()]
+        mismatch = check_usage(record.data, examples, extra)
+        if mismatch:
+            ranked.append((mismatch, record))
+    findings: List[Finding] = []
+    for mismatch, record in sorted(ranked, key=lambda item: -item[0].confidence):
+        path, text, tag = mismatch.examples[0]
+        evidence = f"{path} '{text}': {'; '.join(tag.cues)}"
+        severity = "warning" if mismatch.confidence >= threshold else "info"
+        findings.append(Finding("pos-usage", severity, label(record), f"declared {mismatch.declared}, but its examples use it as {POS_NAMES[mismatch.used_as]} (confidence {mismatch.confidence:.2f}); {evidence}"))
+    return findings
+
+
+def rule_reading_refs(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag glossed reading words whose vocab reference matches no vocabulary entry, and chapters without comprehension questions."""
+    scheme = load_id_scheme(config.get("ids"))
+    vocab_index = index_vocab([entry_for(record, scheme) for record in dataset.vocab])
+    findings: List[Finding] = []
+    for record in dataset.readings:
+        for ref in record.data.get("glossed") or []:
+            if lookup(ref, vocab_index) is None:
+                form = ref.get("form") if isinstance(ref, dict) else ref
+                findings.append(Finding("reading-refs", "warning", label(record), f"glossed '{form}' refers to unknown vocabulary {ref.get('vocab_id') if isinstance(ref, dict) else ref}"))
+        if not record.data.get("questions"):
+            findings.append(Finding("reading-refs", "info", label(record), "chapter has no comprehension questions"))
+    return findings
+
+
+def rule_references(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag cross-entry references that name no entry of the kind they expect, at each reference type's severity."""
+    scheme = load_id_scheme(config.get("ids"))
+    kinds = {"lesson": dataset.lessons, "vocab": dataset.vocab, "reading": dataset.readings, "unit": dataset.units, "culture_note": dataset.culture_notes, "pron_drill": dataset.pron_drills}
+    records = {record_id(record, scheme): record for items in kinds.values() for record in items}
+    _, dangling = resolve_references({kind: [entry_for(record, scheme) for record in items] for kind, items in kinds.items()}, load_reference_types(config.get("references")))
+    return [Finding("references", item.type.severity, label(records[item.source]) if item.source in records else item.source, f"{item.path} ({item.type.name}): {item.ref} {item.reason}") for item in dangling]
+
+
+def rule_relations(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag synonyms and antonyms matching no vocabulary entry, and links listed on one side only."""
+    scheme = load_id_scheme(config.get("ids"))
+    records = {record_id(record, scheme): record for record in dataset.vocab}
+    vocab = [entry_for(record, scheme) for record in dataset.vocab]
+    problems = resolve_relations(vocab, index_vocab(vocab))
+    problems += asymmetric_relations(vocab)
+    return [Finding("relations", "warning", label(records[target]), problem) for target, problem in problems]
+
+
+def rule_units(dataset: Dataset, config: Dict[str, Any]) -> List[Finding]:
+    """Flag gaps and repeats in unit and lesson numbering, and unit lesson lists that disagree with the lessons' own unit."""
+    scheme = load_id_scheme(config.get("ids"))
+    units = {record_id(record, scheme): record for record in dataset.units}
+    lessons = [entry_for(record, scheme) for record in dataset.lessons]
+    findings: List[Finding] = []
+    for target, problem in check_units(build_units([entry_for(record, scheme) for record in dataset.units], lessons, scheme), lessons):
+        findings.append(Finding("units", "warning", label(units[target]) if target in units else target, problem))
+    return findings
+
+
+RULES: Dict[str, Callable[[Dataset, Dict[str, Any]], List[Finding]]] = {
+    "accents": rule_accents,
+    "alignment": rule_alignment,
+    "culture-notes": rule_culture_notes,
+    "example-headword": rule_example_headword,
+    "gloss-consistency": rule_gloss_consistency,
+    "headwords": rule_headwords,
+    "intro-order": rule_intro_order,
+    "lesson-flow": rule_lesson_flow,
+    "numerals": rule_numerals,
+    "owners": rule_owners,
+    "plural": rule_plural,
+    "pos-usage": rule_pos_usage,
+    "pron-drills": rule_pron_drills,
+    "reading-refs": rule_reading_refs,
+    "references": rule_references,
+    "relations": rule_relations,
+    "schema": rule_schema,
+    "second-person": rule_second_person,
+    "skipped-files": rule_skipped_files,
+    "step-media": rule_step_media,
+    "study-time": rule_study_time,
+    "syllables": rule_syllables,
+    "units": rule_units,
+}
+
+
+def load_lint_config(path: str) -> Dict[str, Any]:
+    cfg_path = Path(path)
+    return load_json(cfg_path) if cfg_path.exists() else {}
+
+
+def finding_records(dataset: Dataset) -> Dict[str, Record]:
+    """Every rule targets records through label(), so findings map back to their record by target."""
+    return {label(record): record for record in dataset.vocab + dataset.lessons + dataset.readings + dataset.units + dataset.culture_notes + dataset.pron_drills}
+
+
+def strict_failures(dataset: Dataset, findings: List[Finding], config: Dict[str, Any]) -> List[StrictFailure]:
+    scheme = load_id_scheme(config.get("ids"))
+    records = finding_records(dataset)
+    failures: List[StrictFailure] = []
+    for f in findings:
+        if f.severity == "info":
+            continue
+        record = records.get(f.target)
+        if record:
+            failures.append(StrictFailure(f.rule, record.source, f.message, record_id(record, scheme), record.index, f.severity))
+        else:
+            failures.append(StrictFailure(f.rule, f.target.split("#", 1)[0], f.message, severity=f.severity))
+    return failures
+
+
+def owner_report(dataset: Dataset, findings: List[Finding], owners_path: str) -> List[str]:
+    """Rejects and findings grouped under the entry's owner, or its path owner, so cleanup can be assigned."""
+    owners = load_owners(owners_path)
+    by_target = finding_records(dataset)
+    groups: Dict[str, List[str]] = {}
+    for reject in collect_rejects(dataset):
+        owner = entry_owner(reject.record if isinstance(reject.record, dict) else {}, reject.source, owners)
+        groups.setdefault(owner, []).append(f"- [reject] {reject.source}: {reject.reason}")
+    for f in findings:
+        record = by_target.get(f.target)
+        owner = entry_owner(record.data, record.source, owners) if record else entry_owner({}, f.target.split("#", 1)[0], owners)
+        groups.setdefault(owner, []).append(f"- [{f.severity}] {f.rule} {f.target}: {f.message}")
+    out = ["Cleanup by owner", f"- owners: {len(groups)}", f"- items: {sum(len(items) for items in groups.values())}"]
+    # Largest queues first; unowned work goes last so it stands out as needing an owner rule.
+    for owner, items in sorted(groups.items(), key=lambda kv: (kv[0] == UNOWNED, -len(kv[1]), kv[0])):
+        out.append(f"## {owner} ({len(items)})")
+        out += items
+    return out
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Run content lint rules over lessons and vocabulary.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to scan")
+    parser.add_argument("--config", default=str(DEFAULT_LINT_PATH), help="Path to the lint config")
+    parser.add_argument("--rule", action="append", choices=sorted(RULES), help="Run only this rule (repeatable)")
+    parser.add_argument("--dialect", help="Override the target dialect from the lint config")
+    parser.add_argument("--strict", action="store_true", help="Exit non-zero when any warning or error is reported (info findings never fail)")
+    parser.add_argument("--owners", default=str(DEFAULT_OWNERS_PATH), help="Path to the owners config mapping content paths to people")
+    parser.add_argument("--by-owner", action="store_true", help="Also write owners.md grouping rejects and findings by owner")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    config = load_lint_config(args.config)
+    if args.dialect:
+        config["dialect"] = args.dialect
+    try:
+        check_dialect(config)
+    except ConfigError as exc:
+        parser.error(str(exc))
+    config["owners"] = args.owners
+    dataset = collect(args.content)
+
+    findings: List[Finding] = []
+    for name in args.rule or sorted(RULES):
+        findings.extend(RULES[name](dataset, config))
+
+    out = ["Content lint", f"- findings: {len(findings)}"]
+    if findings:
+        out.append("## findings")
+        out += [f"- [{f.severity}] {f.rule} {f.target}: {f.message}" for f in findings]
+    write_report("lint.md", out)
+    if args.by_owner:
+        path = write_report("owners.md", owner_report(dataset, findings, config["owners"]))
+        out.append(f"Findings by owner written to {path}")
+    print("\n".join(out))
+    return fail_strict("lint", strict_failures(dataset, findings, config)) if args.strict else 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
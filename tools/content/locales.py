@@ -0,0 +1,164 @@
+"""Instruction text in the learner's language: per-locale variants beside the English original.
+
+Lesson step lines and notes are written in English. A translated field keeps the English in place and adds
+a sibling map named after it, `line_i18n: {"es": "...", "pt": "..."}`; the plain field counts as the default
+locale. config/locales.json lists the locales a map may use, which fields are instruction text, and which
+locales every one of those fields must have; validation reports each missing one at the configured severity.
+An export profile with `"locale": "pt"` ships one language: each field takes its variant (English when there
+is none) and the maps are left out.
+"""
+
+from __future__ import annotations
+
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional, Tuple, Union
+
+try:
+    from .common import CLASSIFY_NEEDS, CONFIG_DIR, load_json
+    from .errors import ConfigError
+    from .references import walk
+    from .rules import SEVERITIES
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CLASSIFY_NEEDS, CONFIG_DIR, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from references import walk  # type: ignore
+    from rules import SEVERITIES  # type: ignore
+
+DEFAULT_LOCALES_PATH = CONFIG_DIR / "locales.json"
+I18N_SUFFIX = "_i18n"
+DEFAULT_INSTRUCTION_FIELDS = {"lesson": ["steps[].line", "steps[].wrap_line", "notes"]}
+
+
+@dataclass
+class LocaleConfig:
+    default: str = "en"
+    supported: List[str] = field(default_factory=lambda: ["en"])
+    required: List[str] = field(default_factory=list)
+    severity: str = "error"
+    # kind -> field paths holding instruction text ("steps[].line" reaches every step).
+    fields: Dict[str, List[str]] = field(default_factory=lambda: {kind: list(paths) for kind, paths in DEFAULT_INSTRUCTION_FIELDS.items()})
+
+
+@dataclass
+class LocaleCheck:
+    """What validation checks for one kind: its instruction fields, against the configured locales."""
+
+    paths: List[str]
+    config: LocaleConfig
+
+
+@dataclass
+class LocaleIssue:
+    rule: str
+    path: str
+    message: str
+
+
+def load_locales(path: Optional[Union[str, Path]] = None) -> LocaleConfig:
+    """The locale settings in path; no file means English only, with nothing required."""
+    cfg_path = Path(path) if path else DEFAULT_LOCALES_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    config = LocaleConfig()
+    config.default = str(data.get("default", config.default))
+    config.supported = [str(locale) for locale in data.get("supported", [config.default])]
+    config.required = [str(locale) for locale in data.get("required", [])]
+    config.severity = str(data.get("severity", config.severity))
+    config.fields = {kind: [str(path) for path in paths] for kind, paths in data.get("fields", config.fields).items()}
+    if config.default not in config.supported:
+        config.supported.insert(0, config.default)
+    unknown = [locale for locale in config.required if locale not in config.supported]
+    if unknown:
+        raise ConfigError(f"locales: required locale {', '.join(unknown)} is not in supported", "required", unknown, config.supported)
+    if config.severity not in SEVERITIES:
+        raise ConfigError(f"locales: unknown severity '{config.severity}'", "severity", config.severity, list(SEVERITIES))
+    for kind in config.fields:
+        if kind not in CLASSIFY_NEEDS:
+            raise ConfigError(f"locales: unknown kind '{kind}'", f"fields.{kind}", kind, sorted(CLASSIFY_NEEDS))
+    return config
+
+
+def locales_for(config: LocaleConfig, kind: str) -> Optional[LocaleCheck]:
+    """The check for kind, or None when it has no instruction fields."""
+    return LocaleCheck(config.fields[kind], config) if config.fields.get(kind) else None
+
+
+def i18n_path(path: str) -> str:
+    """steps[2].line -> steps[2].line_i18n"""
+    return path + I18N_SUFFIX
+
+
+def variants_at(entry: Dict[str, Any], path: str) -> Iterator[Tuple[str, Dict[str, Any]]]:
+    """(concrete path, locale -> text) for each value a field path reaches; the plain text is the default locale."""
+    parent_path, _, name = path.rpartition(".")
+    parents = walk(entry, parent_path) if parent_path else iter([("", entry)])
+    for where, parent in parents:
+        if not isinstance(parent, dict) or (name not in parent and i18n_path(name) not in parent):
+            continue
+        variants = parent.get(i18n_path(name))
+        yield (f"{where}.{name}" if where else name), dict(variants) if isinstance(variants, dict) else {}
+
+
+def locale_issues(entry: Dict[str, Any], check: LocaleCheck) -> List[LocaleIssue]:
+    """Missing required locales, locales not in supported, and variants that are not text, for one entry."""
+    config = check.config
+    issues: List[LocaleIssue] = []
+    for path in check.paths:
+        for where, variants in variants_at(entry, path):
+            plain = where.rsplit(".", 1)[-1]
+            stated = {locale for locale, text in variants.items() if isinstance(text, str) and text.strip()}
+            plain_value = resolve(entry, where)
+            if isinstance(plain_value, str) and plain_value.strip():
+                stated.add(config.default)
+            missing = [locale for locale in config.required if locale not in stated]
+            if missing:
+                issues.append(LocaleIssue("locale.missing", where, f"no {', '.join(missing)} text (add it to {plain}{I18N_SUFFIX})"))
+            unknown = sorted(locale for locale in variants if locale not in config.supported)
+            if unknown:
+                issues.append(LocaleIssue("locale.unsupported", i18n_path(where), f"locale {', '.join(unknown)} is not in supported ({', '.join(config.supported)})"))
+            blank = sorted(locale for locale, text in variants.items() if locale not in unknown and not (isinstance(text, str) and text.strip()))
+            if blank:
+                issues.append(LocaleIssue("locale.empty", i18n_path(where), f"{', '.join(blank)} variant is empty or not text"))
+    return issues
+
+
+def resolve(value: Any, path: str) -> Any:
+    """The value at a concrete path such as steps[2].line; None when it is not there."""
+    for part in path.split("."):
+        key, _, index = part.partition("[")
+        value = value.get(key) if isinstance(value, dict) else None
+        if index:
+            position = int(index.rstrip("]"))
+            value = value[position] if isinstance(value, list) and position < len(value) else None
+    return value
+
+
+def localize(value: Any, locale: str) -> Tuple[Any, int]:
+    """(copy of value with every *_i18n map folded into its field in locale, fields that fell back to the original)."""
+    if isinstance(value, list):
+        fallbacks = 0
+        items = []
+        for item in value:
+            item, missed = localize(item, locale)
+            items.append(item)
+            fallbacks += missed
+        return items, fallbacks
+    if not isinstance(value, dict):
+        return value, 0
+    out: Dict[str, Any] = {}
+    fallbacks = 0
+    for key, item in value.items():
+        if key.endswith(I18N_SUFFIX) and isinstance(item, dict):
+            continue
+        out[key], missed = localize(item, locale)
+        fallbacks += missed
+    for key, item in value.items():
+        if not key.endswith(I18N_SUFFIX) or not isinstance(item, dict):
+            continue
+        name = key[: -len(I18N_SUFFIX)]
+        text = item.get(locale)
+        if isinstance(text, str) and text.strip():
+            out[name] = text
+        elif name in out:
+            fallbacks += 1
+    return out, fallbacks
@@ -0,0 +1,382 @@
+#!/usr/bin/env python3
+"""Check content files as authors write them: a language server for editors, speaking JSON-RPC over stdio.
+
+The server uses the Language Server Protocol's framing (Content-Length headers) and its document
+notifications. An editor extension starts it once and sends didOpen, didChange, and didSave. On open and
+save (and on every change with --on-change) it publishes diagnostics for the file:
+- JSON that does not parse;
+- keys repeated within an object (JSON keeps only the last one);
+- records that match no kind;
+- every schema and custom-rule issue, as validate.py reports them;
+- misspelled field names;
+- Spanish missing an accent or writing a number against config/numerals.json.
+
+Field renames and unambiguous accent and numeral fixes come back as quick fixes (textDocument/codeAction).
+The custom request content/classify previews, per record, the kind, ID, and range collect would give it.
+"""
+
+from __future__ import annotations
+
+import argparse
+import json
+import re
+import sys
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, BinaryIO, Dict, Iterable, List, Optional, Tuple
+from urllib.parse import unquote, urlparse
+
+try:
+    from .common import CLASSIFY_NEEDS, DEFAULT_IDS_PATH, UNIT_NAME, Record, classify, display_path, entry_level, is_pinned, load_id_scheme, record_id, skip_separators
+    from .accents import DEFAULT_ACCENTS_PATH, load_accent_dictionary, restore_accents
+    from .console import add_output_arguments, configure_output
+    from .fieldnames import CORRECTIONS_KEY, correct_fields
+    from .numerals import DEFAULT_NUMERALS_PATH, load_numeral_style, normalize_numerals
+    from .quarantine import suggest_kind
+    from .rules import DEFAULT_RULES_PATH, load_rules, rules_for
+    from .validate import SCHEMAS_DIR, entry_for, load_schemas, validate_all
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CLASSIFY_NEEDS, DEFAULT_IDS_PATH, UNIT_NAME, Record, classify, display_path, entry_level, is_pinned, load_id_scheme, record_id, skip_separators  # type: ignore
+    from accents import DEFAULT_ACCENTS_PATH, load_accent_dictionary, restore_accents  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from fieldnames import CORRECTIONS_KEY, correct_fields  # type: ignore
+    from numerals import DEFAULT_NUMERALS_PATH, load_numeral_style, normalize_numerals  # type: ignore
+    from quarantine import suggest_kind  # type: ignore
+    from rules import DEFAULT_RULES_PATH, load_rules, rules_for  # type: ignore
+    from validate import SCHEMAS_DIR, entry_for, load_schemas, validate_all  # type: ignore
+
+# LSP DiagnosticSeverity and TextDocumentSyncKind.Full.
+SEVERITY_CODES = {"error": 1, "warning": 2, "info": 3}
+FULL_SYNC = 1
+# JSON-RPC error codes.
+METHOD_NOT_FOUND = -32601
+INVALID_REQUEST = -32600
+PARSE_ERROR = -32700
+SPANISH_WORD = r"[\wÁÉÍÓÚÜÑáéíóúüñ]"
+JSON_STRING = re.compile(r'"(?:[^"\\]|\\.)*"')
+
+
+@dataclass
+class Span:
+    """A top-level record: where its text starts and ends, and the decoded value."""
+
+    start: int
+    end: int
+    value: Any
+
+
+@dataclass
+class Fix:
+    title: str
+    start: int
+    end: int
+    new_text: str
+
+
+@dataclass
+class Diagnostic:
+    start: int
+    end: int
+    severity: str
+    rule: str
+    message: str
+    fixes: List[Fix] = field(default_factory=list)
+
+
+@dataclass
+class Checker:
+    """Everything a check needs, loaded once when the server starts."""
+
+    schemas: Dict[str, Dict[str, Any]]
+    rules: List[Any]
+    scheme: Any
+    accents: Any
+    numerals: Any
+
+
+def locate_records(text: str) -> Tuple[List[Span], Optional[Tuple[int, str]]]:
+    """The records in a file, read the way decode_objects reads them (one value, JSON lines, or a top-level
+    array), with their offsets; plus (offset, message) for the first place parsing fails."""
+    decoder = json.JSONDecoder()
+    spans: List[Span] = []
+    idx = skip_separators(text, 0)
+    try:
+        while idx < len(text):
+            if text[idx] != "[":
+                value, end = decoder.raw_decode(text, idx)
+                spans.append(Span(idx, end, value))
+                idx = skip_separators(text, end)
+                continue
+            idx = skip_separators(text, idx + 1)
+            while idx < len(text) and text[idx] != "]":
+                value, end = decoder.raw_decode(text, idx)
+                spans.append(Span(idx, end, value))
+                idx = skip_separators(text, end)
+            if idx >= len(text):
+                return spans, (idx, "unterminated top-level array")
+            idx = skip_separators(text, idx + 1)
+    except json.JSONDecodeError as exc:
+        return spans, (exc.pos, exc.msg)
+    return spans, None
+
+
+def locate_key(text: str, span: Span, path: str) -> Tuple[int, int]:
+    """The range of the deepest key along path found inside the record, or the record's opening brace."""
+    found = (span.start, span.start + 1)
+    offset = span.start
+    for name in re.findall(r"[^.\[\]]+", path):
+        if name.isdigit():
+            continue
+        match = re.compile(rf'"{re.escape(name)}"\s*:').search(text, offset, span.end)
+        if not match:
+            break
+        found = (match.start(), match.start() + len(name) + 2)
+        offset = match.end()
+    return found
+
+
+def duplicate_keys(span: Span, text: str) -> List[Diagnostic]:
+    """Keys written twice in one object; the repeat is flagged, since it is the value that wins."""
+    found: List[Diagnostic] = []
+    # One entry per open container: the keys seen so far in an object, None for an array.
+    stack: List[Optional[set]] = []
+    expect_key = False
+    idx = span.start
+    while idx < span.end:
+        char = text[idx]
+        if char == '"':
+            end = JSON_STRING.match(text, idx).end()
+            if expect_key and stack and stack[-1] is not None:
+                key = json.loads(text[idx:end])
+                if key in stack[-1]:
+                    found.append(Diagnostic(idx, end, "warning", "duplicate-key", f"'{key}' is repeated in this object; only the last value is kept"))
+                stack[-1].add(key)
+                expect_key = False
+            idx = end
+            continue
+        if char in "{[":
+            stack.append(set() if char == "{" else None)
+            expect_key = char == "{"
+        elif char in "}]":
+            stack.pop()
+        elif char == ",":
+            expect_key = bool(stack) and stack[-1] is not None
+        idx += 1
+    return found
+
+
+def text_fix(text: str, span: Span, path: str, found: str, suggestion: str) -> Optional[Fix]:
+    """Replace found inside the string at path, as a whole word."""
+    _, key_end = locate_key(text, span, path)
+    match = re.compile(rf"(?<!{SPANISH_WORD}){re.escape(found)}(?!{SPANISH_WORD})").search(text, key_end, span.end)
+    return Fix(f"Change '{found}' to '{suggestion}'", match.start(), match.end(), suggestion) if match else None
+
+
+def check_record(text: str, span: Span, source: str, index: int, checker: Checker) -> List[Diagnostic]:
+    found = duplicate_keys(span, text)
+    data = span.value
+    if not isinstance(data, dict):
+        return found + [Diagnostic(span.start, span.end, "error", "classify", "a record must be a JSON object")]
+    fixed = data if is_pinned(data) else correct_fields(data)
+    for typo, name in fixed.get(CORRECTIONS_KEY, {}).items() if fixed is not data else ():
+        start, end = locate_key(text, span, typo)
+        found.append(Diagnostic(start, end, "warning", "field-name", f"'{typo}' is not a field; probably '{name}'", [Fix(f"Rename to '{name}'", start, end, json.dumps(name))]))
+    kind = "unit" if source.rsplit("/", 1)[-1] == UNIT_NAME else classify(fixed)
+    if kind is None:
+        suggestion = suggest_kind(fixed, checker.schemas)
+        hint = f"; it looks like a {suggestion.kind} missing {', '.join(suggestion.missing) or 'nothing required'}" if suggestion else ""
+        return found + [Diagnostic(span.start, span.start + 1, "warning", "classify", f"this record matches no kind and will not be collected{hint}")]
+    entry = entry_for(Record(kind=kind, data=fixed, source=source, index=index), checker.scheme)
+    for issue in validate_all(entry, checker.schemas[kind], rules_for(checker.rules, kind)):
+        start, end = locate_key(text, span, issue.path)
+        found.append(Diagnostic(start, end, issue.severity, issue.rule, f"{issue.path}: {issue.message}" if issue.path else issue.message))
+    suggestions = [(fix.path, fix.word, fix.suggestion, fix.ambiguous, "accents", "") for fix in restore_accents(data, checker.accents)]
+    suggestions += [(fix.path, fix.found, fix.suggestion, fix.ambiguous, "numerals", f" ({fix.reason})") for fix in normalize_numerals(data, checker.numerals, entry_level(data))]
+    for path, word, suggestion, ambiguous, rule, reason in suggestions:
+        quick = None if ambiguous else text_fix(text, span, path, word, suggestion)
+        start, end = (quick.start, quick.end) if quick else locate_key(text, span, path)
+        hint = " (ambiguous, check context)" if ambiguous else ""
+        found.append(Diagnostic(start, end, "info" if ambiguous else "warning", rule, f"'{word}' should probably be '{suggestion}'{reason}{hint}", [quick] if quick else []))
+    return found
+
+
+def check_text(text: str, source: str, checker: Checker) -> List[Diagnostic]:
+    """Every diagnostic for one file's text."""
+    spans, failure = locate_records(text)
+    found: List[Diagnostic] = []
+    for index, span in enumerate(spans):
+        found += check_record(text, span, source, index, checker)
+    if failure:
+        offset, message = failure
+        found.append(Diagnostic(offset, min(offset + 1, len(text)), "error", "json", f"{message}; nothing after this point is read"))
+    return found
+
+
+def classify_preview(text: str, source: str, checker: Checker) -> List[Dict[str, Any]]:
+    spans, _ = locate_records(text)
+    preview: List[Dict[str, Any]] = []
+    for index, span in enumerate(spans):
+        data = span.value if isinstance(span.value, dict) else {"value": span.value}
+        fixed = data if is_pinned(data) else correct_fields(data)
+        kind = "unit" if source.rsplit("/", 1)[-1] == UNIT_NAME and isinstance(span.value, dict) else classify(fixed)
+        row: Dict[str, Any] = {"index": index, "range": to_range(text, span.start, span.end), "kind": kind}
+        if kind:
+            row["id"] = record_id(Record(kind=kind, data=fixed, source=source, index=index), checker.scheme)
+        else:
+            suggestion = suggest_kind(fixed, checker.schemas) if isinstance(span.value, dict) else None
+            row["suggested_kind"] = suggestion.kind if suggestion else None
+            row["needs"] = {name: CLASSIFY_NEEDS[name] for name in sorted(CLASSIFY_NEEDS)} if not suggestion else {suggestion.kind: CLASSIFY_NEEDS[suggestion.kind]}
+        preview.append(row)
+    return preview
+
+
+def to_position(text: str, offset: int) -> Dict[str, int]:
+    """An LSP position: zero-based line, and character counted in UTF-16 code units as the protocol requires."""
+    line_start = text.rfind("\n", 0, offset) + 1
+    return {"line": text.count("\n", 0, offset), "character": len(text[line_start:offset].encode("utf-16-le")) // 2}
+
+
+def to_range(text: str, start: int, end: int) -> Dict[str, Dict[str, int]]:
+    return {"start": to_position(text, start), "end": to_position(text, end)}
+
+
+def overlaps(text: str, item: Diagnostic, wanted: Dict[str, Any]) -> bool:
+    got = to_range(text, item.start, item.end)
+    key = lambda pos: (pos["line"], pos["character"])  # noqa: E731 - tiny sort key
+    return key(got["start"]) <= key(wanted["end"]) and key(wanted["start"]) <= key(got["end"])
+
+
+def uri_source(uri: str) -> str:
+    """The source name collect would give the file (its repo-relative path), so IDs and unit files match."""
+    parsed = urlparse(uri)
+    return display_path(Path(unquote(parsed.path))) if parsed.scheme == "file" else uri
+
+
+def read_message(stream: BinaryIO) -> Optional[Dict[str, Any]]:
+    headers: Dict[str, str] = {}
+    while True:
+        line = stream.readline()
+        if not line:
+            return None
+        line = line.decode("ascii", errors="replace").strip()
+        if not line:
+            break
+        name, _, value = line.partition(":")
+        headers[name.strip().lower()] = value.strip()
+    body = stream.read(int(headers.get("content-length", "0")))
+    return json.loads(body.decode("utf-8"))
+
+
+def write_message(stream: BinaryIO, payload: Dict[str, Any]) -> None:
+    body = json.dumps(payload, ensure_ascii=False).encode("utf-8")
+    stream.write(f"Content-Length: {len(body)}\r\n\r\n".encode("ascii") + body)
+    stream.flush()
+
+
+class Server:
+    """Open documents and the requests and notifications the editor sends about them."""
+
+    def __init__(self, checker: Checker, output: BinaryIO, on_change: bool = False) -> None:
+        self.checker = checker
+        self.output = output
+        self.on_change = on_change
+        self.documents: Dict[str, str] = {}
+        self.shutdown = False
+
+    def publish(self, uri: str) -> None:
+        text = self.documents.get(uri)
+        found = check_text(text, uri_source(uri), self.checker) if text is not None else []
+        diagnostics = [{"range": to_range(text or "", item.start, item.end), "severity": SEVERITY_CODES.get(item.severity, 3), "source": "content", "code": item.rule, "message": item.message} for item in found]
+        write_message(self.output, {"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": uri, "diagnostics": diagnostics}})
+
+    def code_actions(self, params: Dict[str, Any]) -> List[Dict[str, Any]]:
+        uri = params["textDocument"]["uri"]
+        text = self.documents.get(uri, "")
+        actions: List[Dict[str, Any]] = []
+        for item in check_text(text, uri_source(uri), self.checker):
+            if not overlaps(text, item, params["range"]):
+                continue
+            for fix in item.fixes:
+                edit = {"range": to_range(text, fix.start, fix.end), "newText": fix.new_text}
+                actions.append({"title": fix.title, "kind": "quickfix", "isPreferred": True, "edit": {"changes": {uri: [edit]}}})
+        return actions
+
+    def handle(self, message: Dict[str, Any]) -> Optional[Dict[str, Any]]:
+        """The response to a request, or None for a notification."""
+        method, params = message.get("method"), message.get("params") or {}
+        document = params.get("textDocument") or {}
+        result: Any = None
+        if method == "initialize":
+            result = {"capabilities": {"textDocumentSync": {"openClose": True, "change": FULL_SYNC, "save": {"includeText": True}}, "codeActionProvider": {"codeActionKinds": ["quickfix"]}}, "serverInfo": {"name": "content-lsp"}}
+        elif method == "shutdown":
+            self.shutdown = True
+        elif method == "textDocument/didOpen":
+            self.documents[document["uri"]] = document.get("text", "")
+            self.publish(document["uri"])
+        elif method == "textDocument/didChange":
+            changes = params.get("contentChanges") or []
+            if changes:
+                self.documents[document["uri"]] = changes[-1]["text"]
+            if self.on_change:
+                self.publish(document["uri"])
+        elif method == "textDocument/didSave":
+            if isinstance(params.get("text"), str):
+                self.documents[document["uri"]] = params["text"]
+            self.publish(document["uri"])
+        elif method == "textDocument/didClose":
+            self.documents.pop(document["uri"], None)
+            self.publish(document["uri"])
+        elif method == "textDocument/codeAction":
+            result = self.code_actions(params)
+        elif method == "content/classify":
+            uri = document.get("uri", "")
+            text = params["text"] if isinstance(params.get("text"), str) else self.documents.get(uri, "")
+            result = classify_preview(text, uri_source(uri), self.checker)
+        elif "id" in message and method not in ("initialized", "exit"):
+            return {"jsonrpc": "2.0", "id": message["id"], "error": {"code": METHOD_NOT_FOUND, "message": f"unknown method {method}"}}
+        return {"jsonrpc": "2.0", "id": message["id"], "result": result} if "id" in message else None
+
+    def serve(self, stream: BinaryIO) -> int:
+        while True:
+            try:
+                message = read_message(stream)
+            except ValueError as exc:
+                # One garbled frame (bad JSON, bad UTF-8, a bad Content-Length) is answered; the next one may be fine.
+                write_message(self.output, {"jsonrpc": "2.0", "id": None, "error": {"code": PARSE_ERROR, "message": f"Parse error: {exc}"}})
+                continue
+            if message is None or (isinstance(message, dict) and message.get("method") == "exit"):
+                return 0 if self.shutdown else 1
+            if not isinstance(message, dict):
+                write_message(self.output, {"jsonrpc": "2.0", "id": None, "error": {"code": INVALID_REQUEST, "message": "expected a JSON-RPC object"}})
+                continue
+            try:
+                response = self.handle(message)
+            except (KeyError, TypeError, ValueError) as exc:
+                print(f"[lsp] {message.get('method')}: {exc}", file=sys.stderr)
+                response = {"jsonrpc": "2.0", "id": message["id"], "error": {"code": INVALID_REQUEST, "message": str(exc)}} if "id" in message else None
+            if response is not None:
+                write_message(self.output, response)
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Language server for content files: diagnostics and quick fixes over JSON-RPC on stdio.")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument("--schemas", default=str(SCHEMAS_DIR), help="Directory holding the entry schemas")
+    parser.add_argument("--rules", default=str(DEFAULT_RULES_PATH), help="Custom rules config checked alongside the schemas")
+    parser.add_argument("--accents", default=str(DEFAULT_ACCENTS_PATH), help="Path to the accent dictionary")
+    parser.add_argument("--numerals", default=str(DEFAULT_NUMERALS_PATH), help="Path to the number, date, and time style config")
+    parser.add_argument("--on-change", action="store_true", help="Check on every edit, not only on open and save")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    try:
+        checker = Checker(load_schemas(Path(args.schemas)), load_rules(args.rules), load_id_scheme(args.ids), load_accent_dictionary(args.accents), load_numeral_style(args.numerals))
+    except ValueError as exc:
+        parser.error(str(exc))
+    print("[lsp] Listening on stdio", file=sys.stderr)
+    return Server(checker, sys.stdout.buffer, args.on_change).serve(sys.stdin.buffer)
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
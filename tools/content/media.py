@@ -0,0 +1,173 @@
+"""Media attached to lesson steps: audio, images, video, and embedded players, checked against a type registry.
+
+A step lists its attachments under `media`, each `{"type": "audio", "src": "audio/hola.mp3"}` with optional
+`alt`, `caption`, and `title`. config/media.json is the registry: each type's file extensions, whether it may
+be a file under the assets directory and/or an http(s) URL, the hosts an embed may come from, and the fields
+it needs (images need alt text). Local files are checked for existence; URLs are only requested when export
+runs with --online-checks.
+"""
+
+from __future__ import annotations
+
+import urllib.error
+import urllib.request
+from concurrent.futures import ThreadPoolExecutor
+from dataclasses import dataclass, field
+from pathlib import Path, PurePosixPath
+from typing import Any, Dict, Iterable, Iterator, List, Optional, Tuple, Union
+from urllib.parse import urlparse
+
+try:
+    from .common import CONFIG_DIR, load_json
+    from .errors import ConfigError
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+
+DEFAULT_MEDIA_PATH = CONFIG_DIR / "media.json"
+REMOTE_PREFIXES = ("http://", "https://")
+TYPE_KEYS = ("extensions", "local", "remote", "hosts", "requires")
+ONLINE_TIMEOUT = 10
+ONLINE_JOBS = 8
+
+
+@dataclass
+class MediaType:
+    name: str
+    extensions: List[str] = field(default_factory=list)
+    local: bool = True
+    remote: bool = True
+    # Empty means any host.
+    hosts: List[str] = field(default_factory=list)
+    requires: List[str] = field(default_factory=list)
+
+
+@dataclass
+class MediaRef:
+    lesson: str
+    path: str
+    type: str
+    src: str
+
+
+def load_media_types(path: Optional[Union[str, Path]] = None) -> Dict[str, MediaType]:
+    """Type name -> MediaType from the registry in path; an unknown key or a type that allows no source is an error."""
+    cfg_path = Path(path) if path else DEFAULT_MEDIA_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    types = data.get("types", {})
+    if not isinstance(types, dict):
+        raise ConfigError("media: types must be an object of type name -> settings", "types", types, "object")
+    registry: Dict[str, MediaType] = {}
+    for name, settings in types.items():
+        if not isinstance(settings, dict):
+            raise ConfigError(f"media: type '{name}' must be an object", f"types.{name}", settings, "object")
+        unknown = sorted(key for key in settings if key not in TYPE_KEYS)
+        if unknown:
+            raise ConfigError(f"media: type '{name}' has unknown setting {', '.join(unknown)}", f"types.{name}", unknown, list(TYPE_KEYS))
+        media = MediaType(
+            name,
+            [str(ext).lower().lstrip(".") for ext in settings.get("extensions", [])],
+            bool(settings.get("local", True)),
+            bool(settings.get("remote", True)),
+            [str(host).lower() for host in settings.get("hosts", [])],
+            [str(key) for key in settings.get("requires", [])],
+        )
+        if not media.local and not media.remote:
+            raise ConfigError(f"media: type '{name}' allows neither local files nor URLs", f"types.{name}", settings, "local or remote")
+        if media.hosts and not media.remote:
+            raise ConfigError(f"media: type '{name}' lists hosts but does not allow URLs", f"types.{name}.hosts", media.hosts, "remote: true")
+        registry[name] = media
+    return registry
+
+
+def iter_step_media(lessons: Iterable[Dict[str, Any]]) -> Iterator[Tuple[str, str, Any]]:
+    """(lesson ID, path, attachment) for every item in a step's media list."""
+    for lesson in lessons:
+        for idx, step in enumerate(lesson.get("steps") or []):
+            if not isinstance(step, dict):
+                continue
+            media = step.get("media")
+            items = media if isinstance(media, list) else [media] if media is not None else []
+            for pos, item in enumerate(items):
+                yield str(lesson.get("id")), f"steps[{idx}].media[{pos}]", item
+
+
+def media_problem(item: Any, registry: Dict[str, MediaType], assets: Optional[Path]) -> Optional[str]:
+    """What is wrong with one attachment, or None; local files are looked up under assets unless it is None."""
+    if not isinstance(item, dict):
+        return "is not an object with type and src"
+    name = item.get("type")
+    media = registry.get(name) if isinstance(name, str) else None
+    if media is None:
+        return f"unknown media type '{name}' (known: {', '.join(sorted(registry)) or 'none'})"
+    src = item.get("src")
+    if not isinstance(src, str) or not src.strip():
+        return f"{name} has no src"
+    src = src.strip()
+    missing = [key for key in media.requires if not (isinstance(item.get(key), str) and item[key].strip())]
+    if missing:
+        return f"{name} {src} has no {', '.join(missing)}"
+    remote = src.startswith(REMOTE_PREFIXES)
+    suffix = PurePosixPath(urlparse(src).path if remote else src).suffix.lower().lstrip(".")
+    if media.extensions and suffix not in media.extensions:
+        return f"{name} {src} is not a {'/'.join(media.extensions)} file"
+    if remote:
+        if not media.remote:
+            return f"{name} {src} must be a file under the assets directory, not a URL"
+        host = (urlparse(src).hostname or "").lower()
+        if media.hosts and host not in media.hosts:
+            return f"{name} {src} is not from an allowed host ({', '.join(media.hosts)})"
+        return None
+    if not media.local:
+        return f"{name} {src} must be an http(s) URL"
+    if assets is None:
+        return None
+    path = (assets / src).resolve()
+    if assets.resolve() not in path.parents:
+        return f"{name} {src} points outside the assets root"
+    if not path.is_file():
+        return f"{name} {src} not found under {assets}"
+    return None
+
+
+def check_step_media(lessons: Iterable[Dict[str, Any]], registry: Dict[str, MediaType], assets: Optional[Path] = None) -> List[Tuple[str, str]]:
+    """(lesson ID, problem) for every step attachment that is malformed, of an unknown type, or missing locally."""
+    problems: List[Tuple[str, str]] = []
+    for lesson_id, path, item in iter_step_media(lessons):
+        problem = media_problem(item, registry, assets)
+        if problem:
+            problems.append((lesson_id, f"{path}: {problem}"))
+    return problems
+
+
+def remote_media(lessons: Iterable[Dict[str, Any]], registry: Dict[str, MediaType]) -> List[MediaRef]:
+    """Well-formed attachments that point at a URL, the ones --online-checks requests."""
+    refs: List[MediaRef] = []
+    for lesson_id, path, item in iter_step_media(lessons):
+        if media_problem(item, registry, None) is None and item["src"].strip().startswith(REMOTE_PREFIXES):
+            refs.append(MediaRef(lesson_id, path, item["type"], item["src"].strip()))
+    return refs
+
+
+def url_problem(url: str, timeout: float = ONLINE_TIMEOUT) -> Optional[str]:
+    """Why url is not reachable, or None; servers that refuse HEAD are asked again with GET."""
+    for method in ("HEAD", "GET"):
+        request = urllib.request.Request(url, method=method, headers={"User-Agent": "spanish-content-check"})
+        try:
+            with urllib.request.urlopen(request, timeout=timeout):
+                return None
+        except urllib.error.HTTPError as exc:
+            if method == "HEAD" and exc.code in (403, 405, 501):
+                continue
+            return f"HTTP {exc.code}"
+        except (urllib.error.URLError, OSError) as exc:
+            return f"unreachable ({getattr(exc, 'reason', exc)})"
+    return None
+
+
+def check_reachable(refs: List[MediaRef], timeout: float = ONLINE_TIMEOUT, jobs: int = ONLINE_JOBS) -> List[Tuple[str, str]]:
+    """(lesson ID, problem) for attachments whose URL does not answer; each URL is requested once."""
+    urls = sorted({ref.src for ref in refs})
+    with ThreadPoolExecutor(max_workers=max(1, min(jobs, len(urls) or 1))) as pool:
+        answers = dict(zip(urls, pool.map(lambda url: url_problem(url, timeout), urls)))
+    return [(ref.lesson, f"{ref.path}: {ref.type} {ref.src} {answers[ref.src]}") for ref in refs if answers[ref.src]]
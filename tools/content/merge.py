@@ -0,0 +1,281 @@
+"""Merge helpers for records that share an ID or are near-duplicates."""
+
+from __future__ import annotations
+
+import difflib
+import itertools
+import re
+import unicodedata
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional, Tuple
+
+try:
+    from .common import is_pinned, source_file, string_values, words
+    from .errors import ConfigError
+    from .senses import entry_senses, normalize_senses, sense_key
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import is_pinned, source_file, string_values, words  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from senses import entry_senses, normalize_senses, sense_key  # type: ignore
+
+PROSE_FIELDS = ("definition", "story", "origin")
+MERGED_BANNER = "--- MERGED VARIANT ---"
+DEFAULT_PROSE_THRESHOLD = 0.75
+DEFAULT_LESSON_REVIEW_THRESHOLD = 0.8
+REVIEW_STATUSES = ("draft", "reviewed", "published")
+DUPLICATE_POLICIES = ("merge", "keep-first", "keep-newest", "reject", "review")
+TITLE_STOPWORDS = {"vs", "versus", "and", "y", "the", "el", "la", "los", "las"}
+
+
+def merge_prose(first: str, second: str, threshold: float = DEFAULT_PROSE_THRESHOLD) -> str:
+    """Combine two variants of a prose field word by word.
+
+    Variants that are mostly the same text are merged edit by edit: replacements and
+    insertions from the second variant win, words only present in the first are kept.
+    Substantively different variants fall back to the MERGED VARIANT banner.
+    """
+    if first == second or not second.strip():
+        return first
+    if not first.strip():
+        return second
+    if MERGED_BANNER in first and second in first:
+        return first
+    # Words are compared, but each keeps the whitespace after it, so line and paragraph breaks survive the merge.
+    left = re.findall(r"\S+\s*", first)
+    right = re.findall(r"\S+\s*", second)
+    matcher = difflib.SequenceMatcher(a=[word.rstrip() for word in left], b=[word.rstrip() for word in right], autojunk=False)
+    if matcher.ratio() < threshold:
+        return f"{first}\n\n{MERGED_BANNER}\n{second}"
+    words: List[str] = []
+    for op, a_start, a_end, b_start, b_end in matcher.get_opcodes():
+        for word in left[a_start:a_end] if op in ("equal", "delete") else right[b_start:b_end]:
+            if words and words[-1] == words[-1].rstrip():
+                words[-1] += " "
+            words.append(word)
+    leading = first[: len(first) - len(first.lstrip())]
+    return leading + "".join(words).rstrip() + first[len(first.rstrip()) :]
+
+
+def review_status(entry: Dict[str, Any]) -> str:
+    """Editorial state of an entry; anything missing or unrecognised counts as draft."""
+    status = entry.get("review_status")
+    return status if status in REVIEW_STATUSES else "draft"
+
+
+def same_sense(first: Dict[str, Any], second: Dict[str, Any], threshold: float) -> bool:
+    """Senses match on gloss, or on near-identical definitions when a gloss is missing."""
+    if sense_key(first) and sense_key(second):
+        return sense_key(first) == sense_key(second)
+    left, right = str(first.get("definition") or "").split(), str(second.get("definition") or "").split()
+    return bool(left and right) and difflib.SequenceMatcher(a=left, b=right, autojunk=False).ratio() >= threshold
+
+
+def merge_senses(first: List[Dict[str, Any]], second: List[Dict[str, Any]], threshold: float) -> List[Dict[str, Any]]:
+    """Fold each incoming sense into the sense it matches, appending senses that are new (banco: bank, then bench)."""
+    merged = [dict(sense) for sense in first]
+    for sense in second:
+        idx = next((i for i, existing in enumerate(merged) if same_sense(existing, sense, threshold)), None)
+        if idx is None:
+            merged.append(dict(sense))
+        else:
+            merged[idx] = merge_records(merged[idx], sense, threshold)
+    return merged
+
+
+def merge_values(key: Any, first: Any, second: Any, threshold: float) -> Any:
+    if first is None or first == "" or first == []:
+        return second
+    if second is None or second == "" or second == [] or first == second:
+        return first
+    if isinstance(first, str) and isinstance(second, str):
+        if key in PROSE_FIELDS:
+            return merge_prose(first, second, threshold)
+        return first
+    if isinstance(first, dict) and isinstance(second, dict):
+        return merge_records(first, second, threshold)
+    if isinstance(first, list) and isinstance(second, list):
+        if any(isinstance(item, dict) for item in first + second):
+            if len(first) == len(second) and all(isinstance(a, dict) and isinstance(b, dict) for a, b in zip(first, second)):
+                return [merge_records(a, b, threshold) for a, b in zip(first, second)]
+            if key != "examples":
+                return first
+        merged = list(first)
+        for item in second:
+            if item not in merged:
+                merged.append(item)
+        return merged
+    return first
+
+
+def merge_records(first: Dict[str, Any], second: Dict[str, Any], threshold: float = DEFAULT_PROSE_THRESHOLD) -> Dict[str, Any]:
+    merged = dict(first)
+    for key, value in second.items():
+        merged[key] = merge_values(key, first.get(key), value, threshold) if key in first else value
+    if "review_status" in first or "review_status" in second:
+        # A merged entry is only as far along as its least-reviewed variant, and a variant without a status is a draft.
+        merged["review_status"] = min(review_status(first), review_status(second), key=REVIEW_STATUSES.index)
+    if "senses" in first or "senses" in second:
+        # Distinct meanings become separate senses instead of a MERGED VARIANT definition.
+        merged["senses"] = merge_senses(entry_senses(first), entry_senses(second), threshold)
+        normalize_senses(merged)
+    return merged
+
+
+def merge_by_id(entries: List[Dict[str, Any]], threshold: float = DEFAULT_PROSE_THRESHOLD, events: Any = None) -> Tuple[List[Dict[str, Any]], int]:
+    """Fold entries sharing an ID into the first occurrence; returns the entries and merge count."""
+    merged: Dict[str, Dict[str, Any]] = {}
+    count = 0
+    for entry in entries:
+        entry_id = entry["id"]
+        if entry_id in merged:
+            merged[entry_id] = merge_records(merged[entry_id], entry, threshold)
+            count += 1
+            if events:
+                sources = ", ".join(entry.get("source_files", []))
+                events.emit("duplicate_merged", sources, f"merged into {entry_id}", id=entry_id)
+        else:
+            merged[entry_id] = entry
+    return list(merged.values()), count
+
+
+@dataclass
+class DuplicateCluster:
+    """Entries sharing one ID, in input order, and which of them the policy kept (None when merged)."""
+
+    id: str
+    entries: List[Dict[str, Any]]
+    kept: Optional[int] = None
+    pinned: bool = False
+
+    def differing_fields(self) -> List[str]:
+        keys = {key for entry in self.entries for key in entry if key != "source_files"}
+        return sorted(key for key in keys if len({repr(entry.get(key)) for entry in self.entries}) > 1)
+
+
+def source_mtime(entry: Dict[str, Any]) -> float:
+    """Newest modification time among an entry's source files; 0 when none can be found."""
+    times = [0.0]
+    for source in entry.get("source_files", []):
+        path = source_file(source)
+        if path.exists():
+            times.append(path.stat().st_mtime)
+    return max(times)
+
+
+def resolve_duplicates(
+    entries: List[Dict[str, Any]], policy: str = "merge", threshold: float = DEFAULT_PROSE_THRESHOLD, events: Any = None
+) -> Tuple[List[Dict[str, Any]], List[DuplicateCluster]]:
+    """Apply a duplicate policy to entries sharing an ID; returns the kept entries (first-occurrence order) and every cluster.
+
+    merge folds the cluster like merge_by_id; keep-first and keep-newest keep one entry untouched; reject and review keep
+    the first and leave the rest to the caller (rejects output, or the duplicates report) instead of merging anything.
+    Whatever the policy, a cluster holding a pinned entry keeps the first pinned entry as written and merges nothing into it.
+    """
+    if policy not in DUPLICATE_POLICIES:
+        raise ConfigError(f"Unknown duplicate policy '{policy}'. Expected one of: {', '.join(DUPLICATE_POLICIES)}", "on_duplicate", policy, list(DUPLICATE_POLICIES))
+    groups: Dict[str, List[Dict[str, Any]]] = {}
+    for entry in entries:
+        groups.setdefault(entry["id"], []).append(entry)
+    kept: List[Dict[str, Any]] = []
+    clusters: List[DuplicateCluster] = []
+    for entry_id, group in groups.items():
+        if len(group) == 1:
+            kept.append(group[0])
+            continue
+        cluster = DuplicateCluster(entry_id, group)
+        clusters.append(cluster)
+        pinned = [idx for idx, entry in enumerate(group) if is_pinned(entry)]
+        if pinned:
+            cluster.kept, cluster.pinned = pinned[0], True
+            kept.append(group[cluster.kept])
+            if events:
+                for idx, entry in enumerate(group):
+                    if idx != cluster.kept:
+                        events.emit("merge_refused", ", ".join(entry.get("source_files", [])), f"{entry_id} is pinned; {policy} did not touch it", id=entry_id)
+            continue
+        if policy == "merge":
+            merged, _ = merge_by_id(group, threshold, events)
+            kept.append(merged[0])
+            continue
+        # Later entries win ties, matching the usual "last write" intuition for keep-newest.
+        cluster.kept = max(range(len(group)), key=lambda idx: (source_mtime(group[idx]), idx)) if policy == "keep-newest" else 0
+        kept.append(group[cluster.kept])
+        if events:
+            for idx, entry in enumerate(group):
+                if idx != cluster.kept:
+                    sources = ", ".join(entry.get("source_files", []))
+                    events.emit("duplicate_dropped", sources, f"{policy}: not merged into {entry_id}", id=entry_id)
+    return kept, clusters
+
+
+@dataclass
+class LessonMatch:
+    keep: str
+    duplicate: str
+    title_score: float
+    step_score: float
+
+    @property
+    def score(self) -> float:
+        return (self.title_score + self.step_score) / 2
+
+
+def normalize_title(title: Any) -> List[str]:
+    """Fold accents, punctuation, and filler words so "Ser vs. Estar" and "Ser vs Estar" compare equal."""
+    folded = unicodedata.normalize("NFKD", str(title or ""))
+    folded = "".join(ch for ch in folded if not unicodedata.combining(ch)).lower()
+    return [word for word in re.findall(r"[a-z0-9]+", folded) if word not in TITLE_STOPWORDS]
+
+
+def step_words(lesson: Dict[str, Any]) -> List[str]:
+    return [word for text in string_values(lesson.get("steps", [])) for word in words(text)]
+
+
+def similar_lessons(lessons: List[Dict[str, Any]], threshold: float = DEFAULT_LESSON_REVIEW_THRESHOLD) -> List[LessonMatch]:
+    """Pair up lessons with different IDs whose normalized titles and step text look like the same lesson."""
+    prepared = [(lesson["id"], normalize_title(lesson.get("title")), step_words(lesson)) for lesson in lessons]
+    matches: List[LessonMatch] = []
+    for (left_id, left_title, left_steps), (right_id, right_title, right_steps) in itertools.combinations(prepared, 2):
+        title_score = difflib.SequenceMatcher(a=left_title, b=right_title, autojunk=False).ratio()
+        matcher = difflib.SequenceMatcher(a=left_steps, b=right_steps, autojunk=False)
+        if (title_score + matcher.quick_ratio()) / 2 < threshold:
+            continue
+        match = LessonMatch(left_id, right_id, title_score, matcher.ratio())
+        if match.score >= threshold:
+            matches.append(match)
+    return matches
+
+
+def merge_similar_lessons(
+    lessons: List[Dict[str, Any]],
+    matches: List[LessonMatch],
+    merge_threshold: Optional[float],
+    prose_threshold: float = DEFAULT_PROSE_THRESHOLD,
+    events: Any = None,
+) -> Tuple[List[Dict[str, Any]], Dict[str, str]]:
+    """Fold each duplicate scoring at or above merge_threshold into the lesson it matched; None disables merging.
+
+    Returns the remaining lessons and each merged-away ID mapped to the lesson that now holds it, so references
+    can follow. Pairs with a pinned lesson on either side are never merged.
+    """
+    if merge_threshold is None:
+        return lessons, {}
+    by_id = {lesson["id"]: lesson for lesson in lessons}
+    folded: Dict[str, str] = {}
+    for match in sorted(matches, key=lambda m: -m.score):
+        if match.score < merge_threshold or match.duplicate in folded:
+            continue
+        keep = match.keep
+        while keep in folded:
+            keep = folded[keep]
+        if keep == match.duplicate or is_pinned(by_id[keep]) or is_pinned(by_id[match.duplicate]):
+            continue
+        by_id[keep] = merge_records(by_id[keep], by_id[match.duplicate], prose_threshold)
+        folded[match.duplicate] = keep
+        if events:
+            sources = ", ".join(by_id[match.duplicate].get("source_files", []))
+            events.emit("duplicate_merged", sources, f"similar lesson merged into {keep} (score {match.score:.2f})", id=match.duplicate)
+    for duplicate in folded:
+        while folded[duplicate] in folded:
+            folded[duplicate] = folded[folded[duplicate]]
+    return [by_id[lesson["id"]] for lesson in lessons if lesson["id"] not in folded], folded
@@ -0,0 +1,153 @@
+"""Coerce badly shaped lesson and vocabulary fields into the canonical shape, saying what was changed.
+
+Hand-written and imported content gets shapes the schemas do not expect: `unit: [3]`, `"lesson_number": "2"`,
+a step that is a bare string, tags as one comma-separated string, a gloss given as a list. Normalization fixes
+what has one clear reading and leaves the rest for validation, and either way records a structured warning
+on the entry under `normalization_warnings` ({path, problem, action}), so a validation failure arrives with
+the reason the field looked wrong. Export lists the warnings in its audit; pinned entries are not normalized.
+"""
+
+from __future__ import annotations
+
+from dataclasses import asdict, dataclass
+from typing import Any, Dict, List, Optional, Tuple
+
+try:
+    from .common import LEVELS
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import LEVELS  # type: ignore
+
+NORMALIZATION_KEY = "normalization_warnings"
+INTEGER_FIELDS = ("unit", "lesson_number")
+LIST_FIELDS = {"lesson": ("tags", "prerequisites", "introduces", "culture_notes"), "vocab": ("tags", "synonyms", "antonyms")}
+TEXT_FIELDS = {"lesson": ("title", "nickname"), "vocab": ("spanish", "english_gloss", "pos")}
+
+
+@dataclass
+class Coercion:
+    path: str
+    problem: str
+    action: str
+
+
+def describe(value: Any) -> str:
+    return {dict: "an object", list: "a list", str: "text", bool: "true/false", type(None): "null"}.get(type(value), "a number")
+
+
+def as_integer(value: Any) -> Optional[int]:
+    if isinstance(value, bool):
+        return None
+    if isinstance(value, int):
+        return value
+    if isinstance(value, float) and value.is_integer():
+        return int(value)
+    if isinstance(value, str) and value.strip().isdigit():
+        return int(value.strip())
+    if isinstance(value, list) and len(value) == 1:
+        return as_integer(value[0])
+    return None
+
+
+def coerce_integer(entry: Dict[str, Any], name: str, notes: List[Coercion]) -> None:
+    value = entry[name]
+    if isinstance(value, int) and not isinstance(value, bool):
+        return
+    number = as_integer(value)
+    if number is None:
+        notes.append(Coercion(name, f"expected a whole number, got {describe(value)}", "left as written"))
+        return
+    entry[name] = number
+    notes.append(Coercion(name, f"expected a whole number, got {describe(value)}", f"used {number}"))
+
+
+def coerce_list(entry: Dict[str, Any], name: str, notes: List[Coercion]) -> None:
+    value = entry[name]
+    if isinstance(value, list):
+        return
+    if isinstance(value, str):
+        items = [item.strip() for item in value.split(",") if item.strip()]
+        entry[name] = items
+        notes.append(Coercion(name, "expected a list, got text", f"split on commas into {len(items)} item" + ("" if len(items) == 1 else "s")))
+        return
+    notes.append(Coercion(name, f"expected a list, got {describe(value)}", "left as written"))
+
+
+def coerce_text(entry: Dict[str, Any], name: str, notes: List[Coercion]) -> None:
+    value = entry[name]
+    if isinstance(value, str):
+        return
+    if isinstance(value, list) and value and all(isinstance(item, str) for item in value):
+        entry[name] = value[0] if len(value) == 1 else "; ".join(value)
+        notes.append(Coercion(name, "expected text, got a list", "used the only item" if len(value) == 1 else f"joined {len(value)} items with '; '"))
+        return
+    if isinstance(value, (int, float)) and not isinstance(value, bool):
+        entry[name] = str(value)
+        notes.append(Coercion(name, "expected text, got a number", "used it as text"))
+        return
+    notes.append(Coercion(name, f"expected text, got {describe(value)}", "left as written"))
+
+
+def coerce_level(entry: Dict[str, Any], notes: List[Coercion]) -> None:
+    value = entry["level"]
+    if not isinstance(value, str) or value in LEVELS + ("UNSET",):
+        return
+    level = value.strip().upper()
+    if level in LEVELS:
+        entry["level"] = level
+        notes.append(Coercion("level", f"'{value}' is not written as a CEFR level", f"used {level}"))
+
+
+def coerce_steps(entry: Dict[str, Any], notes: List[Coercion]) -> None:
+    steps = entry["steps"]
+    if isinstance(steps, dict):
+        entry["steps"] = [steps]
+        notes.append(Coercion("steps", "expected a list of steps, got one object", "wrapped it in a list"))
+        return
+    if not isinstance(steps, list):
+        return
+    kept: List[Any] = []
+    for idx, step in enumerate(steps):
+        if isinstance(step, dict):
+            kept.append(step)
+        elif isinstance(step, str) and step.strip():
+            kept.append({"line": step.strip()})
+            notes.append(Coercion(f"steps[{idx}]", "expected a step object, got text", "used it as the step's line"))
+        else:
+            notes.append(Coercion(f"steps[{idx}]", f"expected a step object, got {describe(step)}", "dropped it"))
+    entry["steps"] = kept
+
+
+def normalize_record(entry: Dict[str, Any], kind: str) -> Tuple[Dict[str, Any], List[Coercion]]:
+    """A copy of a lesson or vocabulary entry in canonical shape, with what was coerced (or could not be) attached."""
+    entry = dict(entry)
+    notes: List[Coercion] = []
+    if kind == "lesson":
+        for name in INTEGER_FIELDS:
+            if name in entry:
+                coerce_integer(entry, name, notes)
+        if "steps" in entry:
+            coerce_steps(entry, notes)
+    for name in LIST_FIELDS.get(kind, ()):
+        if name in entry:
+            coerce_list(entry, name, notes)
+    for name in TEXT_FIELDS.get(kind, ()):
+        if name in entry and entry[name] is not None:
+            coerce_text(entry, name, notes)
+    if "level" in entry:
+        coerce_level(entry, notes)
+    if notes:
+        entry[NORMALIZATION_KEY] = [asdict(note) for note in notes]
+    return entry, notes
+
+
+def normalize_lesson(entry: Dict[str, Any]) -> Dict[str, Any]:
+    return normalize_record(entry, "lesson")[0]
+
+
+def normalize_vocab(entry: Dict[str, Any]) -> Dict[str, Any]:
+    return normalize_record(entry, "vocab")[0]
+
+
+def normalization_warnings(entry: Dict[str, Any]) -> List[Dict[str, str]]:
+    warnings = entry.get(NORMALIZATION_KEY)
+    return [note for note in warnings if isinstance(note, dict)] if isinstance(warnings, list) else []
@@ -0,0 +1,249 @@
+"""Normalize how Spanish examples write numbers, dates, and times.
+
+Beginner material spells small numbers out (tres gatos, not 3 gatos); config/numerals.json sets the threshold
+per level. Dates become "15 de marzo de 2024" (or a day-first numeric form) and times follow one clock. Digits
+that are references, measurements, decimals, ordinals, or part of a date or time are left alone, and so is
+anything that cannot be settled from the text: a number ending in one (un, una, or uno depends on the noun)
+or a date that reads month first.
+"""
+
+from __future__ import annotations
+
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional, Tuple, Union
+
+try:
+    from .common import CONFIG_DIR, is_spanish_key, load_json, spanish_texts
+    from .errors import ConfigError
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, is_spanish_key, load_json, spanish_texts  # type: ignore
+    from errors import ConfigError  # type: ignore
+
+DEFAULT_NUMERALS_PATH = CONFIG_DIR / "numerals.json"
+TIME_STYLES = ("24h", "12h")
+DATE_STYLES = ("long", "numeric")
+MONTHS = ("enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre")
+UNITS = (
+    "cero", "uno", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve", "diez", "once", "doce", "trece",
+    "catorce", "quince", "dieciséis", "diecisiete", "dieciocho", "diecinueve", "veinte", "veintiuno", "veintidós",
+    "veintitrés", "veinticuatro", "veinticinco", "veintiséis", "veintisiete", "veintiocho", "veintinueve",
+)
+TENS = {3: "treinta", 4: "cuarenta", 5: "cincuenta", 6: "sesenta", 7: "setenta", 8: "ochenta", 9: "noventa"}
+HUNDREDS = {
+    1: "ciento", 2: "doscientos", 3: "trescientos", 4: "cuatrocientos", 5: "quinientos", 6: "seiscientos",
+    7: "setecientos", 8: "ochocientos", 9: "novecientos",
+}
+# Words after which a number is a label, not a quantity (página 3, lección 4, el autobús 21).
+REFERENCE_WORDS = {
+    "página", "páginas", "capítulo", "lección", "número", "nº", "núm", "calle", "piso", "planta", "habitación",
+    "línea", "autobús", "tema", "unidad", "ejercicio", "versículo", "puerta", "andén", "mesa", "aula",
+}
+NUMBER_RE = re.compile(r"(?<![\w.,:/$€#-])(\d+)(?![\w,:/%º°ª€$-]|[.,]\d|\.[ºª]|\s*(?:%|€|\$|°|(?:km|kg|g|cm|mm|m|l|ml|h|min|s)\b))")
+DATE_RE = re.compile(r"(?<![\d/.-])(\d{1,2})([/.-])(\d{1,2})\2(\d{4})(?![\d/.-]\d)")
+ISO_DATE_RE = re.compile(r"(?<![\d-])(\d{4})-(\d{2})-(\d{2})(?![\d-])")
+LONG_DATE_RE = re.compile(r"(?<!\d)(\d{1,2}) de (" + "|".join(MONTHS) + r")\b", re.IGNORECASE)
+MERIDIEM_RE = re.compile(r"(?<![\d:.])(\d{1,2})(?:[:.](\d{2}))?\s*([ap])\.?\s?m\.?(?![\w.])", re.IGNORECASE)
+CLOCK_RE = re.compile(r"(?<![\d:.])(\d{1,2}):(\d{2})(?![\d:]|\s*[ap]\.?\s?m\b)", re.IGNORECASE)
+WORD_RE = re.compile(r"[A-Za-zÁÉÍÓÚÜÑáéíóúüñ]+")
+
+
+@dataclass
+class NumeralStyle:
+    # Keyed by level, with "default" for levels not listed: numbers below the value are spelled out.
+    spell_below: Dict[str, int] = field(default_factory=dict)
+    date_style: str = "long"
+    time_style: str = "24h"
+
+    def threshold(self, level: str) -> int:
+        return self.spell_below.get(level, self.spell_below.get("default", 0))
+
+
+@dataclass
+class NumeralFix:
+    path: str
+    found: str
+    suggestion: str
+    reason: str
+    ambiguous: bool
+    start: int = 0
+    end: int = 0
+
+
+def load_numeral_style(path: Optional[Union[str, Path]] = None) -> NumeralStyle:
+    """The style in path; no file means dates and times are normalized but no number is spelled out."""
+    cfg_path = Path(path) if path else DEFAULT_NUMERALS_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    spell_below = data.get("spell_below", {})
+    if not isinstance(spell_below, dict) or any(not isinstance(value, int) or not 0 <= value <= 1_000_000 for value in spell_below.values()):
+        raise ConfigError("numerals: spell_below must map levels to whole numbers up to 1000000", "spell_below", spell_below)
+    style = NumeralStyle({str(level).upper() if level != "default" else level: value for level, value in spell_below.items()})
+    for key, allowed in (("date_style", DATE_STYLES), ("time_style", TIME_STYLES)):
+        value = data.get(key, getattr(style, key))
+        if value not in allowed:
+            raise ConfigError(f"numerals: unknown {key} {value!r}", key, value, list(allowed))
+        setattr(style, key, value)
+    return style
+
+
+def spell_number(number: int, apocope: bool = False) -> str:
+    """Spanish words for 0 to 999999; apocope gives the form before a noun (veintiún, not veintiuno)."""
+    if not 0 <= number < 1_000_000:
+        raise ValueError(f"{number} is out of range")
+    if number < 30:
+        words = UNITS[number]
+    elif number < 100:
+        words = TENS[number // 10] + (f" y {UNITS[number % 10]}" if number % 10 else "")
+    elif number < 1000:
+        rest = number % 100
+        words = "cien" if number == 100 else HUNDREDS[number // 100] + (f" {spell_number(rest)}" if rest else "")
+    else:
+        thousands, rest = divmod(number, 1000)
+        words = ("mil" if thousands == 1 else f"{spell_number(thousands, apocope=True)} mil") + (f" {spell_number(rest)}" if rest else "")
+    if apocope and words.endswith("uno"):
+        words = words[:-3] + ("ún" if words.endswith("veintiuno") else "un")
+    return words
+
+
+def sentence_start(text: str, position: int) -> bool:
+    before = text[:position].rstrip()
+    return not before or before[-1] in ".!?¿¡"
+
+
+def long_date(day: int, month: int, year: str) -> str:
+    return f"{day} de {MONTHS[month - 1]} de {year}"
+
+
+def format_date(style: NumeralStyle, day: int, month: int, year: str) -> str:
+    return long_date(day, month, year) if style.date_style == "long" else f"{day}/{month}/{year}"
+
+
+def format_time(style: NumeralStyle, hour: int, minutes: Optional[str]) -> str:
+    if style.time_style == "24h":
+        return f"{hour}:{minutes or '00'}"
+    clock = hour % 12 or 12
+    return f"{clock}{':' + minutes if minutes else ''} {'a. m.' if hour < 12 else 'p. m.'}"
+
+
+def date_fixes(text: str, style: NumeralStyle, path: str) -> Iterator[NumeralFix]:
+    for match in DATE_RE.finditer(text):
+        first, second, year = int(match.group(1)), int(match.group(3)), match.group(4)
+        if 1 <= first <= 31 and 1 <= second <= 12:
+            yield NumeralFix(path, match.group(0), format_date(style, first, second, year), "date", False, match.start(), match.end())
+        elif 1 <= first <= 12 and 13 <= second <= 31:
+            yield NumeralFix(path, match.group(0), format_date(style, second, first, year), "date written month first", True, match.start(), match.end())
+    for match in ISO_DATE_RE.finditer(text):
+        year, month, day = match.group(1), int(match.group(2)), int(match.group(3))
+        if 1 <= month <= 12 and 1 <= day <= 31:
+            yield NumeralFix(path, match.group(0), format_date(style, day, month, year), "date", False, match.start(), match.end())
+    for match in LONG_DATE_RE.finditer(text):
+        written = f"{int(match.group(1))} de {match.group(2).lower()}"
+        yield NumeralFix(path, match.group(0), written, "date", False, match.start(), match.end())
+
+
+def time_fixes(text: str, style: NumeralStyle, path: str) -> Iterator[NumeralFix]:
+    for match in MERIDIEM_RE.finditer(text):
+        hour, minutes, half = int(match.group(1)), match.group(2), match.group(3).lower()
+        if not 1 <= hour <= 12 or (minutes and int(minutes) > 59):
+            continue
+        hour = hour % 12 + (12 if half == "p" else 0)
+        suggestion = format_time(style, hour, minutes)
+        # The abbreviation's dot doubles as a full stop at the end of a sentence; keep the full stop.
+        if match.group(0).endswith(".") and not suggestion.endswith(".") and re.match(r"\s*(?:$|[A-ZÁÉÍÓÚÑ¿¡])", text[match.end() :]):
+            suggestion += "."
+        yield NumeralFix(path, match.group(0), suggestion, "time", False, match.start(), match.end())
+    for match in CLOCK_RE.finditer(text):
+        hour, minutes = int(match.group(1)), match.group(2)
+        if hour > 23 or int(minutes) > 59:
+            continue
+        if style.time_style == "24h":
+            suggestion = format_time(style, hour, minutes)
+            yield NumeralFix(path, match.group(0), suggestion, "time", False, match.start(), match.end())
+        elif hour == 0 or hour > 12:
+            # 9:30 alone could be morning or evening; only unmistakable 24-hour times are converted.
+            yield NumeralFix(path, match.group(0), format_time(style, hour, minutes), "time", False, match.start(), match.end())
+
+
+def taken_spans(text: str) -> List[Tuple[int, int]]:
+    return [(m.start(), m.end()) for pattern in (DATE_RE, ISO_DATE_RE, LONG_DATE_RE, MERIDIEM_RE, CLOCK_RE) for m in pattern.finditer(text)]
+
+
+def plain_numbers(text: str, taken: List[Tuple[int, int]]) -> Iterator[re.Match]:
+    """Digits counting something: not part of a date or time span, a label, a measurement, or a padded code."""
+    for match in NUMBER_RE.finditer(text):
+        if any(start <= match.start() < end for start, end in taken) or (match.group(1).startswith("0") and len(match.group(1)) > 1):
+            continue
+        before = WORD_RE.findall(text[: match.start()])
+        if before and before[-1].lower() in REFERENCE_WORDS:
+            continue
+        if re.match(r"\s+de\s+(" + "|".join(MONTHS) + r")\b", text[match.end() :], re.IGNORECASE):
+            continue
+        yield match
+
+
+def suggest(text: str, style: NumeralStyle, level: str, path: str = "") -> List[NumeralFix]:
+    fixes = [fix for fix in [*date_fixes(text, style, path), *time_fixes(text, style, path)] if fix.suggestion != fix.found]
+    taken = taken_spans(text)
+    threshold = style.threshold(level)
+    for match in plain_numbers(text, taken):
+        number = int(match.group(1))
+        if number >= threshold:
+            continue
+        words = spell_number(number)
+        ambiguous = words.endswith("uno")
+        if ambiguous:
+            words = f"{words[:-3]}{'ún' if words == 'veintiuno' else 'un'}/{words[:-3]}una"
+        if sentence_start(text, match.start()):
+            words = words[:1].upper() + words[1:]
+        fixes.append(NumeralFix(path, match.group(0), words, f"spelled out below {threshold} at {level}", ambiguous, match.start(), match.end()))
+    return sorted(fixes, key=lambda fix: fix.start)
+
+
+def apply_fixes(text: str, fixes: List[NumeralFix]) -> str:
+    """Replace each unambiguous span, last first so the earlier offsets stay valid."""
+    for fix in sorted(fixes, key=lambda fix: -fix.start):
+        if not fix.ambiguous and text[fix.start : fix.end] == fix.found:
+            text = text[: fix.start] + fix.suggestion + text[fix.end :]
+    return text
+
+
+def normalize_numerals(value: Any, style: NumeralStyle, level: str, fix: bool = False, path: str = "") -> List[NumeralFix]:
+    """Collect suggestions for every Spanish string in a record; with fix set, rewrite the unambiguous ones in place."""
+    found: List[NumeralFix] = []
+    items = value.items() if isinstance(value, dict) else enumerate(value) if isinstance(value, list) else []
+    for key, item in list(items):
+        child = f"{path}.{key}" if path and isinstance(value, dict) else f"{path}[{key}]" if isinstance(value, list) else str(key)
+        if isinstance(item, str) and isinstance(value, dict) and is_spanish_key(key):
+            fixes = suggest(item, style, level, child)
+            found += fixes
+            if fix:
+                value[key] = apply_fixes(item, fixes)
+        elif isinstance(item, (dict, list)):
+            found += normalize_numerals(item, style, level, fix, child)
+    return found
+
+
+@dataclass
+class MixedNumber:
+    number: int
+    digits: List[str]  # paths writing it as digits
+    words: List[str]  # paths spelling it out
+
+
+def mixed_numerals(value: Any) -> List[MixedNumber]:
+    """Numbers a record writes both as digits and as words (3 in one example, tres in the next).
+
+    One is left out: un and una are far more often articles than numbers.
+    """
+    spelled = {spell_number(number): number for number in range(2, 100)}
+    digits: Dict[int, List[str]] = {}
+    words: Dict[int, List[str]] = {}
+    for path, text in spanish_texts(value):
+        for match in plain_numbers(text, taken_spans(text)):
+            digits.setdefault(int(match.group(1)), []).append(path)
+        lowered = text.lower()
+        for phrase, number in spelled.items():
+            if re.search(rf"(?<![\wáéíóúüñ]){re.escape(phrase)}(?![\wáéíóúüñ])", lowered):
+                words.setdefault(number, []).append(path)
+    return [MixedNumber(number, sorted(set(digits[number])), sorted(set(words[number]))) for number in sorted(set(digits) & set(words))]
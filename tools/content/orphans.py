@@ -0,0 +1,109 @@
+#!/usr/bin/env python3
+"""Report stale canonical entries, content files that produced no records, and content files stored twice."""
+
+from __future__ import annotations
+
+import argparse
+import json
+import sys
+from pathlib import Path
+from typing import Any, Dict, Iterable, List
+
+try:
+    from .common import BUILD_DIR, CONTENT_DIR, ENTRY_OUTPUTS, collect, iter_sources, load_json, source_file, write_report
+    from .console import add_output_arguments, configure_output
+    from .plugins import DEFAULT_PLUGINS_PATH, load_plugins
+    from .sources import duplicate_sources, remove_copies
+    from .verify import manifest_row, mark_generated, unmark_generated
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import BUILD_DIR, CONTENT_DIR, ENTRY_OUTPUTS, collect, iter_sources, load_json, source_file, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from plugins import DEFAULT_PLUGINS_PATH, load_plugins  # type: ignore
+    from sources import duplicate_sources, remove_copies  # type: ignore
+    from verify import manifest_row, mark_generated, unmark_generated  # type: ignore
+
+def is_stale(entry: Dict[str, Any]) -> bool:
+    sources = entry.get("source_files")
+    if not isinstance(sources, list) or not sources:
+        return False
+    # source_file maps an archive member (pack.zip!/inner.json) to the archive, which is what has to exist.
+    return not any(source_file(str(source)).exists() for source in sources)
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Find stale canonical entries, content files that yield no records, and duplicated content files.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to scan")
+    parser.add_argument("--canonical", default=str(BUILD_DIR / "canonical"), help="Directory holding canonical JSON")
+    parser.add_argument("--plugins", default=str(DEFAULT_PLUGINS_PATH), help="Plugin config, so plugin kinds' records and output files are checked too")
+    parser.add_argument("--prune-stale", action="store_true", help="List the stale entries that would be removed from the canonical files; add --apply to rewrite them")
+    parser.add_argument("--apply", action="store_true", help="With --prune-stale, actually rewrite the canonical files without the stale entries")
+    parser.add_argument("--dedupe-sources", action="store_true", help="Delete duplicated content files, keeping one canonical path per cluster")
+    parser.add_argument("--dry-run", action="store_true", help="With --dedupe-sources, list the files that would be deleted without deleting them")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    if args.apply and not args.prune_stale:
+        parser.error("--apply only applies to --prune-stale")
+
+    try:
+        plugins = load_plugins(args.plugins)
+    except ValueError as exc:
+        parser.error(str(exc))
+
+    dataset = collect(args.content)
+    plugin_records, _ = plugins.claim(dataset.unclassified)
+    productive = {record.source for record in dataset.classified() + [record for records in plugin_records.values() for record in records]}
+    sources = [source for source, _ in iter_sources(args.content)]
+    empty_files = [source for source in sources if source not in productive]
+
+    canonical_dir = Path(args.canonical)
+    stale: Dict[str, List[Dict[str, Any]]] = {}
+    for name in [f"{output}.json" for output in ENTRY_OUTPUTS] + [f"{kind.output}.json" for kind in plugins.kinds.values()]:
+        path = canonical_dir / name
+        if not path.exists():
+            continue
+        payload = load_json(path)
+        entries = unmark_generated(payload)
+        stale[name] = [entry for entry in entries if isinstance(entry, dict) and is_stale(entry)]
+        if args.prune_stale and args.apply and stale[name]:
+            kept = [entry for entry in entries if not (isinstance(entry, dict) and is_stale(entry))]
+            data = (json.dumps(mark_generated(kept) if payload is not entries else kept, ensure_ascii=False, indent=2) + "\n").encode("utf-8")
+            path.write_bytes(data)
+            # A sanctioned rewrite: keep the manifest hash current so export does not mistake it for a hand-edit.
+            manifest_path = canonical_dir / "manifest.json"
+            if manifest_path.exists():
+                manifest = load_json(manifest_path)
+                manifest.setdefault("files", {})[name] = manifest_row(data)
+                manifest_path.write_text(json.dumps(manifest, ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+
+    clusters = duplicate_sources(args.content)
+    removed: List[str] = []
+    if args.dedupe_sources and not args.dry_run:
+        for cluster in clusters:
+            removed += remove_copies(cluster)
+
+    stale_count = sum(len(entries) for entries in stale.values())
+    out = ["Orphan audit", f"- stale canonical entries: {stale_count}", f"- content files with zero records: {len(empty_files)}", f"- duplicated content files: {sum(len(cluster.copies) for cluster in clusters)} in {len(clusters)} clusters"]
+    if stale_count:
+        out.append("## stale entries" + (" (pruned)" if args.prune_stale and args.apply else " (would prune; pass --apply)" if args.prune_stale else ""))
+        for name, entries in sorted(stale.items()):
+            out += [f"- {name}: {entry.get('id')} <- {', '.join(entry.get('source_files', []))}" for entry in entries]
+    if empty_files:
+        out.append("## empty or ignored files")
+        out += [f"- {path}" for path in empty_files]
+    if clusters:
+        action = "deleted" if removed else "would delete" if args.dedupe_sources else "suggest deleting"
+        out.append(f"## duplicated content files (keep the first path; {action} the rest)")
+        for cluster in clusters:
+            out.append(f"- {cluster.match}: {cluster.canonical}")
+            out += [f"    - {copy}" for copy in cluster.copies]
+    write_report("audit-orphans.md", out)
+    print("\n".join(out))
+    if removed:
+        print(f"[orphans] Deleted {len(removed)} duplicated content files; re-run the export so source_files follow the kept copies.")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
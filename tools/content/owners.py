@@ -0,0 +1,88 @@
+"""CODEOWNERS-style ownership of content paths, and checks on entries' own owner/reviewers fields."""
+
+from __future__ import annotations
+
+import fnmatch
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Union
+
+try:
+    from .common import CONFIG_DIR, load_json
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, load_json  # type: ignore
+
+DEFAULT_OWNERS_PATH = CONFIG_DIR / "owners.json"
+UNOWNED = "(unowned)"
+
+
+@dataclass
+class OwnerRule:
+    path: str
+    owners: List[str]
+
+    def matches(self, source: str) -> bool:
+        """CODEOWNERS matching: a trailing slash is a directory prefix, a pattern without a slash matches the file name anywhere."""
+        source = source.replace("\\", "/")
+        pattern = self.path.lstrip("/")
+        if pattern.endswith("/"):
+            return any(fnmatch.fnmatchcase(source[: idx + 1], pattern) for idx, ch in enumerate(source) if ch == "/")
+        if "/" not in pattern:
+            return fnmatch.fnmatchcase(source.rsplit("/", 1)[-1], pattern)
+        return fnmatch.fnmatchcase(source, pattern)
+
+
+@dataclass
+class Owners:
+    """rules are checked in order and the last match wins, as in CODEOWNERS; people lists everyone who may review."""
+
+    rules: List[OwnerRule] = field(default_factory=list)
+    people: List[str] = field(default_factory=list)
+
+    def for_source(self, source: str) -> List[str]:
+        owners: List[str] = []
+        for rule in self.rules:
+            if rule.matches(source):
+                owners = rule.owners
+        return owners
+
+    def known(self) -> set:
+        return set(self.people) | {owner for rule in self.rules for owner in rule.owners}
+
+
+def load_owners(path: Optional[Union[str, Path]] = None) -> Owners:
+    cfg_path = Path(path) if path else DEFAULT_OWNERS_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    rules = [OwnerRule(str(rule["path"]), [str(o) for o in rule.get("owners", [])]) for rule in data.get("rules", [])]
+    return Owners(rules, [str(person) for person in data.get("people", [])])
+
+
+def entry_owner(entry: Dict[str, Any], source: str, owners: Owners) -> str:
+    """The entry's own owner field, else the first owner of its path, else UNOWNED."""
+    if isinstance(entry.get("owner"), str) and entry["owner"]:
+        return entry["owner"]
+    return next(iter(owners.for_source(source)), UNOWNED)
+
+
+def check_ownership(entry: Dict[str, Any], source: str, owners: Owners) -> List[str]:
+    """Problems with an entry's owner/reviewers fields; entries without them inherit their path's owners and are fine."""
+    problems: List[str] = []
+    known = owners.known()
+    owner = entry.get("owner")
+    if owner is not None:
+        path_owners = owners.for_source(source)
+        if not isinstance(owner, str) or not owner:
+            problems.append("owner must be a non-empty string")
+        elif owner not in known:
+            problems.append(f"owner '{owner}' is not listed in the owners config")
+        elif path_owners and owner not in path_owners:
+            problems.append(f"owner '{owner}' does not own {source} (owners: {', '.join(path_owners)})")
+    reviewers = entry.get("reviewers")
+    if reviewers is not None:
+        if not isinstance(reviewers, list) or not all(isinstance(r, str) and r for r in reviewers):
+            problems.append("reviewers must be a list of non-empty strings")
+        else:
+            problems += [f"reviewer '{r}' is not listed in the owners config" for r in reviewers if r not in known]
+            if owner in reviewers:
+                problems.append(f"owner '{owner}' cannot also review the entry")
+    return problems
@@ -0,0 +1,246 @@
+#!/usr/bin/env python3
+"""Spread lessons and new vocabulary across a study calendar, schedule reviews of the new words, and export the pacing plan."""
+
+from __future__ import annotations
+
+import argparse
+import datetime as dt
+import heapq
+import json
+import sys
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+from zoneinfo import ZoneInfo, ZoneInfoNotFoundError
+
+try:
+    from .common import BUILD_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, collect, lesson_order_key, load_id_scheme
+    from .console import add_output_arguments, configure_output
+    from .export import build_entries
+    from .merge import merge_by_id
+    from .srs import DEFAULT_INTERVALS, introduction_lessons
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import BUILD_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, collect, lesson_order_key, load_id_scheme  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from export import build_entries  # type: ignore
+    from merge import merge_by_id  # type: ignore
+    from srs import DEFAULT_INTERVALS, introduction_lessons  # type: ignore
+
+WEEKDAYS = ("mon", "tue", "wed", "thu", "fri", "sat", "sun")
+
+
+def curriculum_sequence(lessons: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """Order lessons by level/unit/number while honouring each lesson's optional `prerequisites` IDs."""
+    by_id = {lesson["id"]: lesson for lesson in lessons}
+    waiting = {lesson["id"]: {p for p in lesson.get("prerequisites", []) if p in by_id and p != lesson["id"]} for lesson in lessons}
+    unlocks: Dict[str, List[str]] = {}
+    for lesson_id, prereqs in waiting.items():
+        for prereq in prereqs:
+            unlocks.setdefault(prereq, []).append(lesson_id)
+    ready = [(lesson_order_key(by_id[i]), i) for i, prereqs in waiting.items() if not prereqs]
+    heapq.heapify(ready)
+    ordered: List[Dict[str, Any]] = []
+    while ready:
+        _, lesson_id = heapq.heappop(ready)
+        ordered.append(by_id[lesson_id])
+        for follower in unlocks.get(lesson_id, []):
+            waiting[follower].discard(lesson_id)
+            if not waiting[follower]:
+                heapq.heappush(ready, (lesson_order_key(by_id[follower]), follower))
+    # Prerequisite cycles cannot be satisfied; append those lessons in curriculum order rather than dropping them.
+    placed = {lesson["id"] for lesson in ordered}
+    ordered += sorted((lesson for lesson in lessons if lesson["id"] not in placed), key=lesson_order_key)
+    return ordered
+
+
+def lesson_minutes(lesson: Dict[str, Any], per_step: float) -> float:
+    minutes = lesson.get("minutes")
+    if isinstance(minutes, (int, float)) and minutes > 0:
+        return float(minutes)
+    return max(1.0, per_step * len(lesson.get("steps", [])))
+
+
+def study_dates(start: dt.date, days: List[int]) -> Iterable[dt.date]:
+    day = start
+    while True:
+        if day.weekday() in days:
+            yield day
+        day += dt.timedelta(days=1)
+
+
+def build_plan(lessons: List[Dict[str, Any]], vocab: List[Dict[str, Any]], args: argparse.Namespace) -> List[Dict[str, Any]]:
+    """Fill each study day up to the minute budget; a lesson longer than one day continues on the next."""
+    intro = introduction_lessons(vocab, lessons)
+    new_words: Dict[str, List[str]] = {}
+    for vocab_id, lesson_id in intro.items():
+        if lesson_id:
+            new_words.setdefault(lesson_id, []).append(vocab_id)
+
+    dates = study_dates(args.start, [WEEKDAYS.index(day) for day in args.study_days])
+    plan: List[Dict[str, Any]] = []
+    day: Dict[str, Any] = {}
+    for lesson in curriculum_sequence(lessons):
+        words = new_words.get(lesson["id"], [])
+        remaining = lesson_minutes(lesson, args.minutes_per_step) + args.minutes_per_word * len(words)
+        part = 1
+        while remaining > 0:
+            if not day or day["minutes"] >= args.minutes_per_day - 1e-9:
+                day = {"date": next(dates).isoformat(), "minutes": 0.0, "lessons": [], "new_vocab": []}
+                plan.append(day)
+            chunk = min(remaining, args.minutes_per_day - day["minutes"])
+            day["lessons"].append({"id": lesson["id"], "title": lesson.get("title"), "minutes": round(chunk, 1), "part": part})
+            if part == 1:
+                day["new_vocab"] += words
+            day["minutes"] += chunk
+            remaining -= chunk
+            part += 1
+    for day in plan:
+        day["minutes"] = round(day["minutes"], 1)
+    return plan
+
+
+def add_reviews(plan: List[Dict[str, Any]], days: List[int], intervals: List[int], minutes_per_review: float) -> List[Dict[str, Any]]:
+    """Review each day's new words on the SRS interval ladder, counted in calendar days from the day they were
+    introduced; a review falling on a rest day moves to the next study day. Review-only days extend the plan."""
+    by_date = {day["date"]: day for day in plan}
+    for day in plan:
+        day["reviews"] = []
+    for day in list(plan):
+        if not day["new_vocab"]:
+            continue
+        due = dt.date.fromisoformat(day["date"])
+        for interval in intervals:
+            due += dt.timedelta(days=interval)
+            date = next(study_dates(due, days)).isoformat()
+            if date not in by_date:
+                by_date[date] = {"date": date, "minutes": 0.0, "lessons": [], "new_vocab": [], "reviews": []}
+            by_date[date]["reviews"] += [vocab_id for vocab_id in day["new_vocab"] if vocab_id not in by_date[date]["reviews"]]
+    for day in by_date.values():
+        day["review_minutes"] = round(minutes_per_review * len(day["reviews"]), 1)
+    return sorted(by_date.values(), key=lambda day: day["date"])
+
+
+def fold_ics(line: str) -> List[str]:
+    """Split a content line into 75-octet chunks, continuation lines starting with a space (RFC 5545)."""
+    chunks: List[str] = []
+    current = ""
+    for ch in line:
+        if len((current + ch).encode("utf-8")) > (75 if not chunks else 74):
+            chunks.append(current)
+            current = ""
+        current += ch
+    chunks.append(current)
+    return [chunks[0]] + [" " + chunk for chunk in chunks[1:]]
+
+
+def ics_text(text: str) -> str:
+    return text.replace("\\", "\\\\").replace(",", "\\,").replace(";", "\\;").replace("\n", "\\n")
+
+
+def event_times(date: str, start: Optional[dt.time], minutes: float, tz: Optional[str], offset: float = 0.0) -> List[str]:
+    """DTSTART/DTEND lines: an all-day event without a start time, else one beginning offset minutes after start
+    and lasting minutes (floating local time unless tz names a zone)."""
+    day = dt.date.fromisoformat(date)
+    if start is None:
+        return [f"DTSTART;VALUE=DATE:{day.strftime('%Y%m%d')}", f"DTEND;VALUE=DATE:{(day + dt.timedelta(days=1)).strftime('%Y%m%d')}"]
+    begin = dt.datetime.combine(day, start) + dt.timedelta(minutes=round(offset))
+    end = begin + dt.timedelta(minutes=max(1, round(minutes)))
+    param = f";TZID={tz}" if tz else ""
+    return [f"DTSTART{param}:{begin.strftime('%Y%m%dT%H%M%S')}", f"DTEND{param}:{end.strftime('%Y%m%dT%H%M%S')}"]
+
+
+def to_ics(plan: List[Dict[str, Any]], start: Optional[dt.time] = None, tz: Optional[str] = None) -> str:
+    """One event per study day's lessons and one per review session; with a start time the review follows the lessons."""
+    stamp = dt.datetime.now(dt.timezone.utc).strftime("%Y%m%dT%H%M%SZ")
+    lines = ["BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//mmspanish//pacing plan//EN", "CALSCALE:GREGORIAN", "X-WR-CALNAME:Spanish study plan"]
+    if tz:
+        lines.append(f"X-WR-TIMEZONE:{tz}")
+    for idx, day in enumerate(plan):
+        date = day["date"].replace("-", "")
+        if day["lessons"]:
+            titles = ", ".join(str(item["title"] or item["id"]) + (f" (part {item['part']})" if item["part"] > 1 else "") for item in day["lessons"])
+            description = f"{day['minutes']} minutes, {len(day['new_vocab'])} new words"
+            lines += [
+                "BEGIN:VEVENT",
+                f"UID:plan-{date}-{idx}@mmspanish",
+                f"DTSTAMP:{stamp}",
+                *event_times(day["date"], start, day["minutes"], tz),
+                f"SUMMARY:{ics_text('Spanish: ' + titles)}",
+                f"DESCRIPTION:{ics_text(description)}",
+                "END:VEVENT",
+            ]
+        if day.get("reviews"):
+            lines += [
+                "BEGIN:VEVENT",
+                f"UID:review-{date}-{idx}@mmspanish",
+                f"DTSTAMP:{stamp}",
+                *event_times(day["date"], start, day["review_minutes"], tz, offset=day["minutes"]),
+                f"SUMMARY:Spanish review: {len(day['reviews'])} words",
+                f"DESCRIPTION:{ics_text(str(day['review_minutes']) + ' minutes: ' + ', '.join(day['reviews']))}",
+                "END:VEVENT",
+            ]
+    lines.append("END:VCALENDAR")
+    return "\r\n".join(folded for line in lines for folded in fold_ics(line)) + "\r\n"
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Build a daily pacing plan for the curriculum.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to collect")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument("--out", default=str(BUILD_DIR / "plan"), help="Output directory for plan.json / plan.ics")
+    parser.add_argument("--format", choices=("json", "ics", "both"), default="both", help="Which plan files to write")
+    parser.add_argument("--minutes-per-day", type=float, default=20.0, help="Study budget per study day")
+    parser.add_argument("--minutes-per-step", type=float, default=2.0, help="Estimated minutes per lesson step when a lesson has no `minutes`")
+    parser.add_argument("--minutes-per-word", type=float, default=1.0, help="Estimated minutes per newly introduced word")
+    parser.add_argument("--start", type=dt.date.fromisoformat, default=dt.date.today(), help="First study date (YYYY-MM-DD)")
+    parser.add_argument("--study-days", type=lambda v: [d.strip().lower()[:3] for d in v.split(",")], default=list(WEEKDAYS[:5]), help="Comma-separated weekdays to study (default: mon-fri)")
+    parser.add_argument(
+        "--review-intervals",
+        type=lambda v: [int(d) for d in v.split(",")],
+        default=list(DEFAULT_INTERVALS),
+        help=f"Days between successive reviews of a new word (default: {','.join(map(str, DEFAULT_INTERVALS))}, the SRS ladder)",
+    )
+    parser.add_argument("--minutes-per-review", type=float, default=0.5, help="Estimated minutes per reviewed word")
+    parser.add_argument("--no-reviews", action="store_true", help="Schedule lessons only")
+    parser.add_argument("--time", type=dt.time.fromisoformat, help="Start time (HH:MM) for timed calendar events; without it every event is all-day")
+    parser.add_argument("--timezone", help="IANA time zone for timed events (e.g. America/Mexico_City); default is the calendar's own zone")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    unknown = [day for day in args.study_days if day not in WEEKDAYS]
+    if unknown or not args.study_days:
+        parser.error(f"--study-days takes weekdays from {', '.join(WEEKDAYS)}")
+    if args.minutes_per_day <= 0:
+        parser.error("--minutes-per-day must be positive")
+    if any(interval < 1 for interval in args.review_intervals):
+        parser.error("--review-intervals must be whole days, each at least 1")
+    if args.timezone:
+        try:
+            ZoneInfo(args.timezone)
+        except (ValueError, ZoneInfoNotFoundError):
+            parser.error(f"unknown time zone {args.timezone}")
+        if args.time is None:
+            parser.error("--timezone needs --time")
+
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+    vocab, _ = merge_by_id(build_entries(dataset.vocab, scheme))
+    lessons, _ = merge_by_id(build_entries(dataset.lessons, scheme))
+    plan = build_plan(lessons, vocab, args)
+    if not args.no_reviews:
+        plan = add_reviews(plan, [WEEKDAYS.index(day) for day in args.study_days], args.review_intervals, args.minutes_per_review)
+
+    out_dir = Path(args.out)
+    out_dir.mkdir(parents=True, exist_ok=True)
+    if args.format in ("json", "both"):
+        (out_dir / "plan.json").write_text(json.dumps(plan, ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+    if args.format in ("ics", "both"):
+        (out_dir / "plan.ics").write_text(to_ics(plan, args.time, args.timezone), encoding="utf-8", newline="")
+    last = plan[-1]["date"] if plan else args.start.isoformat()
+    reviews = sum(1 for day in plan if day.get("reviews"))
+    print(f"[plan] {len(lessons)} lessons and {reviews} review sessions over {len(plan)} study days ({args.start.isoformat()} to {last}) written to {out_dir}")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
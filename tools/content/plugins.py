@@ -0,0 +1,145 @@
+"""Team-specific record types and enrichers, loaded from Python hooks named in config/plugins.json.
+
+A plugin kind claims records that no built-in kind recognises, so a team can add its own record types
+(idioms, dialogues, exam items) without maintaining a fork. Export gives them IDs, validates them against
+the kind's schema when one is named, merges duplicates, and writes <output>.json beside the built-in files:
+
+    {"kinds": [{"name": "idiom", "classifier": "team_hooks:is_idiom", "output": "idioms",
+                "needs": "an idiom field", "schema": "tools/schemas/idiom.schema.json"}],
+     "enrichers": [{"hook": "team_hooks:add_region", "kinds": ["vocab", "idiom"]}]}
+
+A hook is `module:function` (a module importable from where the tools run) or `path/to/file.py:function`.
+A classifier takes a record's fields and returns whether it is of the kind. An enricher takes one entry
+and either edits it in place or returns the entry to use instead; pinned entries are never enriched.
+Only Python hooks load: WebAssembly and compiled plugins would need a runtime this toolchain does not ship.
+"""
+
+from __future__ import annotations
+
+import dataclasses
+import importlib
+import importlib.util
+import sys
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional, Tuple, Union
+
+try:
+    from .common import CLASSIFY_NEEDS, CONFIG_DIR, ENTRY_OUTPUTS, ROOT, Record, is_pinned, load_json
+    from .errors import ConfigError
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CLASSIFY_NEEDS, CONFIG_DIR, ENTRY_OUTPUTS, ROOT, Record, is_pinned, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+
+DEFAULT_PLUGINS_PATH = CONFIG_DIR / "plugins.json"
+# Files export writes itself; a plugin kind's output must not overwrite one.
+RESERVED_OUTPUTS = {*ENTRY_OUTPUTS, "tombstones", "relations", "manifest", "forms_index", "alignment", "search-index"}
+
+
+@dataclass
+class PluginKind:
+    name: str
+    classifier: Callable[[Dict[str, Any]], Any]
+    output: str
+    needs: str = ""
+    schema: Optional[Dict[str, Any]] = None
+
+
+@dataclass
+class Enricher:
+    spec: str
+    hook: Callable[[Dict[str, Any]], Any]
+    kinds: List[str] = field(default_factory=list)
+
+
+@dataclass
+class Plugins:
+    kinds: Dict[str, PluginKind] = field(default_factory=dict)
+    enrichers: List[Enricher] = field(default_factory=list)
+
+    def claim(self, records: List[Record]) -> Tuple[Dict[str, List[Record]], List[Record]]:
+        """(records per plugin kind, records still unclassified); the first kind whose classifier accepts a record takes it."""
+        claimed: Dict[str, List[Record]] = {name: [] for name in self.kinds}
+        rest: List[Record] = []
+        for record in records:
+            kind = next((name for name, plugin in self.kinds.items() if plugin.classifier(dict(record.data))), None)
+            if kind:
+                claimed[kind].append(dataclasses.replace(record, kind=kind))
+            else:
+                rest.append(record)
+        return claimed, rest
+
+    def enrich(self, kinds: Dict[str, List[Dict[str, Any]]]) -> int:
+        """Run every enricher over the entries of its kinds, in config order; returns how many entries changed."""
+        changed = 0
+        for kind, entries in kinds.items():
+            for idx, entry in enumerate(entries):
+                if is_pinned(entry):
+                    continue
+                before = repr(entry)
+                for enricher in self.enrichers:
+                    if kind in enricher.kinds:
+                        result = enricher.hook(entry)
+                        entry = result if isinstance(result, dict) else entry
+                entries[idx] = entry
+                changed += repr(entry) != before
+        return changed
+
+
+def load_hook(spec: str, setting: str) -> Callable[..., Any]:
+    """Resolve `module:function` or `path/to/file.py:function`."""
+    target, _, func_name = spec.rpartition(":")
+    if not target or not func_name:
+        raise ConfigError(f"{setting}: a hook must look like module:function, got {spec!r}", setting, spec)
+    try:
+        if target.endswith(".py"):
+            path = Path(target) if Path(target).is_absolute() else ROOT / target
+            module_spec = importlib.util.spec_from_file_location(f"content_plugin_{path.stem}", path)
+            if module_spec is None or module_spec.loader is None or not path.is_file():
+                raise ImportError(f"no such file {path}")
+            module = importlib.util.module_from_spec(module_spec)
+            module_spec.loader.exec_module(module)
+        else:
+            # Hooks usually live next to where the tools are run, not next to this script.
+            if str(Path.cwd()) not in sys.path:
+                sys.path.append(str(Path.cwd()))
+            module = importlib.import_module(target)
+        hook = getattr(module, func_name)
+    except (ImportError, AttributeError, SyntaxError) as exc:
+        raise ConfigError(f"{setting}: cannot load {spec}: {exc}", setting, spec) from None
+    if not callable(hook):
+        raise ConfigError(f"{setting}: {spec} is not a function", setting, spec)
+    return hook
+
+
+def load_plugins(path: Optional[Union[str, Path]] = None) -> Plugins:
+    """The kinds and enrichers in path; no file means no plugins."""
+    cfg_path = Path(path) if path else DEFAULT_PLUGINS_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    plugins = Plugins()
+    for row in data.get("kinds", []):
+        name = row.get("name") if isinstance(row, dict) else None
+        if not isinstance(name, str) or not name or not isinstance(row.get("classifier"), str):
+            raise ConfigError("plugins: every kind needs a name and a classifier hook", "kinds", row)
+        if name in CLASSIFY_NEEDS or name in plugins.kinds:
+            raise ConfigError(f"plugin kind {name}: the name is already taken", f"kinds.{name}", name)
+        output = str(row.get("output") or f"{name}s")
+        if output in RESERVED_OUTPUTS or any(kind.output == output for kind in plugins.kinds.values()):
+            raise ConfigError(f"plugin kind {name}: output {output}.json is already written by export", f"kinds.{name}.output", output)
+        schema = None
+        if row.get("schema"):
+            schema_path = ROOT / row["schema"]
+            if not schema_path.is_file():
+                raise ConfigError(f"plugin kind {name}: schema {row['schema']} not found", f"kinds.{name}.schema", row["schema"])
+            schema = load_json(schema_path)
+        plugins.kinds[name] = PluginKind(name, load_hook(row["classifier"], f"kinds.{name}.classifier"), output, str(row.get("needs", "")), schema)
+    known = set(CLASSIFY_NEEDS) | set(plugins.kinds)
+    for index, row in enumerate(data.get("enrichers", [])):
+        if not isinstance(row, dict) or not isinstance(row.get("hook"), str):
+            raise ConfigError("plugins: every enricher needs a hook", f"enrichers[{index}]", row)
+        kinds = row.get("kinds") or sorted(known)
+        unknown = [kind for kind in kinds if kind not in known]
+        if unknown:
+            raise ConfigError(f"enricher {row['hook']}: unknown kind {', '.join(unknown)}", f"enrichers[{index}].kinds", unknown, sorted(known))
+        plugins.enrichers.append(Enricher(row["hook"], load_hook(row["hook"], f"enrichers[{index}].hook"), list(kinds)))
+    return plugins
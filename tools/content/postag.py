@@ -0,0 +1,131 @@
+"""Tag how an example sentence uses its headword (noun, verb, or adjective) from the word's form and its neighbours.
+
+This is not a full tagger. Each occurrence of the headword is scored against a few strong cues: a determiner
+or preposition in front suggests a noun, a subject or object pronoun or "no" suggests a verb, a copula or
+intensifier suggests an adjective. A form only one part of speech produces (hablamos, casas) counts as well.
+That is enough to notice a "noun" entry whose examples only ever use the word as a verb.
+"""
+
+from __future__ import annotations
+
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Set, Tuple
+
+try:
+    from .forms import fold, inflect
+except ImportError:  # pragma: no cover - allow running as a script
+    from forms import fold, inflect  # type: ignore
+
+TAGGED_POS = ("noun", "verb", "adj")
+POS_NAMES = {"noun": "a noun", "verb": "a verb", "adj": "an adjective"}
+DETERMINERS = {
+    "el", "los", "un", "una", "unos", "unas", "del", "al", "este", "esta", "estos", "estas", "ese", "esa", "esos", "esas",
+    "aquel", "aquella", "aquellos", "aquellas", "mi", "mis", "tu", "tus", "su", "sus", "nuestro", "nuestra", "nuestros",
+    "nuestras", "vuestro", "vuestra", "vuestros", "vuestras", "cada", "otro", "otra", "otros", "otras", "algún", "alguna",
+    "ningún", "ninguna", "varios", "varias",
+}
+# Articles that are also object pronouns (la veo, las compro): weak evidence for either reading.
+AMBIGUOUS_ARTICLES = {"la", "las", "lo"}
+PREPOSITIONS = {"de", "en", "con", "sin", "por", "para", "sobre", "entre", "hacia", "desde", "hasta"}
+SUBJECT_PRONOUNS = {"yo", "tú", "él", "ella", "usted", "nosotros", "nosotras", "vosotros", "vosotras", "ellos", "ellas", "ustedes", "vos"}
+OBJECT_PRONOUNS = {"me", "te", "se", "nos", "os", "le", "les"}
+# Words after which an infinitive is the verb, not a noun (quiero comer, voy a comer, hay que comer).
+VERB_GOVERNORS = {"quiero", "quieres", "quiere", "puedo", "puedes", "puede", "debo", "debes", "debe", "necesito", "necesita", "sé", "sabe", "que"}
+COPULAS = {"es", "son", "soy", "eres", "somos", "está", "están", "estoy", "estás", "estamos", "parece", "muy", "más", "menos", "tan", "bastante", "demasiado"}
+
+
+@dataclass
+class Tag:
+    pos: str
+    confidence: float
+    cues: List[str] = field(default_factory=list)
+
+
+def pos_forms(headword: str, extra: Optional[Dict[str, Set[str]]] = None) -> Dict[str, Set[str]]:
+    """Accent-folded forms the headword would take as each part of speech; extra adds forms (from the form bank) per part of speech."""
+    forms: Dict[str, Set[str]] = {}
+    for pos in TAGGED_POS:
+        produced = {form for _, form in inflect({"spanish": headword, "pos": pos}) if " " not in form}
+        forms[pos] = {fold(form) for form in produced | (extra or {}).get(pos, set())} | {fold(headword.strip())}
+    return forms
+
+
+def occurrence_scores(tokens: List[str], position: int, forms: Dict[str, Set[str]]) -> Dict[str, Tuple[float, List[str]]]:
+    """Score and cues per part of speech for the token at position."""
+    token = fold(tokens[position])
+    before = tokens[position - 1] if position > 0 else ""
+    earlier = tokens[position - 2] if position > 1 else ""
+    scores: Dict[str, Tuple[float, List[str]]] = {pos: (0.0, []) for pos in TAGGED_POS}
+
+    def add(pos: str, weight: float, cue: str) -> None:
+        score, cues = scores[pos]
+        scores[pos] = (score + weight, cues + [cue])
+
+    producers = [pos for pos in TAGGED_POS if token in forms[pos]]
+    if len(producers) == 1:
+        add(producers[0], 2.0, f"'{tokens[position]}' is a {producers[0]} form")
+    if before in DETERMINERS:
+        add("noun", 3.0, f"after the determiner '{before}'")
+    elif before in AMBIGUOUS_ARTICLES:
+        add("noun", 1.0, f"after '{before}'")
+        add("verb", 1.0, f"after '{before}'")
+    elif before in PREPOSITIONS:
+        add("noun", 1.5, f"after the preposition '{before}'")
+    if before in SUBJECT_PRONOUNS:
+        add("verb", 2.5, f"after the subject pronoun '{before}'")
+    if before in OBJECT_PRONOUNS or before == "no":
+        add("verb", 2.5, f"after '{before}'")
+    if before in VERB_GOVERNORS or (before == "a" and earlier.startswith(("voy", "vas", "va", "vamos", "van"))):
+        add("verb", 2.0, f"after '{earlier} a'" if before == "a" else f"after '{before}'")
+    if before in COPULAS:
+        add("adj", 2.5, f"after '{before}'")
+    if earlier in DETERMINERS and before and fold(before) not in forms["noun"]:
+        add("adj", 1.0, f"follows the noun in '{earlier} {before} {tokens[position]}'")
+    return scores
+
+
+def tag_usage(text: str, forms: Dict[str, Set[str]]) -> Optional[Tag]:
+    """How text uses the headword: the best-scoring part of speech over its occurrences, with confidence as
+    that score's share of all scores. None when the headword does not occur or nothing points either way."""
+    tokens = re.findall(r"\w+", text.lower())
+    every = set().union(*forms.values())
+    totals: Dict[str, float] = {pos: 0.0 for pos in TAGGED_POS}
+    cues: Dict[str, List[str]] = {pos: [] for pos in TAGGED_POS}
+    for position, token in enumerate(tokens):
+        if fold(token) not in every:
+            continue
+        for pos, (score, found) in occurrence_scores(tokens, position, forms).items():
+            totals[pos] += score
+            cues[pos] += found
+    best = max(TAGGED_POS, key=lambda pos: totals[pos])
+    overall = sum(totals.values())
+    if not overall or list(totals.values()).count(totals[best]) > 1:
+        return None
+    return Tag(best, totals[best] / overall, cues[best])
+
+
+@dataclass
+class UsageMismatch:
+    declared: str
+    used_as: str
+    confidence: float
+    examples: List[Tuple[str, str, Tag]]  # (path, Spanish, tag) for each example using the word as used_as
+
+
+def check_usage(entry: Dict[str, Any], examples: List[Tuple[str, str]], extra: Optional[Dict[str, Set[str]]] = None) -> Optional[UsageMismatch]:
+    """A mismatch when no example uses the headword as its declared noun/verb/adjective and some use it as another.
+
+    confidence is the mean tag confidence times the share of examples agreeing on the other part of speech.
+    """
+    declared, headword = entry.get("pos"), entry.get("spanish")
+    if declared not in TAGGED_POS or not isinstance(headword, str) or " " in headword.strip():
+        return None
+    forms = pos_forms(headword, extra)
+    tagged = [(path, text, tag) for path, text in examples for tag in [tag_usage(text, forms)] if tag]
+    if not tagged or any(tag.pos == declared for _, _, tag in tagged):
+        return None
+    used_as = max(TAGGED_POS, key=lambda pos: sum(1 for _, _, tag in tagged if tag.pos == pos))
+    agreeing = [row for row in tagged if row[2].pos == used_as]
+    confidence = sum(tag.confidence for _, _, tag in agreeing) / len(agreeing) * len(agreeing) / len(examples)
+    return UsageMismatch(declared, used_as, round(confidence, 2), agreeing)
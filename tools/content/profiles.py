@@ -0,0 +1,135 @@
+"""Export profiles: per-consumer builds with their own fields, levels, licenses, formats, and output path."""
+
+from __future__ import annotations
+
+import fnmatch
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Union
+
+try:
+    from .common import CONFIG_DIR, LEVELS, load_json
+    from .errors import ConfigError
+    from .licenses import license_violations
+    from .locales import I18N_SUFFIX, localize
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, LEVELS, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from licenses import license_violations  # type: ignore
+    from locales import I18N_SUFFIX, localize  # type: ignore
+
+DEFAULT_PROFILES_PATH = CONFIG_DIR / "export_profiles.json"
+PROFILE_FORMATS = ("json", "jsonl")
+LICENSE_MODES = ("gate", "filter")
+
+
+@dataclass
+class ExportProfile:
+    """exclude lists key patterns dropped at any depth; unreviewed lists keys kept only once reviewed.
+
+    An entry marks review with `"reviewed": true` (everything) or a list of reviewed field names;
+    a review_status of reviewed or published counts as `"reviewed": true`.
+    licenses lists the third-party licenses the profile may publish; without it no third-party entry is allowed.
+    license_mode "gate" fails the export on any other license, "filter" leaves those entries out of the profile.
+    compact publishes an entry's summary (see export --summaries) as its definition and drops the summary field.
+    include keeps only these top-level fields (plus id); levels keeps only entries at these CEFR levels.
+    formats picks json (one array per kind) and/or jsonl; output is the path inside the storage backend (default: the name).
+    locale ships instruction text in one language: each `*_i18n` variant replaces its field (see locales.py).
+    An excluded key takes its variants with it.
+    """
+
+    name: str
+    exclude: List[str] = field(default_factory=list)
+    unreviewed: List[str] = field(default_factory=list)
+    licenses: List[str] = field(default_factory=list)
+    compact: bool = False
+    include: List[str] = field(default_factory=list)
+    levels: List[str] = field(default_factory=list)
+    formats: List[str] = field(default_factory=lambda: ["json"])
+    output: str = ""
+    license_mode: str = "gate"
+    locale: str = ""
+
+    def excludes(self, key: str) -> bool:
+        key = key[: -len(I18N_SUFFIX)] if key.endswith(I18N_SUFFIX) else key
+        return any(fnmatch.fnmatchcase(key, pattern) for pattern in self.exclude)
+
+    @property
+    def path(self) -> str:
+        return self.output.strip("/") or self.name
+
+
+def load_profiles(path: Optional[Union[str, Path]] = None) -> Dict[str, ExportProfile]:
+    cfg_path = Path(path) if path else DEFAULT_PROFILES_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    profiles = {
+        name: ExportProfile(
+            name,
+            [str(p) for p in cfg.get("exclude", [])],
+            [str(k) for k in cfg.get("unreviewed", [])],
+            [str(spdx) for spdx in cfg.get("licenses", [])],
+            bool(cfg.get("compact", False)),
+            [str(k) for k in cfg.get("include", [])],
+            [str(level).upper() for level in cfg.get("levels", [])],
+            [str(fmt) for fmt in cfg.get("formats", ["json"])],
+            str(cfg.get("output", "")),
+            str(cfg.get("license_mode", "gate")),
+            str(cfg.get("locale", "")),
+        )
+        for name, cfg in data.items()
+    }
+    for profile in profiles.values():
+        problems = [f"unknown format '{fmt}'" for fmt in profile.formats if fmt not in PROFILE_FORMATS]
+        problems += [f"unknown level '{level}'" for level in profile.levels if level not in LEVELS + ("UNSET",)]
+        if profile.license_mode not in LICENSE_MODES:
+            problems.append(f"unknown license_mode '{profile.license_mode}'")
+        if problems:
+            raise ConfigError(f"export profile {profile.name}: {'; '.join(problems)}", profile.name)
+    return profiles
+
+
+def redact(value: Any, profile: ExportProfile, reviewed: Any = False) -> Any:
+    """Return a copy of value without the profile's excluded or unreviewed keys; the review flag inherits downward."""
+    if isinstance(value, list):
+        return [redact(item, profile, reviewed) for item in value]
+    if not isinstance(value, dict):
+        return value
+    if value.get("review_status") in ("reviewed", "published"):
+        reviewed = True
+    reviewed = value.get("reviewed", reviewed)
+    out: Dict[str, Any] = {}
+    for key, item in value.items():
+        if profile.excludes(key):
+            continue
+        if key in profile.unreviewed and not (reviewed is True or (isinstance(reviewed, list) and key in reviewed)):
+            continue
+        out[key] = redact(item, profile, reviewed)
+    return out
+
+
+def compact(entry: Dict[str, Any]) -> Dict[str, Any]:
+    if not entry.get("summary"):
+        return entry
+    out = {key: value for key, value in entry.items() if key != "summary"}
+    out["definition"] = entry["summary"]
+    return out
+
+
+def selected(entry: Dict[str, Any], profile: ExportProfile) -> bool:
+    """Whether the profile publishes the entry at all: its level is listed, and its license passes in filter mode."""
+    level = entry.get("level")
+    level = level.upper() if isinstance(level, str) and level else "UNSET"
+    if profile.levels and level not in profile.levels:
+        return False
+    return not (profile.license_mode == "filter" and license_violations([entry], profile.licenses))
+
+
+def apply_profile(entries: List[Dict[str, Any]], profile: ExportProfile) -> List[Dict[str, Any]]:
+    redacted = [redact(entry, profile) for entry in entries if selected(entry, profile)]
+    if profile.compact:
+        redacted = [compact(entry) for entry in redacted]
+    if profile.locale:
+        redacted = [localize(entry, profile.locale)[0] for entry in redacted]
+    if profile.include:
+        redacted = [{key: value for key, value in entry.items() if key == "id" or key in profile.include} for entry in redacted]
+    return redacted
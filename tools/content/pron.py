@@ -0,0 +1,72 @@
+"""Pronunciation drills: a target phoneme or contrast, a word list with IPA, tongue twisters, and audio."""
+
+from __future__ import annotations
+
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+REMOTE_PREFIXES = ("http://", "https://")
+# "perro /ˈpero/" or "perro [ˈpero]" in a plain word list.
+WORD_WITH_IPA = re.compile(r"^(?P<word>.*?)\s*[/\[](?P<ipa>[^/\]]+)[/\]]$")
+# Stress and length marks do not change which phonemes a transcription contains.
+IPA_MARKS = str.maketrans("", "", "/[]ˈˌː.")
+
+
+def normalize_drill(entry: Dict[str, Any]) -> Dict[str, Any]:
+    """The canonical drill shape: kind set, a `drill` name moved to title (a `drill` object's fields lifted to
+    the top), words as {word, ipa, audio} and tongue twisters as {es, en, audio} even when written as strings."""
+    entry = dict(entry)
+    entry["kind"] = "pron_drill"
+    drill = entry.pop("drill", None)
+    if isinstance(drill, dict):
+        for key, value in drill.items():
+            entry.setdefault(key, value)
+    elif isinstance(drill, str) and drill.strip():
+        entry.setdefault("title", drill.strip())
+    if isinstance(entry.get("words"), list):
+        entry["words"] = [drill_word(word) for word in entry["words"]]
+    if isinstance(entry.get("tongue_twisters"), list):
+        entry["tongue_twisters"] = [{"es": item} if isinstance(item, str) else item for item in entry["tongue_twisters"]]
+    return entry
+
+
+def drill_word(word: Any) -> Any:
+    if not isinstance(word, str):
+        return word
+    match = WORD_WITH_IPA.match(word.strip())
+    return {"word": match.group("word"), "ipa": f"/{match.group('ipa').strip()}/"} if match and match.group("word") else {"word": word.strip()}
+
+
+def phonemes(value: Any) -> List[str]:
+    """The target symbols of a phoneme or contrast field: "/r/ vs /ɾ/", ["/r/", "/ɾ/"], or "/θ/"."""
+    items = value if isinstance(value, list) else re.split(r"\s+(?:vs\.?|/)\s+|,\s*", value) if isinstance(value, str) else []
+    return [symbol for symbol in (str(item).translate(IPA_MARKS).strip() for item in items) if symbol]
+
+
+def audio_refs(drill: Dict[str, Any]) -> List[str]:
+    refs = drill.get("audio")
+    found = [refs] if isinstance(refs, str) else [ref for ref in refs if isinstance(ref, str)] if isinstance(refs, list) else []
+    for key in ("words", "tongue_twisters"):
+        found += [item["audio"] for item in drill.get(key) or [] if isinstance(item, dict) and isinstance(item.get("audio"), str)]
+    return found
+
+
+def check_drills(drills: List[Dict[str, Any]], assets: Optional[Path] = None) -> List[Tuple[str, str]]:
+    """(drill ID, problem) for words without IPA, IPA that contains none of the drilled phonemes, and local
+    audio missing under assets. Remote audio is not fetched; pass assets=None to skip the file check."""
+    problems: List[Tuple[str, str]] = []
+    for drill in drills:
+        targets = phonemes(drill.get("phoneme")) + phonemes(drill.get("contrast"))
+        for item in drill.get("words") or []:
+            if not isinstance(item, dict):
+                continue
+            word, ipa = item.get("word", "?"), item.get("ipa")
+            if not isinstance(ipa, str) or not ipa.strip():
+                problems.append((drill["id"], f"word {word} has no IPA"))
+            elif targets and not any(target in ipa.translate(IPA_MARKS) for target in targets):
+                problems.append((drill["id"], f"IPA {ipa} for {word} contains none of {', '.join(targets)}"))
+        for ref in audio_refs(drill):
+            if assets is not None and not ref.startswith(REMOTE_PREFIXES) and not (assets / ref).is_file():
+                problems.append((drill["id"], f"audio {ref} not found under {assets}"))
+    return problems
@@ -0,0 +1,112 @@
+"""Commit canonical outputs to a dedicated git branch, so a release is one export instead of a copy and a commit by hand.
+
+The branch is checked out in its own worktree under build/worktrees, leaving the working tree you export from
+untouched. The branch mirrors the storage layout (canonical/..., public/...), and every commit message carries
+the dataset version and the counts from the manifest.
+"""
+
+from __future__ import annotations
+
+import hashlib
+import re
+import shutil
+import subprocess
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+try:
+    from .common import BUILD_DIR, ROOT
+    from .errors import PublishFailed
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import BUILD_DIR, ROOT  # type: ignore
+    from errors import PublishFailed  # type: ignore
+
+DEFAULT_BRANCH = "canonical-data"
+WORKTREES_DIR = BUILD_DIR / "worktrees"
+
+
+@dataclass
+class PublishResult:
+    branch: str
+    commit: Optional[str]  # None when the outputs matched the branch and there was nothing to commit
+    pushed: Optional[str] = None
+
+
+def git(args: List[str], cwd: Path, check: bool = True, stdin: Optional[str] = None) -> subprocess.CompletedProcess:
+    done = subprocess.run(["git", *args], cwd=cwd, input=stdin, capture_output=True, text=True)
+    if check and done.returncode != 0:
+        raise PublishFailed(args, done.stderr or done.stdout)
+    return done
+
+
+def dataset_version(files: Dict[str, Dict[str, Any]]) -> str:
+    """Short digest of every output's hash: equal versions mean byte-identical releases."""
+    rows = "\n".join(f"{name} {row['sha256']}" for name, row in sorted(files.items()))
+    return hashlib.sha256(rows.encode("utf-8")).hexdigest()[:12]
+
+
+def commit_message(version: str, counts: Dict[str, int], run: Dict[str, Any], changed: int) -> str:
+    content = run["git"]["commit"] or "unknown"
+    lines = [
+        f"Canonical data {version}: {counts.get('lessons', 0)} lessons, {counts.get('vocabulary', 0)} vocabulary, {counts.get('readings', 0)} readings",
+        "",
+        f"Dataset version: {version}",
+        f"Content commit: {content}" + (" (dirty)" if run["git"]["dirty"] else ""),
+        f"Tool version: {run['tool_version']}",
+        f"Changed entries: {changed}",
+        "",
+    ]
+    lines += [f"- {name.replace('_', ' ')}: {count}" for name, count in counts.items()]
+    return "\n".join(lines) + "\n"
+
+
+def branch_worktree(branch: str, repo: Path = ROOT, worktrees: Path = WORKTREES_DIR) -> Path:
+    """The worktree holding branch, created on first use from the local branch, origin's, or a new orphan branch."""
+    path = worktrees / re.sub(r"[^A-Za-z0-9._-]+", "-", branch)
+    if (path / ".git").exists():
+        return path
+    worktrees.mkdir(parents=True, exist_ok=True)
+    git(["worktree", "prune"], repo)
+    if git(["rev-parse", "--verify", "--quiet", f"refs/heads/{branch}"], repo, check=False).returncode == 0:
+        git(["worktree", "add", str(path), branch], repo)
+    elif git(["rev-parse", "--verify", "--quiet", f"refs/remotes/origin/{branch}"], repo, check=False).returncode == 0:
+        git(["worktree", "add", "-b", branch, str(path), f"origin/{branch}"], repo)
+    else:
+        # An orphan branch shares no history with the content, so it holds nothing but releases.
+        git(["worktree", "add", "--detach", str(path)], repo)
+        git(["checkout", "--orphan", branch], path)
+        git(["rm", "-rf", "--quiet", "."], path)
+    return path
+
+
+def commit_outputs(storage_root: Path, out: str, names: List[str], message: str, branch: str = DEFAULT_BRANCH, push: Optional[str] = None, repo: Path = ROOT) -> PublishResult:
+    """Replace the branch's copy of each output directory with this export and commit it.
+
+    names are manifest names: relative to out, except profile copies ("public/lessons.json"), which sit at the
+    storage root; manifest.json itself is always included.
+    """
+    worktree = branch_worktree(branch, repo)
+    paths = [name if "/" in name else f"{out}/{name}" for name in names] + [f"{out}/manifest.json"]
+    tops = sorted({path.split("/", 1)[0] for path in paths})
+    # Whole directories are replaced so outputs a later export stops writing disappear from the branch too.
+    for top in tops:
+        shutil.rmtree(worktree / top, ignore_errors=True)
+    for path in paths:
+        target = worktree / path
+        target.parent.mkdir(parents=True, exist_ok=True)
+        shutil.copyfile(storage_root / path, target)
+    git(["add", "--all", "--", *tops], worktree)
+    staged = git(["diff", "--cached", "--name-only"], worktree).stdout.split()
+    # The manifest records when each export ran, so it always differs; alone it is not a new release.
+    if all(Path(name).name == "manifest.json" for name in staged):
+        if staged:
+            git(["reset", "--quiet", "--hard", "HEAD"], worktree)
+        return PublishResult(branch, None)
+    git(["commit", "--quiet", "-F", "-"], worktree, stdin=message)
+    commit = git(["rev-parse", "HEAD"], worktree).stdout.strip()
+    result = PublishResult(branch, commit)
+    if push:
+        git(["push", push, f"HEAD:refs/heads/{branch}"], worktree)
+        result.pushed = push
+    return result
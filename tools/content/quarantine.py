@@ -0,0 +1,202 @@
+#!/usr/bin/env python3
+"""Hold near-miss records in build/quarantine with a suggested kind, and promote them back into content once fixed.
+
+A record that classify() does not recognise but that shares most of one kind's required fields (a vocab entry
+whose gloss key is missing, a lesson whose steps are a string) is quarantined instead of rejected: export
+writes it to quarantine/<name>.json with the kind it resembles and what it lacks. Fix the record in that file,
+then `quarantine.py promote <name>` validates it and writes it back over the original in its source file.
+"""
+
+from __future__ import annotations
+
+import argparse
+import hashlib
+import json
+import sys
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+try:
+    from .common import CLASSIFY_NEEDS, DEFAULT_IDS_PATH, Record, in_archive, is_pinned, load_id_scheme, slugify, source_file
+    from .console import add_output_arguments, configure_output
+    from .errors import InvalidEntry
+    from .fieldnames import CORRECTIONS_KEY, correct_fields
+    from .rules import DEFAULT_RULES_PATH, load_rules
+    from .storage import DEFAULT_STORAGE_PATH, Storage, load_storage
+    from .submissions import SubmissionRejected, SubmissionWriter
+    from .translations import save_objects, source_objects
+    from .validate import load_schemas
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CLASSIFY_NEEDS, DEFAULT_IDS_PATH, Record, in_archive, is_pinned, load_id_scheme, slugify, source_file  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from errors import InvalidEntry  # type: ignore
+    from fieldnames import CORRECTIONS_KEY, correct_fields  # type: ignore
+    from rules import DEFAULT_RULES_PATH, load_rules  # type: ignore
+    from storage import DEFAULT_STORAGE_PATH, Storage, load_storage  # type: ignore
+    from submissions import SubmissionRejected, SubmissionWriter  # type: ignore
+    from translations import save_objects, source_objects  # type: ignore
+    from validate import load_schemas  # type: ignore
+
+# Share of a kind's distinctive required fields a record needs before it is quarantined as that kind.
+QUARANTINE_THRESHOLD = 0.5
+# Fields every kind carries; matching them says nothing about which kind a record was meant to be.
+GENERIC_FIELDS = {"id", "source_files", "level", "tags", "notes", "owner", "reviewers", "review_status", "locked", "third_party", CORRECTIONS_KEY}
+SOURCE_SLUG_LENGTH = 48
+
+
+@dataclass
+class Suggestion:
+    kind: str
+    score: float
+    matched: List[str]
+    missing: List[str]
+
+
+def kind_scores(data: Dict[str, Any], schemas: Dict[str, Dict[str, Any]]) -> List[Suggestion]:
+    """Every kind with the share of its distinctive required fields the record has, best first."""
+    scored: List[Suggestion] = []
+    for kind, schema in sorted(schemas.items()):
+        required = [name for name in schema.get("required", []) if name not in GENERIC_FIELDS]
+        if not required:
+            continue
+        matched = [name for name in required if name in data]
+        scored.append(Suggestion(kind, len(matched) / len(required), matched, [name for name in required if name not in data]))
+    return sorted(scored, key=lambda suggestion: -suggestion.score)
+
+
+def suggest_kind(data: Dict[str, Any], schemas: Dict[str, Dict[str, Any]]) -> Optional[Suggestion]:
+    """The kind whose distinctive required fields the record has the largest share of, when that share reaches
+    QUARANTINE_THRESHOLD and no other kind scores the same."""
+    scored = kind_scores(data, schemas)
+    if not scored or scored[0].score < QUARANTINE_THRESHOLD or (len(scored) > 1 and scored[1].score == scored[0].score):
+        return None
+    return scored[0]
+
+
+def split_unclassified(records: List[Record], schemas: Dict[str, Dict[str, Any]]) -> Tuple[List[Tuple[Record, Suggestion]], List[Record]]:
+    """(near misses with their suggested kind, records that resemble nothing and stay rejects)."""
+    quarantined: List[Tuple[Record, Suggestion]] = []
+    unknown: List[Record] = []
+    for record in records:
+        suggestion = suggest_kind(record.data, schemas)
+        if suggestion:
+            quarantined.append((record, suggestion))
+        else:
+            unknown.append(record)
+    return quarantined, unknown
+
+
+def record_digest(data: Any) -> str:
+    return hashlib.sha256(json.dumps(data, ensure_ascii=False, sort_keys=True).encode("utf-8")).hexdigest()
+
+
+def quarantine_name(record: Record) -> str:
+    digest = hashlib.sha256(f"{record.source}\0{record.index}\0{record_digest(record.data)}".encode("utf-8")).hexdigest()
+    return f"quarantine_{slugify(record.source)[:SOURCE_SLUG_LENGTH]}_{digest[:16]}"
+
+
+def write_quarantine(items: List[Tuple[Record, Suggestion]], storage: Storage) -> List[Dict[str, Any]]:
+    """Write one editable JSON file per quarantined record plus index.json; returns the index rows."""
+    index: List[Dict[str, Any]] = []
+    for record, suggestion in sorted(items, key=lambda item: (item[0].source, item[0].index)):
+        name = quarantine_name(record)
+        needs = f"to be collected as {suggestion.kind} it needs {CLASSIFY_NEEDS.get(suggestion.kind, 'its kind')}"
+        payload = {
+            "source": record.source,
+            "index": record.index,
+            "suggested_kind": suggestion.kind,
+            "matched_fields": suggestion.matched,
+            "missing_fields": suggestion.missing,
+            "hint": needs + "; edit record below, then run quarantine.py promote " + name,
+            "original_sha256": record_digest(record.data),
+            "record": record.data,
+        }
+        storage.write_text(f"{name}.json", json.dumps(payload, ensure_ascii=False, indent=2) + "\n")
+        index.append({"name": name, "source": record.source, "index": record.index, "suggested_kind": suggestion.kind, "score": round(suggestion.score, 2), "missing_fields": suggestion.missing})
+    storage.write_text("index.json", json.dumps(index, ensure_ascii=False, indent=2) + "\n")
+    return index
+
+
+def promote(payload: Dict[str, Any], kind: str, writer: SubmissionWriter) -> str:
+    """Validate the fixed record and write it over the original in its source file; returns the file written.
+
+    Raises SubmissionRejected when the record still is not that kind or the source changed since the export,
+    and InvalidEntry when it fails validation.
+    """
+    source, position = payload["source"], payload["index"]
+    if in_archive(source):
+        raise SubmissionRejected(409, f"{source} is inside an archive; fix the record there")
+    record = payload["record"]
+    if isinstance(record, dict) and CORRECTIONS_KEY in record:
+        record = {key: value for key, value in record.items() if key != CORRECTIONS_KEY}
+    stored, _, _ = writer.check(kind, record)
+    path = source_file(source)
+    try:
+        layout, objects = source_objects(path)
+    except (OSError, ValueError) as exc:
+        raise SubmissionRejected(409, f"{source} cannot be rewritten: {exc}") from exc
+    original = objects[position] if position < len(objects) else None
+    # Compare the way collect saw the record, with misspelled field names already corrected.
+    seen = original if not isinstance(original, dict) or is_pinned(original) else correct_fields(original)
+    if record_digest(seen) != payload["original_sha256"]:
+        raise SubmissionRejected(409, f"record {position} of {source} changed since the export; re-run export and fix the new quarantine file")
+    objects[position] = stored
+    save_objects(path, layout, objects)
+    return str(path)
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="List quarantined near-miss records, or promote a fixed one back into its source file.")
+    parser.add_argument("--storage", default=str(DEFAULT_STORAGE_PATH), help="Path to the storage backend config")
+    parser.add_argument("--quarantine", default="quarantine", help="Location of quarantined records inside the storage backend")
+    sub = parser.add_subparsers(dest="command", required=True)
+    sub.add_parser("list", help="List quarantined records with their suggested kind and missing fields")
+    prom = sub.add_parser("promote", help="Validate a fixed quarantine file and write its record back into the source")
+    prom.add_argument("name", help="Quarantine file name, with or without .json")
+    prom.add_argument("--kind", choices=sorted(CLASSIFY_NEEDS), help="Promote as this kind instead of the suggested one")
+    prom.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    prom.add_argument("--rules", default=str(DEFAULT_RULES_PATH), help="Custom rules config checked alongside the schemas")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    storage = load_storage(args.storage).child(args.quarantine)
+    raw_index = storage.read_bytes("index.json")
+    index = json.loads(raw_index.decode("utf-8")) if raw_index else []
+    if args.command == "list":
+        if not index:
+            print(f"[quarantine] Nothing quarantined in {storage.describe()}")
+            return 0
+        print(f"[quarantine] {len(index)} records in {storage.describe()}:")
+        for row in index:
+            print(f"    • {row['name']}: {row['source']}#{row['index']} looks like {row['suggested_kind']}, missing {', '.join(row['missing_fields']) or 'nothing required'}")
+        return 0
+
+    name = args.name[:-5] if args.name.endswith(".json") else args.name
+    raw = storage.read_bytes(f"{name}.json")
+    if raw is None:
+        print(f"[quarantine] No {name}.json in {storage.describe()}", file=sys.stderr)
+        return 1
+    payload = json.loads(raw.decode("utf-8"))
+    kind = args.kind or payload["suggested_kind"]
+    writer = SubmissionWriter(load_schemas(), load_rules(args.rules), load_id_scheme(args.ids))
+    try:
+        written = promote(payload, kind, writer)
+    except InvalidEntry as exc:
+        print(f"[quarantine] {name} is still invalid as {kind}; fix the record and retry:", file=sys.stderr)
+        for issue in exc.issues:
+            print(f"    • {issue.path}: {issue.message}", file=sys.stderr)
+        return 1
+    except SubmissionRejected as exc:
+        print(f"[quarantine] Cannot promote {name}: {exc}", file=sys.stderr)
+        return 1
+    # index.json is the list of open cases (as for rejects, files from earlier exports are not cleaned up).
+    storage.write_text("index.json", json.dumps([row for row in index if row["name"] != name], ensure_ascii=False, indent=2) + "\n")
+    print(f"[quarantine] Promoted {name} as {kind} into {written}; the next export collects it")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
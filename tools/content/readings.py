@@ -0,0 +1,62 @@
+"""Graded-reader chapters: glossed vocabulary references and how they resolve against the vocabulary."""
+
+from __future__ import annotations
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+try:
+    from .common import IdScheme, slugify
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import IdScheme, slugify  # type: ignore
+
+# [[form]], [[form|lemma]], or [[form|lemma|gloss]] inside manuscript text.
+GLOSS_PATTERN = re.compile(r"\[\[([^\[\]|]+)(?:\|([^\[\]|]*))?(?:\|([^\[\]]*))?\]\]")
+
+
+def vocab_ref(lemma: str, scheme: IdScheme) -> str:
+    """The ID generate_id gives the vocab entry headed by lemma (without a sense_key)."""
+    return scheme.kind_prefix("vocab") + slugify(lemma)
+
+
+def extract_glosses(text: str, scheme: IdScheme) -> Tuple[str, List[Dict[str, str]]]:
+    """Strip gloss markup from text; returns the plain text and one reference per distinct (form, lemma)."""
+    glossed: List[Dict[str, str]] = []
+    seen = set()
+
+    def replace(match: "re.Match[str]") -> str:
+        form = match.group(1).strip()
+        lemma = (match.group(2) or "").strip() or form.lower()
+        key = (form.lower(), lemma)
+        if key not in seen:
+            seen.add(key)
+            ref = {"form": form, "lemma": lemma, "vocab_id": vocab_ref(lemma, scheme)}
+            if (match.group(3) or "").strip():
+                ref["gloss"] = match.group(3).strip()
+            glossed.append(ref)
+        return form
+
+    return GLOSS_PATTERN.sub(replace, text), glossed
+
+
+def resolve_glosses(readings: List[Dict[str, Any]], vocab_index: Dict[str, Dict[str, Any]]) -> List[str]:
+    """Fill missing glosses from the referenced vocab entry; returns "<reading id>: <vocab id>" for references that resolve to nothing."""
+    missing: List[str] = []
+    for reading in readings:
+        for ref in reading.get("glossed") or []:
+            target = lookup(ref, vocab_index)
+            if target is None:
+                missing.append(f"{reading['id']}: {ref.get('vocab_id')}")
+            elif not ref.get("gloss") and isinstance(target.get("english_gloss"), str):
+                ref["gloss"] = target["english_gloss"]
+    return missing
+
+
+def lookup(ref: Any, vocab_index: Dict[str, Dict[str, Any]]) -> Optional[Dict[str, Any]]:
+    """A reference resolves by vocab_id, or by lemma headword when the entry carries a sense_key or a hand-written ID."""
+    if not isinstance(ref, dict):
+        return None
+    for key in (ref.get("vocab_id"), str(ref.get("lemma", "")).strip().lower()):
+        if key and key in vocab_index:
+            return vocab_index[key]
+    return None
@@ -0,0 +1,106 @@
+"""Double-entry verification: an entry is verified once two independent source files agree on its critical fields.
+
+Our dual-review process has two authors write the same entry in separate files. Export (with --double-entry)
+compares the copies that share an ID: when at least two different files state every critical field in
+config/reconcile.json (level, gender, and gloss for vocabulary) and none disagree, the entry gets
+`verified: true`. Copies that disagree go to build/reports/reconciliation.md with each value and the files
+that wrote it; an entry with one source, or where only one file states a field, stays unverified. Values
+compare without case or surrounding space, so "Cat" and "cat " agree.
+"""
+
+from __future__ import annotations
+
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple, Union
+
+try:
+    from .common import CLASSIFY_NEEDS, CONFIG_DIR, load_json
+    from .errors import ConfigError
+    from .merge import DuplicateCluster
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CLASSIFY_NEEDS, CONFIG_DIR, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from merge import DuplicateCluster  # type: ignore
+
+DEFAULT_RECONCILE_PATH = CONFIG_DIR / "reconcile.json"
+DEFAULT_CRITICAL_FIELDS = {"vocab": ["level", "gender", "english_gloss"]}
+VERDICTS = ("verified", "conflict", "unconfirmed")
+
+
+@dataclass
+class Verdict:
+    id: str
+    status: str
+    sources: List[str] = field(default_factory=list)
+    # field -> [(value as written, files stating it)] for fields the files disagree on.
+    conflicts: Dict[str, List[Tuple[Any, List[str]]]] = field(default_factory=dict)
+    # Fields only one file states.
+    unconfirmed: List[str] = field(default_factory=list)
+
+
+def load_critical_fields(path: Optional[Union[str, Path]] = None) -> Dict[str, List[str]]:
+    """Kind -> the fields two sources must agree on; no file means vocabulary level, gender, and gloss."""
+    cfg_path = Path(path) if path else DEFAULT_RECONCILE_PATH
+    data = load_json(cfg_path).get("critical_fields", {}) if cfg_path.exists() else DEFAULT_CRITICAL_FIELDS
+    for kind, names in data.items():
+        if kind not in CLASSIFY_NEEDS:
+            raise ConfigError(f"reconcile: unknown kind '{kind}'", f"critical_fields.{kind}", kind, sorted(CLASSIFY_NEEDS))
+        if not isinstance(names, list) or not names or not all(isinstance(name, str) for name in names):
+            raise ConfigError(f"reconcile: critical_fields.{kind} must be a non-empty list of field names", f"critical_fields.{kind}", names)
+    return {kind: list(names) for kind, names in data.items()}
+
+
+def comparable(value: Any) -> Any:
+    return value.strip().casefold() if isinstance(value, str) else value
+
+
+def copy_source(entry: Dict[str, Any]) -> str:
+    return ", ".join(entry.get("source_files", [])) or "unknown"
+
+
+def reconcile(entries: List[Dict[str, Any]], clusters: List[DuplicateCluster], fields: List[str]) -> Dict[str, Verdict]:
+    """A verdict for every merged entry: copies come from its duplicate cluster, one copy per source file."""
+    verdicts: Dict[str, Verdict] = {entry["id"]: Verdict(entry["id"], "unconfirmed", [copy_source(entry)]) for entry in entries}
+    for cluster in clusters:
+        copies: Dict[str, Dict[str, Any]] = {}
+        for entry in cluster.entries:
+            copies.setdefault(copy_source(entry), entry)
+        verdict = Verdict(cluster.id, "unconfirmed", sorted(copies))
+        confirmed = 0
+        for name in fields:
+            stated: Dict[str, Tuple[Any, List[str]]] = {}
+            for source, entry in copies.items():
+                if entry.get(name) not in (None, "", []):
+                    stated.setdefault(repr(comparable(entry[name])), (entry[name], []))[1].append(source)
+            if len(stated) > 1:
+                verdict.conflicts[name] = list(stated.values())
+            elif stated and len(next(iter(stated.values()))[1]) < 2:
+                verdict.unconfirmed.append(name)
+            elif stated:
+                confirmed += 1
+        if verdict.conflicts:
+            verdict.status = "conflict"
+        elif len(copies) > 1 and confirmed and not verdict.unconfirmed:
+            verdict.status = "verified"
+        verdicts[cluster.id] = verdict
+    return verdicts
+
+
+def reconciliation_report(verdicts: Dict[str, Dict[str, Verdict]]) -> List[str]:
+    """verdicts is kind -> entry ID -> verdict."""
+    every = [verdict for per_kind in verdicts.values() for verdict in per_kind.values()]
+    lines = ["Double-entry reconciliation"]
+    lines += [f"- {status}: {sum(1 for verdict in every if verdict.status == status)}" for status in VERDICTS]
+    for kind, per_kind in verdicts.items():
+        conflicts = [verdict for verdict in per_kind.values() if verdict.status == "conflict"]
+        if conflicts:
+            lines.append(f"## {kind} disagreements")
+        for verdict in conflicts:
+            for name, values in verdict.conflicts.items():
+                lines.append(f"- {verdict.id} {name}: " + "; ".join(f"{value!r} in {', '.join(sources)}" for value, sources in values))
+        partial = [verdict for verdict in per_kind.values() if verdict.status == "unconfirmed" and verdict.unconfirmed]
+        if partial:
+            lines.append(f"## {kind} stated by one source only")
+            lines += [f"- {verdict.id}: {', '.join(verdict.unconfirmed)}" for verdict in partial]
+    return lines
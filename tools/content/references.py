@@ -0,0 +1,209 @@
+"""Resolve every cross-entry reference (prerequisites, step items, synonyms, glosses, note lessons, ...) at once.
+
+Each reference type names the kind that holds it, a field path ("steps[].items[]" walks lists), the kinds it
+may point at, and how bad a dangling one is. The built-in types cover the fields this tree understands;
+config/references.json can change a type's severity or declare new fields (quiz links, family IDs) without
+code. A reference is an ID string, or an object carrying ref, vocab_id, vocab, or lemma; vocabulary also
+resolves by lowercased headword, as step items and glosses always have. Objects with none of those keys are
+inline content, not references, and are skipped.
+"""
+
+from __future__ import annotations
+
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional, Set, Tuple, Union
+
+try:
+    from .common import CLASSIFY_NEEDS, CONFIG_DIR, load_json
+    from .errors import ConfigError
+    from .rules import SEVERITIES
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CLASSIFY_NEEDS, CONFIG_DIR, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from rules import SEVERITIES  # type: ignore
+
+DEFAULT_REFERENCES_PATH = CONFIG_DIR / "references.json"
+REFERENCE_KEYS = ("ref", "vocab_id", "vocab", "lemma")
+
+
+@dataclass
+class ReferenceType:
+    name: str
+    kind: str
+    field: str
+    targets: List[str] = field(default_factory=list)
+    severity: str = "warning"
+
+
+@dataclass
+class Dangling:
+    type: ReferenceType
+    source: str
+    path: str
+    ref: str
+    reason: str
+
+
+DEFAULT_TYPES = [
+    ReferenceType("prerequisite", "lesson", "prerequisites[]", ["lesson"], "error"),
+    ReferenceType("step-item", "lesson", "steps[].items[]", ["vocab"], "error"),
+    ReferenceType("lesson-culture-note", "lesson", "culture_notes[]", ["culture_note"]),
+    ReferenceType("synonym", "vocab", "synonyms[]", ["vocab"]),
+    ReferenceType("antonym", "vocab", "antonyms[]", ["vocab"]),
+    ReferenceType("intro-lesson", "vocab", "intro_lesson", ["lesson"]),
+    ReferenceType("gloss", "reading", "glossed[]", ["vocab"]),
+    ReferenceType("note-lesson", "culture_note", "lessons[]", ["lesson"]),
+]
+
+
+def load_reference_types(path: Optional[Union[str, Path]] = None) -> List[ReferenceType]:
+    """The built-in types, with rows in path changing one by name or adding a new one."""
+    cfg_path = Path(path) if path else DEFAULT_REFERENCES_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    types = {ref_type.name: ReferenceType(ref_type.name, ref_type.kind, ref_type.field, list(ref_type.targets), ref_type.severity) for ref_type in DEFAULT_TYPES}
+    for row in data.get("references", []):
+        if not isinstance(row, dict) or not isinstance(row.get("name"), str):
+            raise ConfigError("references: every row needs a name", "references", row)
+        name = row["name"]
+        known = types.get(name)
+        ref_type = ReferenceType(
+            name,
+            row.get("kind", known.kind if known else ""),
+            row.get("field", known.field if known else ""),
+            list(row.get("targets", known.targets if known else [])),
+            row.get("severity", known.severity if known else "warning"),
+        )
+        for kind in [ref_type.kind] + ref_type.targets:
+            if kind not in CLASSIFY_NEEDS:
+                raise ConfigError(f"reference {name}: unknown kind '{kind}'", f"references.{name}", kind, sorted(CLASSIFY_NEEDS))
+        if not ref_type.field or not ref_type.targets:
+            raise ConfigError(f"reference {name}: needs a field and at least one target kind", f"references.{name}", row)
+        if ref_type.severity not in SEVERITIES:
+            raise ConfigError(f"reference {name}: unknown severity '{ref_type.severity}'", f"references.{name}", ref_type.severity, list(SEVERITIES))
+        types[name] = ref_type
+    return list(types.values())
+
+
+def walk(value: Any, path: str, prefix: str = "") -> Iterator[Tuple[str, Any]]:
+    """(concrete path, value) for each value a field path reaches; "items[]" steps into every list item."""
+    head, _, rest = path.partition(".")
+    key, many = (head[:-2], True) if head.endswith("[]") else (head, False)
+    if not isinstance(value, dict) or key not in value:
+        return
+    found = value[key]
+    here = f"{prefix}.{key}" if prefix else key
+    children = [(f"{here}[{idx}]", item) for idx, item in enumerate(found)] if many and isinstance(found, list) else [] if many else [(here, found)]
+    for child_path, child in children:
+        if rest:
+            yield from walk(child, rest, child_path)
+        elif child not in (None, ""):
+            yield child_path, child
+
+
+def renamed_reference(value: Any, renamed: Dict[str, str]) -> Any:
+    """value pointing at renamed[ID] instead of ID; an object keeps its other fields."""
+    if isinstance(value, str):
+        return renamed.get(value.strip(), value)
+    if isinstance(value, dict):
+        return {key: renamed.get(item.strip(), item) if key in REFERENCE_KEYS and isinstance(item, str) else item for key, item in value.items()}
+    return value
+
+
+def rename_in(value: Any, path: str, renamed: Dict[str, str], owner: str) -> int:
+    """Rewrite the references a field path reaches in place. A list drops references the renaming turns into
+    repeats, or into its owner's own ID (a lesson that listed its duplicate as a prerequisite)."""
+    head, _, rest = path.partition(".")
+    key, many = (head[:-2], True) if head.endswith("[]") else (head, False)
+    if not isinstance(value, dict) or key not in value:
+        return 0
+    found = value[key]
+    if many and not isinstance(found, list):
+        return 0
+    if rest:
+        return sum(rename_in(child, rest, renamed, owner) for child in (found if many else [found]))
+    if not many:
+        value[key] = renamed_reference(found, renamed)
+        return int(value[key] != found)
+    targets = set(renamed.values())
+    kept: List[Any] = []
+    count = 0
+    for item in found:
+        new = renamed_reference(item, renamed)
+        count += new != item
+        keys = reference_keys(new)
+        if keys and set(keys) <= targets and (new in kept or (new != item and keys == [owner])):
+            continue
+        kept.append(new)
+    value[key] = kept
+    return count
+
+
+def rename_references(entries: Dict[str, List[Dict[str, Any]]], types: List[ReferenceType], kind: str, renamed: Dict[str, str]) -> int:
+    """Point every reference to an entry of kind that renamed maps (a lesson merged into another) at its new ID.
+
+    Entries are changed in place; returns how many references were rewritten.
+    """
+    count = 0
+    for ref_type in types:
+        if kind not in ref_type.targets:
+            continue
+        for entry in entries.get(ref_type.kind, []):
+            count += rename_in(entry, ref_type.field, renamed, str(entry.get("id")))
+    return count
+
+
+def reference_keys(value: Any) -> List[str]:
+    if isinstance(value, str):
+        return [value.strip()] if value.strip() else []
+    if isinstance(value, dict):
+        return [value[key].strip() for key in REFERENCE_KEYS if isinstance(value.get(key), str) and value[key].strip()]
+    return []
+
+
+def build_index(entries: Dict[str, List[Dict[str, Any]]]) -> Dict[str, Set[str]]:
+    """Every ID (and vocabulary headword, lowercased) mapped to the kinds it names."""
+    index: Dict[str, Set[str]] = {}
+    for kind, items in entries.items():
+        for entry in items:
+            index.setdefault(str(entry.get("id")), set()).add(kind)
+            if kind == "vocab" and isinstance(entry.get("spanish"), str):
+                index.setdefault(entry["spanish"].strip().lower(), set()).add(kind)
+    return index
+
+
+def resolve_references(entries: Dict[str, List[Dict[str, Any]]], types: List[ReferenceType]) -> Tuple[Dict[str, int], List[Dangling]]:
+    """References checked per type, and the ones that name nothing or an entry of the wrong kind. entries maps
+    a kind (lesson, vocab, ...) to its normalized entries; pass them before anything prunes references."""
+    index = build_index(entries)
+    checked: Dict[str, int] = {ref_type.name: 0 for ref_type in types}
+    dangling: List[Dangling] = []
+    for ref_type in types:
+        for entry in entries.get(ref_type.kind, []):
+            for path, value in walk(entry, ref_type.field):
+                keys = reference_keys(value)
+                if not keys:
+                    continue
+                checked[ref_type.name] += 1
+                kinds = set().union(*(index.get(key, set()) | index.get(key.lower(), set()) for key in keys))
+                if kinds & set(ref_type.targets):
+                    continue
+                reason = f"is a {', '.join(sorted(kinds))}, not a {' or '.join(ref_type.targets)}" if kinds else f"matches no {' or '.join(ref_type.targets)}"
+                dangling.append(Dangling(ref_type, str(entry.get("id")), path, keys[0], reason))
+    return checked, dangling
+
+
+def reference_report(checked: Dict[str, int], dangling: List[Dangling]) -> List[str]:
+    by_severity = {severity: [item for item in dangling if item.type.severity == severity] for severity in SEVERITIES}
+    lines = ["Dangling references", f"- references checked: {sum(checked.values())}"]
+    lines.append(f"- dangling: {len(dangling)} ({', '.join(f'{len(items)} {severity}' for severity, items in by_severity.items())})")
+    lines.append("## by type")
+    counts: Dict[str, int] = {}
+    for item in dangling:
+        counts[item.type.name] = counts.get(item.type.name, 0) + 1
+    lines += [f"- {name}: {counts.get(name, 0)} of {total} dangling" for name, total in checked.items()]
+    for severity, items in by_severity.items():
+        if items:
+            lines.append(f"## {severity}")
+            lines += [f"- {item.source} {item.path} ({item.type.name}): {item.ref} {item.reason}" for item in items]
+    return lines
@@ -0,0 +1,141 @@
+"""Write rejected content in a configurable output format."""
+
+from __future__ import annotations
+
+import hashlib
+import json
+from dataclasses import dataclass
+from typing import Any, Collection, Dict, List, Optional, Tuple
+
+try:
+    from .common import Dataset, slugify
+    from .errors import ConfigError
+    from .storage import Storage
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import Dataset, slugify  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from storage import Storage  # type: ignore
+
+REJECT_FORMATS = ("yaml", "json", "raw")
+# Keeps names readable for deep content paths; the digest already covers the full path.
+SOURCE_SLUG_LENGTH = 48
+
+
+@dataclass
+class Reject:
+    source: str
+    reason: str
+    record: Optional[Any] = None
+    # The damaged part of an undecodable file, from offset on.
+    raw: Optional[bytes] = None
+    offset: Optional[int] = None
+    issues: Optional[List[Dict[str, str]]] = None
+    # The whole undecodable file, untouched by conflict resolution; raw format writes this.
+    original: Optional[bytes] = None
+
+
+def collect_rejects(dataset: Dataset, quarantined: Collection[Tuple[str, int]] = ()) -> List[Reject]:
+    """Decode errors and unclassified records, except the (source, index) pairs held in quarantine instead."""
+    rejects: List[Reject] = []
+    for source, result in sorted(dataset.decode_errors.items()):
+        reason = f"undecodable JSON from byte {result.byte_offset} after {len(result.objects)} complete objects: {result.error}"
+        rejects.append(Reject(source=source, reason=reason, raw=result.tail, offset=result.byte_offset, original=result.original or None))
+    for record in dataset.unclassified:
+        if (record.source, record.index) in quarantined:
+            continue
+        rejects.append(Reject(source=record.source, reason="record matched no known kind", record=record.data, offset=record.index))
+    return rejects
+
+
+def yaml_scalar(value: Any) -> str:
+    if value is None:
+        return "null"
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    if isinstance(value, (int, float)):
+        return json.dumps(value)
+    return json.dumps(str(value), ensure_ascii=False)
+
+
+def to_yaml(value: Any, indent: int = 0) -> List[str]:
+    pad = "  " * indent
+    lines: List[str] = []
+    if isinstance(value, dict):
+        if not value:
+            return [pad + "{}"]
+        for key, item in value.items():
+            if isinstance(item, (dict, list)) and item:
+                lines.append(f"{pad}{yaml_scalar(key)}:")
+                lines += to_yaml(item, indent + 1)
+            else:
+                lines.append(f"{pad}{yaml_scalar(key)}: {to_yaml(item)[0].strip()}")
+    elif isinstance(value, list):
+        if not value:
+            return [pad + "[]"]
+        for item in value:
+            nested = to_yaml(item, indent + 1)
+            lines.append(f"{pad}- {nested[0].strip()}")
+            lines += nested[1:]
+    else:
+        lines.append(pad + yaml_scalar(value))
+    return lines
+
+
+def reject_payload(reject: Reject) -> Dict[str, Any]:
+    payload: Dict[str, Any] = {"source": reject.source, "reason": reject.reason, "offset": reject.offset}
+    if reject.record is not None:
+        payload["record"] = reject.record
+    if reject.issues:
+        payload["issues"] = reject.issues
+    if reject.raw is not None:
+        payload["raw"] = reject.raw.decode("utf-8", errors="replace")
+    return payload
+
+
+def reject_digest(reject: Reject) -> str:
+    """SHA-256 over source path, offset, and content, so identical content rejected from two files never shares a name."""
+    body = reject.raw if reject.raw is not None else json.dumps(reject.record, sort_keys=True).encode("utf-8")
+    digest = hashlib.sha256(f"{reject.source}\0{reject.offset}\0".encode("utf-8"))
+    digest.update(body)
+    return digest.hexdigest()
+
+
+def reject_name(reject: Reject) -> str:
+    return f"reject_{slugify(reject.source)[:SOURCE_SLUG_LENGTH]}_{reject_digest(reject)[:16]}"
+
+
+def sort_key(reject: Reject) -> tuple:
+    return (reject.source, reject.offset if reject.offset is not None else -1, reject_digest(reject))
+
+
+def write_rejects(rejects: List[Reject], storage: Storage, fmt: str = "yaml") -> List[Dict[str, Any]]:
+    """Write each reject to storage and return the index rows.
+
+    Rejects are written in (source, offset) order so names do not depend on the order sources were
+    processed; an exact repeat (same source, offset, and content) gets a numbered suffix instead of
+    overwriting the first. Each index row maps the file name back to its source and full digest.
+    """
+    if fmt not in REJECT_FORMATS:
+        raise ConfigError(f"Unknown reject format '{fmt}'. Expected one of: {', '.join(REJECT_FORMATS)}", "reject_format", fmt, list(REJECT_FORMATS))
+    index: List[Dict[str, Any]] = []
+    used: Dict[str, int] = {}
+    for reject in sorted(rejects, key=sort_key):
+        base = reject_name(reject)
+        used[base] = used.get(base, 0) + 1
+        name = base if used[base] == 1 else f"{base}_{used[base]}"
+        payload = reject_payload(reject)
+        if fmt == "json":
+            files = [f"{name}.json"]
+            storage.write_text(files[0], json.dumps(payload, ensure_ascii=False, indent=2) + "\n")
+        elif fmt == "yaml":
+            files = [f"{name}.yaml"]
+            storage.write_text(files[0], "\n".join(to_yaml(payload)) + "\n")
+        else:
+            raw = reject.original if reject.original is not None else reject.raw if reject.raw is not None else json.dumps(reject.record, ensure_ascii=False, indent=2).encode("utf-8")
+            meta = {key: value for key, value in payload.items() if key not in ("raw", "record")}
+            files = [f"{name}.raw", f"{name}.meta.json"]
+            storage.write_bytes(files[0], raw)
+            storage.write_text(files[1], json.dumps(meta, ensure_ascii=False, indent=2) + "\n")
+        index.append({"name": name, "files": files, "source": reject.source, "offset": reject.offset, "sha256": reject_digest(reject), "reason": reject.reason})
+    storage.write_text("index.json", json.dumps(index, ensure_ascii=False, indent=2) + "\n")
+    return index
@@ -0,0 +1,51 @@
+"""Synonym and antonym links between vocabulary entries, for related-word navigation in the app.
+
+Entries list related words in `synonyms` and `antonyms` by vocabulary ID or by headword; export rewrites the
+headwords to IDs, and writes the links as an undirected graph in relations.json. Both relations are symmetric,
+so a link listed on one side only is reported.
+"""
+
+from __future__ import annotations
+
+from typing import Any, Dict, List, Tuple
+
+RELATIONS = ("synonyms", "antonyms")
+SINGULAR = {"synonyms": "synonym", "antonyms": "antonym"}
+
+
+def resolve_relations(vocab: List[Dict[str, Any]], vocab_index: Dict[str, Dict[str, Any]]) -> List[Tuple[str, str]]:
+    """Rewrite each relation list to vocabulary IDs in place; (entry ID, problem) for references that resolve to
+    nothing or to the entry itself, and for words listed as both synonym and antonym. Unresolved references are dropped."""
+    problems: List[Tuple[str, str]] = []
+    for entry in vocab:
+        for relation in RELATIONS:
+            refs = entry.get(relation)
+            if not isinstance(refs, list):
+                continue
+            resolved: List[str] = []
+            for ref in refs:
+                target = vocab_index.get(str(ref).strip()) or vocab_index.get(str(ref).strip().lower())
+                if target is None:
+                    problems.append((entry["id"], f"{SINGULAR[relation]} {ref} matches no vocabulary entry"))
+                elif target["id"] == entry["id"]:
+                    problems.append((entry["id"], f"lists itself among its {relation}"))
+                elif target["id"] not in resolved:
+                    resolved.append(target["id"])
+            entry[relation] = resolved
+        both = set(entry.get("synonyms") or []) & set(entry.get("antonyms") or [])
+        problems += [(entry["id"], f"{ref} is listed as both synonym and antonym") for ref in sorted(both)]
+    return problems
+
+
+def asymmetric_relations(vocab: List[Dict[str, Any]]) -> List[Tuple[str, str]]:
+    """(entry ID, problem) for each link whose target does not list it back; run after resolve_relations."""
+    listed = {(entry["id"], relation, ref) for entry in vocab for relation in RELATIONS for ref in entry.get(relation) or []}
+    return [(source, f"lists {target} among its {relation}, but {target} does not list it back") for source, relation, target in sorted(listed) if (target, relation, source) not in listed]
+
+
+def relations_graph(vocab: List[Dict[str, Any]]) -> Dict[str, Any]:
+    """Nodes for entries with at least one link, and one undirected edge per linked pair and relation."""
+    edges = sorted({(min(entry["id"], ref), max(entry["id"], ref), relation) for entry in vocab for relation in RELATIONS for ref in entry.get(relation) or []})
+    linked = {node for source, target, _ in edges for node in (source, target)}
+    nodes = [{"id": entry["id"], "spanish": entry.get("spanish"), "english_gloss": entry.get("english_gloss")} for entry in vocab if entry["id"] in linked]
+    return {"nodes": nodes, "edges": [{"source": source, "target": target, "relation": SINGULAR[relation]} for source, target, relation in edges]}
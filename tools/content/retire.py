@@ -0,0 +1,110 @@
+#!/usr/bin/env python3
+"""Retire entries: record a tombstone so export leaves them out and tells downstream sync to delete them."""
+
+from __future__ import annotations
+
+import argparse
+import datetime as dt
+import json
+import sys
+from dataclasses import asdict, dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Union
+
+try:
+    from .common import CONFIG_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, collect, load_id_scheme, load_json, record_id
+    from .console import add_output_arguments, configure_output
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONFIG_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, collect, load_id_scheme, load_json, record_id  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+
+DEFAULT_TOMBSTONES_PATH = CONFIG_DIR / "tombstones.json"
+
+
+@dataclass
+class Tombstone:
+    id: str
+    kind: str
+    reason: str
+    removed_at: str
+    replaced_by: Optional[str] = None
+
+
+def load_tombstones(path: Optional[Union[str, Path]] = None) -> List[Tombstone]:
+    cfg_path = Path(path) if path else DEFAULT_TOMBSTONES_PATH
+    if not cfg_path.exists():
+        return []
+    return [Tombstone(str(row["id"]), str(row.get("kind", "unknown")), str(row.get("reason", "")), str(row.get("removed_at", "")), row.get("replaced_by")) for row in load_json(cfg_path)]
+
+
+def save_tombstones(tombstones: List[Tombstone], path: Optional[Union[str, Path]] = None) -> None:
+    cfg_path = Path(path) if path else DEFAULT_TOMBSTONES_PATH
+    cfg_path.write_text(json.dumps(tombstone_rows(tombstones), ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+
+
+def tombstone_rows(tombstones: List[Tombstone]) -> List[Dict[str, Any]]:
+    """The canonical tombstones.json payload: every retired ID, so sync deletes rather than reports it missing."""
+    return [{key: value for key, value in asdict(stone).items() if value is not None} for stone in sorted(tombstones, key=lambda stone: stone.id)]
+
+
+def drop_retired(entries: List[Dict[str, Any]], tombstones: List[Tombstone]) -> List[Dict[str, Any]]:
+    retired = {stone.id for stone in tombstones}
+    return [entry for entry in entries if entry.get("id") not in retired]
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Record a tombstone for an entry that was intentionally removed.")
+    parser.add_argument("id", nargs="?", help="ID of the entry to retire (or restore)")
+    parser.add_argument("--reason", help="Why the entry was removed; required when retiring")
+    parser.add_argument("--replaced-by", help="ID of the entry that supersedes it, if any")
+    parser.add_argument("--restore", action="store_true", help="Remove the tombstone so the entry is exported again")
+    parser.add_argument("--list", action="store_true", help="List tombstones and exit")
+    parser.add_argument("--force", action="store_true", help="Retire an ID that no content file defines (already deleted by hand)")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to collect")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument("--tombstones", default=str(DEFAULT_TOMBSTONES_PATH), help="Path to the tombstone registry")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    tombstones = load_tombstones(args.tombstones)
+    if args.list:
+        for stone in sorted(tombstones, key=lambda stone: stone.id):
+            replaced = f" -> {stone.replaced_by}" if stone.replaced_by else ""
+            print(f"{stone.id} ({stone.kind}, {stone.removed_at}){replaced}: {stone.reason}")
+        print(f"[retire] {len(tombstones)} tombstones")
+        return 0
+    if not args.id:
+        parser.error("an entry ID is required unless --list is given")
+
+    existing = next((stone for stone in tombstones if stone.id == args.id), None)
+    if args.restore:
+        if existing is None:
+            print(f"[retire] {args.id} has no tombstone", file=sys.stderr)
+            return 1
+        save_tombstones([stone for stone in tombstones if stone is not existing], args.tombstones)
+        print(f"[retire] Restored {args.id}; it is exported again while its content exists")
+        return 0
+    if not args.reason or not args.reason.strip():
+        parser.error("--reason is required when retiring an entry")
+    if existing is not None:
+        print(f"[retire] {args.id} was already retired on {existing.removed_at}: {existing.reason}", file=sys.stderr)
+        return 1
+
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+    records = [record for record in dataset.vocab + dataset.lessons + dataset.readings + dataset.culture_notes + dataset.pron_drills if record_id(record, scheme) == args.id]
+    if not records and not args.force:
+        print(f"[retire] No entry has ID {args.id}; pass --force to retire an ID already deleted from content", file=sys.stderr)
+        return 1
+    kind = records[0].kind if records else "unknown"
+    stone = Tombstone(args.id, kind, args.reason.strip(), dt.date.today().isoformat(), args.replaced_by)
+    save_tombstones(tombstones + [stone], args.tombstones)
+    where = ", ".join(sorted({record.source for record in records})) or "no content file"
+    print(f"[retire] Retired {kind} {args.id} (defined in {where}); export now leaves it out and lists it in tombstones.json")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
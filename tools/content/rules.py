@@ -0,0 +1,161 @@
+"""Team-specific validation rules declared in config as small expressions instead of code.
+
+Each rule in config/rules.json reads "entries of <kind> where <where> must satisfy <require>":
+
+    {"id": "verb-tag", "kind": "vocab", "where": "pos == 'verb'", "require": "'verb' in tags", "severity": "warning"}
+
+Expressions use Python syntax over the entry's fields: names and dotted paths (third_party.license)
+read fields, missing ones read as None; comparisons, in / not in, and / or / not, + - * / on numbers,
+literals, and the functions len, has, lower, and matches(pattern, text). Nothing else parses, and nothing
+is eval()'d.
+"""
+
+from __future__ import annotations
+
+import ast
+import re
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional, Union
+
+try:
+    from .common import CONFIG_DIR, load_json
+    from .errors import ConfigError
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+
+DEFAULT_RULES_PATH = CONFIG_DIR / "rules.json"
+RULE_KINDS = ("lesson", "vocab", "reading", "unit", "culture_note", "pron_drill", "any")
+SEVERITIES = ("error", "warning", "info")
+FUNCTIONS: Dict[str, Callable[..., Any]] = {
+    "len": lambda value: len(value) if isinstance(value, (str, list, dict)) else 0,
+    "has": lambda value: value is not None and value != "" and value != [] and value != {},
+    "lower": lambda value: value.lower() if isinstance(value, str) else value,
+    "matches": lambda pattern, value: isinstance(value, str) and re.search(str(pattern), value) is not None,
+}
+COMPARISONS: Dict[type, Callable[[Any, Any], bool]] = {
+    ast.Eq: lambda a, b: a == b,
+    ast.NotEq: lambda a, b: a != b,
+    ast.Lt: lambda a, b: a < b,
+    ast.LtE: lambda a, b: a <= b,
+    ast.Gt: lambda a, b: a > b,
+    ast.GtE: lambda a, b: a >= b,
+    ast.In: lambda a, b: b is not None and a in b,
+    ast.NotIn: lambda a, b: b is None or a not in b,
+}
+
+ARITHMETIC: Dict[type, Callable[[Any, Any], Any]] = {
+    ast.Add: lambda a, b: a + b,
+    ast.Sub: lambda a, b: a - b,
+    ast.Mult: lambda a, b: a * b,
+    ast.Div: lambda a, b: a / b,
+}
+
+
+@dataclass
+class CustomRule:
+    id: str
+    kind: str
+    where: str
+    require: str
+    severity: str = "error"
+    message: str = ""
+    _where: Optional[ast.Expression] = None
+    _require: Optional[ast.Expression] = None
+
+    def applies(self, entry: Dict[str, Any]) -> bool:
+        return bool(evaluate(self._where.body, entry)) if self._where is not None else True
+
+    def passes(self, entry: Dict[str, Any]) -> bool:
+        return bool(evaluate(self._require.body, entry))
+
+    def describe(self) -> str:
+        return self.message or (f"{self.where} requires {self.require}" if self.where else f"requires {self.require}")
+
+
+def check_syntax(node: ast.AST, rule_id: str, text: str) -> None:
+    for child in ast.walk(node):
+        allowed = isinstance(child, (ast.Expression, ast.BoolOp, ast.And, ast.Or, ast.UnaryOp, ast.Not, ast.Compare, ast.Name, ast.Load, ast.Attribute, ast.Constant, ast.List, ast.Tuple, ast.Subscript, ast.Call))
+        allowed = allowed or type(child) in COMPARISONS or isinstance(child, ast.BinOp) or type(child) in ARITHMETIC
+        if isinstance(child, ast.Call):
+            allowed = isinstance(child.func, ast.Name) and child.func.id in FUNCTIONS and not child.keywords
+        if isinstance(child, ast.Subscript):
+            allowed = isinstance(child.slice, ast.Constant)
+        if not allowed:
+            raise ConfigError(f"rule {rule_id}: unsupported syntax in {text!r} ({type(child).__name__})", f"rules.{rule_id}", text)
+
+
+def compile_expression(text: str, rule_id: str) -> ast.Expression:
+    try:
+        tree = ast.parse(text, mode="eval")
+    except SyntaxError as exc:
+        raise ConfigError(f"rule {rule_id}: cannot parse {text!r}: {exc.msg}", f"rules.{rule_id}", text) from None
+    check_syntax(tree, rule_id, text)
+    return tree
+
+
+def field(entry: Any, key: Any) -> Any:
+    if isinstance(entry, dict):
+        return entry.get(key)
+    if isinstance(entry, list) and isinstance(key, int) and -len(entry) <= key < len(entry):
+        return entry[key]
+    return None
+
+
+def evaluate(node: ast.AST, entry: Dict[str, Any]) -> Any:
+    if isinstance(node, ast.Constant):
+        return node.value
+    if isinstance(node, ast.Name):
+        return entry.get(node.id)
+    if isinstance(node, ast.Attribute):
+        return field(evaluate(node.value, entry), node.attr)
+    if isinstance(node, ast.Subscript):
+        return field(evaluate(node.value, entry), node.slice.value)
+    if isinstance(node, (ast.List, ast.Tuple)):
+        return [evaluate(item, entry) for item in node.elts]
+    if isinstance(node, ast.Call):
+        return FUNCTIONS[node.func.id](*(evaluate(arg, entry) for arg in node.args))
+    if isinstance(node, ast.BinOp):
+        try:
+            return ARITHMETIC[type(node.op)](evaluate(node.left, entry), evaluate(node.right, entry))
+        except (TypeError, ZeroDivisionError):
+            # Arithmetic on a missing field; the result is missing too.
+            return None
+    if isinstance(node, ast.UnaryOp):
+        return not evaluate(node.operand, entry)
+    if isinstance(node, ast.BoolOp):
+        values = (evaluate(value, entry) for value in node.values)
+        return all(values) if isinstance(node.op, ast.And) else any(values)
+    left = evaluate(node.left, entry)
+    for op, comparator in zip(node.ops, node.comparators):
+        right = evaluate(comparator, entry)
+        try:
+            if not COMPARISONS[type(op)](left, right):
+                return False
+        except TypeError:
+            # Ordering a missing field against a number, say; the comparison simply does not hold.
+            return False
+        left = right
+    return True
+
+
+def load_rules(path: Optional[Union[str, Path]] = None) -> List[CustomRule]:
+    cfg_path = Path(path) if path else DEFAULT_RULES_PATH
+    rules: List[CustomRule] = []
+    for row in load_json(cfg_path) if cfg_path.exists() else []:
+        rule = CustomRule(str(row.get("id", "")), str(row.get("kind", "any")), str(row.get("where", "")), str(row.get("require", "")), str(row.get("severity", "error")), str(row.get("message", "")))
+        if not rule.id or not rule.require:
+            raise ConfigError(f"custom rule {rule.id or '#' + str(len(rules))}: id and require are required", "rules", row)
+        if rule.kind not in RULE_KINDS:
+            raise ConfigError(f"rule {rule.id}: unknown kind '{rule.kind}'", f"rules.{rule.id}", rule.kind, list(RULE_KINDS))
+        if rule.severity not in SEVERITIES:
+            raise ConfigError(f"rule {rule.id}: unknown severity '{rule.severity}'", f"rules.{rule.id}", rule.severity, list(SEVERITIES))
+        rule._where = compile_expression(rule.where, rule.id) if rule.where.strip() else None
+        rule._require = compile_expression(rule.require, rule.id)
+        rules.append(rule)
+    return rules
+
+
+def rules_for(rules: List[CustomRule], kind: str) -> List[CustomRule]:
+    return [rule for rule in rules if rule.kind in (kind, "any")]
@@ -0,0 +1,183 @@
+#!/usr/bin/env python3
+"""Draw a stratified random sample of exported entries for QA spot checks.
+
+Reviewers check a sample of the dataset each release. Picking entries by hand over-samples whatever is easy
+to find; this draws the sample at random within strata (level and part of speech by default), sized in
+proportion to each stratum with at least one entry from every stratum, and writes it as a Markdown
+checklist or a CSV with blank verdict and notes columns. The seed is printed so a sample can be drawn again.
+"""
+
+from __future__ import annotations
+
+import argparse
+import csv
+import json
+import random
+import sys
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Tuple
+
+try:
+    from .common import REPORTS_DIR, entry_level, spanish_texts, write_report
+    from .console import add_output_arguments, configure_output
+    from .storage import DEFAULT_STORAGE_PATH, load_storage
+    from .verify import unmark_generated
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import REPORTS_DIR, entry_level, spanish_texts, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from storage import DEFAULT_STORAGE_PATH, load_storage  # type: ignore
+    from verify import unmark_generated  # type: ignore
+
+SAMPLE_KINDS = ("vocabulary", "lessons", "readings", "culture_notes", "pron_drills")
+SAMPLE_FORMATS = ("markdown", "csv")
+CSV_FIELDS = ("stratum", "kind", "id", "level", "headline", "english", "spanish", "sources", "verdict", "notes")
+# Spanish lines shown per entry; a reviewer needs a taste of the content, not all of it.
+SHOWN_TEXTS = 5
+Stratum = Tuple[str, ...]
+
+
+def stratum_value(kind: str, entry: Dict[str, Any], field: str) -> str:
+    if field == "kind":
+        return kind
+    if field == "level":
+        return entry_level(entry)
+    value = entry.get(field)
+    if isinstance(value, list):
+        return ",".join(sorted(str(item) for item in value)) or "(none)"
+    return str(value) if value not in (None, "") else "(none)"
+
+
+def stratify(entries: List[Tuple[str, Dict[str, Any]]], fields: List[str]) -> Dict[Stratum, List[Tuple[str, Dict[str, Any]]]]:
+    strata: Dict[Stratum, List[Tuple[str, Dict[str, Any]]]] = {}
+    for kind, entry in entries:
+        strata.setdefault(tuple(stratum_value(kind, entry, field) for field in fields), []).append((kind, entry))
+    return strata
+
+
+def allocate(sizes: Dict[Stratum, int], n: int, minimum: int = 1) -> Dict[Stratum, int]:
+    """Entries to draw per stratum: proportional to its size (largest remainder), at least minimum where the
+    stratum has that many, n in total. With more strata than n, the largest strata get one each."""
+    total = sum(sizes.values())
+    if n >= total:
+        return dict(sizes)
+    floor = {key: min(minimum, size) for key, size in sizes.items()}
+    if sum(floor.values()) > n:
+        chosen = sorted(sizes, key=lambda key: (-sizes[key], key))[:n]
+        return {key: int(key in chosen) for key in sizes}
+    quotas = {key: n * size / total for key, size in sizes.items()}
+    counts = {key: min(size, max(floor[key], int(quotas[key]))) for key, size in sizes.items()}
+    while sum(counts.values()) < n:
+        key = max((key for key in sizes if counts[key] < sizes[key]), key=lambda key: (quotas[key] - counts[key], sizes[key], key))
+        counts[key] += 1
+    while sum(counts.values()) > n:
+        key = max((key for key in sizes if counts[key] > floor[key]), key=lambda key: (counts[key] - quotas[key], key))
+        counts[key] -= 1
+    return counts
+
+
+def draw(strata: Dict[Stratum, List[Tuple[str, Dict[str, Any]]]], counts: Dict[Stratum, int], seed: int) -> List[Tuple[Stratum, str, Dict[str, Any]]]:
+    rng = random.Random(seed)
+    picked: List[Tuple[Stratum, str, Dict[str, Any]]] = []
+    for key in sorted(strata):
+        members = sorted(strata[key], key=lambda item: str(item[1].get("id")))
+        picked += [(key, kind, entry) for kind, entry in sorted(rng.sample(members, counts[key]), key=lambda item: str(item[1].get("id")))]
+    return picked
+
+
+def headline(entry: Dict[str, Any]) -> Tuple[str, str]:
+    """(Spanish headline or title, English gloss) for whichever kind the entry is."""
+    title = entry.get("spanish") or entry.get("title") or entry.get("id")
+    english = entry.get("english_gloss") or entry.get("english") or entry.get("definition") or ""
+    return str(title), str(english) if isinstance(english, str) else ""
+
+
+def shown_texts(entry: Dict[str, Any]) -> List[str]:
+    return [text for _, text in spanish_texts(entry)][:SHOWN_TEXTS]
+
+
+def render_markdown(sample: List[Tuple[Stratum, str, Dict[str, Any]]], fields: List[str], sizes: Dict[Stratum, int], seed: int) -> List[str]:
+    drawn: Dict[Stratum, int] = {}
+    for key, _, _ in sample:
+        drawn[key] = drawn.get(key, 0) + 1
+    lines = ["QA sample", f"- entries: {len(sample)} of {sum(sizes.values())}", f"- stratified by: {', '.join(fields) or 'nothing'}", f"- seed: {seed}", "## strata"]
+    lines += [f"- {' / '.join(key) or 'all'}: {drawn.get(key, 0)} of {size}" for key, size in sorted(sizes.items())]
+    for key, kind, entry in sample:
+        title, english = headline(entry)
+        lines.append(f"## {title}" + (f" — {english}" if english else ""))
+        lines.append(f"- {entry.get('id')} · {kind} · level {entry_level(entry)} · stratum {' / '.join(key) or 'all'}")
+        lines += [f"- {text}" for text in shown_texts(entry)]
+        lines.append(f"- sources: {', '.join(entry.get('source_files', [])) or 'unknown'}")
+        lines.append("- [ ] correct · notes:")
+    return lines
+
+
+def write_csv(path: Path, sample: List[Tuple[Stratum, str, Dict[str, Any]]]) -> None:
+    path.parent.mkdir(parents=True, exist_ok=True)
+    with open(path, "w", newline="", encoding="utf-8") as handle:
+        writer = csv.DictWriter(handle, fieldnames=CSV_FIELDS)
+        writer.writeheader()
+        for key, kind, entry in sample:
+            title, english = headline(entry)
+            writer.writerow({
+                "stratum": " / ".join(key),
+                "kind": kind,
+                "id": entry.get("id"),
+                "level": entry_level(entry),
+                "headline": title,
+                "english": english,
+                "spanish": " | ".join(shown_texts(entry)),
+                "sources": ", ".join(entry.get("source_files", [])),
+                "verdict": "",
+                "notes": "",
+            })
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Export a stratified random sample of canonical entries for QA review.")
+    parser.add_argument("--storage", default=str(DEFAULT_STORAGE_PATH), help="Path to the storage backend config")
+    parser.add_argument("--out", default="canonical", help="Location of canonical JSON inside the storage backend")
+    parser.add_argument("--n", type=int, default=100, help="Number of entries to sample (default 100)")
+    parser.add_argument("--stratify", default="level,pos", help="Comma-separated fields to stratify by; kind and level are understood for every kind (default level,pos)")
+    parser.add_argument("--kind", action="append", choices=SAMPLE_KINDS, help="Sample only this kind (repeatable; default vocabulary and lessons)")
+    parser.add_argument("--min-per-stratum", type=int, default=1, metavar="N", help="Draw at least N entries from each stratum that has them (default 1)")
+    parser.add_argument("--seed", type=int, help="Random seed; reuse a printed seed to draw the same sample again")
+    parser.add_argument("--format", choices=SAMPLE_FORMATS, default="markdown", help="Sample file format")
+    parser.add_argument("--output", help=f"Sample file (default {REPORTS_DIR}/sample.md or sample.csv)")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    if args.n < 1:
+        parser.error("--n must be at least 1")
+    if args.min_per_stratum < 0:
+        parser.error("--min-per-stratum cannot be negative")
+
+    fields = [field.strip() for field in args.stratify.split(",") if field.strip()]
+    out = load_storage(args.storage, readonly=True).child(args.out)
+    entries: List[Tuple[str, Dict[str, Any]]] = []
+    for kind in args.kind or ["vocabulary", "lessons"]:
+        raw = out.read_bytes(f"{kind}.json")
+        if raw is None:
+            print(f"[sample] No {kind}.json in {out.describe()}; run export first", file=sys.stderr)
+            return 1
+        entries += [(kind, entry) for entry in unmark_generated(json.loads(raw.decode("utf-8"))) if isinstance(entry, dict)]
+    if not entries:
+        print(f"[sample] Nothing to sample in {out.describe()}", file=sys.stderr)
+        return 1
+
+    seed = args.seed if args.seed is not None else random.SystemRandom().randrange(1_000_000)
+    strata = stratify(entries, fields)
+    sizes = {key: len(members) for key, members in strata.items()}
+    sample = draw(strata, allocate(sizes, args.n, args.min_per_stratum), seed)
+    if args.format == "csv":
+        path = Path(args.output) if args.output else REPORTS_DIR / "sample.csv"
+        write_csv(path, sample)
+    else:
+        lines = render_markdown(sample, fields, sizes, seed)
+        path = write_report(Path(args.output).name, lines, Path(args.output).parent) if args.output else write_report("sample.md", lines)
+    print(f"[sample] Drew {len(sample)} of {len(entries)} entries across {len(strata)} strata (seed {seed}); wrote {path}")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,149 @@
+#!/usr/bin/env python3
+"""Run one content file through collect, classify, normalize, and validate, and print every intermediate step.
+
+Debugging a single problematic file otherwise takes a full export and a hunt through its reports. This runs
+the same functions on that file alone, writes nothing, and shows what each stage made of it: conflict markers
+resolved, records decoded (and where decoding stopped), field names corrected, how well the record matches
+each kind, the entry as export builds it, and every validation issue.
+"""
+
+from __future__ import annotations
+
+import argparse
+import hashlib
+import json
+import sys
+from dataclasses import asdict
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+
+try:
+    from . import conflicts
+    from .common import DEFAULT_CONFLICT_CACHE, DEFAULT_IDS_PATH, UNIT_NAME, Record, classify, decode_objects, is_pinned, iter_sources, load_id_scheme
+    from .console import add_output_arguments, configure_output
+    from .fieldnames import CORRECTIONS_KEY, correct_fields
+    from .licenses import mark_third_party
+    from .quarantine import QUARANTINE_THRESHOLD, kind_scores, suggest_kind
+    from .rules import DEFAULT_RULES_PATH, load_rules, rules_for
+    from .validate import Issue, entry_for, format_issue, load_schemas, validate_all
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    import conflicts  # type: ignore
+    from common import DEFAULT_CONFLICT_CACHE, DEFAULT_IDS_PATH, UNIT_NAME, Record, classify, decode_objects, is_pinned, iter_sources, load_id_scheme  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from fieldnames import CORRECTIONS_KEY, correct_fields  # type: ignore
+    from licenses import mark_third_party  # type: ignore
+    from quarantine import QUARANTINE_THRESHOLD, kind_scores, suggest_kind  # type: ignore
+    from rules import DEFAULT_RULES_PATH, load_rules, rules_for  # type: ignore
+    from validate import Issue, entry_for, format_issue, load_schemas, validate_all  # type: ignore
+
+
+def inspect_record(source: str, index: int, obj: Any, scheme, schemas: Dict[str, Dict[str, Any]], rules) -> Dict[str, Any]:
+    """Every stage one decoded object goes through, as collect and export run them."""
+    stage: Dict[str, Any] = {"index": index, "decoded": obj, "pinned": isinstance(obj, dict) and is_pinned(obj)}
+    if isinstance(obj, dict) and not stage["pinned"]:
+        fixed = correct_fields(obj)
+        stage["field_corrections"] = dict(fixed.get(CORRECTIONS_KEY, {})) if fixed is not obj else {}
+        obj = fixed
+    data = obj if isinstance(obj, dict) else {"value": obj}
+    kind = "unit" if source.rsplit("/", 1)[-1] == UNIT_NAME and isinstance(obj, dict) else classify(obj)
+    stage["kind"] = kind
+    stage["scores"] = [asdict(suggestion) for suggestion in kind_scores(data, schemas)]
+    if kind is None:
+        suggestion = suggest_kind(data, schemas)
+        stage["outcome"] = f"quarantined as a near-miss {suggestion.kind}" if suggestion else "rejected: matches no kind"
+        return stage
+    entry = entry_for(Record(kind=kind, data=data, source=source, index=index), scheme)
+    if not is_pinned(entry) and kind != "unit":
+        mark_third_party([entry])
+    stage["normalized"] = entry
+    issues = validate_all(entry, schemas[kind], rules_for(rules, kind))
+    stage["issues"] = [asdict(issue) for issue in issues]
+    stage["outcome"] = "rejected: fails validation" if any(issue.severity == "error" for issue in issues) else f"exported as {kind} {entry['id']}"
+    return stage
+
+
+def inspect_source(source: str, raw: bytes, scheme, schemas: Dict[str, Dict[str, Any]], rules, cache: Optional[conflicts.ConflictCache], recover: bool) -> Dict[str, Any]:
+    text = raw.decode("utf-8", errors="replace")
+    report: Dict[str, Any] = {"source": source, "bytes": len(raw), "sha256": hashlib.sha256(raw).hexdigest(), "conflicts": None}
+    if conflicts.has_conflicts(text):
+        pending: List[conflicts.Chunk] = []
+        text, notes = conflicts.resolve_conflicts(text, cache, pending)
+        report["conflicts"] = {"notes": notes, "kept_both_sides": [asdict(chunk) for chunk in pending]}
+    result = decode_objects(text, recover)
+    report["decode"] = {"objects": len(result.objects), "error": result.error}
+    if result.error:
+        report["decode"]["byte_offset"] = len(text[: result.error_offset].encode("utf-8"))
+        report["decode"]["unparsed"] = text[result.error_offset : result.error_offset + 200]
+    report["records"] = [inspect_record(source, index, obj, scheme, schemas, rules) for index, obj in enumerate(result.objects)]
+    return report
+
+
+def dump(value: Any) -> List[str]:
+    return ["    " + line for line in json.dumps(value, ensure_ascii=False, indent=2).splitlines()]
+
+
+def render(report: Dict[str, Any]) -> List[str]:
+    out = [f"[sandbox] {report['source']} ({report['bytes']} bytes, sha256 {report['sha256'][:12]})", "## conflicts"]
+    if report["conflicts"] is None:
+        out.append("- no conflict markers")
+    else:
+        out.append("- resolved as export --resolve-conflicts would (without it, the markers fail decoding):")
+        out += [f"- {note}" for note in report["conflicts"]["notes"]]
+        if report["conflicts"]["kept_both_sides"]:
+            out.append(f"- {len(report['conflicts']['kept_both_sides'])} conflicts had no clean choice; record resolutions with triage_conflicts.py")
+    decode = report["decode"]
+    out.append("## decode")
+    out.append(f"- {decode['objects']} objects decoded")
+    if decode["error"]:
+        out.append(f"- stopped at byte {decode['byte_offset']}: {decode['error']}; everything after goes to the rejects")
+        out.append(f"- unparsed text: {decode['unparsed']!r}")
+    for record in report["records"]:
+        out.append(f"## record {record['index']}")
+        out.append("decoded:")
+        out += dump(record["decoded"])
+        if record["pinned"]:
+            out.append("- pinned: field names and generated fields are left as written")
+        for typo, name in record.get("field_corrections", {}).items():
+            out.append(f"- field name corrected: {typo} -> {name}")
+        out.append(f"- classified as: {record['kind'] or 'nothing'}")
+        for score in record["scores"]:
+            mark = " (quarantine threshold)" if score["score"] >= QUARANTINE_THRESHOLD and record["kind"] is None else ""
+            out.append(f"  - {score['kind']}: {score['score']:.2f}{mark}, has {', '.join(score['matched']) or 'none'}; missing {', '.join(score['missing']) or 'none'}")
+        if "normalized" in record:
+            out.append("normalized:")
+            out += dump(record["normalized"])
+            out.append(f"- validation: {len(record['issues'])} issues")
+            out += [f"  - {format_issue(Issue(**issue))}" for issue in record["issues"]]
+        out.append(f"- outcome: {record['outcome']}")
+    return out
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Run a single content file through the pipeline and print every intermediate representation.")
+    parser.add_argument("file", help="Content file (.json/.jsonl) or archive to inspect")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument("--rules", default=str(DEFAULT_RULES_PATH), help="Custom validation rules config")
+    parser.add_argument("--recover", action="store_true", help="Salvage the complete objects of a damaged top-level array, as export --recover does")
+    parser.add_argument("--conflict-cache", default=str(DEFAULT_CONFLICT_CACHE), help="Manual conflict resolutions recorded by triage_conflicts.py")
+    parser.add_argument("--json", action="store_true", help="Print the stages as JSON instead of text")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    if not Path(args.file).is_file():
+        parser.error(f"{args.file} is not a file")
+
+    scheme = load_id_scheme(args.ids)
+    schemas = load_schemas()
+    rules = load_rules(args.rules)
+    cache = conflicts.ConflictCache(args.conflict_cache)
+    reports = [inspect_source(source, raw, scheme, schemas, rules, cache, args.recover) for source, raw in iter_sources([args.file])]
+    if args.json:
+        print(json.dumps(reports, ensure_ascii=False, indent=2))
+    else:
+        print("\n".join(line for report in reports for line in render(report)))
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,166 @@
+"""Prebuilt MiniSearch index over lessons and vocabulary, so clients skip indexing at page load.
+
+Vocabulary also carries `english_keywords`: search synonyms beyond the primary gloss, so a learner searching
+"car" finds "el coche" glossed "automobile". Authors list their own; export seeds the rest from the words of
+every gloss (`english_keywords` below) and the index searches them as their own field.
+"""
+
+from __future__ import annotations
+
+import re
+import unicodedata
+from typing import Any, Dict, List, Optional
+
+try:
+    from .common import spanish_texts
+    from .senses import entry_senses
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import spanish_texts  # type: ignore
+    from senses import entry_senses  # type: ignore
+
+SEARCH_FIELDS = ("spanish", "english", "keywords")
+KEYWORDS_FIELD = "english_keywords"
+# Gloss alternatives: "automobile; car", "to go up / climb", "big or large".
+GLOSS_ALTERNATIVES = re.compile(r"[;,/]|\bor\b")
+PARENTHESES = re.compile(r"\(([^)]*)\)")
+# Leading words a gloss phrase drops as a keyword ("to eat" -> "eat"), and words never seeded on their own.
+PHRASE_ARTICLES = ("to ", "a ", "an ", "the ")
+KEYWORD_STOPWORDS = {"a", "an", "the", "to", "of", "or", "and", "be", "in", "on", "at", "for", "with", "by", "from", "one", "someone", "something", "sb", "sth", "etc"}
+MIN_KEYWORD_LENGTH = 3
+ENGLISH_KEYS = {"en", "english", "english_gloss", "gloss", "definition"}
+STORE_FIELDS = ("kind", "label", "gloss", "level")
+# Client side: MiniSearch.loadJS(file.index, {...file.options, processTerm: t => t.normalize("NFD").replace(/\p{Diacritic}/gu, "").toLowerCase()})
+PROCESS_TERM = "nfd-strip-diacritics-lowercase"
+SERIALIZATION_VERSION = 2
+
+
+def fold(term: str) -> str:
+    decomposed = unicodedata.normalize("NFD", term)
+    return "".join(ch for ch in decomposed if not unicodedata.combining(ch)).lower()
+
+
+def tokenize(text: str) -> List[str]:
+    """Split like MiniSearch's default tokenizer: on whitespace, separators, and punctuation (/[\\n\\r\\p{Z}\\p{P}]+/u)."""
+    tokens: List[str] = []
+    current = ""
+    for ch in text:
+        if ch in "\n\r" or unicodedata.category(ch)[0] in ("Z", "P"):
+            tokens.append(current)
+            current = ""
+        else:
+            current += ch
+    tokens.append(current)
+    return tokens
+
+
+def gloss_keywords(gloss: str) -> List[str]:
+    """Keywords a gloss seeds: each alternative as a phrase (without a leading to/a/the) and its content words."""
+    found: List[str] = []
+    hints = PARENTHESES.findall(gloss)
+    for phrase in GLOSS_ALTERNATIVES.split(PARENTHESES.sub(" ", gloss)) + hints:
+        phrase = " ".join(phrase.lower().split())
+        for article in PHRASE_ARTICLES:
+            if phrase.startswith(article):
+                phrase = phrase[len(article) :]
+        if phrase and phrase not in KEYWORD_STOPWORDS:
+            found.append(phrase)
+        found += [word for word in tokenize(phrase) if word != phrase and len(word) >= MIN_KEYWORD_LENGTH and word not in KEYWORD_STOPWORDS]
+    return found
+
+
+def english_keywords(entry: Dict[str, Any]) -> List[str]:
+    """The entry's authored keywords, then those seeded from its glosses, deduplicated without case; the primary
+    gloss itself is left out, since search already matches it."""
+    authored = entry.get(KEYWORDS_FIELD)
+    candidates = [item.strip() for item in authored if isinstance(item, str)] if isinstance(authored, list) else []
+    glosses = [entry.get("english_gloss")] + [sense.get("gloss") for sense in entry_senses(entry)]
+    for gloss in glosses:
+        if isinstance(gloss, str):
+            candidates += gloss_keywords(gloss)
+    primary = str(entry.get("english_gloss") or "").strip().casefold()
+    keywords: List[str] = []
+    seen = {primary}
+    for keyword in candidates:
+        if keyword and keyword.casefold() not in seen:
+            seen.add(keyword.casefold())
+            keywords.append(keyword)
+    return keywords
+
+
+def english_texts(value: Any) -> List[str]:
+    """Every English-language string inside a record (en/english/gloss/definition keys, or *_en)."""
+    if isinstance(value, dict):
+        found: List[str] = []
+        for key, item in value.items():
+            if isinstance(item, str):
+                if (key in ENGLISH_KEYS or str(key).endswith("_en")) and item.strip():
+                    found.append(item)
+            else:
+                found += english_texts(item)
+        return found
+    if isinstance(value, list):
+        return [text for item in value for text in english_texts(item)]
+    return []
+
+
+def document(entry: Dict[str, Any], kind: str) -> Dict[str, Any]:
+    keywords: List[str] = []
+    if kind == "vocab":
+        label, gloss = entry.get("spanish"), entry.get("english_gloss")
+        keywords = english_keywords(entry)
+        senses = entry_senses(entry)
+        # The first sense already mirrors the flat examples.
+        body: Any = {"spanish": entry.get("spanish"), "senses": senses, "examples": None if senses else entry.get("examples")}
+    else:
+        label, gloss = entry.get("title"), entry.get("nickname")
+        body = {"title_en": entry.get("title"), "nickname_en": entry.get("nickname"), "steps": entry.get("steps")}
+    return {
+        "id": entry["id"],
+        "kind": kind,
+        "label": label,
+        "gloss": gloss,
+        "level": entry.get("level"),
+        "spanish": " ".join(text for _, text in spanish_texts(body)),
+        "english": " ".join(english_texts(body)),
+        "keywords": " ".join(keywords),
+    }
+
+
+def build_search_index(vocab: List[Dict[str, Any]], lessons: List[Dict[str, Any]]) -> Dict[str, Any]:
+    """Serialize the same structure MiniSearch.toJSON() produces after adding every document in order."""
+    documents = [document(entry, "vocab") for entry in vocab] + [document(entry, "lesson") for entry in lessons]
+    field_ids = {name: idx for idx, name in enumerate(SEARCH_FIELDS)}
+    index: Dict[str, Dict[str, Dict[str, int]]] = {}
+    field_length: Dict[str, List[Optional[int]]] = {}
+    average: List[float] = [0.0] * len(SEARCH_FIELDS)
+    for short_id, doc in enumerate(documents):
+        for field, field_id in field_ids.items():
+            text = doc.get(field)
+            if not text or not text.strip():
+                continue
+            tokens = tokenize(text)
+            lengths = field_length.setdefault(str(short_id), [None] * len(SEARCH_FIELDS))
+            lengths[field_id] = len(set(tokens))
+            # MiniSearch keeps a running mean weighted by the document count so far.
+            average[field_id] = (average[field_id] * short_id + lengths[field_id]) / (short_id + 1)
+            for token in tokens:
+                term = fold(token)
+                if term:
+                    postings = index.setdefault(term, {}).setdefault(str(field_id), {})
+                    postings[str(short_id)] = postings.get(str(short_id), 0) + 1
+    return {
+        "format": "minisearch",
+        "options": {"fields": list(SEARCH_FIELDS), "storeFields": list(STORE_FIELDS), "idField": "id", "processTerm": PROCESS_TERM},
+        "index": {
+            "documentCount": len(documents),
+            "nextId": len(documents),
+            "documentIds": {str(short_id): doc["id"] for short_id, doc in enumerate(documents)},
+            "fieldIds": field_ids,
+            "fieldLength": field_length,
+            "averageFieldLength": average,
+            "storedFields": {str(short_id): {key: doc[key] for key in STORE_FIELDS if doc.get(key) is not None} for short_id, doc in enumerate(documents)},
+            "dirtCount": 0,
+            "index": [[term, postings] for term, postings in sorted(index.items())],
+            "serializationVersion": SERIALIZATION_VERSION,
+        },
+    }
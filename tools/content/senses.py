@@ -0,0 +1,36 @@
+"""Multiple senses per vocabulary entry, migrated from and mirrored into the flat gloss fields."""
+
+from __future__ import annotations
+
+from typing import Any, Dict, List
+
+# Sense key -> flat entry field it mirrors. The first sense is the one the flat fields describe.
+SENSE_FIELDS = {"gloss": "english_gloss", "definition": "definition", "examples": "examples", "level": "level"}
+
+
+def flat_sense(entry: Dict[str, Any]) -> Dict[str, Any]:
+    return {key: entry[flat] for key, flat in SENSE_FIELDS.items() if flat in entry}
+
+
+def entry_senses(entry: Dict[str, Any]) -> List[Dict[str, Any]]:
+    senses = entry.get("senses")
+    if isinstance(senses, list) and any(isinstance(sense, dict) for sense in senses):
+        return [sense for sense in senses if isinstance(sense, dict)]
+    sense = flat_sense(entry)
+    return [sense] if sense.get("gloss") or sense.get("definition") else []
+
+
+def normalize_senses(entry: Dict[str, Any]) -> Dict[str, Any]:
+    """Give an entry a `senses` list (built from the flat fields when missing) and refresh the flat fields from the first sense."""
+    senses = entry_senses(entry)
+    if not senses:
+        return entry
+    entry["senses"] = senses
+    for key, flat in SENSE_FIELDS.items():
+        if key in senses[0]:
+            entry[flat] = senses[0][key]
+    return entry
+
+
+def sense_key(sense: Dict[str, Any]) -> str:
+    return " ".join(str(sense.get("gloss") or "").lower().split())
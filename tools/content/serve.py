@@ -0,0 +1,292 @@
+#!/usr/bin/env python3
+"""Serve the merged dataset as JSON over HTTP, rebuilding in the background when content changes.
+
+With --token set, POST /lessons and /vocabulary add records and PUT /lessons/<id> and /vocabulary/<id> edit
+them; each submission is validated before it is written to content/ (see submissions.py). A write answers
+once the file is saved; the watcher thread rebuilds, so the new record is served from the next version.
+"""
+
+from __future__ import annotations
+
+import argparse
+import datetime as dt
+import hmac
+import json
+import os
+import sys
+import threading
+import time
+import traceback
+from dataclasses import asdict, dataclass, field
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+from urllib.parse import unquote, urlparse
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_IDS_PATH, collect, iter_source_files, load_id_scheme
+    from .console import add_output_arguments, configure_output
+    from .errors import InvalidEntry
+    from .export import build_entries
+    from .merge import merge_by_id
+    from .rules import DEFAULT_RULES_PATH, load_rules
+    from .senses import normalize_senses
+    from .submissions import KINDS, SubmissionRejected, SubmissionWriter
+    from .validate import SCHEMAS_DIR, load_schemas
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_IDS_PATH, collect, iter_source_files, load_id_scheme  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from errors import InvalidEntry  # type: ignore
+    from export import build_entries  # type: ignore
+    from merge import merge_by_id  # type: ignore
+    from rules import DEFAULT_RULES_PATH, load_rules  # type: ignore
+    from senses import normalize_senses  # type: ignore
+    from submissions import KINDS, SubmissionRejected, SubmissionWriter  # type: ignore
+    from validate import SCHEMAS_DIR, load_schemas  # type: ignore
+
+Fingerprint = Tuple[Tuple[str, int, int], ...]
+TOKEN_ENV = "CONTENT_SERVE_TOKEN"
+MAX_BODY_BYTES = 1_000_000
+
+
+@dataclass(frozen=True)
+class Snapshot:
+    """One complete build. Never mutated after construction; rebuilds replace it whole."""
+
+    version: int
+    built_at: str
+    fingerprint: Fingerprint
+    lessons: List[Dict[str, Any]] = field(default_factory=list)
+    vocab: List[Dict[str, Any]] = field(default_factory=list)
+    by_id: Dict[str, Dict[str, Any]] = field(default_factory=dict)
+    rejected_files: List[str] = field(default_factory=list)
+
+
+def now_iso() -> str:
+    return dt.datetime.now(dt.timezone.utc).isoformat(timespec="seconds")
+
+
+def fingerprint(paths: List[str]) -> Fingerprint:
+    stats = []
+    for path in iter_source_files(paths):
+        try:
+            stat = path.stat()
+        except OSError:
+            continue
+        stats.append((str(path), stat.st_mtime_ns, stat.st_size))
+    return tuple(sorted(stats))
+
+
+def build_snapshot(paths: List[str], ids: str, version: int, prints: Fingerprint) -> Snapshot:
+    scheme = load_id_scheme(ids)
+    dataset = collect(paths)
+    vocab, _ = merge_by_id([normalize_senses(entry) for entry in build_entries(dataset.vocab, scheme)])
+    lessons, _ = merge_by_id(build_entries(dataset.lessons, scheme))
+    by_id = {entry["id"]: entry for entry in vocab + lessons}
+    return Snapshot(version, now_iso(), prints, lessons, vocab, by_id, sorted(dataset.decode_errors))
+
+
+class DatasetState:
+    """Holds the current snapshot and the outcome of the latest build attempt."""
+
+    def __init__(self, paths: List[str], ids: str, verbose: bool = False) -> None:
+        self.paths = paths
+        self.ids = ids
+        self.verbose = verbose
+        self._lock = threading.Lock()
+        self._snapshot: Optional[Snapshot] = None
+        self._requested = threading.Event()
+        self.last_build: Dict[str, Any] = {"status": "pending"}
+
+    def current(self) -> Optional[Snapshot]:
+        with self._lock:
+            return self._snapshot
+
+    def rebuild(self, prints: Optional[Fingerprint] = None) -> bool:
+        """Build off to the side and swap it in only when complete; a failed build keeps serving the old snapshot."""
+        prints = prints if prints is not None else fingerprint(self.paths)
+        previous = self.current()
+        started = time.monotonic()
+        try:
+            snapshot = build_snapshot(self.paths, self.ids, (previous.version if previous else 0) + 1, prints)
+        except Exception as exc:  # noqa: BLE001 - any build failure must leave the server running
+            self.last_build = {"status": "failed", "error": f"{type(exc).__name__}: {exc}", "finished_at": now_iso(), "duration_ms": round((time.monotonic() - started) * 1000)}
+            traceback.print_exc(file=sys.stderr)
+            return False
+        with self._lock:
+            self._snapshot = snapshot
+        self.last_build = {"status": "ok", "finished_at": snapshot.built_at, "duration_ms": round((time.monotonic() - started) * 1000)}
+        return True
+
+    def request_rebuild(self) -> None:
+        """Have the watcher rebuild now instead of at its next check; returns at once."""
+        self._requested.set()
+
+    def watch(self, interval: float, stop: threading.Event) -> None:
+        seen = self.current().fingerprint if self.current() else None
+        while not stop.is_set():
+            requested = self._requested.wait(interval)
+            self._requested.clear()
+            if stop.is_set():
+                return
+            prints = fingerprint(self.paths)
+            current = self.current()
+            # A write through the API asks for a rebuild even when its edit left the size and mtime unchanged.
+            if not requested and (prints == seen or (current and prints == current.fingerprint)):
+                seen = prints
+                continue
+            seen = prints
+            if self.rebuild(prints):
+                print(f"[serve] Content changed; now serving version {self.current().version}", file=sys.stderr)
+
+
+def make_handler(state: DatasetState, writer: Optional[SubmissionWriter] = None, token: str = "") -> type:
+    class Handler(BaseHTTPRequestHandler):
+        def send_json(self, status: int, payload: Any) -> None:
+            body = json.dumps(payload, ensure_ascii=False).encode("utf-8")
+            self.send_response(status)
+            self.send_header("Content-Type", "application/json; charset=utf-8")
+            self.send_header("Content-Length", str(len(body)))
+            self.send_header("Cache-Control", "no-store")
+            self.end_headers()
+            self.wfile.write(body)
+
+        def do_GET(self) -> None:  # noqa: N802 - http.server naming
+            parts = [unquote(part) for part in urlparse(self.path).path.strip("/").split("/") if part]
+            # Take the snapshot once so every response comes from a single consistent build.
+            snapshot = state.current()
+            if parts == ["health"]:
+                payload = {
+                    "status": "ok" if snapshot and state.last_build.get("status") == "ok" else "degraded",
+                    "version": snapshot.version if snapshot else None,
+                    "built_at": snapshot.built_at if snapshot else None,
+                    "counts": {"lessons": len(snapshot.lessons), "vocabulary": len(snapshot.vocab)} if snapshot else {},
+                    "rejected_files": snapshot.rejected_files if snapshot else [],
+                    "last_build": state.last_build,
+                }
+                self.send_json(200 if snapshot else 503, payload)
+                return
+            if snapshot is None:
+                self.send_json(503, {"error": "dataset not built yet", "last_build": state.last_build})
+                return
+            if parts in (["lessons"], ["vocabulary"]):
+                self.send_json(200, {"version": snapshot.version, "items": snapshot.lessons if parts[0] == "lessons" else snapshot.vocab})
+            elif len(parts) == 2 and parts[0] in ("lessons", "vocabulary") and parts[1] in snapshot.by_id:
+                item = snapshot.by_id[parts[1]]
+                if ("steps" in item) != (parts[0] == "lessons"):
+                    self.send_json(404, {"error": f"{parts[1]} is not in /{parts[0]}"})
+                else:
+                    self.send_json(200, {"version": snapshot.version, "item": item})
+            else:
+                self.send_json(404, {"error": f"no route for /{'/'.join(parts)}"})
+
+        def read_body(self) -> Any:
+            header = self.headers.get("Content-Length")
+            if header is None:
+                raise SubmissionRejected(411, "a Content-Length header is required")
+            try:
+                length = int(header)
+            except ValueError:
+                raise SubmissionRejected(400, f"Content-Length {header!r} is not a number") from None
+            if length < 0:
+                raise SubmissionRejected(400, f"Content-Length {length} is negative")
+            if length > MAX_BODY_BYTES:
+                raise SubmissionRejected(413, f"request body over {MAX_BODY_BYTES} bytes")
+            try:
+                return json.loads(self.rfile.read(length).decode("utf-8"))
+            except (UnicodeDecodeError, json.JSONDecodeError) as exc:
+                raise SubmissionRejected(400, f"request body is not JSON: {exc}") from exc
+
+        def handle_write(self, method: str) -> None:
+            parts = [unquote(part) for part in urlparse(self.path).path.strip("/").split("/") if part]
+            if writer is None or not token:
+                self.send_json(403, {"error": "the write API is off; start serve with --token"})
+                return
+            if not hmac.compare_digest(self.headers.get("Authorization", "").encode("utf-8"), f"Bearer {token}".encode("utf-8")):
+                self.send_json(401, {"error": "missing or wrong bearer token"})
+                return
+            kind = KINDS.get(parts[0]) if parts else None
+            if kind is None or len(parts) != (1 if method == "POST" else 2):
+                self.send_json(404, {"error": f"no {method} route for /{'/'.join(parts)}"})
+                return
+            snapshot = state.current()
+            if snapshot is None:
+                self.send_json(503, {"error": "dataset not built yet", "last_build": state.last_build})
+                return
+            try:
+                data = self.read_body()
+                if method == "POST":
+                    entry, path, warnings = writer.create(kind, data, snapshot.by_id)
+                else:
+                    current = snapshot.by_id.get(parts[1])
+                    if current is None or ("steps" in current) != (kind == "lesson"):
+                        raise SubmissionRejected(404, f"{parts[1]} is not in /{parts[0]}")
+                    entry, path, warnings = writer.update(kind, parts[1], data, current)
+            except InvalidEntry as exc:
+                self.send_json(422, {"error": str(exc), "id": exc.entry_id, "issues": [asdict(issue) for issue in exc.issues]})
+                return
+            except SubmissionRejected as exc:
+                self.send_json(exc.status, {"error": str(exc)})
+                return
+            # The watcher thread rebuilds; the response does not wait for it, so "version" is the build still being served.
+            state.request_rebuild()
+            print(f"[serve] {'Added' if method == 'POST' else 'Updated'} {entry['id']} in {path}", file=sys.stderr)
+            self.send_json(201 if method == "POST" else 200, {"version": snapshot.version, "rebuild": "queued", "file": path, "item": entry, "warnings": [asdict(issue) for issue in warnings]})
+
+        def do_POST(self) -> None:  # noqa: N802 - http.server naming
+            self.handle_write("POST")
+
+        def do_PUT(self) -> None:  # noqa: N802 - http.server naming
+            self.handle_write("PUT")
+
+        def log_message(self, format: str, *args: Any) -> None:  # noqa: A002 - signature fixed by http.server
+            if state.verbose:
+                super().log_message(format, *args)
+
+    return Handler
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Serve lessons and vocabulary over HTTP with live reload.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to collect")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument("--host", default="127.0.0.1", help="Interface to bind")
+    parser.add_argument("--port", type=int, default=8765, help="Port to listen on")
+    parser.add_argument("--interval", type=float, default=1.0, help="Seconds between content change checks")
+    parser.add_argument("--verbose", action="store_true", help="Log every request")
+    parser.add_argument("--token", default=os.environ.get(TOKEN_ENV, ""), help=f"Bearer token that turns on the POST/PUT write API (default: ${TOKEN_ENV}); without one the server is read-only")
+    parser.add_argument("--schemas", default=str(SCHEMAS_DIR), help="Directory holding the schemas submissions are validated against")
+    parser.add_argument("--rules", default=str(DEFAULT_RULES_PATH), help="Custom rules config checked alongside the schemas")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    writer = None
+    if args.token:
+        try:
+            rules = load_rules(args.rules)
+        except ValueError as exc:
+            parser.error(str(exc))
+        content_dir = next((Path(path) for path in args.content if Path(path).is_dir()), CONTENT_DIR)
+        writer = SubmissionWriter(load_schemas(Path(args.schemas)), rules, load_id_scheme(args.ids), content_dir)
+    state = DatasetState(args.content, args.ids, args.verbose)
+    state.rebuild()
+    stop = threading.Event()
+    watcher = threading.Thread(target=state.watch, args=(args.interval, stop), name="content-watcher", daemon=True)
+    watcher.start()
+    server = ThreadingHTTPServer((args.host, args.port), make_handler(state, writer, args.token))
+    snapshot = state.current()
+    print(f"[serve] Serving version {snapshot.version if snapshot else '-'} on http://{args.host}:{server.server_port} (/health, /lessons, /vocabulary{'; write API on' if writer else ''})")
+    try:
+        server.serve_forever()
+    except KeyboardInterrupt:
+        pass
+    finally:
+        stop.set()
+        server.server_close()
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,130 @@
+#!/usr/bin/env python3
+"""Render a single lesson or vocabulary entry in the terminal."""
+
+from __future__ import annotations
+
+import argparse
+import re
+import sys
+import textwrap
+from pathlib import Path
+from typing import Any, Dict, Iterable, List
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_IDS_PATH, collect, load_id_scheme
+    from .console import add_output_arguments, configure_output
+    from .export import build_entries, index_vocab, item_reference
+    from .merge import merge_by_id
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_IDS_PATH, collect, load_id_scheme  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from export import build_entries, index_vocab, item_reference  # type: ignore
+    from merge import merge_by_id  # type: ignore
+
+COLORS = {"title": "1;36", "phase": "1;33", "vocab": "1;32", "muted": "2", "missing": "1;31"}
+TEXT_FIELDS = ("line", "origin", "story", "wrap_line", "wrap", "cue", "npc", "es", "you")
+SPANISH_FIELDS = ("es", "you")
+
+
+class Painter:
+    def __init__(self, enabled: bool, palette: Dict[str, str] | None = None) -> None:
+        self.enabled = enabled
+        self.colors = {**COLORS, **(palette or {})}
+
+    def __call__(self, style: str, text: str) -> str:
+        if not self.enabled:
+            return text
+        return f"\033[{self.colors[style]}m{text}\033[0m"
+
+
+def highlight(text: str, headwords: List[str], paint: Painter) -> str:
+    if not headwords:
+        return text
+    pattern = re.compile(r"(?<!\w)(" + "|".join(re.escape(h) for h in headwords) + r")(?!\w)", re.I)
+    return pattern.sub(lambda m: paint("vocab", m.group(0)), text)
+
+
+def wrap(text: str, indent: str) -> str:
+    return textwrap.fill(text, width=88, initial_indent=indent, subsequent_indent=indent)
+
+
+def render_lesson(lesson: Dict[str, Any], vocab_index: Dict[str, Dict[str, Any]], headwords: List[str], paint: Painter) -> List[str]:
+    out = [paint("title", f"{lesson.get('title', '(untitled)')}"), paint("muted", f"{lesson['id']} · {lesson.get('mode', '')}")]
+    for number, step in enumerate(lesson.get("steps", []), 1):
+        if not isinstance(step, dict):
+            continue
+        out.append("")
+        out.append(paint("phase", f"{number}. {step.get('phase', 'step')}"))
+        for key in TEXT_FIELDS:
+            value = step.get(key)
+            if isinstance(value, str) and value.strip():
+                text = wrap(f"{key}: {value}", "   ")
+                out.append(highlight(text, headwords, paint) if key in SPANISH_FIELDS else text)
+        for item in step.get("items", []) if isinstance(step.get("items"), list) else []:
+            ref = item_reference(item)
+            target = vocab_index.get(ref) or vocab_index.get(ref.lower()) if ref else None
+            if target:
+                out.append(f"   • {paint('vocab', target.get('spanish', ''))} — {target.get('english_gloss', '')} [{target['id']}]")
+            elif ref:
+                out.append(f"   • {paint('missing', ref)} (unresolved reference)")
+            elif isinstance(item, dict):
+                spanish = highlight(str(item.get("es", "")), headwords, paint)
+                out.append(f"   • {item.get('en', '')} → {spanish}" if item.get("en") else f"   • {spanish}")
+    return out
+
+
+def render_vocab(entry: Dict[str, Any], paint: Painter) -> List[str]:
+    out = [paint("title", entry.get("spanish", "")) + f" ({entry.get('pos', '?')}) — {entry.get('english_gloss', '')}"]
+    out.append(paint("muted", f"{entry['id']} · level {entry.get('level', 'UNSET')} · tags: {', '.join(entry.get('tags', [])) or '-'}"))
+    for key in ("definition", "origin", "story", "notes"):
+        value = entry.get(key)
+        if isinstance(value, str) and value.strip():
+            out.append(wrap(f"{key}: {value}", ""))
+    for example in entry.get("examples", []):
+        if isinstance(example, dict):
+            out.append(f"   • {highlight(example.get('es', ''), [entry.get('spanish', '')], paint)}")
+            if example.get("en"):
+                out.append(paint("muted", f"     {example['en']}"))
+    return out
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Show a lesson or vocabulary entry with formatting.")
+    parser.add_argument("kind", choices=("lesson", "vocab"), help="Entry kind to show")
+    parser.add_argument("id", help="Entry ID (vocabulary also accepts the headword)")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to collect")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument("--color", choices=("auto", "always", "never"), default="auto", help="Colorize output")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    output = configure_output(args)
+    if args.color == "always":
+        output.color = True
+
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+    vocab, _ = merge_by_id(build_entries(dataset.vocab, scheme))
+    vocab_index = index_vocab(vocab)
+    paint = Painter(args.color == "always" or (args.color == "auto" and output.color and sys.stdout.isatty()), output.palette)
+
+    if args.kind == "vocab":
+        entry = vocab_index.get(args.id) or vocab_index.get(args.id.lower())
+        if not entry:
+            print(f"[show] No vocabulary entry '{args.id}'", file=sys.stderr)
+            return 1
+        print("\n".join(render_vocab(entry, paint)))
+        return 0
+
+    lessons, _ = merge_by_id(build_entries(dataset.lessons, scheme))
+    lesson = next((item for item in lessons if item["id"] == args.id), None)
+    if not lesson:
+        print(f"[show] No lesson '{args.id}'", file=sys.stderr)
+        return 1
+    headwords = sorted({e["spanish"] for e in vocab if isinstance(e.get("spanish"), str)}, key=len, reverse=True)
+    print("\n".join(render_lesson(lesson, vocab_index, headwords, paint)))
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
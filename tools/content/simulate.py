@@ -0,0 +1,96 @@
+#!/usr/bin/env python3
+"""Model a learner working through the curriculum and report study load."""
+
+from __future__ import annotations
+
+import argparse
+import sys
+from pathlib import Path
+from typing import Any, Dict, Iterable, List
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_IDS_PATH, collect, lesson_order_key, load_id_scheme, write_report
+    from .console import add_output_arguments, configure_output
+    from .export import build_entries
+    from .merge import merge_by_id
+    from .srs import introduction_lessons, simulate, srs_metadata
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_IDS_PATH, collect, lesson_order_key, load_id_scheme, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from export import build_entries  # type: ignore
+    from merge import merge_by_id  # type: ignore
+    from srs import introduction_lessons, simulate, srs_metadata  # type: ignore
+
+
+def simulate_srs(vocab: List[Dict[str, Any]], lessons: List[Dict[str, Any]], args: argparse.Namespace) -> List[str]:
+    intro = introduction_lessons(vocab, lessons)
+    for entry in vocab:
+        entry["srs"] = srs_metadata(entry, intro.get(entry["id"]))
+    lesson_ids = [lesson["id"] for lesson in sorted(lessons, key=lesson_order_key)]
+    timeline = simulate(vocab, lesson_ids, args.new_per_day, args.days)
+
+    per_level: Dict[str, Dict[str, int]] = {}
+    for day in timeline:
+        for level in set(day["new"]) | set(day["reviews"]):
+            stats = per_level.setdefault(level, {"new_days": 0, "new": 0, "reviews": 0, "peak_reviews": 0})
+            if day["new"].get(level):
+                stats["new_days"] += 1
+                stats["new"] += day["new"][level]
+            stats["reviews"] += day["reviews"].get(level, 0)
+            stats["peak_reviews"] = max(stats["peak_reviews"], day["reviews"].get(level, 0))
+
+    totals = [sum(day["reviews"].values()) for day in timeline]
+    unplaced = sorted(entry_id for entry_id, lesson_id in intro.items() if lesson_id is None)
+    out = [
+        "SRS simulation",
+        f"- cards: {len(vocab)}",
+        f"- new cards per day: {args.new_per_day}",
+        f"- simulated days: {len(timeline)}",
+        f"- peak daily reviews: {max(totals, default=0)}",
+        f"- cards with no introducing lesson: {len(unplaced)}",
+        "## load per level",
+    ]
+    for level, stats in sorted(per_level.items()):
+        out.append(
+            f"- {level}: {stats['new']} new over {stats['new_days']} days, "
+            f"{stats['reviews']} reviews, peak {stats['peak_reviews']} reviews/day"
+        )
+    out.append("## daily load")
+    for day, total in zip(timeline, totals):
+        levels = ", ".join(f"{level} {day['new'].get(level, 0)}/{day['reviews'].get(level, 0)}" for level in sorted(set(day["new"]) | set(day["reviews"])))
+        out.append(f"- day {day['day']}: {sum(day['new'].values())} new, {total} reviews ({levels or 'idle'})")
+    if unplaced:
+        out.append("## cards with no introducing lesson")
+        out += [f"- {entry_id}" for entry_id in unplaced]
+    return out
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    shared = argparse.ArgumentParser(add_help=False)
+    shared.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to collect")
+    shared.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser = argparse.ArgumentParser(description="Simulate learner progress and write reports under build/reports.")
+    sub = parser.add_subparsers(dest="simulation", required=True)
+
+    srs = sub.add_parser("srs", parents=[shared], help="Daily new-card and review load per level")
+    srs.add_argument("--new-per-day", type=int, default=10, help="New cards introduced per study day")
+    srs.add_argument("--days", type=int, default=365, help="Maximum number of days to simulate")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+    vocab, _ = merge_by_id(build_entries(dataset.vocab, scheme))
+    lessons, _ = merge_by_id(build_entries(dataset.lessons, scheme))
+
+    if args.simulation == "srs":
+        out = simulate_srs(vocab, lessons, args)
+        write_report("srs.md", out)
+    print("\n".join(out))
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
@@ -0,0 +1,86 @@
+"""Find content files stored at more than one path, a leftover of copy-paste reorganizations.
+
+Two files are byte-identical when their bytes match, and structurally identical when they decode to the same
+records regardless of formatting, key order, record order, or JSON versus JSON lines. Either way every record
+is collected twice and merged back together, so all but one copy can go.
+"""
+
+from __future__ import annotations
+
+import hashlib
+import json
+from collections import Counter
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Dict, Iterable, List, Tuple, Union
+
+try:
+    from .common import LEVELS, ROOT, UNIT_NAME, decode_objects, in_archive, iter_sources
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import LEVELS, ROOT, UNIT_NAME, decode_objects, in_archive, iter_sources  # type: ignore
+
+
+@dataclass
+class SourceCluster:
+    match: str  # "identical" or "structural"
+    canonical: str
+    copies: List[str]
+
+
+def structure_key(data: bytes) -> str:
+    """A digest of the decoded records, or "" for files that do not decode to any."""
+    result = decode_objects(data.decode("utf-8", errors="replace"))
+    if result.error or not result.objects:
+        return ""
+    rows = sorted(json.dumps(obj, ensure_ascii=False, sort_keys=True) for obj in result.objects)
+    return hashlib.sha256("\n".join(rows).encode("utf-8")).hexdigest()
+
+
+def record_level(data: bytes) -> str:
+    result = decode_objects(data.decode("utf-8", errors="replace"))
+    levels = Counter(str(obj.get("level")) for obj in result.objects if isinstance(obj, dict) and obj.get("level") in LEVELS)
+    return levels.most_common(1)[0][0] if levels else ""
+
+
+def canonical_rank(source: str, level: str) -> Tuple[int, int, int, str]:
+    """Sort key for the copy to keep: inside the level folder its records belong to (content/A1/...), then a
+    path without spaces, then the shortest, then alphabetical so the choice is stable."""
+    parts = Path(source).parts
+    return (0 if level and level in parts else 1, 1 if " " in source else 0, len(source), source)
+
+
+def duplicate_sources(paths: Iterable[Union[str, Path]]) -> List[SourceCluster]:
+    """Clusters of two or more files with the same content, byte-identical clusters first.
+
+    Archive members and unit files (whose meaning comes from the folder they sit in) are left out, as are
+    empty files, which match each other without repeating anything. Files that do not decode match on bytes only.
+    """
+    files: Dict[str, bytes] = {}
+    for source, data in iter_sources(paths):
+        if not in_archive(source) and source.rsplit("/", 1)[-1] != UNIT_NAME and data.strip():
+            files[source] = data
+    groups: Dict[str, List[str]] = {}
+    for source, data in files.items():
+        key = structure_key(data) or "bytes:" + hashlib.sha256(data).hexdigest()
+        groups.setdefault(key, []).append(source)
+
+    clusters: List[SourceCluster] = []
+    for members in groups.values():
+        if len(members) < 2:
+            continue
+        level = record_level(files[members[0]])
+        keep, *rest = sorted(members, key=lambda source: canonical_rank(source, level))
+        match = "identical" if len({files[source] for source in members}) == 1 else "structural"
+        clusters.append(SourceCluster(match=match, canonical=keep, copies=rest))
+    return sorted(clusters, key=lambda cluster: (cluster.match != "identical", cluster.canonical))
+
+
+def remove_copies(cluster: SourceCluster, root: Path = ROOT) -> List[str]:
+    """Delete every copy but the canonical one; returns the paths removed."""
+    removed = []
+    for source in cluster.copies:
+        path = root / source if (root / source).exists() else Path(source)
+        if path.exists():
+            path.unlink()
+            removed.append(source)
+    return removed
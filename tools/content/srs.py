@@ -0,0 +1,79 @@
+"""Spaced-repetition scheduling metadata and a simple learner simulation."""
+
+from __future__ import annotations
+
+import re
+from collections import Counter
+from typing import Any, Dict, List, Optional
+
+try:
+    from .common import LEVELS, entry_level, lesson_order_key, string_values
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import LEVELS, entry_level, lesson_order_key, string_values  # type: ignore
+
+LEVEL_EASE = {"A1": 2.5, "A2": 2.4, "B1": 2.3, "B2": 2.2, "C1": 2.1, "C2": 2.0}
+DEFAULT_INTERVALS = [1, 3, 7, 14, 30, 60]
+
+
+def introduction_lessons(vocab: List[Dict[str, Any]], lessons: List[Dict[str, Any]]) -> Dict[str, Optional[str]]:
+    """Map vocabulary IDs to the first lesson (in curriculum order) whose text uses the headword.
+
+    Lessons mix Spanish into English prose, so every string is searched rather than only Spanish-keyed fields.
+    """
+    ordered = sorted(lessons, key=lesson_order_key)
+    texts = [(lesson["id"], " ".join(string_values(lesson)).lower()) for lesson in ordered]
+    intro: Dict[str, Optional[str]] = {}
+    for entry in vocab:
+        headword = str(entry.get("spanish", "")).strip().lower()
+        pattern = re.compile(r"(?<!\w)" + re.escape(headword) + r"(?!\w)") if headword else None
+        intro[entry["id"]] = next((lesson_id for lesson_id, text in texts if pattern and pattern.search(text)), None)
+    return intro
+
+
+def srs_metadata(entry: Dict[str, Any], intro_lesson: Optional[str]) -> Dict[str, Any]:
+    return {
+        "initial_ease": LEVEL_EASE.get(entry_level(entry), 2.5),
+        "intro_lesson": intro_lesson,
+        "review_intervals": list(DEFAULT_INTERVALS),
+    }
+
+
+def curriculum_order(vocab: List[Dict[str, Any]], lesson_ids: List[str]) -> List[Dict[str, Any]]:
+    """Order cards by level, then by the position of their introduction lesson, then easiest first by difficulty score.
+
+    Unplaced cards close out their level; entries without a difficulty score sort as middling (0.5).
+    """
+    position = {lesson_id: index for index, lesson_id in enumerate(lesson_ids)}
+
+    def key(entry: Dict[str, Any]) -> tuple:
+        level = entry_level(entry)
+        intro = entry.get("srs", {}).get("intro_lesson")
+        score = (entry.get("difficulty") or {}).get("score", 0.5)
+        return (LEVELS.index(level) if level in LEVELS else len(LEVELS), position.get(intro, len(position)), score, entry["id"])
+
+    return sorted(vocab, key=key)
+
+
+def simulate(vocab: List[Dict[str, Any]], lesson_ids: List[str], new_per_day: int, days: int) -> List[Dict[str, Any]]:
+    """Introduce cards in curriculum order and review each on its interval ladder, assuming recall succeeds."""
+    queue = curriculum_order(vocab, lesson_ids)
+    due: Dict[int, Counter] = {}
+    timeline: List[Dict[str, Any]] = []
+    for day in range(days):
+        introduced, queue = queue[:new_per_day], queue[new_per_day:]
+        for entry in introduced:
+            offset = day
+            for interval in entry.get("srs", {}).get("review_intervals") or DEFAULT_INTERVALS:
+                offset += interval
+                due.setdefault(offset, Counter())[entry_level(entry)] += 1
+        reviews = due.pop(day, Counter())
+        timeline.append(
+            {
+                "day": day + 1,
+                "new": dict(Counter(entry_level(entry) for entry in introduced)),
+                "reviews": dict(reviews),
+            }
+        )
+        if not queue and not due:
+            break
+    return timeline
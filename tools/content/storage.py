@@ -0,0 +1,440 @@
+"""Output storage backends: local directory, in-memory, zip archive, S3, and GCS."""
+
+from __future__ import annotations
+
+import io
+import os
+import shutil
+import tempfile
+import zipfile
+from pathlib import Path
+from typing import IO, Any, Dict, List, Optional, Set, Union
+
+try:
+    from .common import CONFIG_DIR, ROOT, load_json
+    from .errors import ConfigError
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, ROOT, load_json  # type: ignore
+    from errors import ConfigError  # type: ignore
+
+DEFAULT_STORAGE_PATH = CONFIG_DIR / "storage.json"
+STORAGE_BACKENDS = ("local", "memory", "zip", "s3", "gcs")
+COPY_CHUNK_BYTES = 1 << 20
+# S3 takes multipart uploads in parts of at least 5 MiB; GCS resumable uploads in multiples of 256 KiB.
+UPLOAD_PART_BYTES = 8 << 20
+
+
+def join_key(prefix: str, name: str) -> str:
+    return "/".join(part.strip("/") for part in (prefix, name) if part.strip("/"))
+
+
+class BufferedUpload(io.BytesIO):
+    """Collects a streamed file and hands it to write_bytes on close, for backends that keep whole objects (memory)."""
+
+    def __init__(self, storage: "Storage", name: str) -> None:
+        super().__init__()
+        self.storage = storage
+        self.name = name
+
+    def close(self) -> None:
+        if not self.closed:
+            self.storage.write_bytes(self.name, self.getvalue())
+        super().close()
+
+
+class Storage:
+    """Sink for build outputs, addressed by slash-separated names; reading back is best-effort."""
+
+    def write_bytes(self, name: str, data: bytes) -> None:
+        raise NotImplementedError
+
+    def write_text(self, name: str, text: str) -> None:
+        self.write_bytes(name, text.encode("utf-8"))
+
+    def open_write(self, name: str) -> IO[bytes]:
+        """A binary file to stream name into; it is stored when closed. The local, zip, S3, and GCS backends hold
+        at most a chunk (an upload part for S3 and GCS) in memory; the memory backend keeps the whole file."""
+        return BufferedUpload(self, name)
+
+    def read_bytes(self, name: str) -> Optional[bytes]:
+        """Previously written bytes, or None when missing or the backend cannot read back."""
+        return None
+
+    def open_read(self, name: str) -> Optional[IO[bytes]]:
+        """A binary file to read name from a chunk at a time, or None like read_bytes; backends that cannot
+        stream hand back the whole file in memory."""
+        data = self.read_bytes(name)
+        return io.BytesIO(data) if data is not None else None
+
+    def put_file(self, name: str, path: Path) -> None:
+        """Store the file at path as name, streamed a chunk at a time; the file may be moved rather than copied."""
+        with open(path, "rb") as src, self.open_write(name) as dst:
+            shutil.copyfileobj(src, dst, COPY_CHUNK_BYTES)
+
+    def delete(self, name: str) -> None:
+        """Remove name, or everything stored under it; backends that cannot delete leave it in place."""
+
+    def child(self, prefix: str) -> "Storage":
+        return PrefixedStorage(self, prefix)
+
+    def close(self) -> None:
+        pass
+
+    def describe(self) -> str:
+        return type(self).__name__
+
+
+class PrefixedStorage(Storage):
+    def __init__(self, parent: Storage, prefix: str) -> None:
+        self.parent = parent
+        self.prefix = prefix
+
+    def write_bytes(self, name: str, data: bytes) -> None:
+        self.parent.write_bytes(join_key(self.prefix, name), data)
+
+    def open_write(self, name: str) -> IO[bytes]:
+        return self.parent.open_write(join_key(self.prefix, name))
+
+    def put_file(self, name: str, path: Path) -> None:
+        self.parent.put_file(join_key(self.prefix, name), path)
+
+    def read_bytes(self, name: str) -> Optional[bytes]:
+        return self.parent.read_bytes(join_key(self.prefix, name))
+
+    def open_read(self, name: str) -> Optional[IO[bytes]]:
+        return self.parent.open_read(join_key(self.prefix, name))
+
+    def delete(self, name: str) -> None:
+        self.parent.delete(join_key(self.prefix, name))
+
+    def describe(self) -> str:
+        return f"{self.parent.describe()}/{self.prefix.strip('/')}"
+
+
+class LocalStorage(Storage):
+    def __init__(self, root: Union[str, Path]) -> None:
+        self.root = Path(root)
+
+    def write_bytes(self, name: str, data: bytes) -> None:
+        path = self.root / name
+        path.parent.mkdir(parents=True, exist_ok=True)
+        # Write then rename, so an interrupted write leaves the previous file (or none), never half of one.
+        partial = path.with_name(path.name + ".partial")
+        partial.write_bytes(data)
+        os.replace(partial, path)
+
+    def open_write(self, name: str) -> IO[bytes]:
+        path = self.root / name
+        path.parent.mkdir(parents=True, exist_ok=True)
+        return open(path, "wb")
+
+    def read_bytes(self, name: str) -> Optional[bytes]:
+        path = self.root / name
+        return path.read_bytes() if path.is_file() else None
+
+    def open_read(self, name: str) -> Optional[IO[bytes]]:
+        path = self.root / name
+        return open(path, "rb") if path.is_file() else None
+
+    def put_file(self, name: str, path: Path) -> None:
+        target = self.root / name
+        target.parent.mkdir(parents=True, exist_ok=True)
+        shutil.move(str(path), str(target))
+
+    def delete(self, name: str) -> None:
+        path = self.root / name
+        if path.is_dir():
+            shutil.rmtree(path)
+        elif path.exists():
+            path.unlink()
+
+    def child(self, prefix: str) -> Storage:
+        return LocalStorage(self.root / prefix)
+
+    def describe(self) -> str:
+        return str(self.root)
+
+
+class MemoryStorage(Storage):
+    """Keeps outputs in a dict; for library callers that never want files on disk."""
+
+    def __init__(self) -> None:
+        self.files: Dict[str, bytes] = {}
+
+    def write_bytes(self, name: str, data: bytes) -> None:
+        self.files[join_key("", name)] = data
+
+    def read_bytes(self, name: str) -> Optional[bytes]:
+        return self.files.get(join_key("", name))
+
+    def delete(self, name: str) -> None:
+        key = join_key("", name)
+        for stored in [stored for stored in self.files if not key or stored == key or stored.startswith(key + "/")]:
+            del self.files[stored]
+
+    def describe(self) -> str:
+        return f"memory ({len(self.files)} files)"
+
+
+class StagingStorage(Storage):
+    """Holds a run's outputs until it is known they may be published, then moves them into the real backend.
+
+    Export builds into one of these so the size budget, content freeze, and gates are judged on the finished
+    files before anything reaches the destination. Files are staged in a temporary directory, so memory stays
+    bounded; a memory destination is staged in memory instead, keeping it off disk.
+    """
+
+    def __init__(self, target: Optional[Storage], directory: Optional[Union[str, Path]] = None) -> None:
+        self.target = target
+        self.staged: Storage = MemoryStorage()
+        if not isinstance(target, MemoryStorage):
+            if directory is not None:
+                Path(directory).mkdir(parents=True, exist_ok=True)
+            self.staged = LocalStorage(tempfile.mkdtemp(prefix=".staging-", dir=directory))
+
+    def write_bytes(self, name: str, data: bytes) -> None:
+        self.staged.write_bytes(name, data)
+
+    def open_write(self, name: str) -> IO[bytes]:
+        return self.staged.open_write(name)
+
+    def read_bytes(self, name: str) -> Optional[bytes]:
+        return self.staged.read_bytes(name)
+
+    def open_read(self, name: str) -> Optional[IO[bytes]]:
+        return self.staged.open_read(name)
+
+    def delete(self, name: str) -> None:
+        self.staged.delete(name)
+
+    def names(self) -> List[str]:
+        if isinstance(self.staged, MemoryStorage):
+            return sorted(self.staged.files)
+        return sorted(path.relative_to(self.staged.root).as_posix() for path in self.staged.root.rglob("*") if path.is_file())
+
+    def promote(self, target: Optional[Storage] = None) -> List[str]:
+        """Move every staged file into target (default: the one given at construction); returns the names."""
+        target = target or self.target
+        if target is None:
+            raise ValueError("staged outputs have nowhere to go")
+        names = self.names()
+        for name in names:
+            if isinstance(self.staged, MemoryStorage):
+                target.write_bytes(name, self.staged.files[name])
+            else:
+                target.put_file(name, self.staged.root / name)
+        self.target = target
+        self.discard()
+        return names
+
+    def discard(self) -> None:
+        if isinstance(self.staged, LocalStorage):
+            shutil.rmtree(self.staged.root, ignore_errors=True)
+        else:
+            self.staged = MemoryStorage()
+
+    def describe(self) -> str:
+        return self.target.describe() if self.target is not None else "staging"
+
+
+class ZipStorage(Storage):
+    """A zip archive used like a directory: written names replace their old copies, the rest are kept.
+
+    Nothing touches the archive until the first write, so opening one to read (verify, budget) cannot damage
+    it. Writes go to a temporary archive next to it, which replaces the original only on close; a run that
+    dies halfway leaves the previous archive as it was. Reads always see that previous archive.
+    """
+
+    def __init__(self, path: Union[str, Path], readonly: bool = False) -> None:
+        self.path = Path(path)
+        self.readonly = readonly
+        self._staging = self.path.with_name(self.path.name + ".tmp")
+        self._archive: Optional[zipfile.ZipFile] = None
+        self._written: Set[str] = set()
+        self._closed = False
+
+    def _writer(self, name: str) -> zipfile.ZipFile:
+        if self.readonly:
+            raise ValueError(f"{self.path} is open read-only")
+        if self._closed:
+            raise ValueError(f"{self.path} is already closed")
+        if self._archive is None:
+            self.path.parent.mkdir(parents=True, exist_ok=True)
+            self._archive = zipfile.ZipFile(self._staging, "w", compression=zipfile.ZIP_DEFLATED)
+        self._written.add(name)
+        return self._archive
+
+    def write_bytes(self, name: str, data: bytes) -> None:
+        key = join_key("", name)
+        self._writer(key).writestr(key, data)
+
+    def open_write(self, name: str) -> IO[bytes]:
+        key = join_key("", name)
+        return self._writer(key).open(key, "w", force_zip64=True)
+
+    def read_bytes(self, name: str) -> Optional[bytes]:
+        if not self.path.is_file():
+            return None
+        try:
+            with zipfile.ZipFile(self.path) as archive:
+                return archive.read(join_key("", name))
+        except KeyError:
+            return None
+        except zipfile.BadZipFile as exc:
+            raise ValueError(f"{self.path} is not a readable zip archive: {exc}") from None
+
+    def open_read(self, name: str) -> Optional[IO[bytes]]:
+        if not self.path.is_file():
+            return None
+        try:
+            # The member keeps the archive's file open after the archive itself is closed.
+            with zipfile.ZipFile(self.path) as archive:
+                return archive.open(join_key("", name))
+        except KeyError:
+            return None
+        except zipfile.BadZipFile as exc:
+            raise ValueError(f"{self.path} is not a readable zip archive: {exc}") from None
+
+    def close(self) -> None:
+        if self._archive is not None:
+            if self.path.is_file():
+                with zipfile.ZipFile(self.path) as previous:
+                    for info in previous.infolist():
+                        if info.filename not in self._written:
+                            self._archive.writestr(info, previous.read(info))
+            self._archive.close()
+            self._archive = None
+            os.replace(self._staging, self.path)
+        self._closed = True
+
+    def describe(self) -> str:
+        return str(self.path)
+
+
+class S3Upload(io.RawIOBase):
+    """Streams one object to S3 as a multipart upload, holding a single part in memory.
+
+    A file smaller than one part is sent with a plain put_object. Closing after an error aborts the upload,
+    so a failed write never leaves a truncated object behind.
+    """
+
+    def __init__(self, client: Any, bucket: str, key: str, part_bytes: int = UPLOAD_PART_BYTES) -> None:
+        super().__init__()
+        self.client = client
+        self.bucket = bucket
+        self.key = key
+        self.part_bytes = part_bytes
+        self.pending = bytearray()
+        self.upload_id: Optional[str] = None
+        self.parts: List[Dict[str, Any]] = []
+
+    def writable(self) -> bool:
+        return True
+
+    def write(self, data: Any) -> int:
+        self.pending += data
+        while len(self.pending) >= self.part_bytes:
+            self._send(bytes(self.pending[: self.part_bytes]))
+            del self.pending[: self.part_bytes]
+        return len(data)
+
+    def _send(self, part: bytes) -> None:
+        if self.upload_id is None:
+            self.upload_id = self.client.create_multipart_upload(Bucket=self.bucket, Key=self.key)["UploadId"]
+        number = len(self.parts) + 1
+        response = self.client.upload_part(Bucket=self.bucket, Key=self.key, UploadId=self.upload_id, PartNumber=number, Body=part)
+        self.parts.append({"ETag": response["ETag"], "PartNumber": number})
+
+    def abort(self) -> None:
+        if self.upload_id is not None:
+            self.client.abort_multipart_upload(Bucket=self.bucket, Key=self.key, UploadId=self.upload_id)
+            self.upload_id = None
+        self.pending.clear()
+        super().close()
+
+    def close(self) -> None:
+        if self.closed:
+            return
+        try:
+            if self.upload_id is None:
+                self.client.put_object(Bucket=self.bucket, Key=self.key, Body=bytes(self.pending))
+            else:
+                if self.pending:
+                    self._send(bytes(self.pending))
+                self.client.complete_multipart_upload(Bucket=self.bucket, Key=self.key, UploadId=self.upload_id, MultipartUpload={"Parts": self.parts})
+        except BaseException:
+            self.abort()
+            raise
+        super().close()
+
+    def __exit__(self, *exc_info: Any) -> None:
+        if exc_info[0] is not None:
+            self.abort()
+        else:
+            self.close()
+
+
+class S3Storage(Storage):
+    def __init__(self, bucket: str, prefix: str = "", **client_options: Any) -> None:
+        try:
+            import boto3  # type: ignore
+        except ImportError as exc:  # pragma: no cover - optional dependency
+            raise SystemExit("[storage] the s3 backend needs boto3 (pip install boto3)") from exc
+        self.bucket = bucket
+        self.prefix = prefix
+        self.client = boto3.client("s3", **client_options)
+
+    def write_bytes(self, name: str, data: bytes) -> None:
+        self.client.put_object(Bucket=self.bucket, Key=join_key(self.prefix, name), Body=data)
+
+    def open_write(self, name: str) -> IO[bytes]:
+        return S3Upload(self.client, self.bucket, join_key(self.prefix, name))
+
+    def describe(self) -> str:
+        return f"s3://{join_key(self.bucket, self.prefix)}"
+
+
+class GCSStorage(Storage):
+    def __init__(self, bucket: str, prefix: str = "", project: Optional[str] = None) -> None:
+        try:
+            from google.cloud import storage as gcs  # type: ignore
+        except ImportError as exc:  # pragma: no cover - optional dependency
+            raise SystemExit("[storage] the gcs backend needs google-cloud-storage (pip install google-cloud-storage)") from exc
+        self.prefix = prefix
+        self.bucket = gcs.Client(project=project).bucket(bucket)
+
+    def write_bytes(self, name: str, data: bytes) -> None:
+        self.bucket.blob(join_key(self.prefix, name)).upload_from_string(data)
+
+    def open_write(self, name: str) -> IO[bytes]:
+        # A resumable upload, sent a part at a time.
+        return self.bucket.blob(join_key(self.prefix, name)).open("wb", chunk_size=UPLOAD_PART_BYTES)
+
+    def describe(self) -> str:
+        return f"gs://{join_key(self.bucket.name, self.prefix)}"
+
+
+def open_storage(config: Dict[str, Any], readonly: bool = False) -> Storage:
+    """Build a backend from a config dict such as {"backend": "zip", "path": "build/out.zip"}.
+
+    readonly is for tools that only read outputs back; a zip opened that way refuses writes.
+    """
+    backend = config.get("backend", "local")
+    if backend not in STORAGE_BACKENDS:
+        raise ConfigError(f"Unknown storage backend '{backend}'. Expected one of: {', '.join(STORAGE_BACKENDS)}", "backend", backend, list(STORAGE_BACKENDS))
+    if backend == "memory":
+        return MemoryStorage()
+    if backend in ("s3", "gcs"):
+        if not config.get("bucket"):
+            raise ConfigError(f"The {backend} storage backend needs a bucket", "bucket")
+        options = {key: value for key, value in config.items() if key not in ("backend", "bucket", "prefix")}
+        cls = S3Storage if backend == "s3" else GCSStorage
+        return cls(str(config["bucket"]), str(config.get("prefix", "")), **options)
+    location = Path(str(config.get("path", "build.zip" if backend == "zip" else "build")))
+    location = location if location.is_absolute() else ROOT / location
+    return ZipStorage(location, readonly) if backend == "zip" else LocalStorage(location)
+
+
+def load_storage(path: Optional[Union[str, Path]] = None, readonly: bool = False) -> Storage:
+    cfg_path = Path(path) if path else DEFAULT_STORAGE_PATH
+    return open_storage(load_json(cfg_path) if cfg_path.exists() else {}, readonly)
@@ -0,0 +1,76 @@
+"""Structured strict-mode failures, so a failing gate says which entry, file, and rule to fix."""
+
+from __future__ import annotations
+
+import json
+import sys
+from dataclasses import asdict, dataclass
+from pathlib import Path
+from typing import List, Optional
+
+try:
+    from .common import REPORTS_DIR
+    from .errors import StrictFailureError
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import REPORTS_DIR  # type: ignore
+    from errors import StrictFailureError  # type: ignore
+
+STRICT_FAILURES_NAME = "strict_failures.json"
+STRICT_SUMMARY_LIMIT = 10
+SEVERITY_ORDER = {"error": 0, "warning": 1, "info": 2}
+
+
+@dataclass
+class StrictFailure:
+    rule: str
+    file: str
+    message: str
+    id: Optional[str] = None
+    index: Optional[int] = None
+    severity: str = "error"
+
+
+def sort_failures(failures: List[StrictFailure]) -> List[StrictFailure]:
+    return sorted(failures, key=lambda f: (SEVERITY_ORDER.get(f.severity, 3), f.file, f.index if f.index is not None else -1, f.rule))
+
+
+def write_strict_failures(tool: str, failures: List[StrictFailure], reports_dir: Path = REPORTS_DIR) -> Path:
+    reports_dir.mkdir(parents=True, exist_ok=True)
+    path = reports_dir / STRICT_FAILURES_NAME
+    ordered = sort_failures(failures)
+    payload = {
+        "tool": tool,
+        "count": len(ordered),
+        "entries": len({(f.file, f.index) for f in ordered}),
+        "failures": [asdict(f) for f in ordered],
+    }
+    path.write_text(json.dumps(payload, ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+    return path
+
+
+def fail_strict(tool: str, failures: List[StrictFailure], limit: int = STRICT_SUMMARY_LIMIT) -> int:
+    """Write the full list, print the first `limit` failures, and return the exit status (1 when anything failed)."""
+    if not failures:
+        # A passing run must not leave the previous failure list behind for the same tool.
+        stale = REPORTS_DIR / STRICT_FAILURES_NAME
+        if stale.exists() and json.loads(stale.read_text(encoding="utf-8")).get("tool") == tool:
+            stale.unlink()
+        return 0
+    ordered = sort_failures(failures)
+    path = write_strict_failures(tool, ordered)
+    entries = len({(f.file, f.index) for f in ordered})
+    print(f"[{tool}] Strict mode failed: {len(ordered)} problems in {entries} entries", file=sys.stderr)
+    for f in ordered[:limit]:
+        where = f"{f.file}#{f.index}" if f.index is not None else f.file
+        print(f"    • {where} {f.id or '-'} [{f.rule}] {f.message}", file=sys.stderr)
+    if len(ordered) > limit:
+        print(f"    … {len(ordered) - limit} more", file=sys.stderr)
+    print(f"[{tool}] Full list in {path}", file=sys.stderr)
+    return 1
+
+
+def raise_for_failures(tool: str, failures: List[StrictFailure]) -> None:
+    """Library counterpart of fail_strict: write the full list and raise StrictFailureError instead of printing."""
+    if failures:
+        path = write_strict_failures(tool, failures)
+        raise StrictFailureError(tool, sort_failures(failures), str(path))
@@ -0,0 +1,86 @@
+"""Estimated completion minutes per lesson from step word counts, item counts, and exercise counts."""
+
+from __future__ import annotations
+
+import math
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple, Union
+
+try:
+    from .common import CONFIG_DIR, LOCKED, load_json, string_values, words
+    from .errors import ConfigError
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CONFIG_DIR, LOCKED, load_json, string_values, words  # type: ignore
+    from errors import ConfigError  # type: ignore
+
+DEFAULT_STUDY_TIME_PATH = CONFIG_DIR / "study_time.json"
+RATE_KEYS = ("words_per_minute", "step_seconds", "item_seconds", "exercise_seconds")
+# Step keys that drive the player rather than being read by the learner.
+UNREAD_KEYS = {"phase", "npc", "image", "audio", "id"}
+EXERCISE_KEYS = ("exercises", "questions", "drills")
+
+
+@dataclass
+class StudyTimeConfig:
+    words_per_minute: float = 150.0
+    step_seconds: float = 10.0
+    item_seconds: float = 15.0
+    exercise_seconds: float = 45.0
+    cap_minutes: Optional[float] = None
+    phases: Dict[str, Dict[str, float]] = field(default_factory=dict)
+
+    def rate(self, phase: Any, key: str) -> float:
+        """A per-phase override, else the default rate."""
+        return float(self.phases.get(str(phase), {}).get(key, getattr(self, key)))
+
+
+def load_study_time(path: Optional[Union[str, Path]] = None) -> StudyTimeConfig:
+    cfg_path = Path(path) if path else DEFAULT_STUDY_TIME_PATH
+    data = load_json(cfg_path) if cfg_path.exists() else {}
+    config = StudyTimeConfig(**{key: float(data[key]) for key in RATE_KEYS if key in data})
+    if data.get("cap_minutes") is not None:
+        config.cap_minutes = float(data["cap_minutes"])
+    config.phases = {str(phase): {key: float(value) for key, value in rates.items()} for phase, rates in data.get("phases", {}).items()}
+    for phase, rates in [("default", {key: getattr(config, key) for key in RATE_KEYS})] + list(config.phases.items()):
+        unknown = sorted(set(rates) - set(RATE_KEYS))
+        if unknown:
+            raise ConfigError(f"study time phase {phase}: unknown rate(s) {', '.join(unknown)}", f"phases.{phase}", unknown, list(RATE_KEYS))
+        if any(value < 0 for value in rates.values()) or rates.get("words_per_minute", 1) <= 0:
+            raise ConfigError(f"study time phase {phase}: rates cannot be negative and words_per_minute must be positive", f"phases.{phase}", rates)
+    return config
+
+
+def step_counts(step: Dict[str, Any]) -> Tuple[int, int, int]:
+    """(words read, practice items, exercises) in one step; locked text is not shown, so it is not counted."""
+    read = {key: value for key, value in step.items() if key not in UNREAD_KEYS and key not in EXERCISE_KEYS}
+    word_count = sum(len(words(text.replace(LOCKED, ""))) for text in string_values(read))
+    items = len(step["items"]) if isinstance(step.get("items"), list) else 0
+    exercises = sum(len(step[key]) for key in EXERCISE_KEYS if isinstance(step.get(key), list))
+    return word_count, items, exercises
+
+
+def lesson_seconds(lesson: Dict[str, Any], config: StudyTimeConfig) -> float:
+    total = 0.0
+    for step in lesson.get("steps", []):
+        if not isinstance(step, dict):
+            continue
+        phase = step.get("phase")
+        word_count, items, exercises = step_counts(step)
+        total += config.rate(phase, "step_seconds")
+        total += 60.0 * word_count / config.rate(phase, "words_per_minute")
+        total += items * config.rate(phase, "item_seconds")
+        total += exercises * config.rate(phase, "exercise_seconds")
+    return total
+
+
+def estimated_minutes(lesson: Dict[str, Any], config: StudyTimeConfig) -> int:
+    """Whole minutes, rounded up; never less than one for a lesson with steps."""
+    seconds = lesson_seconds(lesson, config)
+    return max(1, math.ceil(seconds / 60.0)) if seconds else 0
+
+
+def over_cap(lessons: List[Dict[str, Any]], config: StudyTimeConfig) -> List[Dict[str, Any]]:
+    if config.cap_minutes is None:
+        return []
+    return [lesson for lesson in lessons if lesson.get("estimated_minutes", estimated_minutes(lesson, config)) > config.cap_minutes]
@@ -0,0 +1,139 @@
+"""Accept new and edited lesson and vocabulary records from outside the content tree (the serve write API).
+
+A submission goes through what collect and export would do to it (field-name corrections, senses
+normalization, schema and custom-rule validation) before anything is written, so content/ only ever gains
+records the next export accepts. New records are appended to content/<level>/<folder>/submitted.jsonl; edits
+replace the record in the file it came from, keeping that file's layout.
+"""
+
+from __future__ import annotations
+
+import threading
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Sequence, Set, Tuple
+
+try:
+    from .common import CLASSIFY_NEEDS, CONTENT_DIR, LEVELS, Record, classify, display_path, entry_level, in_archive, is_pinned, record_id, source_file
+    from .errors import ContentError, InvalidEntry
+    from .fieldnames import CORRECTIONS_KEY, correct_fields
+    from .rules import CustomRule, rules_for
+    from .senses import normalize_senses
+    from .translations import save_objects, source_objects
+    from .validate import Issue, validate_all
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import CLASSIFY_NEEDS, CONTENT_DIR, LEVELS, Record, classify, display_path, entry_level, in_archive, is_pinned, record_id, source_file  # type: ignore
+    from errors import ContentError, InvalidEntry  # type: ignore
+    from fieldnames import CORRECTIONS_KEY, correct_fields  # type: ignore
+    from rules import CustomRule, rules_for  # type: ignore
+    from senses import normalize_senses  # type: ignore
+    from translations import save_objects, source_objects  # type: ignore
+    from validate import Issue, validate_all  # type: ignore
+
+# Route name -> record kind, and the folder new records of that kind go in.
+KINDS = {"lessons": "lesson", "vocabulary": "vocab"}
+FOLDERS = {"lesson": "lessons", "vocab": "vocabulary"}
+SUBMITTED_FILE = "submitted.jsonl"
+
+
+class SubmissionRejected(ContentError):
+    """A submission that cannot be written; status is the HTTP status the serve API answers with."""
+
+    def __init__(self, status: int, message: str) -> None:
+        super().__init__(message)
+        self.status = status
+
+
+def normalize_submission(kind: str, data: Any, scheme) -> Tuple[Dict[str, Any], Dict[str, Any]]:
+    """(record data to store, entry as export builds it) for one submitted record."""
+    if not isinstance(data, dict):
+        raise SubmissionRejected(400, "the request body must be one JSON object")
+    fixed = data if is_pinned(data) else correct_fields(data)
+    if fixed is not data:
+        # Written back under the right names, so the typos are fixed for good and field_corrections stays out of the file.
+        data = {key: value for key, value in fixed.items() if key != CORRECTIONS_KEY or CORRECTIONS_KEY in data}
+    if classify(data) != kind:
+        raise SubmissionRejected(422, f"the record would not be collected as {kind}: it needs {CLASSIFY_NEEDS[kind]}")
+    # The record is stored as written; senses are filled in for validation only, as export fills them in.
+    entry = normalize_senses(dict(fixed)) if kind == "vocab" else dict(fixed)
+    entry["id"] = record_id(Record(kind=kind, data=data, source="", index=0), scheme)
+    return data, entry
+
+
+def find_record(source: str, entry_id: str, kind: str, scheme) -> Tuple[Path, str, List[Any], int]:
+    """(path, layout, objects, index) of the record with entry_id in source."""
+    if in_archive(source):
+        raise SubmissionRejected(409, f"{entry_id} lives in the archive {source}; edit it there")
+    path = source_file(source)
+    try:
+        layout, objects = source_objects(path)
+    except (OSError, ValueError) as exc:
+        raise SubmissionRejected(409, f"{source} cannot be rewritten: {exc}") from exc
+    for index, obj in enumerate(objects):
+        if isinstance(obj, dict) and record_id(Record(kind=kind, data=obj, source=source, index=index), scheme) == entry_id:
+            return path, layout, objects, index
+    raise SubmissionRejected(409, f"{entry_id} is no longer in {source}; wait for the rebuild and retry")
+
+
+class SubmissionWriter:
+    """Validate and persist submissions; one write at a time so concurrent edits to a file cannot interleave."""
+
+    def __init__(self, schemas: Dict[str, Dict[str, Any]], rules: Sequence[CustomRule], scheme, content_dir: Path = CONTENT_DIR) -> None:
+        self.schemas = schemas
+        self.rules = rules
+        self.scheme = scheme
+        self.content_dir = content_dir
+        self._lock = threading.Lock()
+        # IDs created through this writer; the served snapshot may not include them yet.
+        self._created: Set[str] = set()
+
+    def check(self, kind: str, data: Any) -> Tuple[Dict[str, Any], Dict[str, Any], List[Issue]]:
+        """(record data, entry, warnings); raises InvalidEntry with every error-severity issue."""
+        stored, entry = normalize_submission(kind, data, self.scheme)
+        entry.setdefault("source_files", ["(submitted)"])
+        issues = validate_all(entry, self.schemas[kind], rules_for(self.rules, kind))
+        errors = [issue for issue in issues if issue.severity == "error"]
+        if errors:
+            raise InvalidEntry(entry["id"], entry["source_files"], errors)
+        return stored, entry, [issue for issue in issues if issue.severity != "error"]
+
+    def create(self, kind: str, data: Any, existing: Dict[str, Dict[str, Any]]) -> Tuple[Dict[str, Any], str, List[Issue]]:
+        """Append a new record; (entry, file written, warnings) on success.
+
+        existing is the served dataset. The duplicate check runs under the write lock against it, the IDs this
+        writer created since, and the file being appended to, so two concurrent POSTs cannot both add an ID.
+        """
+        stored, entry, warnings = self.check(kind, data)
+        level = entry_level(stored)
+        path = self.content_dir / (level if level in LEVELS else "unleveled") / FOLDERS[kind] / SUBMITTED_FILE
+        with self._lock:
+            layout, objects = source_objects(path) if path.exists() else ("jsonl", [])
+            in_file = any(isinstance(obj, dict) and record_id(Record(kind=kind, data=obj, source="", index=index), self.scheme) == entry["id"] for index, obj in enumerate(objects))
+            if entry["id"] in existing or entry["id"] in self._created or in_file:
+                raise SubmissionRejected(409, f"{entry['id']} already exists; edit it with PUT instead")
+            path.parent.mkdir(parents=True, exist_ok=True)
+            save_objects(path, layout, objects + [stored])
+            self._created.add(entry["id"])
+        entry["source_files"] = [display_path(path)]
+        return entry, display_path(path), warnings
+
+    def update(self, kind: str, entry_id: str, data: Any, current: Dict[str, Any]) -> Tuple[Dict[str, Any], str, List[Issue]]:
+        """Replace the record behind current (the served entry) in its source file; (entry, file written, warnings)."""
+        if isinstance(data, dict):
+            if data.get("id") not in (None, entry_id):
+                raise SubmissionRejected(400, f"the body's id {data.get('id')!r} does not match {entry_id}")
+            # The path names the entry; storing it with that ID keeps an edit from moving the record to a new one.
+            data = dict(data, id=entry_id)
+        stored, entry, warnings = self.check(kind, data)
+        if entry["id"] != entry_id:
+            raise SubmissionRejected(400, f"the edited record's id {entry['id']!r} does not match {entry_id}")
+        sources: Optional[List[str]] = current.get("source_files")
+        if not sources:
+            raise SubmissionRejected(409, f"{entry_id} has no source file to write to")
+        if len(sources) > 1:
+            raise SubmissionRejected(409, f"{entry_id} is merged from {len(sources)} files ({', '.join(sources)}); edit them by hand")
+        with self._lock:
+            path, layout, objects, index = find_record(sources[0], entry_id, kind, self.scheme)
+            objects[index] = stored
+            save_objects(path, layout, objects)
+        entry["source_files"] = [display_path(path)]
+        return entry, display_path(path), warnings
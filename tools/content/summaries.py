@@ -0,0 +1,71 @@
+"""Short, length-capped summaries of long definitions for compact exports and flashcard fronts."""
+
+from __future__ import annotations
+
+import importlib
+import re
+import sys
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional
+
+try:
+    from .errors import ConfigError
+except ImportError:  # pragma: no cover - allow running as a script
+    from errors import ConfigError  # type: ignore
+
+DEFAULT_SUMMARY_LIMIT = 120
+ELLIPSIS = "…"
+# Abbreviations whose trailing period does not end a sentence (English and Spanish definitions both occur).
+ABBREVIATIONS = {"e.g.", "i.e.", "etc.", "vs.", "cf.", "approx.", "p.", "ej.", "sr.", "sra.", "dr.", "dra.", "ud.", "uds.", "lit.", "esp.", "fig."}
+SENTENCE_END = re.compile(r"[.!?…](?=\s|$)")
+
+Summarizer = Callable[[str, int], str]
+
+
+def truncate(text: str, limit: int) -> str:
+    """Cut at the last word boundary that leaves room for an ellipsis."""
+    if len(text) <= limit:
+        return text
+    cut = text[: limit - len(ELLIPSIS)]
+    if " " in cut:
+        cut = cut[: cut.rindex(" ")]
+    return cut.rstrip(" ,;:–—-") + ELLIPSIS
+
+
+def first_sentence(text: str, limit: int = DEFAULT_SUMMARY_LIMIT) -> str:
+    """The first sentence of the first paragraph, capped at limit characters."""
+    paragraph = next((p for p in re.split(r"\n\s*\n", text.strip()) if p.strip()), "")
+    paragraph = " ".join(paragraph.split())
+    for match in SENTENCE_END.finditer(paragraph):
+        last_word = paragraph[: match.end()].rsplit(" ", 1)[-1].lower()
+        if last_word not in ABBREVIATIONS:
+            paragraph = paragraph[: match.end()]
+            break
+    return truncate(paragraph, limit)
+
+
+def load_summarizer(spec: Optional[str]) -> Summarizer:
+    """Resolve a `module:function` hook taking (text, limit); without one, first-sentence extraction is used."""
+    if not spec:
+        return first_sentence
+    module_name, _, func_name = spec.partition(":")
+    if not func_name:
+        raise ConfigError(f"summarizer must look like module:function, got {spec!r}", "summarizer", spec)
+    # Hooks usually live next to where the export is run, not next to this script.
+    if str(Path.cwd()) not in sys.path:
+        sys.path.append(str(Path.cwd()))
+    return getattr(importlib.import_module(module_name), func_name)
+
+
+def add_summaries(entries: List[Dict[str, Any]], limit: int, summarizer: Summarizer = first_sentence) -> int:
+    """Fill `summary` from each entry's definition; supplied summaries are kept. Hook output is capped too. Returns the count added."""
+    added = 0
+    for entry in entries:
+        definition = entry.get("definition")
+        if entry.get("summary") or not isinstance(definition, str) or not definition.strip():
+            continue
+        summary = truncate(" ".join(str(summarizer(definition, limit)).split()), limit)
+        if summary:
+            entry["summary"] = summary
+            added += 1
+    return added
@@ -0,0 +1,114 @@
+"""Spanish syllabification and stress placement for single-word headwords."""
+
+from __future__ import annotations
+
+from typing import Any, Dict, List, Optional, Tuple
+
+STRONG = set("aeoáéó")
+WEAK = set("iuü")
+STRESSED_WEAK = set("íú")
+VOWELS = STRONG | WEAK | STRESSED_WEAK
+ACCENTED = set("áéíóú")
+DIGRAPHS = ("ch", "ll", "rr")
+# Consonant + l/r onsets that never split (com-pra, ha-blar); "tl" varies by region and is split here.
+INSEPARABLE = {"pr", "br", "tr", "dr", "cr", "gr", "fr", "kr", "pl", "bl", "cl", "gl", "fl", "kl"}
+
+
+def units(word: str) -> List[Tuple[str, str]]:
+    """Split a word into (text, "V"|"C") units: digraphs and silent-u qu/gu count as one consonant, y is a vowel only when no vowel follows."""
+    lower = word.lower()
+    out: List[Tuple[str, str]] = []
+    idx = 0
+    while idx < len(word):
+        pair = lower[idx : idx + 2]
+        if pair in DIGRAPHS or (pair in ("qu", "gu") and lower[idx + 2 : idx + 3] in ("e", "i", "é", "í")):
+            out.append((word[idx : idx + 2], "C"))
+            idx += 2
+            continue
+        ch = lower[idx]
+        if ch == "y":
+            kind = "C" if idx + 1 < len(lower) and lower[idx + 1] in VOWELS else "V"
+        else:
+            kind = "V" if ch in VOWELS else "C"
+        out.append((word[idx], kind))
+        idx += 1
+    return out
+
+
+def hiatus(left: str, right: str) -> bool:
+    """Two adjacent vowels belong to different syllables: two strong vowels, or a stressed í/ú next to a strong one (pa-ís; cui-da-do stays)."""
+    left, right = left.lower().replace("y", "i"), right.lower().replace("y", "i")
+    if left in STRONG and right in STRONG:
+        return True
+    return (left in STRESSED_WEAK and right in STRONG) or (left in STRONG and right in STRESSED_WEAK)
+
+
+def syllabify(word: str) -> List[str]:
+    """Syllables of one word (ca-sa, com-pra, pa-ís, ins-tan-te); returns [] for anything that is not a single word."""
+    word = word.strip()
+    if not word or not all(ch.isalpha() for ch in word):
+        return []
+    parts = units(word)
+    # Indices where a new syllable starts.
+    starts = [0]
+    nuclei = [i for i, (_, kind) in enumerate(parts) if kind == "V"]
+    if not nuclei:
+        return [word]
+    for left, right in zip(nuclei, nuclei[1:]):
+        if right == left + 1:
+            if hiatus(parts[left][0], parts[right][0]):
+                starts.append(right)
+            continue
+        if right - left == 2:
+            starts.append(left + 1)
+        elif (parts[right - 2][0] + parts[right - 1][0]).lower() in INSEPARABLE:
+            starts.append(right - 2)
+        else:
+            starts.append(right - 1)
+    starts = sorted(set(starts))
+    bounds = starts + [len(parts)]
+    return ["".join(text for text, _ in parts[a:b]) for a, b in zip(bounds, bounds[1:]) if a < b]
+
+
+def stress_index(syllables: List[str]) -> Optional[int]:
+    """0-based index of the stressed syllable: a written accent wins, else -n/-s/vowel endings stress the penultimate."""
+    if not syllables:
+        return None
+    for idx, syllable in enumerate(syllables):
+        if any(ch in ACCENTED for ch in syllable.lower()):
+            return idx
+    if len(syllables) == 1:
+        return 0
+    last = syllables[-1].lower()[-1]
+    return len(syllables) - 2 if last in VOWELS or last in "ns" else len(syllables) - 1
+
+
+def pronunciation(entry: Dict[str, Any]) -> Optional[Tuple[List[str], int]]:
+    headword = entry.get("spanish")
+    syllables = syllabify(headword) if isinstance(headword, str) else []
+    index = stress_index(syllables)
+    return (syllables, index) if syllables and index is not None else None
+
+
+def check_pronunciation(entry: Dict[str, Any]) -> List[str]:
+    """Problems with manually supplied syllables/stress_index; entries without them are fine."""
+    problems: List[str] = []
+    computed = pronunciation(entry)
+    supplied = entry.get("syllables")
+    if supplied is not None:
+        if not isinstance(supplied, list) or not all(isinstance(s, str) and s for s in supplied):
+            problems.append("syllables must be a list of non-empty strings")
+        elif "".join(supplied).lower() != str(entry.get("spanish", "")).strip().lower():
+            problems.append(f"syllables {'-'.join(supplied)} do not spell '{entry.get('spanish')}'")
+        elif computed and [s.lower() for s in supplied] != [s.lower() for s in computed[0]]:
+            problems.append(f"syllables {'-'.join(supplied)} differ from computed {'-'.join(computed[0])}")
+    index = entry.get("stress_index")
+    if index is not None:
+        count = len(supplied) if isinstance(supplied, list) else len(computed[0]) if computed else 0
+        # Only compare against the computed stress when the syllables it counts are the same ones.
+        same_split = not isinstance(supplied, list) or (computed is not None and [s.lower() for s in supplied] == [s.lower() for s in computed[0]])
+        if not isinstance(index, int) or isinstance(index, bool) or not 0 <= index < count:
+            problems.append(f"stress_index {index!r} is outside the {count} syllables")
+        elif computed and same_split and index != computed[1]:
+            problems.append(f"stress_index {index} differs from computed {computed[1]}")
+    return problems
@@ -0,0 +1,43 @@
+"""Optional telemetry hooks for services that embed the content pipeline."""
+
+from __future__ import annotations
+
+import sys
+from contextlib import contextmanager
+from typing import Any, Iterator, Optional
+
+
+class Reporter:
+    """No-op base for per-stage spans and metrics.
+
+    Embedders subclass it to forward to OpenTelemetry or any other backend; this
+    package never imports a telemetry SDK itself. Attribute values are plain scalars.
+    """
+
+    @contextmanager
+    def span(self, stage: str, **attributes: Any) -> Iterator[None]:
+        yield
+
+    def metric(self, name: str, value: float, **attributes: Any) -> None:
+        pass
+
+
+def peak_rss_bytes() -> Optional[int]:
+    """Peak resident memory of this process so far, or None where the platform does not report it (Windows)."""
+    try:
+        import resource
+    except ImportError:  # pragma: no cover - not available on Windows
+        return None
+    peak = resource.getrusage(resource.RUSAGE_SELF).ru_maxrss
+    # Linux reports kilobytes, macOS bytes.
+    return peak if sys.platform == "darwin" else peak * 1024
+
+
+@contextmanager
+def stage(reporter: Reporter, name: str, **attributes: Any) -> Iterator[None]:
+    """A span for one pipeline stage, followed by the process's peak memory once the stage is done."""
+    with reporter.span(name, **attributes):
+        yield
+    peak = peak_rss_bytes()
+    if peak is not None:
+        reporter.metric("peak_rss_bytes", peak, stage=name)
@@ -0,0 +1 @@
+"""Behavior tests for the content tools; run from the repository root with python3 -m unittest discover -s tools/content/tests -t ."""
@@ -0,0 +1,84 @@
+"""Conflict markers left in content files: two-way, diff3, nested, unterminated, and stray markers."""
+
+from __future__ import annotations
+
+import json
+import tempfile
+import unittest
+from pathlib import Path
+
+from tools.content.common import EventLog, collect
+from tools.content.conflicts import ConflictCache, has_conflicts, require_resolved, resolve_conflicts
+from tools.content.errors import ConflictUnresolvable
+
+
+def lines(*rows: str) -> str:
+    return "\n".join(rows) + "\n"
+
+
+class ResolveConflictsTest(unittest.TestCase):
+    def test_diff3_takes_the_only_side_that_changed(self) -> None:
+        text = lines("[", "<<<<<<< ours", '{"id": "a", "gloss": "cat"}', "||||||| base", '{"id": "a", "gloss": "cat"}', "=======", '{"id": "a", "gloss": "tomcat"}', ">>>>>>> theirs", "]")
+        resolved, notes = resolve_conflicts(text)
+        self.assertEqual(json.loads(resolved), [{"id": "a", "gloss": "tomcat"}])
+        self.assertEqual(notes, ["line 2: diff3 conflict resolved"])
+
+    def test_two_way_conflict_keeps_both_sides(self) -> None:
+        text = lines("[", "<<<<<<< ours", '{"id": "a"},', "=======", '{"id": "b"},', ">>>>>>> theirs", '{"id": "c"}', "]")
+        pending: list = []
+        resolved, notes = resolve_conflicts(text, pending=pending)
+        self.assertEqual(json.loads(resolved), [{"id": "a"}, {"id": "b"}, {"id": "c"}])
+        self.assertEqual([chunk.line for chunk in pending], [2])
+        with self.assertRaises(ConflictUnresolvable):
+            require_resolved(text, "a.json")
+
+    def test_recorded_resolution_wins(self) -> None:
+        text = lines("<<<<<<< ours", '{"id": "a"}', "=======", '{"id": "b"}', ">>>>>>> theirs")
+        cache = ConflictCache()
+        pending: list = []
+        resolve_conflicts(text, pending=pending)
+        cache.record(pending[0], ['{"id": "b"}'], "a.json")
+        resolved, notes = require_resolved(text, "a.json", cache)
+        self.assertEqual(resolved, lines('{"id": "b"}'))
+        self.assertEqual(notes, ["line 1: two-way conflict resolved from the conflict cache"])
+
+    def test_nested_and_unterminated_blocks_resolve(self) -> None:
+        text = lines("<<<<<<< ours", "<<<<<<< inner", '{"id": "a"}', "=======", '{"id": "a"}', ">>>>>>> inner", "=======", '{"id": "a"}')
+        resolved, notes = resolve_conflicts(text)
+        self.assertEqual(resolved, lines('{"id": "a"}'))
+        self.assertIn("line 2: nested inside the conflict at line 1", notes)
+        self.assertIn("line 1: conflict never closed; resolved with the sections present", notes)
+
+    def test_stray_markers_outside_a_block_are_dropped(self) -> None:
+        for marker in ("=======", ">>>>>>> theirs", "||||||| base"):
+            text = lines("[", '{"id": "a"},', marker, '{"id": "b"}', "]")
+            resolved, notes = resolve_conflicts(text)
+            self.assertEqual(json.loads(resolved), [{"id": "a"}, {"id": "b"}], marker)
+            self.assertEqual(notes, [f"line 3: stray {marker.split()[0]} marker dropped"], marker)
+            self.assertTrue(has_conflicts(text), marker)
+
+    def test_marker_lookalikes_inside_json_are_kept(self) -> None:
+        text = lines("[", '{"id": "a", "note": "======="},', "  =======", '{"id": "b"}', "]")
+        self.assertEqual(resolve_conflicts(text), (text, []))
+
+
+class CollectConflictsTest(unittest.TestCase):
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.root = Path(tmp.name)
+
+    def test_collect_drops_a_stray_separator(self) -> None:
+        source = self.root / "content" / "animals.json"
+        source.parent.mkdir()
+        gato = {"spanish": "gato", "pos": "noun", "english_gloss": "cat"}
+        perro = {"spanish": "perro", "pos": "noun", "english_gloss": "dog"}
+        source.write_text(lines("[", json.dumps(gato) + ",", "=======", json.dumps(perro), "]"), encoding="utf-8")
+        events = EventLog()
+        dataset = collect([str(source.parent)], events, resolve_conflicts=True, conflict_cache=self.root / "cache.json")
+        self.assertEqual([record.data["spanish"] for record in dataset.vocab], ["gato", "perro"])
+        self.assertEqual(dataset.decode_errors, {})
+
+
+if __name__ == "__main__":
+    unittest.main()
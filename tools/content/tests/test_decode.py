@@ -0,0 +1,66 @@
+"""Decoding content files, and what --recover salvages from a damaged top-level array."""
+
+from __future__ import annotations
+
+import tempfile
+import unittest
+from pathlib import Path
+
+from tools.content.common import collect, decode_objects
+
+
+class DecodeObjectsTest(unittest.TestCase):
+    def test_single_value_lines_and_back_to_back_objects(self) -> None:
+        self.assertEqual(decode_objects('[{"a": 1}, {"b": 2}]').objects, [{"a": 1}, {"b": 2}])
+        self.assertEqual(decode_objects('{"a": 1}\n{"b": 2}\n').objects, [{"a": 1}, {"b": 2}])
+        self.assertEqual(decode_objects('{"a": 1}{"b": 2}').objects, [{"a": 1}, {"b": 2}])
+
+    def test_damage_without_recover_keeps_nothing_of_the_array(self) -> None:
+        result = decode_objects('[{"a": 1}, {"b": 2}, {"c":')
+        self.assertEqual(result.objects, [])
+        self.assertEqual(result.error_offset, 0)
+
+
+class RecoverTest(unittest.TestCase):
+    def recover(self, text: str):
+        return decode_objects(text, recover=True)
+
+    def test_truncated_tail_keeps_every_complete_element(self) -> None:
+        text = '[{"a": 1}, {"b": 2}, {"c": 3, "d": ['
+        result = self.recover(text)
+        self.assertEqual(result.objects, [{"a": 1}, {"b": 2}])
+        self.assertEqual(text[result.error_offset :], '{"c": 3, "d": [')
+
+    def test_unterminated_array_after_a_complete_element(self) -> None:
+        result = self.recover('[{"a": 1}, {"b": 2}')
+        self.assertEqual((result.objects, result.error), ([{"a": 1}, {"b": 2}], "unterminated top-level array"))
+
+    def test_well_formed_arrays_decode_as_without_recover(self) -> None:
+        for text in ("[]", "[ ]", '[{"a": 1}]', '[\n  {"a": 1},\n  {"b": 2}\n]\n'):
+            self.assertEqual(self.recover(text).objects, decode_objects(text).objects, text)
+            self.assertIsNone(self.recover(text).error, text)
+
+    def test_bad_separators_are_not_repaired(self) -> None:
+        for text in ('[{"a": 1} {"b": 2}]', '[{"a": 1},, {"b": 2}]', '[{"a": 1}, {"b": 2},]', '[, {"a": 1}]'):
+            result = self.recover(text)
+            self.assertIsNotNone(result.error, text)
+            self.assertEqual(decode_objects(text).objects, [], text)
+        missing = self.recover('[{"a": 1} {"b": 2}]')
+        self.assertEqual(missing.objects, [{"a": 1}])
+        self.assertEqual(missing.error_offset, len('[{"a": 1}'))
+        self.assertIn("','", missing.error)
+        self.assertEqual(self.recover('[{"a": 1},, {"b": 2}]').objects, [{"a": 1}])
+
+    def test_collect_rejects_the_damaged_part(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        path = Path(tmp.name) / "words.json"
+        path.write_text('[{"spanish": "gato", "pos": "noun"} {"spanish": "perro", "pos": "noun"}]', encoding="utf-8")
+        dataset = collect([path], recover=True)
+        self.assertEqual([record.data["spanish"] for record in dataset.vocab], ["gato"])
+        (error,) = dataset.decode_errors.values()
+        self.assertEqual(error.tail, b' {"spanish": "perro", "pos": "noun"}]')
+
+
+if __name__ == "__main__":
+    unittest.main()
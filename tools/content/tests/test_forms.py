@@ -0,0 +1,65 @@
+"""Rule-based inflection: conjugation of regular verbs only, and plurals that keep the stressed syllable."""
+
+from __future__ import annotations
+
+import unittest
+from typing import Any, Dict
+
+from tools.content.forms import build_forms_index, conjugate, inflect, pluralize
+
+BANK = [
+    {"form": "soy", "lemma": "ser", "features": "pres.1sg"},
+    {"form": "es", "lemma": "ser", "features": "pres.3sg"},
+    {"form": "voy", "lemma": "ir", "features": "pres.1sg"},
+    {"form": "tengo", "lemma": "tener", "features": "pres.1sg"},
+]
+
+
+def verb(spanish: str, **fields: Any) -> Dict[str, Any]:
+    return {"id": f"vocab_{spanish}", "spanish": spanish, "pos": "verb", **fields}
+
+
+class ConjugateTest(unittest.TestCase):
+    def test_regular_verbs_conjugate(self) -> None:
+        forms = dict(conjugate("hablar"))
+        self.assertEqual((forms["pres.1sg"], forms["pret.3sg"], forms["fut.1pl"], forms["ger"]), ("hablo", "habló", "hablaremos", "hablando"))
+        self.assertEqual(dict(conjugate("llamarse"))["pres.1sg.refl"], "me llamo")
+
+    def test_irregular_verbs_get_no_rule_forms(self) -> None:
+        for infinitive in ("ser", "ir", "irse", "tener", "mantener", "estar"):
+            self.assertEqual(conjugate(infinitive), [], infinitive)
+
+    def test_entries_marked_irregular_get_no_rule_forms(self) -> None:
+        self.assertEqual(inflect(verb("hablar", irregular=True)), [])
+
+    def test_forms_index_takes_irregular_forms_from_the_bank_only(self) -> None:
+        index = build_forms_index([verb("ser"), verb("ir"), verb("tener"), verb("hablar")], BANK)
+        for form in ("o", "e", "a", "so", "teno", "tenió"):
+            self.assertNotIn(form, index)
+        self.assertEqual([entry["id"] for entry in index["es"]], ["vocab_ser"])
+        self.assertEqual(index["soy"][0]["features"], ["pres.1sg"])
+        self.assertEqual(index["voy"][0]["id"], "vocab_ir")
+        self.assertEqual(index["tengo"][0]["id"], "vocab_tener")
+        self.assertEqual(index["hablo"][0]["features"], ["pres.1sg"])
+
+
+class PluralizeTest(unittest.TestCase):
+    def test_the_accent_moves_with_the_stress(self) -> None:
+        for singular, plural in (("camión", "camiones"), ("inglés", "ingleses"), ("autobús", "autobuses"), ("examen", "exámenes"), ("joven", "jóvenes")):
+            self.assertEqual(pluralize(singular), plural)
+
+    def test_only_the_last_syllable_loses_its_accent(self) -> None:
+        for singular, plural in (("país", "países"), ("maíz", "maíces"), ("baúl", "baúles"), ("árbol", "árboles"), ("lápiz", "lápices")):
+            self.assertEqual(pluralize(singular), plural)
+
+    def test_stressed_final_vowels(self) -> None:
+        for singular, plural in (("menú", "menús"), ("champú", "champús"), ("sofá", "sofás"), ("café", "cafés"), ("rubí", "rubíes")):
+            self.assertEqual(pluralize(singular), plural)
+
+    def test_endings(self) -> None:
+        for singular, plural in (("casa", "casas"), ("reloj", "relojes"), ("luz", "luces"), ("crisis", "crisis"), ("mes", "meses")):
+            self.assertEqual(pluralize(singular), plural)
+
+
+if __name__ == "__main__":
+    unittest.main()
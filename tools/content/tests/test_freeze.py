@@ -0,0 +1,67 @@
+"""The content freeze: a build compared with a frozen release may fix entries in place but not add or drop any."""
+
+from __future__ import annotations
+
+import io
+import json
+import tempfile
+import unittest
+from pathlib import Path
+from typing import Any, Dict, List
+from unittest import mock
+
+from tools.content.common import EventLog, collect
+from tools.content.export import ExportResult, export, export_options
+from tools.content.freeze import FreezeViolation, check_frozen
+from tools.content.storage import LocalStorage, MemoryStorage
+
+GATO = {"id": "vocab_gato", "spanish": "gato", "pos": "noun", "english_gloss": "cat", "definition": "A cat.", "examples": [], "level": "A1", "tags": []}
+PERRO = {"id": "vocab_perro", "spanish": "perro", "pos": "noun", "english_gloss": "dog", "definition": "A dog.", "examples": [], "level": "A1", "tags": []}
+PEZ = {"id": "vocab_pez", "spanish": "pez", "pos": "noun", "english_gloss": "fish", "definition": "A fish.", "examples": [], "level": "A1", "tags": []}
+
+
+class CheckFrozenTest(unittest.TestCase):
+    def test_added_and_removed_ids_are_violations(self) -> None:
+        frozen = {"vocabulary": {"vocab_gato", "vocab_perro"}, "lessons": set()}
+        violations = check_frozen(frozen, {"vocabulary": [GATO, PEZ], "lessons": []})
+        self.assertEqual(violations, [FreezeViolation("vocabulary", "vocab_pez", "added"), FreezeViolation("vocabulary", "vocab_perro", "removed")])
+
+    def test_in_place_changes_pass(self) -> None:
+        frozen = {"vocabulary": {"vocab_gato", "vocab_perro"}}
+        self.assertEqual(check_frozen(frozen, {"vocabulary": [dict(GATO, definition="A small cat."), PERRO]}), [])
+
+
+class FrozenExportTest(unittest.TestCase):
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.root = Path(tmp.name)
+        self.source = self.root / "content" / "animals.json"
+        self.source.parent.mkdir()
+        self.write([GATO, PERRO])
+        release = self.export(LocalStorage(self.root / "release"))
+        self.assertTrue(release.written)
+        self.frozen = str(self.root / "release" / "canonical")
+
+    def write(self, entries: List[Dict[str, Any]]) -> None:
+        self.source.write_text(json.dumps(entries), encoding="utf-8")
+
+    def export(self, storage: Any, **options: Any) -> ExportResult:
+        with mock.patch("sys.stdout", io.StringIO()), mock.patch("sys.stderr", io.StringIO()):
+            return export(collect([str(self.source.parent)], EventLog()), storage, export_options(**options), reports=MemoryStorage())
+
+    def test_in_place_fixes_are_written(self) -> None:
+        self.write([dict(GATO, definition="A small cat."), PERRO])
+        result = self.export(MemoryStorage(), frozen=self.frozen)
+        self.assertEqual((result.failed, result.written, result.metrics["freeze_violations"]), (False, True, 0))
+
+    def test_new_and_missing_entries_fail_and_write_nothing(self) -> None:
+        self.write([GATO, PEZ])
+        storage = MemoryStorage()
+        result = self.export(storage, frozen=self.frozen)
+        self.assertEqual((result.failed, result.written, result.metrics["freeze_violations"]), (True, False, 2))
+        self.assertIsNone(storage.read_bytes("canonical/vocabulary.json"))
+
+
+if __name__ == "__main__":
+    unittest.main()
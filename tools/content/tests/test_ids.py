@@ -0,0 +1,82 @@
+"""The ID scheme: loading and rejecting configs, and the IDs it generates and accepts."""
+
+from __future__ import annotations
+
+import json
+import tempfile
+import unittest
+from pathlib import Path
+
+from tools.content.common import IdScheme, Record, generate_id, load_id_scheme, record_id
+from tools.content.errors import ConfigError
+
+
+class LoadIdSchemeTest(unittest.TestCase):
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.path = Path(tmp.name) / "ids.json"
+
+    def load(self, config: dict) -> IdScheme:
+        self.path.write_text(json.dumps(config), encoding="utf-8")
+        return load_id_scheme(self.path)
+
+    def test_missing_config_gives_the_defaults(self) -> None:
+        self.assertEqual(load_id_scheme(self.path), IdScheme())
+
+    def test_config_overrides_and_extends_kinds(self) -> None:
+        scheme = self.load({"prefix": "es", "separator": "-", "fallback": "content-hash", "hash_length": 8, "kinds": {"vocab": "word"}})
+        self.assertEqual((scheme.prefix, scheme.separator, scheme.fallback, scheme.hash_length), ("es", "-", "content-hash", 8))
+        self.assertEqual(scheme.kind_prefix("vocab"), "es-word_")
+        self.assertEqual(scheme.kind_prefix("lesson"), "es-lesson_")
+
+    def test_unknown_fallback_is_a_config_error(self) -> None:
+        with self.assertRaises(ConfigError) as caught:
+            self.load({"fallback": "serial"})
+        self.assertEqual((caught.exception.setting, caught.exception.value), ("fallback", "serial"))
+        self.assertIn("ksuid", caught.exception.expected)
+
+    def test_unknown_hash_algorithm_is_a_config_error(self) -> None:
+        with self.assertRaises(ConfigError) as caught:
+            self.load({"hash_algorithm": "sha-nope"})
+        self.assertEqual(caught.exception.setting, "hash_algorithm")
+
+    def test_config_error_is_still_a_value_error(self) -> None:
+        with self.assertRaises(ValueError):
+            self.load({"fallback": "serial"})
+
+
+class SchemeIdsTest(unittest.TestCase):
+    scheme = IdScheme()
+
+    def test_pattern_accepts_only_the_kind_prefix(self) -> None:
+        pattern = self.scheme.pattern("vocab")
+        self.assertTrue(pattern.match("mmspanish__vocab_gato"))
+        self.assertFalse(pattern.match("mmspanish__lesson_gato"))
+        self.assertFalse(pattern.match("vocab_gato"))
+        self.assertFalse(pattern.match("mmspanish__vocab_ga to"))
+
+    def test_generated_ids_slug_the_headword_and_sense_key(self) -> None:
+        record = Record(kind="vocab", data={"spanish": "Cómo", "sense_key": "conj"}, source="", index=0)
+        self.assertEqual(generate_id(record, self.scheme), "mmspanish__vocab_como__conj")
+        lesson = Record(kind="lesson", data={"title": "El presente", "steps": []}, source="", index=0)
+        self.assertTrue(self.scheme.pattern("lesson").match(generate_id(lesson, self.scheme)))
+
+    def test_units_are_named_after_level_and_number(self) -> None:
+        unit = Record(kind="unit", data={"level": "a2", "number": 3}, source="", index=0)
+        self.assertEqual(generate_id(unit, self.scheme), "mmspanish__unit_a2_03")
+
+    def test_content_hash_fallback_is_stable(self) -> None:
+        scheme = IdScheme(fallback="content-hash", hash_length=12)
+        first = Record(kind="vocab", data={"pos": "noun", "english_gloss": "cat"}, source="", index=0)
+        second = Record(kind="vocab", data={"english_gloss": "cat", "pos": "noun"}, source="", index=5)
+        self.assertEqual(generate_id(first, scheme), generate_id(second, scheme))
+        self.assertTrue(scheme.pattern("vocab").match(generate_id(first, scheme)))
+
+    def test_existing_ids_are_kept_as_written(self) -> None:
+        record = Record(kind="vocab", data={"id": "  legacy_gato ", "spanish": "gato"}, source="", index=0)
+        self.assertEqual(record_id(record, self.scheme), "legacy_gato")
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,59 @@
+"""Anki import: a small .apkg built on the fly, through the importer and back through collect."""
+
+from __future__ import annotations
+
+import json
+import sqlite3
+import tempfile
+import unittest
+import zipfile
+from pathlib import Path
+from unittest import mock
+
+from tools.content.common import IdScheme, collect, load_json
+from tools.content.export import build_entries
+from tools.content.import_anki import DEFAULT_ANKI_PATH, main, read_deck
+
+MODELS = {"1": {"flds": [{"name": "Front", "ord": 0}, {"name": "Back", "ord": 1}, {"name": "Gender", "ord": 2}]}}
+NOTES = [(1, "gato<br>[sound:gato.mp3]\x1fcat\x1fmasculine", " A1 animals "), (1, "\x1fno headword\x1f", "")]
+
+
+def write_deck(path: Path) -> None:
+    collection = path.with_suffix(".anki2")
+    conn = sqlite3.connect(collection)
+    conn.execute("CREATE TABLE col (models TEXT)")
+    conn.execute("CREATE TABLE notes (id INTEGER PRIMARY KEY, mid INTEGER, flds TEXT, tags TEXT)")
+    conn.execute("INSERT INTO col VALUES (?)", (json.dumps(MODELS),))
+    conn.executemany("INSERT INTO notes (mid, flds, tags) VALUES (?, ?, ?)", NOTES)
+    conn.commit()
+    conn.close()
+    with zipfile.ZipFile(path, "w") as archive:
+        archive.write(collection, "collection.anki2")
+        archive.writestr("media", json.dumps({"0": "gato.mp3"}))
+
+
+class ImportAnkiTest(unittest.TestCase):
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.tmp = Path(tmp.name)
+        self.deck = self.tmp / "kitchen.apkg"
+        write_deck(self.deck)
+
+    def test_notes_become_vocabulary_records(self) -> None:
+        records = read_deck(self.deck, load_json(DEFAULT_ANKI_PATH))
+        self.assertEqual(records, [{"spanish": "gato", "pos": "expr", "english_gloss": "cat", "definition": "", "examples": [], "level": "A1", "tags": ["animals"], "anki_deck": "kitchen.apkg", "gender": "masculine", "media": ["gato.mp3"]}])
+
+    def test_exported_entries_point_at_the_imported_file_not_the_deck(self) -> None:
+        out = self.tmp / "content"
+        with mock.patch("sys.stdout"):
+            self.assertEqual(main([str(self.deck), "--out", str(out)]), 0)
+        dataset = collect([out])
+        entries = build_entries(dataset.vocab, IdScheme())
+        self.assertEqual(len(entries), 1)
+        self.assertEqual([Path(source).name for source in entries[0]["source_files"]], ["kitchen.jsonl"])
+        self.assertEqual(entries[0]["anki_deck"], "kitchen.apkg")
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,90 @@
+"""Curriculum order: lessons using a word before the lesson introducing it, in export's audit and lint."""
+
+from __future__ import annotations
+
+import io
+import json
+import tempfile
+import unittest
+from pathlib import Path
+from typing import Any, Dict, List
+from unittest import mock
+
+from tools.content.common import DEFAULT_LESSON_FLOW, EventLog, collect
+from tools.content.export import export, export_options, intro_order_problems
+from tools.content.lint import rule_intro_order
+from tools.content.storage import MemoryStorage
+
+PRESENTATION = DEFAULT_LESSON_FLOW["presentation"]
+
+
+def vocab(spanish: str, **fields: Any) -> Dict[str, Any]:
+    return {"id": f"vocab_{spanish}", "spanish": spanish, "pos": "noun", "english_gloss": spanish, "definition": spanish, "examples": [], "level": "A1", "tags": [], **fields}
+
+
+def lesson(number: int, *steps: Dict[str, Any], **fields: Any) -> Dict[str, Any]:
+    return {"id": f"lesson_A1_{number}", "title": f"Lesson {number}", "level": "A1", "lesson_number": number, "steps": list(steps), **fields}
+
+
+def present(*items: str) -> Dict[str, Any]:
+    return {"phase": "presentation", "line": "New words.", "items": list(items)}
+
+
+def practice(spanish: str) -> Dict[str, Any]:
+    return {"phase": "practice", "line": "Say it.", "es": spanish}
+
+
+class IntroOrderProblemsTest(unittest.TestCase):
+    def test_a_word_presented_later_is_used_too_early(self) -> None:
+        lessons = [lesson(1, practice("Tengo un gato.")), lesson(2, present("gato"), practice("El gato duerme."))]
+        self.assertEqual(intro_order_problems(lessons, [vocab("gato")], PRESENTATION), [("lesson_A1_1", "uses 'gato' before it is introduced in lesson_A1_2")])
+
+    def test_the_presenting_lesson_and_later_ones_are_fine(self) -> None:
+        lessons = [lesson(2, practice("Veo el gato.")), lesson(1, present("gato"), practice("Un gato."))]
+        self.assertEqual(intro_order_problems(lessons, [vocab("gato")], PRESENTATION), [])
+
+    def test_a_word_no_lesson_introduces_is_reported_once(self) -> None:
+        lessons = [lesson(1, practice("Un perro.")), lesson(2, practice("El perro come."))]
+        self.assertEqual(intro_order_problems(lessons, [vocab("perro")], PRESENTATION), [("lesson_A1_1", "uses 'perro', which no lesson introduces")])
+
+    def test_explicit_introductions_win_over_presentation(self) -> None:
+        lessons = [lesson(1, present("gato"), present("perro")), lesson(2, practice("Un gato."), introduces=["gato"]), lesson(3, practice("Un perro."))]
+        problems = intro_order_problems(lessons, [vocab("gato"), vocab("perro", intro_lesson="lesson_A1_3")], PRESENTATION)
+        self.assertEqual(problems, [("lesson_A1_1", "uses 'gato' before it is introduced in lesson_A1_2"), ("lesson_A1_1", "uses 'perro' before it is introduced in lesson_A1_3")])
+
+    def test_only_the_configured_phases_present(self) -> None:
+        lessons = [lesson(1, practice("Un gato.")), lesson(2, {"phase": "warmup", "items": ["gato"]})]
+        self.assertEqual(intro_order_problems(lessons, [vocab("gato")], PRESENTATION), [("lesson_A1_1", "uses 'gato', which no lesson introduces")])
+        self.assertEqual(intro_order_problems(lessons, [vocab("gato")], ["warmup"]), [("lesson_A1_1", "uses 'gato' before it is introduced in lesson_A1_2")])
+
+
+class IntroOrderReportTest(unittest.TestCase):
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.content = Path(tmp.name) / "content"
+        self.write("A1/vocabulary/animals.json", [vocab("gato"), vocab("perro")])
+        self.write("A1/lessons/lessons.json", [lesson(1, practice("Tengo un gato y un perro.")), lesson(2, present("gato"))])
+
+    def write(self, name: str, entries: List[Dict[str, Any]]) -> None:
+        path = self.content / name
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_text(json.dumps(entries, indent=2) + "\n", encoding="utf-8")
+
+    def test_export_audits_the_curriculum_order(self) -> None:
+        reports = MemoryStorage()
+        with mock.patch("sys.stdout", io.StringIO()):
+            result = export(collect([str(self.content)], EventLog()), MemoryStorage(), export_options(), reports=reports)
+        audit = (reports.read_bytes("export.md") or b"").decode("utf-8")
+        self.assertIn("## curriculum order", audit)
+        self.assertIn("- lesson_A1_1: uses 'gato' before it is introduced in lesson_A1_2", audit)
+        self.assertIn("- lesson_A1_1: uses 'perro', which no lesson introduces", audit)
+        self.assertEqual(result.metrics["intro_order_problems"], 2)
+
+    def test_lint_flags_the_same_lessons(self) -> None:
+        findings = rule_intro_order(collect([str(self.content)], EventLog()), {})
+        self.assertEqual([(finding.rule, finding.message) for finding in findings], [("intro-order", "uses 'gato' before it is introduced in lesson_A1_2"), ("intro-order", "uses 'perro', which no lesson introduces")])
+
+
+if __name__ == "__main__":
+    unittest.main()
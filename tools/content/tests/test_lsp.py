@@ -0,0 +1,55 @@
+"""The language server's JSON-RPC loop, fed frames from memory."""
+
+from __future__ import annotations
+
+import io
+import json
+import unittest
+from typing import Any, Dict, List
+
+from tools.content.accents import DEFAULT_ACCENTS_PATH, load_accent_dictionary
+from tools.content.common import DEFAULT_IDS_PATH, load_id_scheme
+from tools.content.lsp import PARSE_ERROR, Checker, Server, read_message
+from tools.content.numerals import DEFAULT_NUMERALS_PATH, load_numeral_style
+from tools.content.rules import DEFAULT_RULES_PATH, load_rules
+from tools.content.validate import load_schemas
+
+
+def frame(body: bytes) -> bytes:
+    return f"Content-Length: {len(body)}\r\n\r\n".encode("ascii") + body
+
+
+def request(payload: Dict[str, Any]) -> bytes:
+    return frame(json.dumps(payload).encode("utf-8"))
+
+
+class ServeTest(unittest.TestCase):
+    def setUp(self) -> None:
+        checker = Checker(load_schemas(), load_rules(DEFAULT_RULES_PATH), load_id_scheme(DEFAULT_IDS_PATH), load_accent_dictionary(DEFAULT_ACCENTS_PATH), load_numeral_style(DEFAULT_NUMERALS_PATH))
+        self.output = io.BytesIO()
+        self.server = Server(checker, self.output)
+
+    def responses(self) -> List[Dict[str, Any]]:
+        stream = io.BytesIO(self.output.getvalue())
+        messages = []
+        while True:
+            message = read_message(stream)
+            if message is None:
+                return messages
+            messages.append(message)
+
+    def test_a_malformed_frame_is_answered_and_the_server_keeps_reading(self) -> None:
+        stream = io.BytesIO(frame(b'{"jsonrpc": "2.0", "id": 1, "method":') + frame(b"\xff\xfe") + request({"jsonrpc": "2.0", "id": 2, "method": "shutdown"}) + request({"jsonrpc": "2.0", "method": "exit"}))
+        self.assertEqual(self.server.serve(stream), 0)
+        responses = self.responses()
+        self.assertEqual([response.get("error", {}).get("code") for response in responses], [PARSE_ERROR, PARSE_ERROR, None])
+        self.assertEqual([response["id"] for response in responses], [None, None, 2])
+
+    def test_a_non_object_message_is_an_invalid_request(self) -> None:
+        stream = io.BytesIO(request([1, 2]) + request({"jsonrpc": "2.0", "method": "exit"}))
+        self.assertEqual(self.server.serve(stream), 1)
+        self.assertEqual(self.responses()[0]["error"]["code"], -32600)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,173 @@
+"""Merging records: prose variants, merge.resolve_duplicates under each duplicate policy with the pinned-entry exception,
+and similar lessons, whose merged-away IDs references follow."""
+
+from __future__ import annotations
+
+import io
+import json
+import os
+import tempfile
+import unittest
+from pathlib import Path
+from typing import Any, List, Tuple
+from unittest import mock
+
+from tools.content.common import EventLog, collect
+from tools.content.errors import ConfigError
+from tools.content.export import export, export_options
+from tools.content.merge import DUPLICATE_POLICIES, MERGED_BANNER, LessonMatch, merge_prose, merge_records, merge_similar_lessons, resolve_duplicates
+from tools.content.references import DEFAULT_TYPES, rename_references
+from tools.content.storage import MemoryStorage
+
+
+class RecordingEvents:
+    def __init__(self) -> None:
+        self.events: List[Tuple[str, str, str]] = []
+
+    def emit(self, event: str, source: str, reason: str = "", **details: Any) -> None:
+        self.events.append((event, source, reason))
+
+
+def vocab(entry_id: str, source: str, **fields: Any) -> dict:
+    return {"id": entry_id, "spanish": "gato", "source_files": [source], **fields}
+
+
+class MergeProseTest(unittest.TestCase):
+    def test_similar_variants_merge_word_by_word(self) -> None:
+        self.assertEqual(merge_prose("A small domestic cat.", "A small domestic feline."), "A small domestic feline.")
+        self.assertEqual(merge_prose("A small cat", "A small cat that purrs"), "A small cat that purrs")
+
+    def test_line_and_paragraph_breaks_survive(self) -> None:
+        first = "Used for greetings at any hour.\n\nInformal; used with friends and family.\n"
+        second = "Used for greetings at any hour.\n\nInformal; used with friends and close family.\n"
+        self.assertEqual(merge_prose(first, second), second)
+
+    def test_different_variants_keep_both_under_the_banner(self) -> None:
+        self.assertEqual(merge_prose("A cat.", "Something else entirely."), f"A cat.\n\n{MERGED_BANNER}\nSomething else entirely.")
+        self.assertEqual(merge_prose("A cat.", "  "), "A cat.")
+
+
+class MergeReviewStatusTest(unittest.TestCase):
+    def test_the_least_reviewed_variant_wins(self) -> None:
+        self.assertEqual(merge_records({"review_status": "published"}, {"review_status": "reviewed"})["review_status"], "reviewed")
+        self.assertEqual(merge_records({"review_status": "draft"}, {"review_status": "published"})["review_status"], "draft")
+
+    def test_a_variant_without_a_valid_status_counts_as_draft(self) -> None:
+        for first, second in (({"review_status": "published"}, {}), ({}, {"review_status": "published"}), ({"review_status": "published"}, {"review_status": "final"})):
+            self.assertEqual(merge_records(first, second)["review_status"], "draft", (first, second))
+
+    def test_no_status_on_either_side_adds_none(self) -> None:
+        self.assertNotIn("review_status", merge_records({"spanish": "gato"}, {"spanish": "gato"}))
+
+
+class ResolveDuplicatesTest(unittest.TestCase):
+    def setUp(self) -> None:
+        self.entries = [
+            vocab("v_gato", "a.json", english_gloss="cat", tags=["animals"]),
+            vocab("v_perro", "a.json", english_gloss="dog"),
+            vocab("v_gato", "b.json", english_gloss="tomcat", tags=["pets"], plural="gatos"),
+        ]
+
+    def test_unique_entries_pass_through_in_order(self) -> None:
+        for policy in DUPLICATE_POLICIES:
+            kept, clusters = resolve_duplicates(self.entries[:2], policy)
+            self.assertEqual(kept, self.entries[:2])
+            self.assertEqual(clusters, [])
+
+    def test_merge_folds_the_cluster_into_the_first_occurrence(self) -> None:
+        events = RecordingEvents()
+        kept, clusters = resolve_duplicates(self.entries, "merge", events=events)
+        self.assertEqual([entry["id"] for entry in kept], ["v_gato", "v_perro"])
+        gato = kept[0]
+        self.assertEqual(gato["english_gloss"], "cat")
+        self.assertEqual(gato["tags"], ["animals", "pets"])
+        self.assertEqual(gato["plural"], "gatos")
+        self.assertEqual(gato["source_files"], ["a.json", "b.json"])
+        self.assertEqual(len(clusters), 1)
+        self.assertIsNone(clusters[0].kept)
+        self.assertEqual(clusters[0].differing_fields(), ["english_gloss", "plural", "tags"])
+        self.assertEqual([event for event, _, _ in events.events], ["duplicate_merged"])
+
+    def test_keep_first_reject_and_review_keep_the_first_untouched(self) -> None:
+        for policy in ("keep-first", "reject", "review"):
+            events = RecordingEvents()
+            kept, clusters = resolve_duplicates(self.entries, policy, events=events)
+            self.assertIs(kept[0], self.entries[0], policy)
+            self.assertEqual(clusters[0].kept, 0, policy)
+            self.assertEqual(clusters[0].entries, [self.entries[0], self.entries[2]], policy)
+            self.assertEqual(events.events, [("duplicate_dropped", "b.json", f"{policy}: not merged into v_gato")], policy)
+
+    def test_keep_newest_keeps_the_most_recently_modified_source(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        old, new = Path(tmp.name) / "old.json", Path(tmp.name) / "new.json"
+        for path, mtime in ((old, 1_000_000), (new, 2_000_000)):
+            path.write_text("[]", encoding="utf-8")
+            os.utime(path, (mtime, mtime))
+        first, second = vocab("v_gato", str(new), english_gloss="cat"), vocab("v_gato", str(old), english_gloss="tomcat")
+        kept, clusters = resolve_duplicates([first, second], "keep-newest")
+        self.assertIs(kept[0], first)
+        self.assertEqual(clusters[0].kept, 0)
+        # Equal times (here: sources that do not exist) go to the later entry.
+        kept, _ = resolve_duplicates(self.entries, "keep-newest")
+        self.assertIs(kept[0], self.entries[2])
+
+    def test_pinned_entry_wins_under_every_policy(self) -> None:
+        pinned = vocab("v_gato", "c.json", english_gloss="gato (locked)", locked=True)
+        for policy in DUPLICATE_POLICIES:
+            events = RecordingEvents()
+            kept, clusters = resolve_duplicates(self.entries + [pinned], policy, events=events)
+            self.assertIs(kept[0], pinned, policy)
+            self.assertTrue(clusters[0].pinned, policy)
+            self.assertEqual(clusters[0].kept, 2, policy)
+            self.assertEqual([event for event, _, _ in events.events], ["merge_refused", "merge_refused"], policy)
+
+    def test_unknown_policy_is_a_config_error(self) -> None:
+        with self.assertRaises(ConfigError) as caught:
+            resolve_duplicates(self.entries, "newest")
+        self.assertEqual(caught.exception.setting, "on_duplicate")
+        self.assertEqual(caught.exception.expected, list(DUPLICATE_POLICIES))
+
+
+def lesson(entry_id: str, title: str, **fields: Any) -> dict:
+    return {"id": entry_id, "title": title, "level": "A1", "steps": [{"phase": "presentation", "line": "Ser is for identity; estar is for states and places."}], **fields}
+
+
+class SimilarLessonsTest(unittest.TestCase):
+    def test_merged_ids_map_to_the_lesson_holding_them(self) -> None:
+        lessons = [lesson("l_a", "Ser vs Estar"), lesson("l_b", "Ser vs. Estar"), lesson("l_c", "Ser and Estar")]
+        matches = [LessonMatch("l_a", "l_b", 1.0, 1.0), LessonMatch("l_b", "l_c", 0.9, 1.0)]
+        kept, folded = merge_similar_lessons(lessons, matches, 0.8)
+        self.assertEqual([entry["id"] for entry in kept], ["l_a"])
+        self.assertEqual(folded, {"l_b": "l_a", "l_c": "l_a"})
+        self.assertEqual(merge_similar_lessons(lessons, matches, None), (lessons, {}))
+
+    def test_references_follow_the_merged_lesson(self) -> None:
+        entries = {
+            "lesson": [lesson("l_a", "Ser vs Estar", prerequisites=["l_b"]), lesson("l_d", "Next", prerequisites=["l_a", "l_b", "l_x"])],
+            "vocab": [{"id": "v_ser", "intro_lesson": "l_b"}],
+            "culture_note": [{"id": "n_1", "lessons": [{"ref": "l_b", "why": "greetings"}]}],
+        }
+        self.assertEqual(rename_references(entries, DEFAULT_TYPES, "lesson", {"l_b": "l_a"}), 4)
+        self.assertEqual([entry["prerequisites"] for entry in entries["lesson"]], [[], ["l_a", "l_x"]])
+        self.assertEqual(entries["vocab"][0]["intro_lesson"], "l_a")
+        self.assertEqual(entries["culture_note"][0]["lessons"], [{"ref": "l_a", "why": "greetings"}])
+
+    def test_export_leaves_no_reference_to_a_merged_lesson(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        content = Path(tmp.name) / "content"
+        content.mkdir()
+        lessons = [lesson("l_a", "Ser vs Estar"), lesson("l_b", "Ser vs. Estar"), lesson("l_d", "Next", prerequisites=["l_b"])]
+        (content / "lessons.json").write_text(json.dumps(lessons), encoding="utf-8")
+        storage, reports = MemoryStorage(), MemoryStorage()
+        with mock.patch("sys.stdout", io.StringIO()):
+            result = export(collect([str(content)], EventLog()), storage, export_options(merge_similar_lessons=0.9), reports=reports)
+        self.assertEqual(result.metrics["dangling_references"], 0)
+        written = {entry["id"]: entry for entry in json.loads(storage.read_bytes("canonical/lessons.json") or b"[]")}
+        self.assertEqual(sorted(written), ["l_a", "l_d"])
+        self.assertEqual(written["l_d"]["prerequisites"], ["l_a"])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,129 @@
+"""The serve write API end to end: a real server on a free port, writing into a temporary content tree."""
+
+from __future__ import annotations
+
+import http.client
+import io
+import json
+import tempfile
+import threading
+import unittest
+from http.server import ThreadingHTTPServer
+from pathlib import Path
+from typing import Any, Dict, Optional, Tuple
+from unittest import mock
+
+from tools.content.common import DEFAULT_IDS_PATH, load_id_scheme
+from tools.content.rules import DEFAULT_RULES_PATH, load_rules
+from tools.content.serve import DatasetState, make_handler
+from tools.content.submissions import SubmissionWriter
+from tools.content.validate import load_schemas
+
+TOKEN = "secret"
+GATO = {"id": "mmspanish__vocab_gato", "spanish": "gato", "pos": "noun", "gender": "masculine", "english_gloss": "cat", "definition": "A small domestic feline.", "examples": [], "level": "A1", "tags": []}
+PERRO = {"spanish": "perro", "pos": "noun", "gender": "masculine", "english_gloss": "dog", "definition": "A domestic canine.", "examples": [], "level": "A1", "tags": []}
+
+
+class ServeWriteApiTest(unittest.TestCase):
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.content = Path(tmp.name) / "content"
+        self.vocab_file = self.content / "A1" / "vocabulary" / "animals.json"
+        self.vocab_file.parent.mkdir(parents=True)
+        self.vocab_file.write_text(json.dumps([GATO], indent=2) + "\n", encoding="utf-8")
+        # The server logs each write to stderr.
+        quiet = mock.patch("sys.stderr", io.StringIO())
+        quiet.start()
+        self.addCleanup(quiet.stop)
+        self.start(SubmissionWriter(load_schemas(), load_rules(DEFAULT_RULES_PATH), load_id_scheme(DEFAULT_IDS_PATH), self.content), TOKEN)
+
+    def start(self, writer: Optional[SubmissionWriter], token: str) -> None:
+        self.state = DatasetState([str(self.content)], str(DEFAULT_IDS_PATH))
+        self.assertTrue(self.state.rebuild())
+        server = ThreadingHTTPServer(("127.0.0.1", 0), make_handler(self.state, writer, token))
+        thread = threading.Thread(target=server.serve_forever, daemon=True)
+        thread.start()
+        self.addCleanup(thread.join)
+        self.addCleanup(server.server_close)
+        self.addCleanup(server.shutdown)
+        self.port = server.server_port
+
+    def request(self, method: str, path: str, body: Any = None, headers: Optional[Dict[str, str]] = None) -> Tuple[int, Dict[str, Any]]:
+        connection = http.client.HTTPConnection("127.0.0.1", self.port, timeout=10)
+        self.addCleanup(connection.close)
+        payload = body if isinstance(body, bytes) or body is None else json.dumps(body).encode("utf-8")
+        connection.request(method, path, payload, {"Authorization": f"Bearer {TOKEN}", **(headers or {})})
+        response = connection.getresponse()
+        return response.status, json.loads(response.read().decode("utf-8"))
+
+    def submitted(self) -> list:
+        path = self.content / "A1" / "vocabulary" / "submitted.jsonl"
+        return [json.loads(line) for line in path.read_text(encoding="utf-8").splitlines()] if path.exists() else []
+
+    def test_post_appends_a_new_record(self) -> None:
+        status, body = self.request("POST", "/vocabulary", PERRO)
+        self.assertEqual(status, 201, body)
+        self.assertEqual(body["item"]["id"], "mmspanish__vocab_perro")
+        self.assertEqual(body["rebuild"], "queued")
+        self.assertEqual(self.submitted(), [PERRO])
+
+    def test_post_of_an_existing_id_is_a_conflict(self) -> None:
+        self.assertEqual(self.request("POST", "/vocabulary", PERRO)[0], 201)
+        # Not in the served snapshot yet, but written by this server: still a duplicate.
+        status, body = self.request("POST", "/vocabulary", PERRO)
+        self.assertEqual(status, 409, body)
+        status, body = self.request("POST", "/vocabulary", {key: value for key, value in GATO.items() if key != "id"})
+        self.assertEqual(status, 409, body)
+        self.assertEqual(self.submitted(), [PERRO])
+
+    def test_invalid_record_is_rejected_with_its_issues(self) -> None:
+        status, body = self.request("POST", "/vocabulary", {**PERRO, "pos": "animal"})
+        self.assertEqual(status, 422, body)
+        self.assertTrue(any(issue["path"] == "pos" for issue in body["issues"]), body)
+        status, body = self.request("POST", "/lessons", PERRO)
+        self.assertEqual(status, 422, body)
+        self.assertEqual(self.submitted(), [])
+
+    def test_put_replaces_the_record_in_its_file(self) -> None:
+        edited = {**GATO, "english_gloss": "cat, tomcat"}
+        status, body = self.request("PUT", "/vocabulary/mmspanish__vocab_gato", edited)
+        self.assertEqual(status, 200, body)
+        self.assertEqual(json.loads(self.vocab_file.read_text(encoding="utf-8")), [edited])
+        # Without an id in the body, the one in the path is stored.
+        status, _ = self.request("PUT", "/vocabulary/mmspanish__vocab_gato", {key: value for key, value in GATO.items() if key != "id"})
+        self.assertEqual(status, 200)
+        self.assertEqual(json.loads(self.vocab_file.read_text(encoding="utf-8")), [GATO])
+
+    def test_put_with_another_id_or_an_unknown_entry_is_refused(self) -> None:
+        status, body = self.request("PUT", "/vocabulary/mmspanish__vocab_gato", {**GATO, "id": "mmspanish__vocab_gata"})
+        self.assertEqual(status, 400, body)
+        self.assertEqual(self.request("PUT", "/vocabulary/mmspanish__vocab_perro", PERRO)[0], 404)
+        self.assertEqual(self.request("PUT", "/lessons/mmspanish__vocab_gato", GATO)[0], 404)
+        self.assertEqual(json.loads(self.vocab_file.read_text(encoding="utf-8")), [GATO])
+
+    def test_body_must_be_sized_json(self) -> None:
+        connection = http.client.HTTPConnection("127.0.0.1", self.port, timeout=10)
+        self.addCleanup(connection.close)
+        connection.putrequest("POST", "/vocabulary")
+        connection.putheader("Authorization", f"Bearer {TOKEN}")
+        connection.endheaders()
+        self.assertEqual(connection.getresponse().status, 411)
+        self.assertEqual(self.request("POST", "/vocabulary", b"{}", {"Content-Length": "two"})[0], 400)
+        self.assertEqual(self.request("POST", "/vocabulary", b"{not json")[0], 400)
+        self.assertEqual(self.request("POST", "/vocabulary", [PERRO])[0], 400)
+
+    def test_wrong_token_is_unauthorized(self) -> None:
+        status, _ = self.request("POST", "/vocabulary", PERRO, {"Authorization": "Bearer guess"})
+        self.assertEqual(status, 401)
+        self.assertEqual(self.submitted(), [])
+
+    def test_write_api_is_off_without_a_token(self) -> None:
+        self.start(None, "")
+        self.assertEqual(self.request("POST", "/vocabulary", PERRO)[0], 403)
+        status, body = self.request("GET", "/vocabulary/mmspanish__vocab_gato")
+        self.assertEqual((status, body["item"]["english_gloss"]), (200, "cat"))
+
+
+if __name__ == "__main__":
+    unittest.main()
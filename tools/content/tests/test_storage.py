@@ -0,0 +1,282 @@
+"""Round-trips through every storage backend, with in-process fakes standing in for S3 and GCS."""
+
+from __future__ import annotations
+
+import io
+import json
+import sys
+import tempfile
+import types
+import unittest
+from pathlib import Path
+from typing import Any, Dict, Optional
+from unittest import mock
+
+from tools.content.errors import ConfigError
+from tools.content.storage import GCSStorage, LocalStorage, MemoryStorage, S3Storage, S3Upload, StagingStorage, Storage, ZipStorage, load_storage, open_storage
+
+# Several S3 upload parts at PART_BYTES.
+PART_BYTES = 8
+PAYLOAD = "¡Hola! ".encode("utf-8") * 5
+
+
+class FakeS3Client:
+    """The slice of a boto3 S3 client the backend calls; finished objects land in objects."""
+
+    def __init__(self, **options: Any) -> None:
+        self.options = options
+        self.objects: Dict[str, bytes] = {}
+        self.uploads: Dict[str, Dict[int, bytes]] = {}
+        self.aborted = 0
+
+    def put_object(self, Bucket: str, Key: str, Body: bytes) -> None:  # noqa: N803 - boto3 naming
+        self.objects[f"{Bucket}/{Key}"] = bytes(Body)
+
+    def create_multipart_upload(self, Bucket: str, Key: str) -> Dict[str, str]:  # noqa: N803
+        upload_id = f"upload-{len(self.uploads)}"
+        self.uploads[upload_id] = {}
+        return {"UploadId": upload_id}
+
+    def upload_part(self, Bucket: str, Key: str, UploadId: str, PartNumber: int, Body: bytes) -> Dict[str, str]:  # noqa: N803
+        self.uploads[UploadId][PartNumber] = bytes(Body)
+        return {"ETag": f"etag-{PartNumber}"}
+
+    def complete_multipart_upload(self, Bucket: str, Key: str, UploadId: str, MultipartUpload: Dict[str, Any]) -> None:  # noqa: N803
+        parts = self.uploads.pop(UploadId)
+        self.objects[f"{Bucket}/{Key}"] = b"".join(parts[part["PartNumber"]] for part in MultipartUpload["Parts"])
+
+    def abort_multipart_upload(self, Bucket: str, Key: str, UploadId: str) -> None:  # noqa: N803
+        del self.uploads[UploadId]
+        self.aborted += 1
+
+
+class FakeBlob:
+    def __init__(self, bucket: "FakeBucket", name: str) -> None:
+        self.bucket = bucket
+        self.name = name
+
+    def upload_from_string(self, data: bytes) -> None:
+        self.bucket.objects[self.name] = bytes(data)
+
+    def open(self, mode: str, chunk_size: Optional[int] = None) -> io.BytesIO:
+        blob = self
+
+        class Upload(io.BytesIO):
+            def close(self) -> None:
+                if not self.closed:
+                    blob.bucket.objects[blob.name] = self.getvalue()
+                super().close()
+
+        return Upload()
+
+
+class FakeBucket:
+    def __init__(self, name: str) -> None:
+        self.name = name
+        self.objects: Dict[str, bytes] = {}
+
+    def blob(self, name: str) -> FakeBlob:
+        return FakeBlob(self, name)
+
+
+def fake_cloud_modules() -> Dict[str, types.ModuleType]:
+    """Stand-ins for boto3 and google.cloud.storage, so the optional dependencies need not be installed."""
+    boto3 = types.ModuleType("boto3")
+    boto3.client = lambda service, **options: FakeS3Client(**options)  # type: ignore[attr-defined]
+    gcs = types.ModuleType("google.cloud.storage")
+    gcs.Client = lambda project=None: types.SimpleNamespace(bucket=FakeBucket)  # type: ignore[attr-defined]
+    google, cloud = types.ModuleType("google"), types.ModuleType("google.cloud")
+    google.cloud, cloud.storage = cloud, gcs  # type: ignore[attr-defined]
+    return {"boto3": boto3, "google": google, "google.cloud": cloud, "google.cloud.storage": gcs}
+
+
+class StorageTestCase(unittest.TestCase):
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.tmp = Path(tmp.name)
+
+    def write_all_ways(self, storage: Storage) -> None:
+        storage.write_text("canonical/vocabulary.json", "[]")
+        with storage.open_write("canonical/lessons.json") as handle:
+            handle.write(PAYLOAD)
+        source = self.tmp / "upload.bin"
+        source.write_bytes(b"\x00\x01")
+        storage.child("canonical").put_file("media/clip.bin", source)
+
+    def assert_reads_back(self, storage: Storage) -> None:
+        self.assertEqual(storage.read_bytes("canonical/vocabulary.json"), b"[]")
+        self.assertEqual(storage.child("canonical").read_bytes("lessons.json"), PAYLOAD)
+        self.assertEqual(storage.read_bytes("canonical/media/clip.bin"), b"\x00\x01")
+        self.assertIsNone(storage.read_bytes("canonical/missing.json"))
+        handle = storage.open_read("canonical/lessons.json")
+        self.assertIsNotNone(handle)
+        with handle:
+            self.assertEqual(handle.read(), PAYLOAD)
+        self.assertIsNone(storage.open_read("canonical/missing.json"))
+
+
+class LocalStorageTest(StorageTestCase):
+    def test_round_trip(self) -> None:
+        storage = LocalStorage(self.tmp / "build")
+        self.write_all_ways(storage)
+        self.assert_reads_back(storage)
+        self.assertEqual((self.tmp / "build" / "canonical" / "lessons.json").read_bytes(), PAYLOAD)
+        self.assertEqual(list((self.tmp / "build").rglob("*.partial")), [])
+
+    def test_delete_removes_files_and_directories(self) -> None:
+        storage = LocalStorage(self.tmp / "build")
+        self.write_all_ways(storage)
+        storage.delete("canonical/vocabulary.json")
+        self.assertIsNone(storage.read_bytes("canonical/vocabulary.json"))
+        storage.child("canonical").delete("")
+        self.assertFalse((self.tmp / "build" / "canonical").exists())
+
+
+class MemoryStorageTest(StorageTestCase):
+    def test_round_trip_without_touching_disk(self) -> None:
+        storage = MemoryStorage()
+        self.write_all_ways(storage)
+        self.assert_reads_back(storage)
+        self.assertEqual(sorted(storage.files), ["canonical/lessons.json", "canonical/media/clip.bin", "canonical/vocabulary.json"])
+
+    def test_delete_takes_a_prefix(self) -> None:
+        storage = MemoryStorage()
+        self.write_all_ways(storage)
+        storage.write_text("canonical-old/x.json", "{}")
+        storage.delete("canonical/media")
+        self.assertNotIn("canonical/media/clip.bin", storage.files)
+        storage.delete("canonical")
+        self.assertEqual(list(storage.files), ["canonical-old/x.json"])
+        storage.delete("")
+        self.assertEqual(storage.files, {})
+
+
+class ZipStorageTest(StorageTestCase):
+    def test_round_trip_after_close(self) -> None:
+        path = self.tmp / "out.zip"
+        storage = ZipStorage(path)
+        self.write_all_ways(storage)
+        # Reads see the previous archive until close swaps the new one in.
+        self.assertIsNone(storage.read_bytes("canonical/vocabulary.json"))
+        storage.close()
+        self.assert_reads_back(ZipStorage(path, readonly=True))
+
+    def test_rewrite_keeps_untouched_members(self) -> None:
+        path = self.tmp / "out.zip"
+        first = ZipStorage(path)
+        self.write_all_ways(first)
+        first.close()
+        second = ZipStorage(path)
+        second.write_text("canonical/vocabulary.json", '[{"id": "v_gato"}]')
+        second.close()
+        reopened = ZipStorage(path, readonly=True)
+        self.assertEqual(json.loads(reopened.read_bytes("canonical/vocabulary.json")), [{"id": "v_gato"}])
+        self.assertEqual(reopened.read_bytes("canonical/lessons.json"), PAYLOAD)
+
+    def test_readonly_archive_refuses_writes(self) -> None:
+        with self.assertRaises(ValueError):
+            ZipStorage(self.tmp / "out.zip", readonly=True).write_text("a.json", "[]")
+        self.assertFalse((self.tmp / "out.zip").exists())
+
+
+class StagingStorageTest(StorageTestCase):
+    def test_nothing_reaches_the_target_before_promote(self) -> None:
+        target = LocalStorage(self.tmp / "build")
+        staging = StagingStorage(target, self.tmp / "build")
+        self.write_all_ways(staging)
+        self.assert_reads_back(staging)
+        self.assertFalse((self.tmp / "build" / "canonical").exists())
+        names = staging.promote()
+        self.assertEqual(names, ["canonical/lessons.json", "canonical/media/clip.bin", "canonical/vocabulary.json"])
+        self.assert_reads_back(target)
+        self.assertEqual([path.name for path in (self.tmp / "build").iterdir()], ["canonical"])
+
+    def test_discard_drops_everything(self) -> None:
+        target = LocalStorage(self.tmp / "build")
+        staging = StagingStorage(target, self.tmp / "build")
+        self.write_all_ways(staging)
+        staging.discard()
+        self.assertEqual(list((self.tmp / "build").iterdir()), [])
+
+    def test_memory_target_is_staged_in_memory(self) -> None:
+        target = MemoryStorage()
+        staging = StagingStorage(target)
+        self.assertIsInstance(staging.staged, MemoryStorage)
+        self.write_all_ways(staging)
+        self.assertEqual(target.files, {})
+        staging.promote()
+        self.assert_reads_back(target)
+
+
+class CloudStorageTest(StorageTestCase):
+    def setUp(self) -> None:
+        super().setUp()
+        patcher = mock.patch.dict(sys.modules, fake_cloud_modules())
+        patcher.start()
+        self.addCleanup(patcher.stop)
+
+    def test_s3_round_trip(self) -> None:
+        storage = open_storage({"backend": "s3", "bucket": "content", "prefix": "releases/", "region_name": "eu-west-1"})
+        self.assertIsInstance(storage, S3Storage)
+        self.assertEqual(storage.client.options, {"region_name": "eu-west-1"})
+        self.write_all_ways(storage)
+        objects = storage.client.objects
+        self.assertEqual(objects["content/releases/canonical/vocabulary.json"], b"[]")
+        self.assertEqual(objects["content/releases/canonical/lessons.json"], PAYLOAD)
+        self.assertEqual(objects["content/releases/canonical/media/clip.bin"], b"\x00\x01")
+        self.assertEqual(storage.describe(), "s3://content/releases")
+
+    def test_s3_upload_sends_large_files_in_parts(self) -> None:
+        client = FakeS3Client()
+        with S3Upload(client, "content", "lessons.json", PART_BYTES) as handle:
+            for start in range(0, len(PAYLOAD), 5):
+                handle.write(PAYLOAD[start : start + 5])
+            # Whole parts go out as they fill; only the remainder is held.
+            self.assertLess(len(handle.pending), PART_BYTES)
+        self.assertEqual(client.objects, {"content/lessons.json": PAYLOAD})
+        self.assertEqual(client.uploads, {})
+
+    def test_s3_upload_that_fails_leaves_no_object(self) -> None:
+        client = FakeS3Client()
+        with self.assertRaises(RuntimeError):
+            with S3Upload(client, "content", "lessons.json", PART_BYTES) as handle:
+                handle.write(PAYLOAD)
+                raise RuntimeError("export died")
+        self.assertEqual(client.objects, {})
+        self.assertEqual((client.uploads, client.aborted), ({}, 1))
+
+    def test_gcs_round_trip(self) -> None:
+        storage = open_storage({"backend": "gcs", "bucket": "content", "prefix": "releases"})
+        self.assertIsInstance(storage, GCSStorage)
+        self.write_all_ways(storage)
+        objects = storage.bucket.objects
+        self.assertEqual(objects["releases/canonical/vocabulary.json"], b"[]")
+        self.assertEqual(objects["releases/canonical/lessons.json"], PAYLOAD)
+        self.assertEqual(objects["releases/canonical/media/clip.bin"], b"\x00\x01")
+        self.assertEqual(storage.describe(), "gs://content/releases")
+
+    def test_cloud_backends_need_a_bucket(self) -> None:
+        for backend in ("s3", "gcs"):
+            with self.assertRaises(ConfigError) as caught:
+                open_storage({"backend": backend})
+            self.assertEqual(caught.exception.setting, "bucket")
+
+
+class LoadStorageTest(StorageTestCase):
+    def test_config_picks_the_backend(self) -> None:
+        config = self.tmp / "storage.json"
+        config.write_text(json.dumps({"backend": "zip", "path": str(self.tmp / "out.zip")}), encoding="utf-8")
+        storage = load_storage(config, readonly=True)
+        self.assertIsInstance(storage, ZipStorage)
+        self.assertTrue(storage.readonly)
+        self.assertIsInstance(load_storage(self.tmp / "absent.json"), LocalStorage)
+
+    def test_unknown_backend_is_a_config_error(self) -> None:
+        with self.assertRaises(ConfigError) as caught:
+            open_storage({"backend": "ftp"})
+        self.assertEqual((caught.exception.setting, caught.exception.value), ("backend", "ftp"))
+
+
+if __name__ == "__main__":
+    unittest.main()
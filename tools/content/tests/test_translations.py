@@ -0,0 +1,41 @@
+"""Rewriting content files in place, as translation imports and serve submissions do."""
+
+from __future__ import annotations
+
+import json
+import tempfile
+import unittest
+from pathlib import Path
+from unittest import mock
+
+from tools.content.translations import save_objects, source_objects
+
+GATO = {"spanish": "gato", "english_gloss": "cat"}
+PERRO = {"spanish": "perro", "english_gloss": "dog"}
+
+
+class SaveObjectsTest(unittest.TestCase):
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.root = Path(tmp.name)
+
+    def test_each_layout_round_trips(self) -> None:
+        for name, layout in (("a.json", "array"), ("b.jsonl", "jsonl"), ("c.json", "object")):
+            path = self.root / name
+            objects = [GATO] if layout == "object" else [GATO, PERRO]
+            save_objects(path, layout, objects)
+            self.assertEqual(source_objects(path), (layout, objects))
+        self.assertEqual(sorted(path.name for path in self.root.iterdir()), ["a.json", "b.jsonl", "c.json"])
+
+    def test_a_failed_write_leaves_the_file_as_it_was(self) -> None:
+        path = self.root / "animals.json"
+        path.write_text(json.dumps([GATO]), encoding="utf-8")
+        with mock.patch("tools.content.translations.os.replace", side_effect=OSError("disk full")):
+            with self.assertRaises(OSError):
+                save_objects(path, "array", [GATO, PERRO])
+        self.assertEqual(json.loads(path.read_text(encoding="utf-8")), [GATO])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,251 @@
+#!/usr/bin/env python3
+"""Export a queue of missing English glosses and example translations (CSV or XLIFF) and merge the completed file back."""
+
+from __future__ import annotations
+
+import argparse
+import csv
+import hashlib
+import json
+import os
+import re
+import sys
+import xml.etree.ElementTree as ET
+from pathlib import Path
+from typing import Any, Dict, Iterable, Iterator, List, Tuple
+
+try:
+    from .common import BUILD_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, LOCKED, ROOT, Record, collect, in_archive, load_id_scheme, record_id
+    from .console import add_output_arguments, configure_output
+    from .import_transcripts import EN_PLACEHOLDER
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import BUILD_DIR, CONTENT_DIR, DEFAULT_IDS_PATH, LOCKED, ROOT, Record, collect, in_archive, load_id_scheme, record_id  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from import_transcripts import EN_PLACEHOLDER  # type: ignore
+
+DEFAULT_QUEUE_DIR = BUILD_DIR / "translations"
+QUEUE_FORMATS = ("csv", "xliff")
+CSV_FIELDS = ("id", "path", "source", "source_hash", "target", "file")
+XLIFF_NS = "urn:oasis:names:tc:xliff:document:1.2"
+PATH_TOKEN = re.compile(r"([^.\[\]]+)|\[(\d+)\]")
+
+
+def source_hash(text: str) -> str:
+    return hashlib.sha256(text.encode("utf-8")).hexdigest()[:16]
+
+
+def untranslated(value: Any) -> bool:
+    return not isinstance(value, str) or not value.strip() or value.strip() == EN_PLACEHOLDER
+
+
+def missing_translations(data: Any, path: str = "") -> Iterator[Tuple[str, str]]:
+    """(target path, Spanish source) for every "es" text whose sibling "en" is missing, empty, or a placeholder."""
+    if isinstance(data, dict):
+        spanish = data.get("es")
+        # Locked text has nothing to translate until its vocabulary unlocks.
+        if isinstance(spanish, str) and re.sub(r"[\W_]", "", spanish.replace(LOCKED, "")) and untranslated(data.get("en")):
+            yield (f"{path}.en" if path else "en"), data["es"]
+        for key, item in data.items():
+            if isinstance(item, (dict, list)):
+                yield from missing_translations(item, f"{path}.{key}" if path else key)
+    elif isinstance(data, list):
+        for idx, item in enumerate(data):
+            if isinstance(item, (dict, list)):
+                yield from missing_translations(item, f"{path}[{idx}]")
+
+
+def parse_path(path: str) -> List[Any]:
+    return [int(index) if index else key for key, index in PATH_TOKEN.findall(path)]
+
+
+def get_path(data: Any, path: str) -> Any:
+    for part in parse_path(path):
+        try:
+            data = data[part]
+        except (KeyError, IndexError, TypeError):
+            return None
+    return data
+
+
+def set_path(data: Any, path: str, value: Any) -> None:
+    parts = parse_path(path)
+    for part in parts[:-1]:
+        data = data[part]
+    data[parts[-1]] = value
+
+
+def source_path(path: str) -> str:
+    """Where the Spanish text for a target path lives: the sibling "es", or the headword for a gloss."""
+    return "spanish" if path == "english_gloss" else path[: -len("en")] + "es"
+
+
+def queue_units(records: List[Record], scheme) -> List[Dict[str, str]]:
+    units: List[Dict[str, str]] = []
+    for record in records:
+        entry_id = record_id(record, scheme)
+        found = list(missing_translations(record.data))
+        headword = record.data.get("spanish")
+        if record.kind == "vocab" and isinstance(headword, str) and headword.strip() and untranslated(record.data.get("english_gloss")):
+            found.insert(0, ("english_gloss", headword))
+        for path, text in found:
+            units.append({"id": entry_id, "path": path, "source": text, "source_hash": source_hash(text), "target": "", "file": record.source})
+    return units
+
+
+def write_csv(path: Path, units: List[Dict[str, str]]) -> None:
+    with open(path, "w", newline="", encoding="utf-8") as handle:
+        writer = csv.DictWriter(handle, fieldnames=CSV_FIELDS)
+        writer.writeheader()
+        writer.writerows(units)
+
+
+def write_xliff(path: Path, units: List[Dict[str, str]]) -> None:
+    ET.register_namespace("", XLIFF_NS)
+    root = ET.Element(f"{{{XLIFF_NS}}}xliff", version="1.2")
+    body = ET.SubElement(ET.SubElement(root, f"{{{XLIFF_NS}}}file", {"source-language": "es", "target-language": "en", "datatype": "plaintext", "original": "content"}), f"{{{XLIFF_NS}}}body")
+    for unit in units:
+        node = ET.SubElement(body, f"{{{XLIFF_NS}}}trans-unit", id=f"{unit['id']}|{unit['path']}", resname=unit["file"])
+        ET.SubElement(node, f"{{{XLIFF_NS}}}source").text = unit["source"]
+        ET.SubElement(node, f"{{{XLIFF_NS}}}target").text = unit["target"] or None
+        ET.SubElement(node, f"{{{XLIFF_NS}}}note", {"from": "source-hash"}).text = unit["source_hash"]
+    ET.indent(root)
+    ET.ElementTree(root).write(path, encoding="utf-8", xml_declaration=True)
+
+
+def read_units(path: Path) -> List[Dict[str, str]]:
+    if path.suffix.lower() in (".xlf", ".xliff"):
+        units: List[Dict[str, str]] = []
+        for node in ET.parse(path).getroot().iter(f"{{{XLIFF_NS}}}trans-unit"):
+            entry_id, _, target_path = (node.get("id") or "").partition("|")
+            note = next((n.text or "" for n in node.iter(f"{{{XLIFF_NS}}}note") if n.get("from") == "source-hash"), "")
+            units.append({
+                "id": entry_id,
+                "path": target_path,
+                "source": node.findtext(f"{{{XLIFF_NS}}}source") or "",
+                "source_hash": note.strip(),
+                "target": (node.findtext(f"{{{XLIFF_NS}}}target") or "").strip(),
+            })
+        return units
+    with open(path, newline="", encoding="utf-8") as handle:
+        return [{key: (row.get(key) or "").strip() if key == "target" else row.get(key) or "" for key in CSV_FIELDS} for row in csv.DictReader(handle)]
+
+
+def source_objects(path: Path) -> Tuple[str, List[Any]]:
+    """The file's layout ("jsonl", "array", or "object") and its records in collect() order.
+
+    Files holding several concatenated JSON values raise ValueError: they cannot be rewritten without
+    losing their hand formatting.
+    """
+    text = path.read_text(encoding="utf-8")
+    if path.suffix == ".jsonl":
+        return "jsonl", [json.loads(line) for line in text.splitlines() if line.strip()]
+    value = json.loads(text)
+    return ("array", value) if isinstance(value, list) else ("object", [value])
+
+
+def save_objects(path: Path, layout: str, objects: List[Any]) -> None:
+    """Rewrite a content file in its layout; written beside it and renamed, so a crash never leaves half a file."""
+    if layout == "jsonl":
+        text = "".join(json.dumps(obj, ensure_ascii=False, separators=(",", ":")) + "\n" for obj in objects)
+    else:
+        text = json.dumps(objects if layout == "array" else objects[0], ensure_ascii=False, indent=2) + "\n"
+    # A dot file, so collect never reads a half-written one as content.
+    partial = path.with_name(f".{path.name}.partial")
+    partial.write_text(text, encoding="utf-8")
+    os.replace(partial, path)
+
+
+def export_queue(args: argparse.Namespace) -> int:
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+    units = queue_units(dataset.vocab + dataset.lessons + dataset.readings, scheme)
+    out = Path(args.out) if args.out else DEFAULT_QUEUE_DIR / f"queue.{'xlf' if args.format == 'xliff' else 'csv'}"
+    out.parent.mkdir(parents=True, exist_ok=True)
+    (write_xliff if args.format == "xliff" else write_csv)(out, units)
+    entries = len({unit["id"] for unit in units})
+    print(f"[translations] Queued {len(units)} missing translations from {entries} entries in {out}")
+    print("[translations] Fill in the targets, then run `translations.py import` with the completed file.")
+    return 0
+
+
+def import_translations(args: argparse.Namespace) -> int:
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+    records: Dict[str, Record] = {}
+    for record in dataset.vocab + dataset.lessons + dataset.readings:
+        records.setdefault(record_id(record, scheme), record)
+
+    conflicts: List[str] = []
+    by_source: Dict[str, Dict[int, List[Dict[str, str]]]] = {}
+    skipped = 0
+    for unit in read_units(Path(args.file)):
+        if not unit["target"]:
+            skipped += 1
+            continue
+        record = records.get(unit["id"])
+        if record is None:
+            conflicts.append(f"{unit['id']} {unit['path']}: no entry has this ID any more")
+            continue
+        current = get_path(record.data, source_path(unit["path"]))
+        if not isinstance(current, str) or source_hash(current) != unit["source_hash"]:
+            conflicts.append(f"{unit['id']} {unit['path']}: source text changed since the queue was exported (now {json.dumps(current, ensure_ascii=False)})")
+            continue
+        existing = get_path(record.data, unit["path"])
+        if not untranslated(existing) and existing != unit["target"] and not args.overwrite:
+            conflicts.append(f"{unit['id']} {unit['path']}: already translated as {json.dumps(existing, ensure_ascii=False)} (pass --overwrite to replace)")
+            continue
+        by_source.setdefault(record.source, {}).setdefault(record.index, []).append(unit)
+
+    applied = 0
+    for source, updates in sorted(by_source.items()):
+        if in_archive(source):
+            conflicts += [f"{unit['id']} {unit['path']}: {source} is inside an archive and cannot be updated in place" for units in updates.values() for unit in units]
+            continue
+        # Sources inside the repo are recorded relative to ROOT; anything else as it was given.
+        path = ROOT / source if (ROOT / source).exists() else Path(source)
+        try:
+            layout, objects = source_objects(path)
+        except ValueError:
+            conflicts += [f"{unit['id']} {unit['path']}: {source} holds several JSON values; only .jsonl or single-value files can be updated in place" for units in updates.values() for unit in units]
+            continue
+        for index, units in updates.items():
+            for unit in units:
+                set_path(objects[index], unit["path"], unit["target"])
+                applied += 1
+        if not args.dry_run:
+            save_objects(path, layout, objects)
+
+    verb = "Would apply" if args.dry_run else "Applied"
+    print(f"[translations] {verb} {applied} translations to {len(by_source)} source files ({skipped} units still empty)")
+    if conflicts:
+        print(f"[translations] {len(conflicts)} units left unapplied:", file=sys.stderr)
+        for problem in conflicts:
+            print(f"    • {problem}", file=sys.stderr)
+    return 1 if conflicts else 0
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Round-trip missing English translations through a CSV or XLIFF queue.")
+    sub = parser.add_subparsers(dest="command", required=True)
+
+    exp = sub.add_parser("export", help="Write the queue of entries missing English glosses or example translations")
+    exp.add_argument("--format", choices=QUEUE_FORMATS, default="csv", help="Queue file format")
+    exp.add_argument("--out", help=f"Queue file (default {DEFAULT_QUEUE_DIR}/queue.csv or queue.xlf)")
+
+    imp = sub.add_parser("import", help="Merge a completed queue back into the content files, matching by entry ID")
+    imp.add_argument("file", help="Completed .csv or .xlf/.xliff queue")
+    imp.add_argument("--overwrite", action="store_true", help="Replace translations someone added since the queue was exported")
+    imp.add_argument("--dry-run", action="store_true", help="Report what would change without writing")
+
+    for command in (exp, imp):
+        command.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to collect")
+        command.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+    return export_queue(args) if args.command == "export" else import_translations(args)
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
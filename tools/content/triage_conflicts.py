@@ -0,0 +1,105 @@
+#!/usr/bin/env python3
+"""Walk through merge conflicts the resolver can only guess at and record manual resolutions in the conflict cache."""
+
+from __future__ import annotations
+
+import argparse
+import sys
+from pathlib import Path
+from typing import Callable, Iterable, List, Optional
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_CONFLICT_CACHE, display_path, iter_source_files
+    from .conflicts import Chunk, ConflictCache, choose, has_conflicts, resolve_conflicts
+    from .console import add_output_arguments, configure_output
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_CONFLICT_CACHE, display_path, iter_source_files  # type: ignore
+    from conflicts import Chunk, ConflictCache, choose, has_conflicts, resolve_conflicts  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+
+CHOICES = "[o]urs, [t]heirs, [b]oth, [s]kip, [q]uit"
+
+
+def pending_chunks(text: str, cache: ConflictCache) -> List[Chunk]:
+    pending: List[Chunk] = []
+    resolve_conflicts(text, cache, pending)
+    return pending
+
+
+def show(chunk: Chunk, source: str) -> None:
+    print(f"\n{source}:{chunk.line}")
+    sections = [("ours", chunk.ours)] + ([("base", chunk.base)] if chunk.base is not None else []) + [("theirs", chunk.theirs)]
+    for name, lines in sections:
+        print(f"  --- {name}")
+        print("\n".join(f"  | {line}" for line in lines) or "  | (empty)")
+
+
+def ask(chunk: Chunk, prompt: Callable[[str], str]) -> Optional[List[str]]:
+    """The chosen lines, None to skip; raises KeyboardInterrupt on quit."""
+    while True:
+        answer = prompt(f"Resolve with {CHOICES}? ").strip().lower()[:1]
+        if answer == "o":
+            return chunk.ours
+        if answer == "t":
+            return chunk.theirs
+        if answer == "b":
+            return choose(chunk.ours, None, chunk.theirs)
+        if answer == "s":
+            return None
+        if answer == "q":
+            raise KeyboardInterrupt
+
+
+def triage_file(path: Path, cache: ConflictCache, prompt: Callable[[str], str]) -> int:
+    """Ask about every unresolved chunk, re-resolving after each answer so outer conflicts see their nested choices."""
+    source = display_path(path)
+    text = path.read_text(encoding="utf-8", errors="replace")
+    skipped: set = set()
+    recorded = 0
+    while True:
+        chunk = next((c for c in pending_chunks(text, cache) if cache.key(c.ours, c.theirs) not in skipped), None)
+        if chunk is None:
+            return recorded
+        show(chunk, source)
+        resolution = ask(chunk, prompt)
+        if resolution is None:
+            skipped.add(cache.key(chunk.ours, chunk.theirs))
+            continue
+        cache.record(chunk, resolution, source)
+        cache.save()
+        recorded += 1
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Record resolutions for conflicts that export --resolve-conflicts cannot settle on its own.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files or directories to scan")
+    parser.add_argument("--cache", default=str(DEFAULT_CONFLICT_CACHE), help="Conflict resolution cache to read and update")
+    parser.add_argument("--list", action="store_true", help="Only list conflicts without a recorded resolution")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    cache = ConflictCache(args.cache)
+    files = [path for path in iter_source_files(args.content) if has_conflicts(path.read_text(encoding="utf-8", errors="replace"))]
+    if args.list:
+        pending = [(display_path(path), chunk) for path in files for chunk in pending_chunks(path.read_text(encoding="utf-8", errors="replace"), cache)]
+        for source, chunk in pending:
+            print(f"{source}:{chunk.line}: {len(chunk.ours)} lines ours / {len(chunk.theirs)} lines theirs")
+        print(f"[triage] {len(pending)} conflicts without a recorded resolution; {len(cache.entries)} resolutions cached")
+        return 1 if pending else 0
+    if not sys.stdin.isatty():
+        print("[triage] Interactive triage needs a terminal; use --list to see pending conflicts", file=sys.stderr)
+        return 2
+    recorded = 0
+    try:
+        for path in files:
+            recorded += triage_file(path, cache, input)
+    except (KeyboardInterrupt, EOFError):
+        print()
+    print(f"[triage] Recorded {recorded} resolutions in {args.cache}; later runs apply them automatically")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
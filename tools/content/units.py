@@ -0,0 +1,112 @@
+"""Units as entities: declared in _unit.json files or inferred from the level and unit numbers lessons carry."""
+
+from __future__ import annotations
+
+from typing import Any, Dict, List, Optional, Tuple
+
+try:
+    from .common import LEVELS, IdScheme, entry_level, lesson_order_key, unit_id
+except ImportError:  # pragma: no cover - allow running as a script
+    from common import LEVELS, IdScheme, entry_level, lesson_order_key, unit_id  # type: ignore
+
+UnitKey = Tuple[str, int]
+
+
+def lesson_unit(lesson: Dict[str, Any]) -> Optional[UnitKey]:
+    """(level, unit number) for a lesson placed in a unit; None when either is missing."""
+    level = entry_level(lesson)
+    unit = lesson.get("unit")
+    if level not in LEVELS or not isinstance(unit, int) or isinstance(unit, bool):
+        return None
+    return level, unit
+
+
+def unit_key(unit: Dict[str, Any]) -> Optional[UnitKey]:
+    level = entry_level(unit)
+    number = unit.get("number")
+    return (level, number) if level in LEVELS and isinstance(number, int) else None
+
+
+def unit_order_key(unit: Dict[str, Any]) -> tuple:
+    level = entry_level(unit)
+    number = unit.get("number") if isinstance(unit.get("number"), int) else 0
+    return (LEVELS.index(level) if level in LEVELS else len(LEVELS), number, str(unit.get("id", "")))
+
+
+def build_units(declared: List[Dict[str, Any]], lessons: List[Dict[str, Any]], scheme: IdScheme) -> List[Dict[str, Any]]:
+    """Declared units, plus an inferred unit for every (level, unit) lessons use that no _unit.json declares.
+
+    A declared unit without a `lessons` list gets the lessons carrying its level and number, in lesson order;
+    inferred units are marked `"inferred": true` and titled "Unit N" until someone declares them.
+    """
+    members: Dict[UnitKey, List[Dict[str, Any]]] = {}
+    for lesson in sorted(lessons, key=lesson_order_key):
+        key = lesson_unit(lesson)
+        if key is not None:
+            members.setdefault(key, []).append(lesson)
+    units: List[Dict[str, Any]] = []
+    for entry in declared:
+        unit = dict(entry, kind="unit")
+        if not isinstance(unit.get("lessons"), list):
+            unit["lessons"] = [lesson["id"] for lesson in members.get(unit_key(unit), [])] if unit_key(unit) else []
+        units.append(unit)
+    covered = {unit_key(unit) for unit in units}
+    for (level, number), found in members.items():
+        if (level, number) in covered:
+            continue
+        units.append({
+            "id": unit_id(level, number, scheme),
+            "kind": "unit",
+            "title": f"Unit {number}",
+            "level": level,
+            "number": number,
+            "lessons": [lesson["id"] for lesson in found],
+            "inferred": True,
+            "source_files": sorted({source for lesson in found for source in lesson.get("source_files", [])}),
+        })
+    return sorted(units, key=unit_order_key)
+
+
+def check_units(units: List[Dict[str, Any]], lessons: List[Dict[str, Any]]) -> List[Tuple[str, str]]:
+    """(unit ID or level, problem) for gaps and repeats in unit numbering, and unit lesson lists that disagree with the lessons.
+
+    Units in a level must be numbered 1..N without gaps, and a unit's lessons must be numbered 1..M in list order.
+    """
+    problems: List[Tuple[str, str]] = []
+    by_id = {lesson["id"]: lesson for lesson in lessons}
+    numbers: Dict[str, List[int]] = {}
+    for unit in units:
+        key = unit_key(unit)
+        if key is None:
+            problems.append((str(unit.get("id")), "unit has no level or integer number"))
+            continue
+        numbers.setdefault(key[0], []).append(key[1])
+    for level, used in numbers.items():
+        for number in sorted({n for n in used if used.count(n) > 1}):
+            problems.append((level, f"unit {number} is declared {used.count(number)} times"))
+        missing = sorted(set(range(1, max(used) + 1)) - set(used))
+        if missing:
+            problems.append((level, f"unit numbers skip {', '.join(str(n) for n in missing)}"))
+
+    placed: Dict[str, str] = {}
+    for unit in units:
+        key = unit_key(unit)
+        listed = [str(ref) for ref in unit.get("lessons", [])]
+        for ref in listed:
+            lesson = by_id.get(ref)
+            if lesson is None:
+                problems.append((unit["id"], f"lists unknown lesson {ref}"))
+                continue
+            if ref in placed:
+                problems.append((unit["id"], f"lesson {ref} is also listed by {placed[ref]}"))
+            placed.setdefault(ref, unit["id"])
+            if key is not None and lesson_unit(lesson) not in (None, key):
+                problems.append((unit["id"], f"lesson {ref} says {lesson_unit(lesson)[0]} unit {lesson_unit(lesson)[1]}"))
+        if key is not None:
+            for lesson in lessons:
+                if lesson_unit(lesson) == key and lesson["id"] not in listed:
+                    problems.append((unit["id"], f"lesson {lesson['id']} says this unit but the unit does not list it"))
+        lesson_numbers = [by_id[ref].get("lesson_number") for ref in listed if ref in by_id]
+        if lesson_numbers and all(isinstance(n, int) for n in lesson_numbers) and lesson_numbers != list(range(1, len(lesson_numbers) + 1)):
+            problems.append((unit["id"], f"lesson numbers run {', '.join(str(n) for n in lesson_numbers)}; expected 1..{len(lesson_numbers)} in list order"))
+    return problems
@@ -0,0 +1,233 @@
+#!/usr/bin/env python3
+"""Validate built lesson, vocabulary, reading, unit, culture note, and pronunciation drill entries against the JSON schemas in tools/schemas."""
+
+from __future__ import annotations
+
+import argparse
+import itertools
+import json
+import sys
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, Iterator, List, Optional, Sequence
+
+try:
+    from .common import CONTENT_DIR, DEFAULT_IDS_PATH, ROOT, Record, collect, is_pinned, load_id_scheme, record_id, write_report
+    from .console import add_output_arguments, configure_output
+    from .errors import InvalidEntry
+    from .normalize import normalization_warnings, normalize_lesson, normalize_vocab
+    from .locales import DEFAULT_LOCALES_PATH, LocaleCheck, load_locales, locale_issues, locales_for
+    from .pron import normalize_drill
+    from .rules import DEFAULT_RULES_PATH, CustomRule, load_rules, rules_for
+    from .senses import normalize_senses
+    from .strict import StrictFailure, fail_strict
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from common import CONTENT_DIR, DEFAULT_IDS_PATH, ROOT, Record, collect, is_pinned, load_id_scheme, record_id, write_report  # type: ignore
+    from console import add_output_arguments, configure_output  # type: ignore
+    from errors import InvalidEntry  # type: ignore
+    from normalize import normalization_warnings, normalize_lesson, normalize_vocab  # type: ignore
+    from locales import DEFAULT_LOCALES_PATH, LocaleCheck, load_locales, locale_issues, locales_for  # type: ignore
+    from pron import normalize_drill  # type: ignore
+    from rules import DEFAULT_RULES_PATH, CustomRule, load_rules, rules_for  # type: ignore
+    from senses import normalize_senses  # type: ignore
+    from strict import StrictFailure, fail_strict  # type: ignore
+
+SCHEMAS_DIR = ROOT / "tools" / "schemas"
+SCHEMA_FILES = {"lesson": "lesson.schema.json", "vocab": "vocab.schema.json", "reading": "reading.schema.json", "unit": "unit.schema.json", "culture_note": "culture_note.schema.json", "pron_drill": "pron_drill.schema.json"}
+JSON_TYPES = {
+    "object": dict,
+    "array": list,
+    "string": str,
+    "integer": int,
+    "number": (int, float),
+    "boolean": bool,
+    "null": type(None),
+}
+VERB_ENDINGS = ("ar", "er", "ir", "ír")
+# Adjective endings whose feminine is not the -o/-a swap (trabajadora, mandona, inglesa, española).
+FEMININE_ENDINGS = ("or", "ón", "án", "ín", "és", "ol")
+# Comparatives in -or keep one form for both genders.
+INVARIABLE_ADJECTIVES = {"mejor", "peor", "mayor", "menor", "superior", "inferior", "exterior", "interior", "anterior", "posterior", "ulterior"}
+
+
+@dataclass
+class Issue:
+    rule: str
+    severity: str
+    path: str
+    message: str
+
+
+def load_schemas(schemas_dir: Path = SCHEMAS_DIR) -> Dict[str, Dict[str, Any]]:
+    return {kind: json.loads((schemas_dir / name).read_text(encoding="utf-8")) for kind, name in SCHEMA_FILES.items()}
+
+
+def type_matches(value: Any, expected: Any) -> bool:
+    for name in expected if isinstance(expected, list) else [expected]:
+        # bool is an int in Python but never a JSON integer/number.
+        if isinstance(value, bool) and name in ("integer", "number"):
+            continue
+        if isinstance(value, JSON_TYPES.get(name, object)):
+            return True
+    return False
+
+
+def iter_issues(value: Any, schema: Dict[str, Any], path: str = "") -> Iterator[Issue]:
+    """Yield schema issues in document order; covers the type/enum/required/properties/items subset the schemas use."""
+    where = path or "$"
+    if "type" in schema and not type_matches(value, schema["type"]):
+        expected = schema["type"] if isinstance(schema["type"], str) else " or ".join(schema["type"])
+        yield Issue("schema.type", "error", where, f"expected {expected}, got {type(value).__name__}")
+        return
+    if "enum" in schema and value not in schema["enum"]:
+        yield Issue("schema.enum", "error", where, f"{json.dumps(value, ensure_ascii=False)} is not one of {', '.join(map(str, schema['enum']))}")
+    if isinstance(value, dict):
+        for key in schema.get("required", []):
+            if key not in value:
+                yield Issue("schema.required", "error", f"{path}.{key}" if path else key, "required field is missing")
+            elif isinstance(value[key], str) and not value[key].strip():
+                yield Issue("schema.empty", "warning", f"{path}.{key}" if path else key, "required field is empty")
+        for key, sub in schema.get("properties", {}).items():
+            if key in value:
+                yield from iter_issues(value[key], sub, f"{path}.{key}" if path else key)
+    if isinstance(value, list) and "items" in schema:
+        for idx, item in enumerate(value):
+            yield from iter_issues(item, schema["items"], f"{path}[{idx}]")
+
+
+def is_infinitive(headword: str) -> bool:
+    """The first word ends in -ar/-er/-ir (or -ír), reflexive -se allowed: hablar, reírse, tener que."""
+    first = headword.strip().lower().split()[0] if headword.strip() else ""
+    return (first[:-2] if first.endswith("se") else first).endswith(VERB_ENDINGS)
+
+
+def needs_feminine(headword: str) -> bool:
+    word = headword.strip().lower()
+    return " " not in word and word not in INVARIABLE_ADJECTIVES and word.endswith(FEMININE_ENDINGS)
+
+
+def iter_pos_issues(entry: Dict[str, Any], strict: bool = False) -> Iterator[Issue]:
+    """Part-of-speech checks the schema cannot express; strict makes the noun and verb checks errors instead of warnings."""
+    headword = entry.get("spanish")
+    if not isinstance(headword, str) or not headword.strip():
+        return
+    pos = entry.get("pos")
+    severity = "error" if strict else "warning"
+    if pos == "noun" and entry.get("gender") not in ("masculine", "feminine"):
+        yield Issue("pos.noun-gender", severity, "gender", "nouns need a gender (masculine or feminine) for article drills")
+    if pos == "verb" and not is_infinitive(headword) and entry.get("irregular") is not True:
+        yield Issue("pos.verb-infinitive", severity, "spanish", f"'{headword}' is not an -ar/-er/-ir infinitive; use the infinitive, or set irregular: true")
+    if pos == "adj" and needs_feminine(headword) and not entry.get("feminine"):
+        yield Issue("pos.adj-feminine", "warning", "feminine", f"'{headword}' does not make its feminine by -o/-a; list it in feminine")
+
+
+def iter_rule_issues(entry: Dict[str, Any], rules: Sequence[CustomRule]) -> Iterator[Issue]:
+    """Issues from config-declared rules (see rules.py); callers pass the rules for the entry's kind."""
+    for rule in rules:
+        if rule.applies(entry) and not rule.passes(entry):
+            yield Issue(f"custom.{rule.id}", rule.severity, "$", rule.describe())
+
+
+def iter_normalization_issues(entry: Dict[str, Any]) -> Iterator[Issue]:
+    """Fields normalization had to coerce, or could not, so schema errors come with what the field looked like."""
+    for note in normalization_warnings(entry):
+        yield Issue("normalize.coerced", "warning", str(note.get("path", "$")), f"{note.get('problem')}; {note.get('action')}")
+
+
+def iter_locale_issues(entry: Dict[str, Any], check: Optional[LocaleCheck]) -> Iterator[Issue]:
+    """Instruction fields missing a required locale (at the configured severity) or holding odd variants (warnings)."""
+    for issue in locale_issues(entry, check) if check else ():
+        yield Issue(issue.rule, check.config.severity if issue.rule == "locale.missing" else "warning", issue.path, issue.message)
+
+
+def validate(entry: Dict[str, Any], schema: Dict[str, Any], rules: Sequence[CustomRule] = (), strict: bool = False, locales: Optional[LocaleCheck] = None) -> Optional[Issue]:
+    """Fast-fail: the first error, or None. Warnings never stop an entry."""
+    issues = itertools.chain(iter_issues(entry, schema), iter_pos_issues(entry, strict), iter_rule_issues(entry, rules), iter_locale_issues(entry, locales), iter_normalization_issues(entry))
+    return next((issue for issue in issues if issue.severity == "error"), None)
+
+
+def validate_all(entry: Dict[str, Any], schema: Dict[str, Any], rules: Sequence[CustomRule] = (), strict: bool = False, locales: Optional[LocaleCheck] = None) -> List[Issue]:
+    """Every issue for one entry, so it can be fixed in a single pass."""
+    return list(iter_issues(entry, schema)) + list(iter_pos_issues(entry, strict)) + list(iter_rule_issues(entry, rules)) + list(iter_locale_issues(entry, locales)) + list(iter_normalization_issues(entry))
+
+
+def require_valid(entry: Dict[str, Any], schema: Dict[str, Any], rules: Sequence[CustomRule] = (), strict: bool = False, locales: Optional[LocaleCheck] = None) -> Dict[str, Any]:
+    """Return the entry unchanged, or raise InvalidEntry carrying every error-severity issue."""
+    errors = [issue for issue in validate_all(entry, schema, rules, strict, locales) if issue.severity == "error"]
+    if errors:
+        raise InvalidEntry(str(entry.get("id", "")), list(entry.get("source_files", [])), errors)
+    return entry
+
+
+def entry_for(record: Record, scheme) -> Dict[str, Any]:
+    """The record as export builds it, so IDs and source_files do not show up as missing."""
+    entry = dict(record.data)
+    entry["id"] = record_id(record, scheme)
+    entry.setdefault("source_files", [record.source])
+    if is_pinned(entry):
+        return entry
+    if record.kind == "pron_drill":
+        return normalize_drill(entry)
+    if record.kind == "lesson":
+        return normalize_lesson(entry)
+    return normalize_senses(normalize_vocab(entry)) if record.kind == "vocab" else entry
+
+
+def format_issue(issue: Issue) -> str:
+    return f"[{issue.severity}] {issue.rule} {issue.path}: {issue.message}"
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Validate built entries against tools/schemas.")
+    parser.add_argument("--content", nargs="+", default=[str(CONTENT_DIR)], help="Content files, directories, or .zip/.tar.gz archives to collect")
+    parser.add_argument("--ids", default=str(DEFAULT_IDS_PATH), help="Path to the ID scheme config")
+    parser.add_argument("--schemas", default=str(SCHEMAS_DIR), help="Directory holding the lesson, vocab, reading, unit, culture note, and pronunciation drill schemas")
+    parser.add_argument("--rules", default=str(DEFAULT_RULES_PATH), help="Custom rules config: expressions checked alongside the schemas, each with its own severity")
+    parser.add_argument("--locales", default=str(DEFAULT_LOCALES_PATH), help="Locale config: the instruction fields and the locales each must be translated into")
+    parser.add_argument("--strict", action="store_true", help="Stop at the first invalid entry and exit non-zero (fast-fail gate); nouns without gender and verbs not in the infinitive become errors")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    schemas = load_schemas(Path(args.schemas))
+    try:
+        rules = load_rules(args.rules)
+        locales = load_locales(args.locales)
+    except ValueError as exc:
+        parser.error(str(exc))
+    scheme = load_id_scheme(args.ids)
+    dataset = collect(args.content)
+    records = dataset.vocab + dataset.lessons + dataset.readings + dataset.units + dataset.culture_notes + dataset.pron_drills
+    entries = [(record.kind, entry_for(record, scheme)) for record in records]
+
+    if args.strict:
+        for record, (kind, entry) in zip(records, entries):
+            issue = validate(entry, schemas[kind], rules_for(rules, kind), strict=True, locales=locales_for(locales, kind))
+            if issue:
+                return fail_strict("validate", [StrictFailure(issue.rule, record.source, f"{issue.path}: {issue.message}", entry["id"], record.index, issue.severity)])
+        print(f"[validate] {len(entries)} entries valid")
+        return 0
+
+    invalid = 0
+    counts: Dict[str, int] = {}
+    sections: List[str] = []
+    for kind, entry in entries:
+        issues = validate_all(entry, schemas[kind], rules_for(rules, kind), locales=locales_for(locales, kind))
+        if not issues:
+            continue
+        invalid += any(issue.severity == "error" for issue in issues)
+        for issue in issues:
+            counts[issue.severity] = counts.get(issue.severity, 0) + 1
+        sections.append(f"## {entry['id']} ({', '.join(entry['source_files'])})")
+        sections += [f"- {format_issue(issue)}" for issue in issues]
+    out = ["Schema validation", f"- entries: {len(entries)}", f"- invalid entries: {invalid}"]
+    out += [f"- {severity}s: {counts[severity]}" for severity in sorted(counts)]
+    path = write_report("validate.md", out + sections)
+    print("\n".join(out))
+    print(f"[validate] Full issue listing per entry in {path}")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
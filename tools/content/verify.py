@@ -0,0 +1,130 @@
+#!/usr/bin/env python3
+"""Detect hand-edited build outputs by checking them against the hashes in the export manifest."""
+
+from __future__ import annotations
+
+import argparse
+import hashlib
+import json
+import sys
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Tuple, Union
+
+try:
+    from .console import add_output_arguments, configure_output
+    from .errors import ConfigError
+    from .storage import COPY_CHUNK_BYTES, DEFAULT_STORAGE_PATH, Storage, load_storage
+except ImportError:  # pragma: no cover - allow running as a script
+    sys.path.append(str(Path(__file__).resolve().parent))
+    from console import add_output_arguments, configure_output  # type: ignore
+    from errors import ConfigError  # type: ignore
+    from storage import COPY_CHUNK_BYTES, DEFAULT_STORAGE_PATH, Storage, load_storage  # type: ignore
+
+GENERATED_KEY = "_generated"
+GENERATED_MARKER = "DO NOT EDIT: generated by tools/content/export.py; edit the files under content/ and re-run the export"
+
+
+def mark_generated(payload: Any) -> Any:
+    """Embed the do-not-edit marker: objects gain a _generated key, lists move under "items"."""
+    if isinstance(payload, dict):
+        return {GENERATED_KEY: GENERATED_MARKER, **payload}
+    return {GENERATED_KEY: GENERATED_MARKER, "items": payload}
+
+
+def unmark_generated(payload: Any) -> Any:
+    """Undo mark_generated so readers handle marked and unmarked outputs alike."""
+    if isinstance(payload, dict) and GENERATED_KEY in payload:
+        return payload["items"] if set(payload) == {GENERATED_KEY, "items"} else {k: v for k, v in payload.items() if k != GENERATED_KEY}
+    return payload
+
+
+def manifest_row(data: bytes) -> Dict[str, Any]:
+    return {"sha256": hashlib.sha256(data).hexdigest(), "bytes": len(data)}
+
+
+def modified_files(root: Storage, out: Storage) -> List[Tuple[str, str]]:
+    """(name, problem) for every manifest file whose bytes no longer match; an empty list when there is no manifest yet.
+
+    Manifest names are relative to the output location, except profile copies ("public/lessons.json"), which sit at the storage root.
+    """
+    raw = out.read_bytes("manifest.json")
+    if raw is None:
+        return []
+    try:
+        manifest = json.loads(raw.decode("utf-8"))
+    except (UnicodeDecodeError, json.JSONDecodeError) as exc:
+        return [("manifest.json", f"unreadable manifest: {exc}")]
+    problems: List[Tuple[str, str]] = []
+    for name, row in sorted(manifest.get("files", {}).items()):
+        handle = (root if "/" in name else out).open_read(name)
+        if handle is None:
+            problems.append((name, "missing"))
+            continue
+        # Hashed a chunk at a time; outputs can be larger than is sensible to hold.
+        digest = hashlib.sha256()
+        with handle:
+            for chunk in iter(lambda: handle.read(COPY_CHUNK_BYTES), b""):
+                digest.update(chunk)
+        if digest.hexdigest() != row.get("sha256"):
+            problems.append((name, "modified since it was generated"))
+    return problems
+
+
+def load_release(path: Union[str, Path], names: Iterable[str], setting: str, label: str) -> Tuple[Dict[str, Any], Dict[str, List[Dict[str, Any]]]]:
+    """(manifest, output file -> entries) of a past release; path is its manifest.json or the directory holding it.
+
+    Only files the manifest lists are read, and each must still match its hash: an edited or missing release
+    file would move the baseline the new build is compared with. The label names the release in errors.
+    """
+    manifest_path = Path(path)
+    if manifest_path.is_dir():
+        manifest_path = manifest_path / "manifest.json"
+    if not manifest_path.is_file():
+        raise ConfigError(f"{label} manifest {manifest_path} not found", setting, str(path))
+    try:
+        manifest = json.loads(manifest_path.read_text(encoding="utf-8"))
+    except (UnicodeDecodeError, json.JSONDecodeError) as exc:
+        raise ConfigError(f"{label} manifest {manifest_path} is unreadable: {exc}", setting, str(path)) from None
+    names = list(names)
+    listed = manifest.get("files", {}) if isinstance(manifest, dict) else {}
+    entries: Dict[str, List[Dict[str, Any]]] = {}
+    for name in names:
+        row = listed.get(f"{name}.json")
+        if row is None:
+            continue
+        data_path = manifest_path.parent / f"{name}.json"
+        data = data_path.read_bytes() if data_path.is_file() else None
+        if data is None or hashlib.sha256(data).hexdigest() != row.get("sha256"):
+            raise ConfigError(f"{label} file {data_path} is missing or no longer matches its manifest", setting, str(data_path))
+        entries[name] = [entry for entry in unmark_generated(json.loads(data.decode("utf-8"))) if isinstance(entry, dict)]
+    if not entries:
+        raise ConfigError(f"{label} manifest {manifest_path} lists none of {', '.join(f'{name}.json' for name in names)}", setting, str(path))
+    return manifest, entries
+
+
+def main(argv: Iterable[str] | None = None) -> int:
+    parser = argparse.ArgumentParser(description="Check exported files against the manifest to catch hand-edits of build artifacts.")
+    parser.add_argument("--storage", default=str(DEFAULT_STORAGE_PATH), help="Path to the storage backend config")
+    parser.add_argument("--out", default="canonical", help="Location of canonical JSON inside the storage backend")
+    add_output_arguments(parser)
+    args = parser.parse_args(list(argv) if argv is not None else None)
+    configure_output(args)
+
+    root = load_storage(args.storage, readonly=True)
+    out = root.child(args.out)
+    if out.read_bytes("manifest.json") is None:
+        print(f"[verify] No manifest in {out.describe()}; nothing to verify (or the backend cannot read back)", file=sys.stderr)
+        return 1
+    problems = modified_files(root, out)
+    if problems:
+        print(f"[verify] {len(problems)} generated files no longer match the manifest in {out.describe()}:", file=sys.stderr)
+        for name, problem in problems:
+            print(f"    • {name}: {problem}", file=sys.stderr)
+        print("[verify] Move the edits into the content sources; export --force overwrites these files.", file=sys.stderr)
+        return 1
+    print(f"[verify] All generated files in {out.describe()} match the manifest.")
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
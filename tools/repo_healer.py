@@ -2,7 +2,7 @@
 import argparse, re, json, sys
 from pathlib import Path
 
-CONFLICT_RE = re.compile(r'^<<<<<<<|^=======|^>>>>>>>', re.M)
+CONFLICT_RE = re.compile(r'^<<<<<<<|^\|\|\|\|\|\|\||^=======|^>>>>>>>', re.M)
 
 def scan_conflict_markers(root: Path):
     hits = []